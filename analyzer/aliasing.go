@@ -0,0 +1,198 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkAliasingReturns reports functions returning a pointer to a const field,
+// or a slice sharing its storage, such as
+//
+//	func (p *Person) NameRef() *string { return &p.Name }
+//
+// Callers can write through the result without ever naming the field, so the
+// function hands out the very mutability the marker forbids. A result aliases
+// a field when writing through it would be a violation: &p.Name and p.Grid[:]
+// of an array alias any const field, while p.Items[1:] and &p.Items[0] only
+// alias +deepconst ones, as a shallow marker leaves the elements mutable.
+// Constructors of the field's type may hand out aliases of the value they
+// build.
+func checkAliasingReturns(pass *analysis.Pass, funcDecl *ast.FuncDecl,
+	constFields map[*types.Var]constField, instantiators map[instantiation]token.Pos) {
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		ret, ok := n.(*ast.ReturnStmt)
+		if !ok {
+			return true
+		}
+		for _, result := range ret.Results {
+			field, cf, kind, ok := aliasedConstField(pass, result, constFields)
+			if !ok || isCachedInstanciator(pass, funcDecl, cf.owner, instantiators) {
+				continue
+			}
+			reportRelated(pass, result, CategoryAlias, cf.markerPos(), "field marked const here",
+				"%s returns a %s const field %s.%s, through which callers can modify it",
+				funcDecl.Name.Name, kind, cf.owner.Name(), field.Name())
+		}
+		return true
+	})
+}
+
+// aliasedConstField returns the const field a returned expression lets callers
+// modify, and how: "pointer into" for addresses, "slice of" for slices.
+func aliasedConstField(pass *analysis.Pass, expr ast.Expr,
+	constFields map[*types.Var]constField) (*types.Var, constField, string, bool) {
+	var target ast.Expr
+	var kind string
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.UnaryExpr:
+		if e.Op != token.AND {
+			return nil, constField{}, "", false
+		}
+		target, kind = e.X, "pointer into"
+
+	case *ast.SliceExpr:
+		// Slicing a string copies nothing writable.
+		if _, ok := pass.TypesInfo.TypeOf(e.X).Underlying().(*types.Basic); ok {
+			return nil, constField{}, "", false
+		}
+		// Writes through the slice are writes to the elements it is cut from.
+		target, kind = &ast.IndexExpr{X: e.X, Index: e.Low}, "slice of"
+
+	default:
+		return nil, constField{}, "", false
+	}
+
+	_, field, cf, ok := writtenConstField(pass, target, constFields, nil)
+	return field, cf, kind, ok
+}
+
+// localAliases maps the local variables of a function that alias memory
+// reachable from other expressions to the expression they were initialized
+// with: f := &p.Data, s := p.Grid[:] or s := p.Items.
+type localAliases map[*types.Var]ast.Expr
+
+// collectLocalAliases returns the aliases of a function body. Only variables
+// assigned once, by their declaration, and whose address is never taken are
+// aliases, so that every use of one refers to the memory it was initialized
+// with; the tracking is deliberately flow-insensitive and one step deep per
+// variable, though aliases of aliases (g := f) chain.
+func collectLocalAliases(pass *analysis.Pass, body *ast.BlockStmt) localAliases {
+	aliases := make(localAliases)
+	reassigned := make(map[*types.Var]bool)
+	assigned := func(expr ast.Expr) {
+		if ident, ok := ast.Unparen(expr).(*ast.Ident); ok {
+			if v, ok := pass.TypesInfo.Uses[ident].(*types.Var); ok {
+				reassigned[v] = true
+			}
+		}
+	}
+	define := func(ident *ast.Ident, value ast.Expr) {
+		if v, ok := pass.TypesInfo.Defs[ident].(*types.Var); ok && isAliasing(pass, value) {
+			aliases[v] = value
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if node.Tok != token.DEFINE || !ok || pass.TypesInfo.Defs[ident] == nil {
+					assigned(lhs)
+					continue
+				}
+				if len(node.Lhs) == len(node.Rhs) {
+					define(ident, node.Rhs[i])
+				}
+			}
+
+		case *ast.ValueSpec:
+			if len(node.Names) == len(node.Values) {
+				for i, name := range node.Names {
+					define(name, node.Values[i])
+				}
+			}
+
+		case *ast.RangeStmt:
+			if node.Tok == token.ASSIGN {
+				for _, lhs := range []ast.Expr{node.Key, node.Value} {
+					if lhs != nil {
+						assigned(lhs)
+					}
+				}
+			}
+
+		case *ast.IncDecStmt:
+			assigned(node.X)
+
+		case *ast.UnaryExpr:
+			if node.Op == token.AND {
+				assigned(node.X)
+			}
+		}
+		return true
+	})
+
+	for v := range reassigned {
+		delete(aliases, v)
+	}
+	return aliases
+}
+
+// isAliasing reports whether a variable initialized with expr shares memory
+// with it: addresses, slices of anything but strings, and copies of pointers,
+// slices and maps.
+func isAliasing(pass *analysis.Pass, expr ast.Expr) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.UnaryExpr:
+		return e.Op == token.AND
+	case *ast.SliceExpr:
+		_, isString := pass.TypesInfo.TypeOf(e.X).Underlying().(*types.Basic)
+		return !isString
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.StarExpr:
+		switch pass.TypesInfo.TypeOf(e).Underlying().(type) {
+		case *types.Pointer, *types.Slice, *types.Map:
+			return true
+		}
+	}
+	return false
+}
+
+// cachedAliases is collectLocalAliases of a function declaration, memoized in
+// aliases. Writes outside of function declarations have no aliases.
+func cachedAliases(pass *analysis.Pass, funcDecl *ast.FuncDecl,
+	aliases map[*ast.FuncDecl]localAliases) localAliases {
+	if funcDecl == nil || funcDecl.Body == nil {
+		return nil
+	}
+	a, cached := aliases[funcDecl]
+	if !cached {
+		a = collectLocalAliases(pass, funcDecl.Body)
+		aliases[funcDecl] = a
+	}
+	return a
+}
+
+// rootIdent returns the variable an expression such as *f, s[i] or q.X starts
+// from, or nil.
+func rootIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
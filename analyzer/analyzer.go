@@ -3,14 +3,20 @@
 package analyzer
 
 import (
+	"errors"
 	"go/ast"
 	"go/token"
 	"go/types"
-	"strings"
+	"log/slog"
+	"maps"
+	"reflect"
+	"regexp"
+	"strconv"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	astinspector "golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
 )
 
 // Analyzer is the main entry point for the linter.
@@ -18,359 +24,1054 @@ var Analyzer = &analysis.Analyzer{
 	Name:     "const",
 	Doc:      "checks for writes to struct fields marked with // +const", // TODO: improve doc field, include new markers
 	Run:      run,
-	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Requires: []*analysis.Analyzer{inspect.Analyzer, disableAnalyzer},
+	// Packages with type errors are checked with -best-effort.
+	RunDespiteErrors: true,
+	ResultType:       reflect.TypeOf((*Inventory)(nil)),
+	FactTypes:        []analysis.Fact{(*constGlobalFact)(nil), (*constMethodFact)(nil), (*externalConstFact)(nil)},
+}
+
+var (
+	// deadMarkers enables reporting of unexported const fields that are never
+	// initialized.
+	deadMarkers bool
+	// completeConstructors enables reporting of constructors that leave const
+	// fields unset.
+	completeConstructors bool
+	// setters enables reporting of setter-shaped methods on const fields.
+	setters bool
+	// exportedConst makes every exported field of annotated packages const
+	// unless marked +mutable.
+	exportedConst bool
+	// constReceivers assumes methods leave their receiver unchanged unless
+	// their name matches mutatorPattern.
+	constReceivers bool
+	mutatorPattern = regexpFlag{regexp.MustCompile(`^(Set|Add|Remove|Reset)`)}
+	// concurrencyAudit enables reporting of const field writes reachable from
+	// goroutines.
+	concurrencyAudit bool
+	// channelOwnership restricts sends on and closes of const channel fields to
+	// the declaring type's methods and constructors.
+	channelOwnership bool
+	// redundantConstParams enables reporting of const markers on parameters
+	// passed by value without references.
+	redundantConstParams bool
+	// consolidateMarkers enables suggesting a struct-level marker for structs
+	// whose fields are all marked alike.
+	consolidateMarkers bool
+	// requireMarkers enables reporting of exported fields of exported struct
+	// types without a +const or +mutable decision.
+	requireMarkers bool
+	// suggestConstMethods enables suggesting +const:receiver for methods
+	// that leave their pointer receiver unchanged.
+	suggestConstMethods bool
+	// valueReceivers enables suggesting value receivers for the read-only
+	// methods of types whose fields are all const.
+	valueReceivers bool
+	// elementWrites enables reporting of assignments replacing the elements
+	// of collections of structs with const fields.
+	elementWrites bool
+	// copyWrites lists the types whose values' copies keep their const
+	// fields, also in functions instantiating the types.
+	copyWrites listFlag
+	// strictGlobals forbids writes to const package-level variables in init
+	// functions and var initializers too.
+	strictGlobals bool
+	// markerSynonyms maps the spellings of a homegrown convention to the
+	// markers they stand for.
+	markerSynonyms synonymFlag
+	// typeFilter and fieldFilter scope the run to the const fields of some
+	// types, or to some fields.
+	typeFilter, fieldFilter listFlag
+	// minConfidence drops diagnostics of rules less confident than it.
+	minConfidence = Possible
+	// debugExemptions enables reporting of the writes to const fields the
+	// linter allows, and why.
+	debugExemptions bool
+	// audit extends debugExemptions to the composite literals initializing
+	// const fields, so that every write to a const field is reported.
+	audit bool
+	// bestEffort checks packages with type errors for the writes their syntax
+	// shows.
+	bestEffort bool
+	// refactorSetters enables fixes turning the obvious setters writing const
+	// fields into constructor parameters.
+	refactorSetters bool
+	// shallowConst enables reporting of bare +const markers on fields of
+	// reference type, which leave the data they reference mutable.
+	shallowConst bool
+)
+
+func init() {
+	Analyzer.Flags.BoolVar(&deadMarkers, "dead-markers", false,
+		"report unexported +const fields that are never initialized in their declaring package")
+	Analyzer.Flags.BoolVar(&completeConstructors, "complete-constructors", false,
+		"report New* constructors that don't initialize every +const field of the type they build")
+	Analyzer.Flags.BoolVar(&setters, "setters", false,
+		"report exported Set<Field> methods on types whose <Field> is +const")
+	Analyzer.Flags.BoolVar(&exportedConst, "exported-const", false,
+		"treat exported struct fields as +const unless marked +mutable, in packages using constlint markers")
+	Analyzer.Flags.BoolVar(&constReceivers, "const-receivers", false,
+		"report methods modifying their receiver unless their name matches -mutator-pattern")
+	Analyzer.Flags.Var(&mutatorPattern, "mutator-pattern",
+		"regular expression matching the names of methods allowed to modify their receiver with -const-receivers")
+	Analyzer.Flags.BoolVar(&concurrencyAudit, "concurrency-audit", false,
+		"report writes to +const fields reachable from go statements and HTTP handlers")
+	Analyzer.Flags.BoolVar(&channelOwnership, "channel-ownership", false,
+		"report sends on and closes of +const channel fields outside the methods and constructors of their type")
+	Analyzer.Flags.BoolVar(&redundantConstParams, "redundant-const-params", false,
+		"report +const on parameters passed by value without references, such as ints and strings, where it only prevents reassigning the copy")
+	Analyzer.Flags.BoolVar(&consolidateMarkers, "consolidate-markers", false,
+		"suggest marking a struct as a whole when all its fields carry the same marker, with a fix")
+	Analyzer.Flags.BoolVar(&requireMarkers, "require-markers", false,
+		"report exported fields of exported struct types not marked +const or +mutable, directly or through their type")
+	Analyzer.Flags.BoolVar(&suggestConstMethods, "suggest-const-methods", false,
+		"suggest marking pointer-receiver methods that never modify their receiver +const:receiver, with a fix")
+	Analyzer.Flags.BoolVar(&valueReceivers, "value-receivers", false,
+		"suggest value receivers for methods that never modify their receiver on types whose fields are all const, with a fix")
+	Analyzer.Flags.BoolVar(&elementWrites, "element-writes", false,
+		"report assignments replacing elements of slices, arrays and maps of structs with +const fields, outside of the functions creating the collection")
+	Analyzer.Flags.Var(&copyWrites, "copy-writes",
+		"report writes to the const fields of copies of existing values of these comma-separated `types`, also in functions instantiating them")
+	Analyzer.Flags.BoolVar(&strictGlobals, "strict-globals", false,
+		"report writes to +const package-level variables in init functions and var initializers too, which may otherwise set them up")
+	Analyzer.Flags.Var(&markerSynonyms, "marker-synonyms",
+		"treat these comma-separated `synonym=marker` pairs as markers, e.g. +readonly=+const,+frozen=+deepconst")
+	Analyzer.Flags.Var(&typeFilter, "type",
+		"only check the const fields of these comma-separated `types`, e.g. Config or mypkg.Config")
+	Analyzer.Flags.Var(&fieldFilter, "field",
+		"only check these comma-separated const `fields`, e.g. APIKey or Config.APIKey")
+	Analyzer.Flags.Var(&minConfidence, "min-confidence",
+		"only report diagnostics at least this `confident`: definite, probable or possible")
+	Analyzer.Flags.BoolVar(&debugExemptions, "debug-exemptions", false,
+		"report every allowed write to a +const field with the reason it is allowed")
+	Analyzer.Flags.BoolVar(&audit, "audit", false,
+		"report every write to a +const field, allowed ones with the reason, including composite literals")
+	Analyzer.Flags.BoolVar(&bestEffort, "best-effort", false,
+		"check packages with type errors for the writes to const fields and parameters their syntax shows, instead of skipping them")
+	Analyzer.Flags.BoolVar(&refactorSetters, "refactor-setters", false,
+		"offer fixes removing Set<Field> methods that write a +const field and passing the value to the nearest constructor instead")
+	Analyzer.Flags.BoolVar(&shallowConst, "shallow-const", false,
+		"report bare +const markers on pointer, slice and map fields, which only protect the reference")
 }
 
 // constField represents a field that should be treated as constant.
 type constField struct {
-	structType *types.TypeName
-	fieldName  string
+	owner  *types.TypeName // the struct type declaring the field
+	pos    token.Pos       // position of the field name
+	marker token.Pos       // position of the marker making the field const
+	mode   constMode       // whether data referenced by the field is protected too
+	secret bool            // the field must not be printed or logged
+	reason string          // why the field is const, if its marker says
+
+	writeOnce bool // a lazy initialization may set the field
+	external  bool // a field of an imported package, const outside of it only
 }
 
-// constParam represents a parameter that should be treated as constant.
+// constParam represents a function parameter that should be treated as
+// constant. Parameters are tracked by their types.Var, and the function by its
+// types.Func rather than its declaration, so the contract follows the function
+// into calls, method values (f := p.Update) and method expressions
+// ((*T).Update) alike.
 type constParam struct {
-	funcName    string
-	paramName   string
-	packagePath string
+	fn     *types.Func // the function declaring the parameter
+	marker token.Pos   // position of the marker making the parameter const
+	reason string      // why the parameter is const, if its marker says
+}
+
+// instantiation identifies a function that may construct a given struct type.
+type instantiation struct {
+	funcDecl *ast.FuncDecl
+	owner    *types.TypeName
+}
+
+// constDefaults are the package-wide defaults of collectConstFields.
+type constDefaults struct {
+	exported bool // exported fields without markers are const
+	deep     bool // bare +const markers are deep
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
+	if len(pass.TypeErrors) > 0 && !bestEffort {
+		return nil, errors.New("analysis skipped due to errors in package; -best-effort checks it anyway")
+	}
 	inspector := pass.ResultOf[inspect.Analyzer].(*astinspector.Inspector)
 
-	// First pass: find all struct fields and function parameters marked with // +const
-	constFields := make(map[constField]token.Pos)
-	constParams := make(map[constParam]token.Pos)
+	// First pass: find all struct fields and function parameters marked with // +const.
+	// Both maps are keyed by the declaring types.Var so the second pass can match
+	// uses with a single lookup.
+	constFields := make(map[*types.Var]constField)
+	constParams := make(map[*types.Var]constParam)
+	valueObjects := make(map[*types.TypeName]bool)
+	directives := collectPackageDirectives(pass.Files)
+	disabled := pass.ResultOf[disableAnalyzer].(*DisabledCode)
+	for _, d := range disabled.problems {
+		emit(pass, d, nil, nil)
+	}
+	typesOff := disabled.types
+	for typeName := range typesOff {
+		trace(pass, slog.LevelInfo, typeName.Pos(), "checks disabled by directive", "type", typeName.Name())
+	}
+	for _, file := range pass.Files {
+		for _, group := range file.Comments {
+			for _, m := range collectMarkers(group) {
+				trace(pass, slog.LevelDebug, m.pos, "marker", "text", m.String())
+			}
+		}
+	}
+	defaults := constDefaults{
+		exported: exportedConst && isAnnotated(pass.Files),
+		deep:     directives.deep,
+	}
 	nodeFilter := []ast.Node{
-		(*ast.TypeSpec)(nil),
+		(*ast.GenDecl)(nil),
 		(*ast.FuncDecl)(nil),
 	}
 	inspector.Preorder(nodeFilter, func(n ast.Node) {
 		switch node := n.(type) {
-		case *ast.TypeSpec:
-			structType, ok := node.Type.(*ast.StructType)
-			if !ok {
-				return
+		case *ast.GenDecl:
+			for _, spec := range node.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok {
+					if typeName, _ := pass.TypesInfo.Defs[spec.Name].(*types.TypeName); typesOff[typeName] {
+						continue
+					}
+					collectConstFields(pass, node, spec, defaults, constFields, valueObjects)
+					if consolidateMarkers && !focused() {
+						checkMarkerConsolidation(pass, node, spec)
+					}
+					if requireMarkers && !defaults.exported {
+						// Under -exported-const, exported fields without a
+						// marker are decided: they are const.
+						checkUndecidedFields(pass, node, spec)
+					}
+				}
 			}
-
-			// Get the type object for this struct
-			obj := pass.TypesInfo.Defs[node.Name]
-			if obj == nil {
-				return
+		case *ast.FuncDecl:
+			if !focused() && !typesOff[receiverType(pass, node)] {
+				collectConstParams(pass, node, constParams)
 			}
+		}
+	})
 
-			typeName, ok := obj.(*types.TypeName)
-			if !ok {
-				return
-			}
+	// Without complete type information, only the writes the syntax shows are
+	// checked.
+	if len(pass.TypeErrors) > 0 {
+		return checkBestEffort(pass, inspector, constFields, constParams), nil
+	}
 
-			// Check each field for the +const comment
-			for _, field := range structType.Fields.List {
-				if field.Doc == nil && field.Comment == nil {
-					continue
-				}
+	exportConstGlobals(pass)
+	exportConstMethods(pass)
 
-				var hasConstMarker bool
-				// Check doc comments
-				if field.Doc != nil {
-					for _, comment := range field.Doc.List {
-						if strings.Contains(comment.Text, "+const") {
-							hasConstMarker = true
-							break
-						}
-					}
-				}
+	// A run focused on some fields leaves out what isn't about them.
+	if !focused() {
+		checkGlobalWrites(pass, inspector, strictGlobals || directives.strict)
+		checkConstStructMethods(pass)
+		for _, file := range pass.Files {
+			checkMarkerPlacement(pass, file)
+			checkConstBlocks(pass, file)
+			checkMarkerTypos(pass, file)
+			checkCustomMarkers(pass, file)
+		}
 
-				// Check inline comments
-				if !hasConstMarker && field.Comment != nil {
-					for _, comment := range field.Comment.List {
-						if strings.Contains(comment.Text, "+const") {
-							hasConstMarker = true
-							break
-						}
-					}
-				}
+		if constReceivers {
+			checkConstReceivers(pass, inspector)
+		}
+		checkConstMethods(pass, inspector)
 
-				if hasConstMarker {
-					for _, name := range field.Names {
-						constFields[constField{
-							structType: typeName,
-							fieldName:  name.Name,
-						}] = name.Pos()
-					}
-				}
-			}
+		if suggestConstMethods {
+			checkConstMethodCandidates(pass, inspector)
+		}
 
-		case *ast.FuncDecl:
-			if node.Doc == nil {
-				return
-			}
-
-			// Look for +const comment
-			var constParamList string
-			var allParamsConst bool
-			
-			for _, comment := range node.Doc.List {
-				text := comment.Text
-				
-				// Check for +const:[param1,param2] format
-				constIndex := strings.Index(text, "// +const:[")
-				if constIndex != -1 {
-					startIdx := constIndex + len("// +const:[")
-					endIdx := strings.Index(text[startIdx:], "]")
-					if endIdx != -1 {
-						constParamList = text[startIdx : startIdx+endIdx]
-						break
-					}
-				}
-				
-				// Check for standalone +const marker (all params are const)
-				if strings.TrimSpace(text) == "// +const" {
-					allParamsConst = true
-					break
-				}
+		if redundantConstParams {
+			checkRedundantConstParams(pass, constParams)
+		}
+	}
+
+	// The +const:external fields of imported packages are checked where this
+	// one writes them, like its own const fields.
+	written := constFields
+	if external := importedExternalFields(pass); len(external) > 0 {
+		written = maps.Clone(constFields)
+		maps.Copy(written, external)
+	}
+
+	if len(written) == 0 && len(constParams) == 0 {
+		return newInventory(constFields, constParams, nil, nil), nil
+	}
+
+	// Second pass: locate mutations of constant fields or params, and record
+	// where const fields are initialized
+	instantiators := make(map[instantiation]token.Pos)
+	options := collectOptions(pass, inspector, constFields)
+	initialized := make(map[*types.Var][]token.Pos)
+	violations := make(map[*types.Var][]token.Pos)
+	aliases := make(map[*ast.FuncDecl]localAliases)
+	assign := func(lhs ast.Expr, stack []ast.Node) {
+		if _, field, _, ok := selectConstField(pass, lhs, constFields); ok {
+			initialized[field] = append(initialized[field], lhs.Pos())
+		}
+		if field, ok := checkFieldAssignment(pass, lhs, stack, written, instantiators, options, aliases); ok {
+			violations[field] = append(violations[field], lhs.Pos())
+		}
+		checkParamAssignment(pass, lhs, constParams, cachedAliases(pass, enclosingFuncDecl(stack), aliases))
+	}
+	assignFilter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.IncDecStmt)(nil),
+		(*ast.RangeStmt)(nil),
+		(*ast.CompositeLit)(nil),
+	}
+	inspector.WithStack(assignFilter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		switch node := n.(type) {
+		case *ast.CompositeLit:
+			recordLiteralInits(pass, node, constFields, initialized)
+			if audit {
+				reportLiteralInits(pass, node, constFields)
 			}
 
-			// If neither format was found, return
-			if constParamList == "" && !allParamsConst {
-				return
+		case *ast.AssignStmt:
+			// Skip declarations (var x = y)
+			if node.Tok == token.DEFINE {
+				return true
 			}
 
-			// Get all parameter names if allParamsConst is true
-			var paramNames []string
-			if allParamsConst {
-				// Get all parameter names from the function
-				if node.Type.Params != nil {
-					for _, field := range node.Type.Params.List {
-						for _, name := range field.Names {
-							paramNames = append(paramNames, name.Name)
-						}
-					}
-				}
-			} else {
-				// Parse the parameter list from the comment
-				paramNames = strings.Split(constParamList, ",")
-				for i := range paramNames {
-					paramNames[i] = strings.TrimSpace(paramNames[i])
-				}
+			// Check each LHS of the assignment
+			for _, lhs := range node.Lhs {
+				assign(lhs, stack)
 			}
 
-			// Get function name and package path
-			funcName := node.Name.Name
-			packagePath := pass.Pkg.Path()
+		case *ast.IncDecStmt:
+			// x++ and x-- assign x.
+			assign(node.X, stack)
 
-			// Mark each parameter as const
-			for _, paramName := range paramNames {
-				constParams[constParam{
-					funcName:    funcName,
-					paramName:   paramName,
-					packagePath: packagePath,
-				}] = node.Pos()
+		case *ast.RangeStmt:
+			// for x.y = range seq assigns each element in turn; the loop body
+			// belongs to the enclosing function, also for range-over-func
+			// iterators whose body runs as a yield callback.
+			if node.Tok != token.ASSIGN {
+				return true
+			}
+			for _, lhs := range []ast.Expr{node.Key, node.Value} {
+				if lhs != nil {
+					assign(lhs, stack)
+				}
 			}
 		}
+		return true
 	})
 
-	// Second pass: locate mutations of constant fields or params
-	assignFilter := []ast.Node{
-		(*ast.AssignStmt)(nil),
+	// A package asking for strict enforcement gets every optional rule on its
+	// const fields, whatever the flags say.
+	if deadMarkers || directives.strict {
+		reportDeadMarkers(pass, constFields, initialized)
 	}
-	inspector.Preorder(assignFilter, func(n ast.Node) {
-		assignStmt, ok := n.(*ast.AssignStmt)
-		if !ok {
-			return
-		}
-
-		// Skip declarations (var x = y)
-		if assignStmt.Tok == token.DEFINE {
-			return
-		}
 
-		// Check each LHS of the assignment
-		for _, lhs := range assignStmt.Lhs {
-			checkFieldAssignment(pass, lhs, constFields)
-			checkParamAssignment(pass, lhs, constParams)
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		if funcDecl := n.(*ast.FuncDecl); funcDecl.Body != nil {
+			checkDoubleWrites(pass, funcDecl, constFields, instantiators)
+			checkPublication(pass, funcDecl, constFields, instantiators)
+			checkAliasingReturns(pass, funcDecl, constFields, instantiators)
 		}
 	})
 
-	return nil, nil
+	if completeConstructors || directives.strict {
+		checkConstructorCompleteness(pass, inspector, constFields)
+	}
+
+	if setters || directives.strict || len(valueObjects) > 0 {
+		checkSetters(pass, inspector, constFields, func(owner *types.TypeName) bool {
+			return setters || directives.strict || valueObjects[owner]
+		})
+	}
+
+	if len(valueObjects) > 0 {
+		checkValueObjectMethods(pass, inspector, valueObjects)
+	}
+
+	if valueReceivers {
+		checkValueReceivers(pass, inspector, constFields, valueObjects)
+	}
+
+	if elementWrites {
+		checkElementWrites(pass, inspector, constFields)
+	}
+
+	if concurrencyAudit || directives.strict {
+		checkConcurrency(pass, inspector, constFields, violations)
+	}
+
+	if channelOwnership || directives.strict {
+		checkChannelOwnership(pass, inspector, constFields, instantiators)
+	}
+
+	checkSecretLeaks(pass, inspector, constFields)
+	checkDecodeTags(pass, constFields)
+	checkDecodeCalls(pass, inspector, written, instantiators)
+	checkMutatorCalls(pass, inspector, written, constParams, instantiators)
+
+	return newInventory(constFields, constParams, initialized, violations), nil
 }
 
-func checkAssignment(pass *analysis.Pass, expr ast.Expr, constFields map[constField]token.Pos) {
-	// We're looking for field selections (x.y = z)
-	selExpr, ok := expr.(*ast.SelectorExpr)
+// collectConstFields records every field of a struct type spec that carries a
+// +const marker in its doc or inline comment, that belongs to a struct type
+// marked +const or +valueobject as a whole and isn't exempted with +mutable, or
+// that is an identity field of an +entity. defaults may make exported fields
+// without markers const too, and bare markers deep.
+func collectConstFields(pass *analysis.Pass, decl *ast.GenDecl, spec *ast.TypeSpec, defaults constDefaults,
+	constFields map[*types.Var]constField, valueObjects map[*types.TypeName]bool) {
+	structType, ok := spec.Type.(*ast.StructType)
 	if !ok {
 		return
 	}
 
-	// Get the type information
-	selection, ok := pass.TypesInfo.Selections[selExpr]
+	// Get the type object for this struct
+	typeName, ok := pass.TypesInfo.Defs[spec.Name].(*types.TypeName)
 	if !ok {
 		return
 	}
+	if !typeInFocus(typeName) {
+		trace(pass, slog.LevelDebug, typeName.Pos(), "type out of focus of -type", "type", typeName.Name())
+		return
+	}
+
+	typeMarkers := structMarkers(decl, spec)
+	typeConstness, clashes := fieldMarkers(typeMarkers)
+	if !focused() {
+		reportClashes(pass, clashes, typeName.Name())
+	}
+	identity := entityIdentity(pass, typeName, structType, typeMarkers)
+	listedConst, listReason := listedConstFields(pass, typeName, structType, typeMarkers)
+	valueObject := typeConstness.valueObject.IsValid()
+	if valueObject {
+		valueObjects[typeName] = true
+	}
+
+	blocks, _ := constBlocks(fileOf(pass, spec.Pos()), structType)
+
+	// Check each field for the +const comment
+	for _, field := range structType.Fields.List {
+		constness, clashes := fieldMarkers(collectMarkers(field.Doc, field.Comment))
+		if block, ok := blockConstness(blocks, field.Pos()); ok && !constness.found {
+			constness = block
+		}
+		if constness.valueObject.IsValid() && !focused() {
+			report(pass, span{constness.valueObject, constness.valueObject + token.Pos(len(valueObjectMarker))},
+				CategoryMarker, "+valueobject marker has no effect on a field; place it on the struct type")
+		}
+		names := field.Names
+		if ident := EmbeddedFieldName(field.Type); len(names) == 0 && constness.found && ident != nil {
+			// An embedded field is only const by a marker of its own, such
+			// as that of a wrapper embedding a protobuf message.
+			names = []*ast.Ident{ident}
+		}
+		own := constness.found
+		if !constness.found {
+			constness = typeConstness
+		}
+		if constness.mutable && valueObject {
+			for _, name := range field.Names {
+				if name.IsExported() && fieldInFocus(typeName, name.Name) {
+					report(pass, name, CategoryValueObject, "value object %s exports mutable field %s",
+						typeName.Name(), name.Name)
+				}
+			}
+		}
+
+		for _, name := range names {
+			if !fieldInFocus(typeName, name.Name) {
+				continue
+			}
+			constness := constness
+			if pos, ok := listedConst[name.Name]; ok && !own {
+				constness = fieldConstness{found: true, mode: constShallow, pos: pos, reason: listReason}
+			}
+			if pos, listed := identity[name.Name]; !constness.found && listed {
+				constness = fieldConstness{found: true, mode: constShallow, pos: pos}
+			}
+			if !constness.found && defaults.exported && name.IsExported() {
+				// Const by default: shallow, without asking to spell it out,
+				// and decided by the field itself.
+				constness = fieldConstness{found: true, mode: constShallow, explicit: true, pos: name.Pos()}
+			}
+			if constness.found && !constness.explicit && defaults.deep {
+				// The package chose deep const for markers that don't say.
+				constness.mode, constness.explicit = constDeep, true
+			}
+			if constness.mutable || !constness.found {
+				continue
+			}
+
+			reportClashes(pass, clashes, typeName.Name()+"."+name.Name)
+
+			v, ok := pass.TypesInfo.Defs[name].(*types.Var)
+			if !ok {
+				continue
+			}
+			if constness.external {
+				// Its own package may write the field freely.
+				exportExternalConst(pass, v, typeName, constness)
+				trace(pass, slog.LevelInfo, name.Pos(), "const field outside its package", "type", typeName.Name(),
+					"field", name.Name, "marker", pass.Fset.Position(constness.pos).String())
+				continue
+			}
+			constFields[v] = constField{
+				owner:  typeName,
+				pos:    name.Pos(),
+				marker: constness.pos,
+				mode:   constness.mode,
+				secret: constness.secret,
+				reason: constness.reason,
+
+				writeOnce: constness.writeOnce,
+			}
+			trace(pass, slog.LevelInfo, name.Pos(), "const field", "type", typeName.Name(), "field", name.Name,
+				"deep", constness.mode == constDeep, "marker", pass.Fset.Position(constness.pos).String(),
+				"reason", constness.reason)
+
+			if shallowConst && !constness.explicit {
+				checkShallowTrap(pass, name, typeName, v)
+			}
+		}
+	}
+}
+
+// EmbeddedFieldName returns the identifier naming an embedded field in its
+// type, Order in *orderpb.Order or List[T], which the type checker records as
+// the field's definition. It returns nil for expressions no field embeds.
+func EmbeddedFieldName(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			expr = e.Sel
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
 
-	// Only interested in field selections
-	if selection.Kind() != types.FieldVal {
+// markerPos returns the position of the marker making the field const, or of
+// the field itself if the marker position is unknown.
+func (cf constField) markerPos() token.Pos {
+	if cf.marker.IsValid() {
+		return cf.marker
+	}
+	return cf.pos
+}
+
+// reportClashes reports the markers on the same declaration that contradict
+// or repeat each other.
+func reportClashes(pass *analysis.Pass, clashes []markerClash, subject string) {
+	for _, clash := range clashes {
+		if clash.duplicate() {
+			report(pass, clash[1], CategoryMarker, "duplicate constlint marker %s on %s", clash[1], subject)
+			continue
+		}
+		report(pass, clash[1], CategoryMarker, "conflicting constlint markers %s and %s on %s",
+			clash[0], clash[1], subject)
+	}
+}
+
+// checkShallowTrap reports a bare +const on a field of reference type, where
+// only the header is protected and the referenced data stays mutable.
+func checkShallowTrap(pass *analysis.Pass, name *ast.Ident, owner *types.TypeName, field *types.Var) {
+	var kind string
+	switch field.Type().Underlying().(type) {
+	case *types.Pointer:
+		kind = "pointer"
+	case *types.Slice:
+		kind = "slice"
+	case *types.Map:
+		kind = "map"
+	default:
 		return
 	}
 
-	// Get the receiver type
-	recvType := selection.Recv()
-	if recvType == nil {
+	report(pass, name, CategoryShallowConst, "+const on %s.%s protects the %s but not the data it references; "+
+		"mark it +deepconst or +const:shallow to record the intent", owner.Name(), name.Name, kind)
+}
+
+// collectConstParams records the parameters of a function declaration that are
+// marked const by its doc comment or by a trailing +const comment on their
+// line, reporting listed names and indexes that don't match a parameter. Lists
+// may name parameters or give their position, counted from 0, so markers on
+// generated code survive parameter renames.
+func collectConstParams(pass *analysis.Pass, funcDecl *ast.FuncDecl, constParams map[*types.Var]constParam) {
+	inline := inlineParamMarkers(pass, funcDecl)
+	marker, found, clashes := funcMarker(funcDecl.Doc)
+	if !found && len(inline) == 0 {
 		return
 	}
+	reportClashes(pass, clashes, funcDecl.Name.Name)
 
-	// Get the named type (dereference pointers if needed)
-	var namedType *types.Named
-	switch t := recvType.(type) {
-	case *types.Named:
-		namedType = t
-	case *types.Pointer:
-		if named, ok := t.Elem().(*types.Named); ok {
-			namedType = named
-		} else {
+	// params holds the parameters in order, nil for unnamed ones.
+	var params []*ast.Ident
+	if funcDecl.Type.Params != nil {
+		for _, field := range funcDecl.Type.Params.List {
+			if len(field.Names) == 0 {
+				params = append(params, nil)
+			}
+			params = append(params, field.Names...)
+		}
+	}
+
+	fn, _ := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+	markParam := func(name *ast.Ident, pos token.Pos, reason string) {
+		if name == nil {
 			return
 		}
-	default:
+		if v, ok := pass.TypesInfo.Defs[name].(*types.Var); ok {
+			constParams[v] = constParam{fn: fn, marker: pos, reason: reason}
+			trace(pass, slog.LevelInfo, name.Pos(), "const parameter", "func", funcDecl.Name.Name, "param", name.Name,
+				"marker", pass.Fset.Position(pos).String(), "reason", reason)
+		}
+	}
+
+	if marker.all {
+		for _, name := range params {
+			markParam(name, marker.pos, marker.reason)
+		}
 		return
 	}
 
-	// Get the type name
-	typeName := namedType.Obj()
-	fieldName := selExpr.Sel.Name
+	marked := make(map[int]bool)
+	for i, name := range params {
+		if pos, ok := inline[name]; ok && name != nil {
+			marked[i] = true
+			markParam(name, pos, "")
+		}
+	}
+
+	// Lists on several marker lines add up; only repeats within one list are
+	// reported.
+	for _, list := range marker.lists {
+		seen := make(map[int]bool, len(list))
+		for _, listed := range list {
+			index := -1
+			if n, err := strconv.Atoi(listed.name); err == nil {
+				if n < 0 || n >= len(params) {
+					report(pass, listed, CategoryMarker,
+						"+const marker lists parameter %d, which is out of range for the %d parameter(s) of %s",
+						n, len(params), funcDecl.Name.Name)
+					continue
+				}
+				index = n
+			} else {
+				for i, name := range params {
+					if name != nil && name.Name == listed.name {
+						index = i
+						break
+					}
+				}
+				if index < 0 {
+					report(pass, listed, CategoryMarker, "+const marker lists %s, which is not a parameter of %s",
+						listed.name, funcDecl.Name.Name)
+					continue
+				}
+			}
+
+			if seen[index] {
+				report(pass, listed, CategoryMarker, "+const marker lists parameter %s of %s more than once",
+					listed.name, funcDecl.Name.Name)
+				continue
+			}
+			seen[index] = true
+			if !marked[index] {
+				marked[index] = true
+				markParam(params[index], listed.pos, marker.reason)
+			}
+		}
+	}
+}
+
+// inlineParamMarkers returns the parameters of a function declaration marked
+// by a trailing // +const comment on their line, mapped to the marker position:
+//
+//	func Transfer(
+//		from *Account, // +const
+//		to *Account,
+//		amount int, // +const
+//	)
+//
+// A marker on a line without a parameter is reported.
+func inlineParamMarkers(pass *analysis.Pass, funcDecl *ast.FuncDecl) map[*ast.Ident]token.Pos {
+	params := funcDecl.Type.Params
+	if params == nil || !params.Opening.IsValid() {
+		return nil
+	}
+	file := fileOf(pass, funcDecl.Pos())
+	if file == nil {
+		return nil
+	}
 
-	// Check if this is a const field
-	cf := constField{
-		structType: typeName,
-		fieldName:  fieldName,
+	var inline map[*ast.Ident]token.Pos
+	for _, group := range file.Comments {
+		if group.Pos() < params.Opening || group.End() > params.Closing {
+			continue
+		}
+		for _, comment := range group.List {
+			for _, m := range parseMarkers(comment) {
+				if m.name != constMarker || m.arg != "" {
+					continue
+				}
+				line := pass.Fset.Position(m.pos).Line
+				var field *ast.Field
+				for _, f := range params.List {
+					if f.End() <= m.pos && pass.Fset.Position(f.End()).Line == line {
+						field = f
+					}
+				}
+				if field == nil {
+					report(pass, m, CategoryMarker,
+						"+const marker in the parameter list of %s must follow a parameter on its line",
+						funcDecl.Name.Name)
+					continue
+				}
+				if inline == nil {
+					inline = make(map[*ast.Ident]token.Pos)
+				}
+				for _, name := range field.Names {
+					inline[name] = m.pos
+				}
+			}
+		}
+	}
+	return inline
+}
+
+// checkFieldAssignment reports an assignment to a const field made outside of
+// a function that instantiates the field's struct type, or of a functional
+// option applied to the value written, unless a registered exemption allows
+// it. Writes into the value stored in a const field (x.y.z, x.y[i]) count as
+// writes to the field, while writes through a reference it holds (*x.y,
+// x.y[k] of a slice or map) only do so for +deepconst fields, also when made
+// through a local alias of the field. A +writeonce field may also be set by
+// its lazy initialization. It returns the field written, if it reported one.
+func checkFieldAssignment(pass *analysis.Pass, expr ast.Expr, stack []ast.Node,
+	constFields map[*types.Var]constField, instantiators map[instantiation]token.Pos,
+	options functionalOptions, aliases map[*ast.FuncDecl]localAliases) (*types.Var, bool) {
+	funcDecl := enclosingFuncDecl(stack)
+	selExpr, field, cf, ok := writtenConstField(pass, expr, constFields, cachedAliases(pass, funcDecl, aliases))
+	if !ok {
+		return nil, false
+	}
+
+	if option, ok := appliedOption(pass, selExpr.X, stack, cf.owner, options); ok {
+		traceAllowed(pass, expr.Pos(), field, cf.owner, "written by an option of "+cf.owner.Name())
+		if debugExemptions || audit {
+			reportRelated(pass, expr, CategoryExemption, option.pos, "option declared here",
+				"assignment to const field %s.%s allowed: written by an option of %s",
+				cf.owner.Name(), field.Name(), cf.owner.Name())
+		}
+		return nil, false
 	}
 
-	if fieldPos, exists := constFields[cf]; exists {
-		// Now we need to determine if we're in a constructor
-		if !isInstanciator(pass, selExpr, namedType) {
-			pass.Reportf(selExpr.Pos(), "assignment to const field %s.%s (marked with // +const at %s)",
-				typeName.Name(), fieldName, pass.Fset.Position(fieldPos))
+	// Now we need to determine if we're in a constructor
+	if site := cachedInstantiationSite(pass, funcDecl, cf.owner, instantiators); site.IsValid() {
+		if from, ok := writtenCopy(pass, funcDecl, selExpr.X); ok && copyWritesOf(cf.owner) {
+			reportWrite(pass, expr, CategoryCopyWrite, field, cf.owner, from, "copied here",
+				"assignment to const field %s.%s of a copy of an existing %s", cf.owner.Name(), field.Name(),
+				cf.owner.Name())
+			return field, true
+		}
+		traceAllowed(pass, expr.Pos(), field, cf.owner, funcDecl.Name.Name+" instantiates "+cf.owner.Name())
+		if debugExemptions || audit {
+			reportRelated(pass, expr, CategoryExemption, site, cf.owner.Name()+" instantiated here",
+				"assignment to const field %s.%s allowed: %s instantiates %s",
+				cf.owner.Name(), field.Name(), funcDecl.Name.Name, cf.owner.Name())
+		}
+		return nil, false
+	}
+	if name, ok := exemptedBy(pass, WriteSite{Expr: expr, Field: field, Owner: cf.owner, Func: funcDecl}); ok {
+		traceAllowed(pass, expr.Pos(), field, cf.owner, "exempted by "+name)
+		if debugExemptions || audit {
+			report(pass, expr, CategoryExemption, "assignment to const field %s.%s allowed: exempted by %s",
+				cf.owner.Name(), field.Name(), name)
 		}
+		return nil, false
 	}
+	if guard, ok := lazyInit(pass.TypesInfo, expr, selExpr, stack); ok && cf.writeOnce {
+		traceAllowed(pass, expr.Pos(), field, cf.owner, "lazy initialization")
+		if debugExemptions || audit {
+			reportRelated(pass, expr, CategoryExemption, guard.Pos(), "lazy initialization guarded here",
+				"assignment to write-once field %s.%s allowed: lazy initialization", cf.owner.Name(), field.Name())
+		}
+		return nil, false
+	}
+	kind, outside := cf.kind(ast.Unparen(expr) == selExpr)
+	if selExpr.Pos() < expr.Pos() || selExpr.End() > expr.End() {
+		// The field was reached through an alias declared elsewhere.
+		reportWrite(pass, expr, CategoryFieldWrite, field, cf.owner, cf.markerPos(), "field marked const here",
+			"assignment to %s %s.%s through %s%s", kind, cf.owner.Name(), field.Name(), rootIdent(expr).Name,
+			because(cf.reason))
+		return field, true
+	}
+	d := writeDiagnostic(expr, CategoryFieldWrite, cf.markerPos(), "field marked const here",
+		"assignment to %s %s.%s%s%s", kind, cf.owner.Name(), field.Name(), outside, because(cf.reason))
+	if refactorSetters {
+		if fix, ok := setterRefactoring(pass, funcDecl, expr, field, cf.owner); ok {
+			d.SuggestedFixes = []analysis.SuggestedFix{fix}
+		}
+	}
+	emit(pass, d, field, cf.owner)
+	return field, true
 }
 
-// Rename checkAssignment to checkFieldAssignment for clarity
-func checkFieldAssignment(pass *analysis.Pass, expr ast.Expr, constFields map[constField]token.Pos) {
-	checkAssignment(pass, expr, constFields)
+// writtenConstField walks an assignment target from the outside in and returns
+// the outermost const field the write modifies. Local aliases dereferenced on
+// the way are replaced by what they alias, so *f = x writes p.Data after
+// f := &p.Data, and s[0] = x writes the elements of p.Items after s := p.Items.
+func writtenConstField(pass *analysis.Pass, expr ast.Expr, constFields map[*types.Var]constField,
+	aliases localAliases) (*ast.SelectorExpr, *types.Var, constField, bool) {
+	indirect := false
+	// deref tells whether the step outside expr dereferenced it, and
+	// beforeDeref whether the write was indirect before that step.
+	deref, beforeDeref := false, false
+	dereference := func() {
+		deref, beforeDeref, indirect = true, indirect, true
+	}
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+
+		case *ast.StarExpr:
+			dereference()
+			expr = e.X
+
+		case *ast.IndexExpr:
+			deref = false
+			switch pass.TypesInfo.TypeOf(e.X).Underlying().(type) {
+			case *types.Slice, *types.Map, *types.Pointer:
+				dereference()
+			}
+			expr = e.X
+
+		case *ast.SelectorExpr:
+			if selExpr, field, cf, ok := selectConstField(pass, e, constFields); ok {
+				if !indirect || cf.mode == constDeep {
+					return selExpr, field, cf, true
+				}
+			}
+			selection, ok := pass.TypesInfo.Selections[e]
+			if !ok || selection.Kind() != types.FieldVal {
+				return nil, nil, constField{}, false
+			}
+			// A promoted field is reached through the fields embedding it,
+			// from the last one out.
+			embedded := embeddedFields(selection)
+			for i := len(embedded) - 1; i >= 0; i-- {
+				f := embedded[i]
+				if _, ok := f.Type().Underlying().(*types.Pointer); ok {
+					indirect = true
+				}
+				if cf, ok := constFields[f.Origin()]; ok && (!indirect || cf.mode == constDeep) {
+					return e, f.Origin(), cf, true
+				}
+			}
+			deref = false
+			if _, ok := selection.Recv().Underlying().(*types.Pointer); ok || selection.Indirect() {
+				dereference()
+			}
+			expr = e.X
+
+		case *ast.Ident:
+			// Assigning an alias itself only changes what it refers to.
+			v, _ := pass.TypesInfo.Uses[e].(*types.Var)
+			origin, ok := aliases[v]
+			if !ok || !deref {
+				return nil, nil, constField{}, false
+			}
+			switch o := ast.Unparen(origin).(type) {
+			case *ast.UnaryExpr:
+				// Dereferencing &x reaches x itself.
+				indirect, deref, expr = beforeDeref, false, o.X
+			case *ast.SliceExpr:
+				// Indexing x[i:j] reaches the elements of x.
+				indirect, deref, expr = beforeDeref, false, &ast.IndexExpr{X: o.X, Index: o.Low}
+			default:
+				// A copied reference reaches the same data.
+				expr = origin
+			}
+
+		default:
+			return nil, nil, constField{}, false
+		}
+	}
 }
 
-// checkParamAssignment checks if a parameter marked as const is being modified
-func checkParamAssignment(pass *analysis.Pass, expr ast.Expr, constParams map[constParam]token.Pos) {
-	// Get the identifier being assigned to
-	var ident *ast.Ident
-	switch e := expr.(type) {
-	case *ast.Ident:
-		ident = e
-	default:
-		return
+// selectConstField resolves a field selection (x.y) to the const field it
+// refers to, if any.
+func selectConstField(pass *analysis.Pass, expr ast.Expr,
+	constFields map[*types.Var]constField) (*ast.SelectorExpr, *types.Var, constField, bool) {
+	// We're looking for field selections (x.y = z)
+	selExpr, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return nil, nil, constField{}, false
 	}
 
-	// Find the enclosing function
-	path, found := astPath(pass.Files, expr)
-	if !found {
-		return
+	// Get the type information, only interested in field selections
+	selection, ok := pass.TypesInfo.Selections[selExpr]
+	if !ok || selection.Kind() != types.FieldVal {
+		return nil, nil, constField{}, false
+	}
+
+	// Fields of instantiated generic types are distinct objects; match on the origin
+	field, ok := selection.Obj().(*types.Var)
+	if !ok {
+		return nil, nil, constField{}, false
 	}
+	field = field.Origin()
+
+	cf, exists := constFields[field]
+	return selExpr, field, cf, exists
+}
 
-	var funcDecl *ast.FuncDecl
-	for i := len(path) - 1; i >= 0; i-- {
-		if fd, ok := path[i].(*ast.FuncDecl); ok {
-			funcDecl = fd
-			break
+// embeddedFields returns the embedded fields a selection of a promoted field
+// or method goes through, outermost first.
+func embeddedFields(selection *types.Selection) []*types.Var {
+	var fields []*types.Var
+	t := selection.Recv()
+	for _, index := range selection.Index()[:len(selection.Index())-1] {
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			return nil
 		}
+		fields = append(fields, st.Field(index))
+		t = st.Field(index).Type()
 	}
+	return fields
+}
 
-	if funcDecl == nil {
+// checkParamAssignment checks if a parameter marked as const is being
+// modified, directly or through a pointer to it held by a local alias
+// (ptr := &p; *ptr = x).
+func checkParamAssignment(pass *analysis.Pass, expr ast.Expr, constParams map[*types.Var]constParam,
+	aliases localAliases) {
+	// Get the identifier being assigned to
+	ident, ok := expr.(*ast.Ident)
+	via := ""
+	if star, isStar := ast.Unparen(expr).(*ast.StarExpr); isStar {
+		ptr, _ := ast.Unparen(star.X).(*ast.Ident)
+		v, _ := pass.TypesInfo.Uses[ptr].(*types.Var)
+		if addr, isAddr := ast.Unparen(aliases[v]).(*ast.UnaryExpr); isAddr && addr.Op == token.AND {
+			ident, ok = ast.Unparen(addr.X).(*ast.Ident)
+			via = " through " + ptr.Name
+		}
+	}
+	if !ok {
 		return
 	}
 
-	// Check if this identifier is a parameter in the function
-	obj := pass.TypesInfo.ObjectOf(ident)
-	if obj == nil || obj.Pos() == token.NoPos {
+	// Check if this identifier refers to a parameter marked as const
+	v, ok := pass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok {
 		return
 	}
 
-	// Check if this parameter is marked as const
-	cp := constParam{funcName: funcDecl.Name.Name, paramName: ident.Name, packagePath: pass.Pkg.Path()}
-	if paramPos, exists := constParams[cp]; exists {
-		pass.Reportf(ident.Pos(), "assignment to const parameter %s (marked with // +const at %s)",
-			ident.Name, pass.Fset.Position(paramPos))
+	if param, exists := constParams[v]; exists {
+		reportWrite(pass, expr, CategoryParamWrite, v, nil, param.marker, "parameter marked const here",
+			"assignment to const parameter %s%s%s", ident.Name, via, because(param.reason))
 	}
 }
 
-func isInstanciator(pass *analysis.Pass, expr ast.Expr, namedType *types.Named) bool {
-	// Find the enclosing function
-	path, _ := astPath(pass.Files, expr)
-	var funcDecl *ast.FuncDecl
-	for i := len(path) - 1; i >= 0; i-- {
-		if fd, ok := path[i].(*ast.FuncDecl); ok {
-			funcDecl = fd
-			break
-		}
+// isInstanciator reports whether the function creates a value of the given
+// struct type, and is therefore allowed to initialize its const fields.
+func isInstanciator(pass *analysis.Pass, funcDecl *ast.FuncDecl, owner *types.TypeName) bool {
+	return instantiationSite(pass, funcDecl, owner).IsValid()
+}
+
+// instantiationSite returns the position of the first place the function
+// creates a value of the given struct type, or token.NoPos if there is none.
+// Values are created by composite literals (T{}, &T{}), new(T) and zero-value
+// declarations (var t T).
+func instantiationSite(pass *analysis.Pass, funcDecl *ast.FuncDecl, owner *types.TypeName) token.Pos {
+	if funcDecl == nil || funcDecl.Body == nil {
+		return token.NoPos
 	}
 
-	if funcDecl == nil {
-		return false
+	isOwner := func(t types.Type) bool {
+		named, ok := t.(*types.Named)
+		return ok && named.Obj() == owner
 	}
 
-	// Check if the function contains a composite literal of the struct type
-	foundInstantiation := false
+	site := token.NoPos
 	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
-		if foundInstantiation {
+		if site.IsValid() {
 			return false
 		}
 
-		// Look for composite literals
-		if compLit, ok := n.(*ast.CompositeLit); ok {
-			// Get the type of the composite literal
-			litType := pass.TypesInfo.TypeOf(compLit.Type)
-			if litType == nil {
-				return true
-			}
-
-			// Handle pointer types
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+			// Get the type of the composite literal, handling pointer types
+			litType := pass.TypesInfo.TypeOf(n)
 			if ptr, ok := litType.(*types.Pointer); ok {
 				litType = ptr.Elem()
 			}
+			if isOwner(litType) {
+				site = n.Pos()
+			}
+
+		case *ast.CallExpr:
+			if len(n.Args) != 1 {
+				return true
+			}
+			if builtin, ok := typeutil.Callee(pass.TypesInfo, n).(*types.Builtin); ok && builtin.Name() == "new" &&
+				isOwner(pass.TypesInfo.TypeOf(n.Args[0])) {
+				site = n.Pos()
+			}
 
-			// Check if it's our struct type
-			if types.Identical(litType, namedType) {
-				foundInstantiation = true
-				return false
+		case *ast.ValueSpec:
+			if n.Type != nil && len(n.Values) == 0 && isOwner(pass.TypesInfo.TypeOf(n.Type)) {
+				site = n.Pos()
 			}
 		}
-		return true
+		return !site.IsValid()
 	})
 
-	return foundInstantiation
+	return site
 }
 
-// astPath returns the path from the root of the AST to the given node
-func astPath(files []*ast.File, target ast.Node) ([]ast.Node, bool) {
-	var path []ast.Node
-	found := false
+// isCachedInstanciator is isInstanciator memoized in instantiators.
+func isCachedInstanciator(pass *analysis.Pass, funcDecl *ast.FuncDecl, owner *types.TypeName,
+	instantiators map[instantiation]token.Pos) bool {
+	return cachedInstantiationSite(pass, funcDecl, owner, instantiators).IsValid()
+}
 
-	for _, file := range files {
-		ast.Inspect(file, func(n ast.Node) bool {
-			if found {
-				return false
-			}
-			if n == target {
-				found = true
-				return false
-			}
-			if n != nil {
-				path = append(path, n)
-			}
-			return true
-		})
-		if found {
-			break
-		}
-		path = nil
+// cachedInstantiationSite is instantiationSite memoized in instantiators.
+func cachedInstantiationSite(pass *analysis.Pass, funcDecl *ast.FuncDecl, owner *types.TypeName,
+	instantiators map[instantiation]token.Pos) token.Pos {
+	key := instantiation{funcDecl: funcDecl, owner: owner}
+	site, cached := instantiators[key]
+	if !cached {
+		site = instantiationSite(pass, funcDecl, owner)
+		instantiators[key] = site
 	}
+	return site
+}
 
-	return path, found
+// enclosingFuncDecl returns the innermost function declaration on the stack.
+func enclosingFuncDecl(stack []ast.Node) *ast.FuncDecl {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if fd, ok := stack[i].(*ast.FuncDecl); ok {
+			return fd
+		}
+	}
+	return nil
 }
@@ -1,13 +1,555 @@
 package analyzer_test
 
 import (
-	"github.com/bunniesandbeatings/constlint/analyzer"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
 )
 
 func TestAnalyzer(t *testing.T) {
 	testdata := analysistest.TestData()
 	analysistest.Run(t, testdata, analyzer.Analyzer, "a")
 }
+
+func TestDeadMarkers(t *testing.T) {
+	setFlag(t, "dead-markers", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "deadmarkers", "deadmarkers/options", "deadmarkers/client")
+}
+
+func TestCompleteConstructors(t *testing.T) {
+	setFlag(t, "complete-constructors", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "constructors")
+}
+
+func TestSetters(t *testing.T) {
+	setFlag(t, "setters", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "setters")
+}
+
+func TestExportedConst(t *testing.T) {
+	setFlag(t, "exported-const", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "exportedconst", "unannotated")
+}
+
+func TestConstReceivers(t *testing.T) {
+	setFlag(t, "const-receivers", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "receivers")
+}
+
+func TestConcurrencyAudit(t *testing.T) {
+	setFlag(t, "concurrency-audit", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "concurrency")
+}
+
+func TestChannelOwnership(t *testing.T) {
+	setFlag(t, "channel-ownership", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "channels")
+}
+
+func TestPackageDirectives(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "strict")
+}
+
+func TestStructConst(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "structconst")
+}
+
+func TestShallowConst(t *testing.T) {
+	setFlag(t, "shallow-const", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "shallowconst")
+}
+
+func TestRedundantConstParams(t *testing.T) {
+	setFlag(t, "redundant-const-params", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "redundant")
+}
+
+func TestDebugExemptions(t *testing.T) {
+	setFlag(t, "debug-exemptions", "true")
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, analyzer.Analyzer, "exemptions")
+
+	fset := results[0].Pass.Fset
+	for _, d := range results[0].Diagnostics {
+		if d.Category != "exemption" {
+			continue
+		}
+		if len(d.Related) != 1 || fset.Position(d.Related[0].Pos).Line != 13 {
+			t.Errorf("exemption %q doesn't point at the instantiation on line 13", d.Message)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	setFlag(t, "audit", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "audit")
+}
+
+func TestRegisterExemption(t *testing.T) {
+	t.Cleanup(analyzer.RegisterExemption("migrations", func(pass *analysis.Pass, site analyzer.WriteSite) bool {
+		return pass.Pkg.Path() == "exemptapi" && site.Func != nil && strings.HasPrefix(site.Func.Name.Name, "Migrate")
+	}))
+	setFlag(t, "debug-exemptions", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "exemptapi")
+}
+
+func TestRegisterMarker(t *testing.T) {
+	t.Cleanup(analyzer.RegisterMarker(analyzer.MarkerDef{
+		Name:    "+locked",
+		Targets: analyzer.TargetField,
+		Check: func(pass *analysis.Pass, use analyzer.MarkerUse) {
+			fields := use.Owner.Type().Underlying().(*types.Struct)
+			for i := 0; i < fields.NumFields(); i++ {
+				if f := fields.Field(i); f.Name() == use.Arg && types.TypeString(f.Type(), nil) == "sync.Mutex" {
+					return
+				}
+			}
+			use.Reportf(pass, use.Marker, "field %s of %s is guarded by %s, which isn't a mutex field of %s",
+				use.Object.Name(), use.Owner.Name(), use.Arg, use.Owner.Name())
+		},
+	}))
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "markerapi")
+}
+
+func TestHandleViolations(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	t.Cleanup(analyzer.HandleViolations(func(pass *analysis.Pass, v analyzer.Violation) bool {
+		if pass.Pkg.Path() != "violations" {
+			return true
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		owner := "-"
+		if v.Owner != nil {
+			owner = v.Owner.Name()
+		}
+		got = append(got, fmt.Sprintf("%s %s %s %s", v.Category, v.Confidence, owner, v.Object.Name()))
+		return v.Category != "field-write"
+	}))
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "violations")
+
+	want := []string{"field-write definite Job ID", "param-write definite - delay"}
+	sort.Strings(got)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("handled\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestInventory(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, analyzer.Analyzer, "inventory")
+	inventory := results[0].Result.(*analyzer.Inventory)
+	if len(inventory.Structs) != 1 {
+		t.Fatalf("got %d structs, want 1", len(inventory.Structs))
+	}
+
+	fset := results[0].Pass.Fset
+	var got []string
+	for _, cf := range inventory.Structs[0].Fields {
+		lines := func(positions []token.Pos) string {
+			var lines []string
+			for _, pos := range positions {
+				lines = append(lines, fmt.Sprint(fset.Position(pos).Line))
+			}
+			return strings.Join(lines, ",")
+		}
+		got = append(got, fmt.Sprintf("%s deep=%t secret=%t marker=%d inits=%s violations=%s", cf.Var.Name(),
+			cf.Deep, cf.Secret, fset.Position(cf.Marker).Line, lines(cf.Inits), lines(cf.Violations)))
+	}
+	want := []string{
+		"Value deep=false secret=true marker=5 inits=15 violations=",
+		"Scopes deep=true secret=false marker=7 inits=16,22 violations=22",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got fields\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestInventoryFuncs(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, analyzer.Analyzer, "inventory")
+	pass := results[0].Pass
+	inventory := results[0].Result.(*analyzer.Inventory)
+
+	var got []string
+	for _, spec := range pass.Files[0].Decls[len(pass.Files[0].Decls)-1].(*ast.GenDecl).Specs {
+		spec := spec.(*ast.ValueSpec)
+		f := inventory.Func(analyzer.FuncOf(pass.TypesInfo, spec.Values[0]))
+		if f == nil {
+			t.Errorf("%s: no const parameters found", spec.Names[0].Name)
+			continue
+		}
+		var params []string
+		for _, param := range f.Params {
+			params = append(params, param.Name())
+		}
+		got = append(got, fmt.Sprintf("%s=%s(%s)", spec.Names[0].Name, f.Func.Name(), strings.Join(params, ",")))
+	}
+	want := "grant=Grant(scope) grantTo=Grant(scope) checkInt=Check(values,v)"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestRelatedInformation(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, analyzer.Analyzer, "related")
+
+	fset := results[0].Pass.Fset
+	var got []string
+	for _, d := range results[0].Diagnostics {
+		for _, related := range d.Related {
+			pos := fset.Position(related.Pos)
+			got = append(got, fmt.Sprintf("%d: %s at %d:%d", fset.Position(d.Pos).Line, related.Message, pos.Line, pos.Column))
+		}
+	}
+	want := []string{
+		"11: field marked const here at 5:5",
+		"17: parameter marked const here at 15:12",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got related information\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDiagnosticRanges(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, analyzer.Analyzer, "related")
+
+	fset := results[0].Pass.Fset
+	var got []string
+	for _, d := range results[0].Diagnostics {
+		pos, end := fset.Position(d.Pos), fset.Position(d.End)
+		got = append(got, fmt.Sprintf("%d:%d-%d:%d", pos.Line, pos.Column, end.Line, end.Column))
+	}
+	want := []string{"11:2-11:5", "17:2-17:8"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got ranges %s, want %s", strings.Join(got, " "), strings.Join(want, " "))
+	}
+}
+
+func TestDiagnosticCategories(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, analyzer.Analyzer, "related")
+
+	var got []string
+	for _, d := range results[0].Diagnostics {
+		got = append(got, d.Category)
+	}
+	want := []string{"field-write", "param-write"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got categories %s, want %s", strings.Join(got, " "), strings.Join(want, " "))
+	}
+}
+
+// setFlag sets an analyzer flag for the duration of the test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	f := analyzer.Analyzer.Flags.Lookup(name)
+	if f == nil {
+		t.Fatalf("unknown analyzer flag %q", name)
+	}
+	previous := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := f.Value.Set(previous); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// BenchmarkAnalyzer measures a run over a large generated package. Package
+// loading happens once, outside the timed loop.
+func BenchmarkAnalyzer(b *testing.B) {
+	dir := b.TempDir()
+	pkgDir := filepath.Join(dir, "src", "bench")
+	if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		src := benchSource(i, 50)
+		if err := os.WriteFile(filepath.Join(pkgDir, fmt.Sprintf("bench%d.go", i)), []byte(src), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Dir:  pkgDir,
+		Env:  append(os.Environ(), "GOPATH="+dir, "GO111MODULE=off", "GOPROXY=off"),
+	}, "bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		b.Fatal("failed to load benchmark package")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		graph, err := checker.Analyze([]*analysis.Analyzer{analyzer.Analyzer}, pkgs, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, root := range graph.Roots {
+			if root.Err != nil {
+				b.Fatal(root.Err)
+			}
+			if len(root.Diagnostics) > 0 {
+				b.Fatalf("unexpected diagnostic: %s", root.Diagnostics[0].Message)
+			}
+		}
+	}
+}
+
+// benchSource returns a file declaring n annotated types together with their
+// constructors, mutators of non-const fields and const-param functions.
+func benchSource(file, n int) string {
+	var sb strings.Builder
+	sb.WriteString("package bench\n")
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("T%d_%d", file, i)
+		fmt.Fprintf(&sb, `
+type %[1]s struct {
+	// +const
+	ID string
+	Name string // +const
+	Count int
+}
+
+func New%[1]s(id, name string) *%[1]s {
+	t := &%[1]s{}
+	t.ID = id
+	t.Name = name
+	t.Count = 0
+	return t
+}
+
+func (t *%[1]s) Inc() {
+	t.Count = t.Count + 1
+}
+
+// Sum%[1]s adds its arguments.
+// +const:[a, b]
+func Sum%[1]s(a, b int) (c int) {
+	c = a + b
+	return c
+}
+`, name)
+	}
+	return sb.String()
+}
+
+func TestConsolidateMarkers(t *testing.T) {
+	setFlag(t, "consolidate-markers", "true")
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, analyzer.Analyzer, "consolidate")
+}
+
+func TestRequireMarkers(t *testing.T) {
+	setFlag(t, "require-markers", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "undecided")
+}
+
+func TestRequireMarkersExportedConst(t *testing.T) {
+	setFlag(t, "require-markers", "true")
+	setFlag(t, "exported-const", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "undecidedexported")
+}
+
+func TestConstMethods(t *testing.T) {
+	setFlag(t, "suggest-const-methods", "true")
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, analyzer.Analyzer, "constmethods")
+}
+
+func TestValueReceivers(t *testing.T) {
+	setFlag(t, "value-receivers", "true")
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, analyzer.Analyzer, "valuereceivers")
+}
+
+func TestMutatorCalls(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "mutators")
+}
+
+func TestProtobufMutators(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "protobuf")
+}
+
+func TestSetterRefactoring(t *testing.T) {
+	setFlag(t, "refactor-setters", "true")
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, analyzer.Analyzer, "refactor")
+}
+
+func TestConstBlocks(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "blocks")
+}
+
+func TestConstFieldLists(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "fieldlists")
+}
+
+func TestExternalConst(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "external", "externaluse")
+}
+
+func TestInterfaceMutators(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "interfaces")
+}
+
+func TestTrace(t *testing.T) {
+	// The handler serializes the writes of concurrent passes.
+	var log bytes.Buffer
+	analyzer.SetLogger(slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer analyzer.SetLogger(nil)
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "trace")
+
+	for _, want := range []string{
+		`level=DEBUG msg=marker package=trace pos=`,
+		`level=DEBUG msg="fact exported" package=trace pos=`,
+		`level=INFO msg="const field" package=trace pos=`,
+		`type=Account field=Owner deep=false marker=`,
+		`type=Account field=Owner reason="NewAccount instantiates Account"`,
+		`msg="diagnostic suppressed" package=trace`,
+		`rule=field-write message="assignment to const field Account.Owner" reason="disabled by a directive"`,
+	} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("trace lacks %s:\n%s", want, log.String())
+		}
+	}
+}
+
+func TestConstGlobals(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "globals", "globaluse")
+}
+
+func TestStrictGlobals(t *testing.T) {
+	setFlag(t, "strict-globals", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "strictglobals")
+}
+
+func TestCopyWrites(t *testing.T) {
+	setFlag(t, "copy-writes", "User")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "copywrites")
+}
+
+func TestElementWrites(t *testing.T) {
+	setFlag(t, "element-writes", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "elementwrites")
+}
+
+func TestMarkerReasons(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "reasons")
+}
+
+func TestDisableDirective(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "disable")
+}
+
+func TestCgo(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("no C compiler")
+	}
+	setFlag(t, "consolidate-markers", "true")
+	testdata := analysistest.TestData()
+	for _, result := range analysistest.Run(t, testdata, analyzer.Analyzer, "cgo") {
+		for _, d := range result.Diagnostics {
+			// The fix would edit the copy cgo compiles.
+			if len(d.SuggestedFixes) > 0 {
+				t.Errorf("%s: %s: unexpected fix", result.Pass.Fset.Position(d.Pos), d.Message)
+			}
+		}
+	}
+}
+
+func TestBestEffort(t *testing.T) {
+	setFlag(t, "best-effort", "true")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "besteffort")
+}
+
+func TestWriteOnce(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "writeonce")
+}
+
+func TestMarkerSynonyms(t *testing.T) {
+	setFlag(t, "marker-synonyms", "+readonly=+const, +frozen=+deepconst, +writable=+mutable")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "synonyms")
+
+	for _, value := range []string{"+readonly", "readonly=+const", "+deepconst=+const", "+readonly=+nonsense",
+		"+readonly=+constlint", "+readonly=+const:[a]", "+ro=+const,+ro=+mutable"} {
+		f := analyzer.Analyzer.Flags.Lookup("marker-synonyms")
+		if err := f.Value.Set(value); err == nil {
+			t.Errorf("-marker-synonyms=%s: want an error", value)
+		}
+	}
+}
+
+func TestMinConfidence(t *testing.T) {
+	setFlag(t, "setters", "true")
+	setFlag(t, "concurrency-audit", "true")
+	setFlag(t, "min-confidence", "probable")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "confidence")
+}
+
+func TestFocus(t *testing.T) {
+	setFlag(t, "type", "focus.Config")
+	setFlag(t, "field", "APIKey,Session.ID")
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.Analyzer, "focus")
+}
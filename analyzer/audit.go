@@ -0,0 +1,22 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// reportLiteralInits reports the const fields a composite literal sets, with
+// -audit. Together with the exemptions of assignments and the violations, this
+// accounts for every write to a const field, as security reviews need; the
+// literal creates the value, so these writes are always allowed.
+func reportLiteralInits(pass *analysis.Pass, lit *ast.CompositeLit, constFields map[*types.Var]constField) {
+	eachLiteralField(pass, lit, func(field *types.Var, elt ast.Expr) {
+		if cf, ok := constFields[field]; ok {
+			report(pass, elt, CategoryExemption,
+				"initialization of const field %s.%s allowed: composite literal of %s",
+				cf.owner.Name(), field.Name(), cf.owner.Name())
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+)
+
+// checkBestEffort reports the writes to const fields and parameters of a
+// package with type errors that can be told from its syntax: assignments to
+// a field selector or a parameter name, made outside the functions creating a
+// value of the field's type. Writes the type checker resolved are reported by
+// their usual rules; those it couldn't resolve are matched by the name of the
+// field or parameter, and reported as such.
+func checkBestEffort(pass *analysis.Pass, inspector *astinspector.Inspector, constFields map[*types.Var]constField,
+	constParams map[*types.Var]constParam) *Inventory {
+	byName := make(map[string][]*types.Var)
+	for field := range constFields {
+		byName[field.Name()] = append(byName[field.Name()], field)
+	}
+	for _, fields := range byName {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Pos() < fields[j].Pos() })
+	}
+
+	violations := make(map[*types.Var][]token.Pos)
+	assign := func(lhs ast.Expr, stack []ast.Node) {
+		funcDecl := enclosingFuncDecl(stack)
+		switch lhs := ast.Unparen(lhs).(type) {
+		case *ast.SelectorExpr:
+			if field, ok := bestEffortFieldWrite(pass, lhs, stack, constFields, byName); ok {
+				violations[field] = append(violations[field], lhs.Pos())
+			}
+		case *ast.Ident:
+			bestEffortParamWrite(pass, lhs, funcDecl, constParams)
+		}
+	}
+	filter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.IncDecStmt)(nil),
+	}
+	inspector.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if node.Tok != token.DEFINE {
+				for _, lhs := range node.Lhs {
+					assign(lhs, stack)
+				}
+			}
+		case *ast.IncDecStmt:
+			assign(node.X, stack)
+		}
+		return true
+	})
+	return newInventory(constFields, constParams, nil, violations)
+}
+
+// bestEffortFieldWrite reports an assignment to a const field selected by
+// sel, returning the field if the type checker resolved it. stack ends at the
+// assignment.
+func bestEffortFieldWrite(pass *analysis.Pass, sel *ast.SelectorExpr, stack []ast.Node,
+	constFields map[*types.Var]constField, byName map[string][]*types.Var) (*types.Var, bool) {
+	funcDecl := enclosingFuncDecl(stack)
+	_, lazy := lazyInit(pass.TypesInfo, sel, sel, stack)
+	if _, field, cf, ok := selectConstField(pass, sel, constFields); ok {
+		if funcDecl != nil && instantiatesByName(funcDecl, cf.owner) || cf.writeOnce && lazy {
+			return nil, false
+		}
+		kind, outside := cf.kind(true)
+		reportWrite(pass, sel, CategoryFieldWrite, field, cf.owner, cf.markerPos(), "field marked const here",
+			"assignment to %s %s.%s%s%s", kind, cf.owner.Name(), field.Name(), outside, because(cf.reason))
+		return field, true
+	}
+	if _, resolved := pass.TypesInfo.Selections[sel]; resolved {
+		return nil, false
+	}
+	if _, qualified := pass.TypesInfo.Uses[sel.Sel]; qualified {
+		return nil, false
+	}
+
+	var names []string
+	var first constField
+	for _, field := range byName[sel.Sel.Name] {
+		cf := constFields[field]
+		if funcDecl != nil && instantiatesByName(funcDecl, cf.owner) || cf.writeOnce && lazy {
+			return nil, false
+		}
+		if names == nil {
+			first = cf
+		}
+		names = append(names, cf.owner.Name()+"."+field.Name())
+	}
+	if names == nil {
+		return nil, false
+	}
+	reportRelated(pass, sel, CategoryBestEffort, first.markerPos(), "field marked const here",
+		"possible assignment to const field %s: the type of %s is unknown", strings.Join(names, " or "),
+		types.ExprString(sel.X))
+	return nil, false
+}
+
+// bestEffortParamWrite reports an assignment to a const parameter named by
+// ident.
+func bestEffortParamWrite(pass *analysis.Pass, ident *ast.Ident, funcDecl *ast.FuncDecl,
+	constParams map[*types.Var]constParam) {
+	if obj, resolved := pass.TypesInfo.Uses[ident]; resolved {
+		if v, ok := obj.(*types.Var); ok {
+			if param, ok := constParams[v]; ok {
+				reportWrite(pass, ident, CategoryParamWrite, v, nil, param.marker, "parameter marked const here",
+					"assignment to const parameter %s%s", ident.Name, because(param.reason))
+			}
+		}
+		return
+	}
+	if funcDecl == nil {
+		return
+	}
+	fn, _ := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+	for v, param := range constParams {
+		if param.fn == fn && fn != nil && v.Name() == ident.Name {
+			reportRelated(pass, ident, CategoryBestEffort, param.marker, "parameter marked const here",
+				"possible assignment to const parameter %s: its declaration is unknown", ident.Name)
+		}
+	}
+}
+
+// instantiatesByName reports whether the function creates a value of the
+// given type, going by the names in its composite literals, new calls and
+// var declarations where their types are unknown.
+func instantiatesByName(funcDecl *ast.FuncDecl, owner *types.TypeName) bool {
+	if funcDecl.Body == nil {
+		return false
+	}
+	names := func(expr ast.Expr) bool {
+		switch e := ast.Unparen(expr).(type) {
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		}
+		ident, ok := ast.Unparen(expr).(*ast.Ident)
+		return ok && ident.Name == owner.Name()
+	}
+	found := false
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+			found = found || n.Type != nil && names(n.Type)
+		case *ast.CallExpr:
+			if fun, ok := n.Fun.(*ast.Ident); ok && fun.Name == "new" && len(n.Args) == 1 {
+				found = found || names(n.Args[0])
+			}
+		case *ast.ValueSpec:
+			found = found || n.Type != nil && len(n.Values) == 0 && names(n.Type)
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,133 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// constBlock is a run of fields between // +const:begin and // +const:end
+// comments of a struct, each of which is const as if marked +const.
+type constBlock struct {
+	begin marker
+	end   token.Pos // position of the +const:end marker, or the closing brace of an unterminated block
+}
+
+// contains reports whether the field at pos lies in the block.
+func (b constBlock) contains(pos token.Pos) bool {
+	return b.begin.pos < pos && pos < b.end
+}
+
+// blockProblem is a +const:begin or +const:end marker that doesn't pair up.
+type blockProblem struct {
+	m       marker
+	message string
+}
+
+// fileOf returns the file of the package holding pos.
+func fileOf(pass *analysis.Pass, pos token.Pos) *ast.File {
+	for _, f := range pass.Files {
+		if f.FileStart <= pos && pos < f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
+
+// constBlocks returns the blocks of fields delimited by +const:begin and
+// +const:end markers in the field list of a struct type of file, and the
+// markers that don't pair up: a block left open extends to the end of the
+// struct, nested and unmatched markers are ignored. The markers of nested
+// struct types belong to those.
+func constBlocks(file *ast.File, structType *ast.StructType) ([]constBlock, []blockProblem) {
+	fields := structType.Fields
+	if file == nil || fields == nil || !fields.Opening.IsValid() {
+		return nil, nil
+	}
+	var (
+		blocks   []constBlock
+		problems []blockProblem
+		open     *marker
+	)
+	for _, group := range file.Comments {
+		if group.Pos() < fields.Opening || group.End() > fields.Closing || inFieldType(fields, group.Pos()) {
+			continue
+		}
+		for _, m := range collectMarkers(group) {
+			if m.name != constMarker {
+				continue
+			}
+			switch {
+			case m.arg == beginArg && open != nil:
+				problems = append(problems, blockProblem{m, "+const:begin inside an open block; blocks don't nest"})
+			case m.arg == beginArg:
+				begin := m
+				open = &begin
+			case m.arg == endArg && open == nil:
+				problems = append(problems, blockProblem{m, "+const:end without a matching +const:begin"})
+			case m.arg == endArg:
+				blocks = append(blocks, constBlock{begin: *open, end: m.pos})
+				open = nil
+			}
+		}
+	}
+	if open != nil {
+		problems = append(problems, blockProblem{*open, "+const:begin without a matching +const:end; " +
+			"the block extends to the end of the struct"})
+		blocks = append(blocks, constBlock{begin: *open, end: fields.Closing})
+	}
+	return blocks, problems
+}
+
+// inFieldType reports whether pos lies within the type of a field, such as
+// the field list of a nested struct type.
+func inFieldType(fields *ast.FieldList, pos token.Pos) bool {
+	for _, field := range fields.List {
+		if field.Type.Pos() <= pos && pos < field.Type.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// blockConstness returns the constness the blocks give the field at pos, if
+// it lies in one: that of a bare +const marker.
+func blockConstness(blocks []constBlock, pos token.Pos) (fieldConstness, bool) {
+	for _, b := range blocks {
+		if b.contains(pos) {
+			return fieldConstness{found: true, mode: constShallow, pos: b.begin.pos, reason: b.begin.reason}, true
+		}
+	}
+	return fieldConstness{}, false
+}
+
+// checkConstBlocks reports the +const:begin and +const:end markers of a file
+// that don't pair up within a struct, or lie outside of any struct's fields.
+func checkConstBlocks(pass *analysis.Pass, file *ast.File) {
+	inStruct := make(map[token.Pos]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if structType, ok := n.(*ast.StructType); ok {
+			_, problems := constBlocks(file, structType)
+			for _, p := range problems {
+				report(pass, p.m, CategoryMarker, "%s", p.message)
+			}
+			fields := structType.Fields
+			for _, group := range file.Comments {
+				if fields.Opening < group.Pos() && group.End() < fields.Closing {
+					for _, m := range collectMarkers(group) {
+						inStruct[m.pos] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	for _, group := range file.Comments {
+		for _, m := range collectMarkers(group) {
+			if m.name == constMarker && (m.arg == beginArg || m.arg == endArg) && !inStruct[m.pos] {
+				report(pass, m, CategoryMarker, "%s marker has no effect outside the fields of a struct", m)
+			}
+		}
+	}
+}
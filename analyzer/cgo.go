@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Packages using cgo are analyzed in the files cgo compiles them from: a copy
+// of each file importing "C" with its references to C rewritten, which //line
+// directives position in the original, and files of declarations cgo
+// synthesizes, such as _cgo_gotypes.go. go list -compiled names both after
+// their hash in the build cache, without a .go suffix.
+
+// cgoSynthesized reports whether pos lies in a file cgo synthesized, which has
+// no source to report diagnostics in.
+func cgoSynthesized(fset *token.FileSet, pos token.Pos) bool {
+	if !pos.IsValid() {
+		return false
+	}
+	name := fset.PositionFor(pos, false).Filename
+	if name != fset.Position(pos).Filename {
+		return false
+	}
+	base := filepath.Base(name)
+	return strings.HasPrefix(base, "_cgo_") || !strings.HasSuffix(base, ".go")
+}
+
+// editsCopy reports whether a fix edits a file compiled from a copy of its
+// source, such as those cgo rewrites. Its //line directives place the
+// diagnostic in the original, but the edits would apply to the copy.
+func editsCopy(fset *token.FileSet, fix analysis.SuggestedFix) bool {
+	for _, edit := range fix.TextEdits {
+		if fset.PositionFor(edit.Pos, false).Filename != fset.Position(edit.Pos).Filename {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// checkChannelOwnership reports sends on, and closes of, const channel fields
+// outside of the methods of the declaring type and the functions instantiating
+// it. A const channel is owned by its struct: other code closing it or sending
+// on it breaks the protocol the struct implements, even though the field itself
+// is never reassigned.
+func checkChannelOwnership(pass *analysis.Pass, inspector *astinspector.Inspector,
+	constFields map[*types.Var]constField, instantiators map[instantiation]token.Pos) {
+	filter := []ast.Node{(*ast.SendStmt)(nil), (*ast.CallExpr)(nil)}
+	inspector.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		var ch ast.Expr
+		var op string
+		switch node := n.(type) {
+		case *ast.SendStmt:
+			ch, op = node.Chan, "sends on"
+		case *ast.CallExpr:
+			builtin, ok := typeutil.Callee(pass.TypesInfo, node).(*types.Builtin)
+			if !ok || builtin.Name() != "close" || len(node.Args) != 1 {
+				return true
+			}
+			ch, op = node.Args[0], "closes"
+		}
+
+		_, field, cf, ok := selectConstField(pass, ast.Unparen(ch), constFields)
+		if !ok {
+			return true
+		}
+		if _, ok := field.Type().Underlying().(*types.Chan); !ok {
+			return true
+		}
+
+		funcDecl := enclosingFuncDecl(stack)
+		if funcDecl == nil || receiverType(pass, funcDecl) == cf.owner ||
+			isCachedInstanciator(pass, funcDecl, cf.owner, instantiators) {
+			return true
+		}
+		reportRelated(pass, n, CategoryChannel, cf.markerPos(), "field marked const here",
+			"%s %s const channel %s.%s, which only methods and constructors of %s may do",
+			funcDecl.Name.Name, op, cf.owner.Name(), field.Name(), cf.owner.Name())
+		return true
+	})
+}
@@ -0,0 +1,151 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// goroutineEntry is a function the package runs on another goroutine: the
+// target of a go statement or an HTTP handler.
+type goroutineEntry struct {
+	pos  token.Pos // the go statement or handler registration
+	what string    // "a go statement" or "an HTTP handler"
+}
+
+// checkConcurrency reports the writes to const fields found in functions
+// reachable from goroutine entry points of the package: go statements and
+// handlers registered with net/http. Const fields are meant to be read without
+// locks, which these writes would race with. Calls are followed within the
+// package only.
+func checkConcurrency(pass *analysis.Pass, inspector *astinspector.Inspector,
+	constFields map[*types.Var]constField, violations map[*types.Var][]token.Pos) {
+	if len(violations) == 0 {
+		return
+	}
+
+	decls := make(map[*types.Func]*ast.FuncDecl)
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		if fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func); ok && funcDecl.Body != nil {
+			decls[fn] = funcDecl
+		}
+	})
+
+	// function returns the declaration or literal a handler or go statement runs.
+	function := func(expr ast.Expr) ast.Node {
+		switch e := ast.Unparen(expr).(type) {
+		case *ast.FuncLit:
+			return e
+		case *ast.Ident:
+			if decl, ok := decls[funcObj(pass.TypesInfo.Uses[e])]; ok {
+				return decl
+			}
+		case *ast.SelectorExpr:
+			if decl, ok := decls[funcObj(pass.TypesInfo.Uses[e.Sel])]; ok {
+				return decl
+			}
+		}
+		// A handler value: run its ServeHTTP method.
+		if t := pass.TypesInfo.TypeOf(expr); t != nil {
+			obj, _, _ := types.LookupFieldOrMethod(t, true, pass.Pkg, "ServeHTTP")
+			if fn, ok := obj.(*types.Func); ok {
+				if decl, ok := decls[fn]; ok {
+					return decl
+				}
+			}
+		}
+		return nil
+	}
+
+	reached := make(map[ast.Node]goroutineEntry)
+	var queue []ast.Node
+	enter := func(node ast.Node, entry goroutineEntry) {
+		if _, seen := reached[node]; !seen {
+			reached[node] = entry
+			queue = append(queue, node)
+		}
+	}
+
+	inspector.Preorder([]ast.Node{(*ast.GoStmt)(nil), (*ast.CallExpr)(nil)}, func(n ast.Node) {
+		var target ast.Node
+		var entry goroutineEntry
+		switch node := n.(type) {
+		case *ast.GoStmt:
+			target, entry = function(node.Call.Fun), goroutineEntry{pos: node.Pos(), what: "a go statement"}
+		case *ast.CallExpr:
+			if isHandlerRegistration(pass, node) {
+				target, entry = function(node.Args[1]), goroutineEntry{pos: node.Pos(), what: "an HTTP handler"}
+			}
+		}
+		if target != nil {
+			enter(target, entry)
+		}
+	})
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		ast.Inspect(node, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if decl, ok := decls[typeutil.StaticCallee(pass.TypesInfo, call)]; ok {
+					enter(decl, reached[node])
+				}
+			}
+			return true
+		})
+	}
+
+	var fields []*types.Var
+	for field := range violations {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Pos() < fields[j].Pos() })
+
+	for _, field := range fields {
+		cf := constFields[field]
+		for _, pos := range violations[field] {
+			entry, ok := reachingEntry(reached, pos)
+			if !ok {
+				continue
+			}
+			reportRelated(pass, at(pos), CategoryConcurrency, entry.pos, "goroutine entry point here",
+				"const field %s.%s is written on a goroutine, reachable from %s", cf.owner.Name(), field.Name(),
+				entry.what)
+		}
+	}
+}
+
+// funcObj returns obj if it is a function or method.
+func funcObj(obj types.Object) *types.Func {
+	fn, _ := obj.(*types.Func)
+	return fn
+}
+
+// reachingEntry returns the entry point of a reached function containing pos,
+// choosing the earliest one when several do.
+func reachingEntry(reached map[ast.Node]goroutineEntry, pos token.Pos) (goroutineEntry, bool) {
+	var found goroutineEntry
+	for node, entry := range reached {
+		if node.Pos() <= pos && pos < node.End() && (!found.pos.IsValid() || entry.pos < found.pos) {
+			found = entry
+		}
+	}
+	return found, found.pos.IsValid()
+}
+
+// isHandlerRegistration reports whether call registers an HTTP handler with
+// net/http: http.Handle, http.HandleFunc or the ServeMux methods of the same
+// names.
+func isHandlerRegistration(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "net/http" || len(call.Args) != 2 {
+		return false
+	}
+	return fn.Name() == "Handle" || fn.Name() == "HandleFunc"
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import "fmt"
+
+// Confidence tells how sure the analyzer is that a diagnostic points at a
+// real problem. Rules matching code exactly, such as writes to const fields,
+// are definite; rules relying on naming conventions or on approximations of
+// how the program runs are probable or possible.
+type Confidence int
+
+const (
+	// Possible findings need a human to decide, such as writes reachable
+	// from a goroutine, which may well be synchronized.
+	Possible Confidence = iota
+	// Probable findings are problems unless the code follows an unusual
+	// convention, such as a New* function that isn't a constructor.
+	Probable
+	// Definite findings hold whatever the program does at run time.
+	Definite
+)
+
+var confidenceNames = [...]string{
+	Possible: "possible",
+	Probable: "probable",
+	Definite: "definite",
+}
+
+func (c Confidence) String() string {
+	if c < 0 || int(c) >= len(confidenceNames) {
+		return fmt.Sprintf("Confidence(%d)", int(c))
+	}
+	return confidenceNames[c]
+}
+
+// ParseConfidence returns the confidence named s: definite, probable or
+// possible.
+func ParseConfidence(s string) (Confidence, error) {
+	for c, name := range confidenceNames {
+		if name == s {
+			return Confidence(c), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown confidence %q: want definite, probable or possible", s)
+}
+
+// Set and String make *Confidence a flag.Value.
+func (c *Confidence) Set(value string) error {
+	parsed, err := ParseConfidence(value)
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// ruleConfidence lists the rules whose diagnostics aren't definite.
+var ruleConfidence = map[string]Confidence{
+	CategoryDeadMarker:  Probable, // the field may be set by reflection or another package
+	CategoryConstructor: Probable, // New* functions are assumed to be constructors
+	CategorySetter:      Probable, // Set<Field> methods are assumed to set <Field>
+	CategorySecretLeak:  Probable, // the value printed may be redacted on the way
+	CategoryPublication: Probable, // the value may not be read before the write
+	CategoryReceiver:    Probable, // methods are assumed const by name
+	CategoryDecode:      Probable, // decoders may be configured to skip the field
+	CategoryConcurrency: Possible, // the write may be synchronized
+	CategoryBestEffort:  Probable, // the name may belong to something else
+}
+
+// RuleConfidence returns the confidence of the diagnostics of a rule, the
+// category of the diagnostics it reports.
+func RuleConfidence(rule string) Confidence {
+	if c, ok := ruleConfidence[rule]; ok {
+		return c
+	}
+	return Definite
+}
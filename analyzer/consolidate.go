@@ -0,0 +1,138 @@
+package analyzer
+
+import (
+	"go/ast"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkMarkerConsolidation reports struct types whose named fields all carry
+// the same lone marker, such as +const or +deepconst, with a fix replacing
+// them by that marker on the type. A field added later is then const too,
+// rather than silently mutable because its author forgot the marker.
+//
+// Only markers written as a comment line of their own, or as the trailing
+// comment of their field, are consolidated, so that the fix never has to edit
+// the prose around them.
+func checkMarkerConsolidation(pass *analysis.Pass, decl *ast.GenDecl, spec *ast.TypeSpec) {
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return
+	}
+	// An unparenthesized declaration carries its doc comment on the GenDecl.
+	doc := spec.Doc
+	if !decl.Lparen.IsValid() {
+		doc = decl.Doc
+	}
+	if len(collectMarkers(doc, spec.Comment)) > 0 {
+		return
+	}
+
+	var common string
+	var edits []analysis.TextEdit
+	fields := 0
+	for _, field := range structType.Fields.List {
+		if len(field.Names) == 0 {
+			continue // embedded fields are never const
+		}
+		markers := collectMarkers(field.Doc, field.Comment)
+		if len(markers) != 1 || markers[0].arg != "" && markers[0].String() != constMarker+":"+shallowArg {
+			return
+		}
+		m := markers[0]
+		if m.name != constMarker && m.name != deepConstMarker {
+			return
+		}
+		if common != "" && m.String() != common {
+			return
+		}
+		common = m.String()
+
+		edit, ok := removeMarkerComment(pass, field, m)
+		if !ok {
+			return
+		}
+		edits = append(edits, edit)
+		fields += len(field.Names)
+	}
+	if fields < 2 {
+		return
+	}
+
+	insert, ok := insertTypeMarker(pass, decl, spec, doc, common)
+	if !ok {
+		return
+	}
+	emit(pass, analysis.Diagnostic{
+		Pos:      spec.Name.Pos(),
+		End:      spec.Name.End(),
+		Category: CategoryConsolidate,
+		Message: "every field of " + spec.Name.Name + " is marked " + common +
+			"; mark the struct instead, so fields added later are " + common + " too",
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   "Mark " + spec.Name.Name + " " + common + " as a whole",
+			TextEdits: append([]analysis.TextEdit{insert}, edits...),
+		}},
+	}, nil, nil)
+}
+
+// removeMarkerComment returns the edit deleting the comment holding m: the
+// whole line for a comment line of its own in the field's doc, or the comment
+// and the space before it for a trailing comment. It fails for comments
+// holding more than the marker.
+func removeMarkerComment(pass *analysis.Pass, field *ast.Field, m marker) (analysis.TextEdit, bool) {
+	for _, group := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			if m.pos < c.Pos() || m.pos >= c.End() {
+				continue
+			}
+			if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) != m.String() {
+				return analysis.TextEdit{}, false
+			}
+			if group == field.Comment {
+				end := field.Type.End()
+				if field.Tag != nil {
+					end = field.Tag.End()
+				}
+				return analysis.TextEdit{Pos: end, End: c.End()}, true
+			}
+			file := pass.Fset.File(c.Pos())
+			line := file.Line(c.Pos())
+			if line == file.LineCount() {
+				return analysis.TextEdit{}, false
+			}
+			return analysis.TextEdit{Pos: file.LineStart(line), End: file.LineStart(line + 1)}, true
+		}
+	}
+	return analysis.TextEdit{}, false
+}
+
+// insertTypeMarker returns the edit adding marker to the doc comment of a type
+// spec, as a paragraph of its own after the last line, or as a new doc comment
+// when it has none.
+func insertTypeMarker(pass *analysis.Pass, decl *ast.GenDecl, spec *ast.TypeSpec, doc *ast.CommentGroup,
+	marker string) (analysis.TextEdit, bool) {
+	if doc != nil {
+		return analysis.TextEdit{Pos: doc.End(), End: doc.End(), NewText: []byte("\n//\n// " + marker)}, true
+	}
+
+	pos := spec.Pos()
+	if !decl.Lparen.IsValid() {
+		pos = decl.Pos()
+	}
+	file := pass.Fset.File(pos)
+	lineStart := file.LineStart(file.Line(pos))
+	src, err := pass.ReadFile(file.Name())
+	if err != nil {
+		return analysis.TextEdit{}, false
+	}
+	indent := string(src[file.Offset(lineStart):file.Offset(pos)])
+	if strings.TrimSpace(indent) != "" {
+		return analysis.TextEdit{}, false
+	}
+	return analysis.TextEdit{Pos: pos, End: pos, NewText: []byte("// " + marker + "\n" + indent)}, true
+}
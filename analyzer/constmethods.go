@@ -0,0 +1,204 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// constMethodMarker returns the +const:receiver marker in a function doc
+// comment, if any.
+func constMethodMarker(doc *ast.CommentGroup) (marker, bool) {
+	for _, m := range collectMarkers(doc) {
+		if m.name == constMarker && m.arg == receiverArg {
+			return m, true
+		}
+	}
+	return marker{}, false
+}
+
+// checkConstMethods reports writes to the receiver of methods marked with a
+// receiver marker, +const:receiver, whatever the mutator pattern says about
+// their name.
+func checkConstMethods(pass *analysis.Pass, inspector *astinspector.Inspector) {
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		m, ok := constMethodMarker(funcDecl.Doc)
+		if !ok {
+			return
+		}
+		typeName := receiverType(pass, funcDecl)
+		if typeName == nil {
+			return
+		}
+		recv := methodReceiver(pass, funcDecl)
+		for _, lhs := range receiverWrites(pass, funcDecl) {
+			reportWrite(pass, lhs, CategoryConstMethod, recv, nil, m.pos, "method marked const here",
+				"const method %s.%s modifies its receiver", typeName.Name(), funcDecl.Name.Name)
+		}
+	})
+}
+
+// markedConstMethods returns the methods of the package carrying a receiver
+// marker.
+func markedConstMethods(pass *analysis.Pass, inspector *astinspector.Inspector) map[*types.Func]bool {
+	constMethods := make(map[*types.Func]bool)
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		if _, ok := constMethodMarker(funcDecl.Doc); ok {
+			if fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func); ok {
+				constMethods[fn] = true
+			}
+		}
+	})
+	return constMethods
+}
+
+// checkConstMethodCandidates reports pointer-receiver methods that provably
+// leave their receiver unchanged, with a fix marking them +const:receiver, to
+// seed the markers on an existing code base.
+func checkConstMethodCandidates(pass *analysis.Pass, inspector *astinspector.Inspector) {
+	constMethods := markedConstMethods(pass, inspector)
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		recv := methodReceiver(pass, funcDecl)
+		if recv == nil || recv.Name() == "_" {
+			return
+		}
+		if _, ok := recv.Type().(*types.Pointer); !ok {
+			return
+		}
+		typeName := receiverType(pass, funcDecl)
+		if typeName == nil || typeName.Pkg() != pass.Pkg {
+			return
+		}
+		if _, ok := constMethodMarker(funcDecl.Doc); ok {
+			return
+		}
+		if len(receiverWrites(pass, funcDecl)) > 0 || !readsOnly(pass, funcDecl.Body, recv, constMethods) {
+			return
+		}
+
+		fix := analysis.TextEdit{Pos: funcDecl.Pos(), End: funcDecl.Pos(),
+			NewText: []byte("// " + constMarker + ":" + receiverArg + "\n")}
+		if funcDecl.Doc != nil {
+			fix = analysis.TextEdit{Pos: funcDecl.Doc.End(), End: funcDecl.Doc.End(),
+				NewText: []byte("\n//\n// " + constMarker + ":" + receiverArg)}
+		}
+		emit(pass, analysis.Diagnostic{
+			Pos:      funcDecl.Name.Pos(),
+			End:      funcDecl.Name.End(),
+			Category: CategoryConstCandidate,
+			Message: "method " + typeName.Name() + "." + funcDecl.Name.Name +
+				" never modifies its receiver; mark it " + constMarker + ":" + receiverArg,
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message:   "Mark " + funcDecl.Name.Name + " " + constMarker + ":" + receiverArg,
+				TextEdits: []analysis.TextEdit{fix},
+			}},
+		}, nil, nil)
+	})
+}
+
+// readsOnly reports whether body only reads through recv, so that it can't
+// modify what recv points to whatever it calls. Every use of recv must read
+// values holding no references out of it, such as r.name or len(r.items),
+// compare it, or call methods marked const on it. Anything else, such as
+// passing recv or one of its slices on, or taking the address of a field,
+// might lead to a write.
+func readsOnly(pass *analysis.Pass, body *ast.BlockStmt, recv *types.Var, constMethods map[*types.Func]bool) bool {
+	only := true
+	var stack []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		if id, ok := n.(*ast.Ident); ok && pass.TypesInfo.Uses[id] == recv {
+			only = only && readOnlyUse(pass, stack, constMethods)
+		}
+		return true
+	})
+	return only
+}
+
+// readOnlyUse reports whether the receiver use at the top of stack only reads
+// from it, following the selectors, indexing and dereferences applied to it
+// up to where the value read ends up.
+func readOnlyUse(pass *analysis.Pass, stack []ast.Node, constMethods map[*types.Func]bool) bool {
+	expr := stack[len(stack)-1].(ast.Expr)
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch parent := stack[i].(type) {
+		case *ast.ParenExpr, *ast.StarExpr:
+			expr = parent.(ast.Expr)
+			continue
+		case *ast.IndexExpr:
+			if parent.X == expr {
+				expr = parent
+				continue
+			}
+		case *ast.SelectorExpr:
+			selection, ok := pass.TypesInfo.Selections[parent]
+			if !ok {
+				return false
+			}
+			if selection.Kind() == types.FieldVal {
+				expr = parent
+				continue
+			}
+			// A method value or call: harmless if the method is const, or
+			// works on a copy holding no references.
+			method, ok := selection.Obj().(*types.Func)
+			if !ok {
+				return false
+			}
+			if constMethods[method] {
+				return true
+			}
+			recv := method.Type().(*types.Signature).Recv()
+			_, pointer := recv.Type().(*types.Pointer)
+			return !pointer && !holdsReferences(recv.Type())
+		case *ast.UnaryExpr:
+			return parent.Op != token.AND
+		case *ast.BinaryExpr:
+			return true
+		case *ast.CallExpr:
+			if builtin, ok := typeutil.Callee(pass.TypesInfo, parent).(*types.Builtin); ok {
+				switch builtin.Name() {
+				case "len", "cap":
+					return true
+				}
+			}
+		case *ast.RangeStmt:
+			if parent.X == expr {
+				return !rangeShares(pass.TypesInfo.TypeOf(expr))
+			}
+		}
+		break
+	}
+	return !holdsReferences(pass.TypesInfo.TypeOf(expr))
+}
+
+// rangeShares reports whether ranging over a value of type t yields keys or
+// elements that share data with it.
+func rangeShares(t types.Type) bool {
+	switch t := t.Underlying().(type) {
+	case *types.Basic:
+		return false
+	case *types.Slice:
+		return holdsReferences(t.Elem())
+	case *types.Array:
+		return holdsReferences(t.Elem())
+	case *types.Pointer:
+		if array, ok := t.Elem().Underlying().(*types.Array); ok {
+			return holdsReferences(array.Elem())
+		}
+	case *types.Map:
+		return holdsReferences(t.Key()) || holdsReferences(t.Elem())
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+)
+
+// checkConstructorCompleteness reports designated constructors that don't
+// initialize every const field of the type they build. A designated constructor
+// is a function named New* whose first result is T or *T and that instantiates
+// T itself; functions delegating to another constructor are not checked.
+func checkConstructorCompleteness(pass *analysis.Pass, inspector *astinspector.Inspector,
+	constFields map[*types.Var]constField) {
+	fieldsByOwner := make(map[*types.TypeName][]*types.Var)
+	for field, cf := range constFields {
+		fieldsByOwner[cf.owner] = append(fieldsByOwner[cf.owner], field)
+	}
+
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		if funcDecl.Recv != nil || funcDecl.Body == nil || !strings.HasPrefix(funcDecl.Name.Name, "New") {
+			return
+		}
+
+		owner := constructedType(pass, funcDecl)
+		fields := fieldsByOwner[owner]
+		if len(fields) == 0 || !isInstanciator(pass, funcDecl, owner) {
+			return
+		}
+
+		initialized := constructorInits(pass, funcDecl, owner)
+		var missing []string
+		for _, field := range fields {
+			if !initialized[field] {
+				missing = append(missing, owner.Name()+"."+field.Name())
+			}
+		}
+		if len(missing) == 0 {
+			return
+		}
+
+		sort.Strings(missing)
+		noun := "field"
+		if len(missing) > 1 {
+			noun = "fields"
+		}
+		report(pass, funcDecl.Name, CategoryConstructor, "constructor %s does not initialize const %s %s",
+			funcDecl.Name.Name, noun, strings.Join(missing, ", "))
+	})
+}
+
+// constructedType returns the named type a function returns as its first
+// result, either as T or *T. Factories returning an interface construct the
+// concrete type of the first value they return.
+func constructedType(pass *analysis.Pass, funcDecl *ast.FuncDecl) *types.TypeName {
+	fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+
+	results := fn.Type().(*types.Signature).Results()
+	if results.Len() == 0 {
+		return nil
+	}
+
+	t := results.At(0).Type()
+	if types.IsInterface(t) {
+		return returnedType(pass, funcDecl)
+	}
+	return namedType(t)
+}
+
+// returnedType returns the named type of the first result of the first return
+// statement of a function returning a value of one, as T or *T.
+func returnedType(pass *analysis.Pass, funcDecl *ast.FuncDecl) *types.TypeName {
+	var returned *types.TypeName
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if returned == nil && len(node.Results) > 0 {
+				if t := pass.TypesInfo.TypeOf(node.Results[0]); t != nil && !types.IsInterface(t) {
+					returned = namedType(t)
+				}
+			}
+		}
+		return returned == nil
+	})
+	return returned
+}
+
+// namedType returns the type name of T or *T, or nil if t is neither.
+func namedType(t types.Type) *types.TypeName {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj()
+	}
+	return nil
+}
+
+// constructorInits returns the fields of owner that a function initializes,
+// through composite literals or assignments.
+func constructorInits(pass *analysis.Pass, funcDecl *ast.FuncDecl, owner *types.TypeName) map[*types.Var]bool {
+	initialized := make(map[*types.Var]bool)
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CompositeLit:
+			if named, ok := pass.TypesInfo.TypeOf(node).(*types.Named); ok && named.Obj() == owner {
+				eachLiteralField(pass, node, func(field *types.Var, _ ast.Expr) {
+					initialized[field] = true
+				})
+			}
+		case *ast.AssignStmt:
+			for _, lhs := range node.Lhs {
+				selExpr, ok := lhs.(*ast.SelectorExpr)
+				if !ok {
+					continue
+				}
+				if selection, ok := pass.TypesInfo.Selections[selExpr]; ok && selection.Kind() == types.FieldVal {
+					if field, ok := selection.Obj().(*types.Var); ok {
+						initialized[field.Origin()] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	return initialized
+}
+
+// eachLiteralField calls fn for every struct field a composite literal sets,
+// together with the element setting it.
+// Keyed literals set the fields they name, positional literals set every field
+// of the struct.
+func eachLiteralField(pass *analysis.Pass, lit *ast.CompositeLit, fn func(field *types.Var, elt ast.Expr)) {
+	if len(lit.Elts) == 0 {
+		return
+	}
+
+	litType := pass.TypesInfo.TypeOf(lit)
+	if litType == nil {
+		return
+	}
+
+	structType, ok := litType.Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+
+	for i, elt := range lit.Elts {
+		var field *types.Var
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			field, _ = pass.TypesInfo.Uses[key].(*types.Var)
+		} else if i < structType.NumFields() {
+			field = structType.Field(i)
+		}
+		if field != nil {
+			fn(field.Origin(), elt)
+		}
+	}
+}
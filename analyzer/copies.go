@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// copyWritesOf reports whether -copy-writes selects the struct type t, as
+// -type takes it.
+func copyWritesOf(t *types.TypeName) bool {
+	for _, pattern := range copyWrites {
+		if matchesType(pattern, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// writtenCopy returns where the value whose const field x.f a function writes
+// was copied from an existing value, when x is a local variable, parameter or
+// receiver held by value, or a field of one. Functions instantiating a type
+// may write the const fields of its values, and -copy-writes reports such
+// writes on copies: cp := *p, cp := users[i], a value parameter or a range
+// variable.
+func writtenCopy(pass *analysis.Pass, funcDecl *ast.FuncDecl, x ast.Expr) (token.Pos, bool) {
+	if funcDecl == nil || funcDecl.Body == nil {
+		return token.NoPos, false
+	}
+	var v *types.Var
+	for v == nil {
+		switch e := x.(type) {
+		case *ast.ParenExpr:
+			x = e.X
+		case *ast.SelectorExpr:
+			selection, ok := pass.TypesInfo.Selections[e]
+			if !ok || selection.Kind() != types.FieldVal || selection.Indirect() {
+				return token.NoPos, false
+			}
+			x = e.X
+		case *ast.Ident:
+			v, _ = pass.TypesInfo.Uses[e].(*types.Var)
+			if v == nil {
+				return token.NoPos, false
+			}
+		default:
+			return token.NoPos, false
+		}
+	}
+	if _, ok := v.Type().Underlying().(*types.Struct); !ok {
+		return token.NoPos, false
+	}
+	if v.Pos() < funcDecl.Pos() || v.Pos() >= funcDecl.End() {
+		return token.NoPos, false
+	}
+	if v.Pos() < funcDecl.Body.Pos() {
+		// A parameter or receiver is a copy of the caller's value, unlike a
+		// named result.
+		results := funcDecl.Type.Results
+		return v.Pos(), results == nil || v.Pos() < results.Pos()
+	}
+
+	copied := token.NoPos
+	isVar := func(expr ast.Expr) bool {
+		id, ok := expr.(*ast.Ident)
+		return ok && (pass.TypesInfo.Defs[id] == v || pass.TypesInfo.Uses[id] == v)
+	}
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		if copied.IsValid() {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if isVar(lhs) && existingValue(pass, n.Rhs[i]) {
+					copied = n.Rhs[i].Pos()
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if isVar(name) && i < len(n.Values) && existingValue(pass, n.Values[i]) {
+					copied = n.Values[i].Pos()
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Value != nil && isVar(n.Value) {
+				copied = n.X.Pos()
+			}
+		}
+		return true
+	})
+	return copied, copied.IsValid()
+}
+
+// existingValue reports whether expr designates a value held elsewhere, so
+// that assigning it makes a copy: a variable, a field, an element or what a
+// pointer points to, rather than a composite literal or a call result.
+func existingValue(pass *analysis.Pass, expr ast.Expr) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		_, ok := pass.TypesInfo.Uses[e].(*types.Var)
+		return ok
+	case *ast.SelectorExpr:
+		if selection, ok := pass.TypesInfo.Selections[e]; ok {
+			return selection.Kind() == types.FieldVal
+		}
+		_, ok := pass.TypesInfo.Uses[e.Sel].(*types.Var)
+		return ok
+	case *ast.StarExpr, *ast.IndexExpr:
+		return true
+	}
+	return false
+}
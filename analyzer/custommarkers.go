@@ -0,0 +1,196 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// MarkerTarget is a set of the declarations a marker can be attached to.
+type MarkerTarget int
+
+const (
+	// TargetField is a struct field, marked by its doc or trailing comment.
+	TargetField MarkerTarget = 1 << iota
+	// TargetStruct is a struct type, marked by its doc or trailing comment.
+	TargetStruct
+	// TargetFunc is a function or method, marked by its doc comment.
+	TargetFunc
+	// TargetPackage is a package, marked by the doc comment of one of its
+	// files.
+	TargetPackage
+)
+
+var targetNames = []struct {
+	target MarkerTarget
+	name   string
+}{
+	{TargetField, "fields"},
+	{TargetStruct, "struct types"},
+	{TargetFunc, "functions"},
+	{TargetPackage, "package doc comments"},
+}
+
+// String lists the declarations of the set, e.g. "fields or struct types".
+func (t MarkerTarget) String() string {
+	var names []string
+	for _, tn := range targetNames {
+		if t&tn.target != 0 {
+			names = append(names, tn.name)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return "nothing"
+	case 1:
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// MarkerDef defines a marker of another tool, which the analyzer finds and
+// checks the placement and spelling of like its own.
+type MarkerDef struct {
+	// Name is the marker as written, with the leading +, such as "+locked".
+	Name string
+	// Targets are the declarations the marker applies to; it is reported
+	// anywhere else.
+	Targets MarkerTarget
+	// Category is the category of the diagnostics Check reports, the name
+	// without the + by default.
+	Category string
+	// Check, if not nil, is called for every use of the marker on one of
+	// Targets.
+	Check func(pass *analysis.Pass, use MarkerUse)
+}
+
+// MarkerUse is a marker of a MarkerDef attached to a declaration.
+type MarkerUse struct {
+	// Arg is the text following the colon of the marker, as in +locked:mu.
+	Arg string
+	// Marker is the extent of the marker in its comment.
+	Marker analysis.Range
+	// Target is the kind of declaration marked, and Node its syntax: an
+	// *ast.Field, an *ast.TypeSpec, an *ast.FuncDecl or an *ast.File.
+	Target MarkerTarget
+	Node   ast.Node
+	// Object is the declared *types.Var of a field, *types.TypeName of a
+	// struct type or *types.Func of a function, and nil for a package. Fields
+	// with several names are marked once per name.
+	Object types.Object
+	// Owner is the struct type declaring a field, nil for other targets.
+	Owner *types.TypeName
+
+	def *MarkerDef
+}
+
+// Reportf reports a diagnostic covering rng in the category of the marker's
+// definition, subject to -min-confidence like those of the analyzer.
+func (u MarkerUse) Reportf(pass *analysis.Pass, rng analysis.Range, format string, args ...any) {
+	report(pass, rng, u.def.Category, format, args...)
+}
+
+var customMarkers []*MarkerDef
+
+// RegisterMarker adds a marker for the analyzer to find. The analyzer
+// reports the marker where it doesn't apply, suggests it for misspelled
+// markers, and calls def.Check for each of its uses. Markers must be
+// registered before the analyzer runs, typically from an init function;
+// RegisterMarker panics if the name is malformed or taken. The function
+// returned removes the marker again, as tests do once they are done; it
+// mustn't be called while the analyzer runs either.
+func RegisterMarker(def MarkerDef) (unregister func()) {
+	if !strings.HasPrefix(def.Name, "+") || len(def.Name) < 2 || strings.ContainsAny(def.Name, ": \t") {
+		panic(fmt.Sprintf("constlint: malformed marker name %q", def.Name))
+	}
+	if isKnownMarker(def.Name) {
+		panic(fmt.Sprintf("constlint: marker %s registered twice", def.Name))
+	}
+	if def.Category == "" {
+		def.Category = strings.TrimPrefix(def.Name, "+")
+	}
+	d := &def
+	customMarkers = append(customMarkers, d)
+	return func() {
+		customMarkers = slices.DeleteFunc(customMarkers, func(other *MarkerDef) bool { return other == d })
+	}
+}
+
+// customMarker returns the definition of a registered marker, or nil.
+func customMarker(name string) *MarkerDef {
+	for _, def := range customMarkers {
+		if def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// checkCustomMarkers calls the handlers of the registered markers used in a
+// file and reports those attached to declarations they don't apply to.
+func checkCustomMarkers(pass *analysis.Pass, file *ast.File) {
+	if len(customMarkers) == 0 {
+		return
+	}
+
+	handled := make(map[marker]bool)
+	use := func(target MarkerTarget, node ast.Node, obj types.Object, owner *types.TypeName,
+		groups ...*ast.CommentGroup) {
+		for _, m := range collectMarkers(groups...) {
+			def := customMarker(m.name)
+			if def == nil || def.Targets&target == 0 {
+				continue
+			}
+			handled[m] = true
+			if def.Check != nil {
+				def.Check(pass, MarkerUse{Arg: m.arg, Marker: m, Target: target, Node: node, Object: obj,
+					Owner: owner, def: def})
+			}
+		}
+	}
+
+	use(TargetPackage, file, nil, nil, file.Doc)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			use(TargetFunc, decl, pass.TypesInfo.Defs[decl.Name], nil, decl.Doc)
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				spec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				structType, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				// An unparenthesized declaration carries its doc comment on
+				// the GenDecl.
+				doc := spec.Doc
+				if !decl.Lparen.IsValid() {
+					doc = decl.Doc
+				}
+				owner, _ := pass.TypesInfo.Defs[spec.Name].(*types.TypeName)
+				use(TargetStruct, spec, owner, nil, doc, spec.Comment)
+				for _, field := range structType.Fields.List {
+					for _, name := range field.Names {
+						use(TargetField, field, pass.TypesInfo.Defs[name], owner, field.Doc, field.Comment)
+					}
+				}
+			}
+		}
+	}
+
+	for _, group := range file.Comments {
+		for _, m := range collectMarkers(group) {
+			if def := customMarker(m.name); def != nil && !handled[m] {
+				report(pass, m, CategoryMarker, "%s marker only applies to %s", m.name, def.Targets)
+			}
+		}
+	}
+}
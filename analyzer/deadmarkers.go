@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// recordLiteralInits records the const fields set by a composite literal as
+// initialized at the literal's elements.
+func recordLiteralInits(pass *analysis.Pass, lit *ast.CompositeLit,
+	constFields map[*types.Var]constField, initialized map[*types.Var][]token.Pos) {
+	eachLiteralField(pass, lit, func(field *types.Var, elt ast.Expr) {
+		if _, exists := constFields[field]; exists {
+			initialized[field] = append(initialized[field], elt.Pos())
+		}
+	})
+}
+
+// reportDeadMarkers reports unexported const fields with no initialization
+// site in the package: they will hold their zero value forever. Exported
+// fields may be initialized by importers, which are checked later.
+func reportDeadMarkers(pass *analysis.Pass, constFields map[*types.Var]constField,
+	initialized map[*types.Var][]token.Pos) {
+	var dead []*types.Var
+	for field := range constFields {
+		if len(initialized[field]) == 0 && !field.Exported() {
+			dead = append(dead, field)
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool { return dead[i].Pos() < dead[j].Pos() })
+
+	for _, field := range dead {
+		cf := constFields[field]
+		report(pass, span{cf.pos, cf.pos + token.Pos(len(field.Name()))}, CategoryDeadMarker,
+			"const field %s.%s is never initialized", cf.owner.Name(), field.Name())
+	}
+}
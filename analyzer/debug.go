@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"context"
+	"go/token"
+	"go/types"
+	"log/slog"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// logger receives the trace of the analyzer's decisions; see SetLogger.
+var logger = slog.New(discardHandler{})
+
+// SetLogger sets the logger the analyzer traces its decisions to, so users can
+// find out why a write was or wasn't reported. At slog.LevelInfo it logs the
+// const fields and parameters it finds and the writes to them it allows or
+// suppresses, with the reason; at slog.LevelDebug also the markers it parses
+// and the facts it exports to and imports from other packages. Every record
+// carries the package and position it is about. A nil logger turns tracing
+// off, which is the default.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(discardHandler{})
+	}
+	logger = l
+}
+
+// discardHandler is a slog.Handler enabled at no level.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// trace logs a decision of the analyzer about the code at pos at level, when
+// the logger is enabled for it.
+func trace(pass *analysis.Pass, level slog.Level, pos token.Pos, msg string, args ...any) {
+	ctx := context.Background()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	args = append([]any{"package", pass.Pkg.Path(), "pos", pass.Fset.Position(pos).String()}, args...)
+	logger.Log(ctx, level, msg, args...)
+}
+
+// traceAllowed logs a write to the const field of owner the analyzer allows,
+// and why.
+func traceAllowed(pass *analysis.Pass, pos token.Pos, field *types.Var, owner *types.TypeName, reason string) {
+	trace(pass, slog.LevelInfo, pos, "write allowed", "type", owner.Name(), "field", field.Name(), "reason", reason)
+}
+
+// exportFact exports fact for obj, as pass.ExportObjectFact does, and logs it.
+func exportFact(pass *analysis.Pass, obj types.Object, fact analysis.Fact) {
+	pass.ExportObjectFact(obj, fact)
+	trace(pass, slog.LevelDebug, obj.Pos(), "fact exported", "object", obj.Name(), "fact", fact)
+}
+
+// importFact imports the fact of obj into fact, as pass.ImportObjectFact does,
+// and logs it if there is one.
+func importFact(pass *analysis.Pass, obj types.Object, fact analysis.Fact) bool {
+	if !pass.ImportObjectFact(obj, fact) {
+		return false
+	}
+	trace(pass, slog.LevelDebug, obj.Pos(), "fact imported", "object", obj.Pkg().Path()+"."+obj.Name(), "fact", fact)
+	return true
+}
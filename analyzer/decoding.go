@@ -0,0 +1,227 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// decoderTags lists the struct tag keys of decoders that set fields by name,
+// with the methods through which a type takes over its own decoding.
+var decoderTags = []struct {
+	key     string
+	methods []string
+}{
+	{"json", []string{"UnmarshalJSON", "UnmarshalText"}},
+	{"yaml", []string{"UnmarshalYAML", "UnmarshalText"}},
+	{"xml", []string{"UnmarshalXML", "UnmarshalText"}},
+	{"toml", []string{"UnmarshalTOML", "UnmarshalText"}},
+	{"bson", []string{"UnmarshalBSON"}},
+	{"msgpack", []string{"DecodeMsgpack"}},
+	{"mapstructure", nil},
+}
+
+// checkDecodeTags reports exported const fields carrying a struct tag that
+// lets a decoder set them, such as json:"name": json.Unmarshal would rewrite
+// the field whenever it is handed the struct. Fields tagged "-" and types
+// decoding themselves, with UnmarshalJSON and the like, are not reported.
+func checkDecodeTags(pass *analysis.Pass, constFields map[*types.Var]constField) {
+	var fields []*types.Var
+	for field := range constFields {
+		if field.Exported() {
+			fields = append(fields, field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Pos() < fields[j].Pos() })
+
+	for _, field := range fields {
+		cf := constFields[field]
+		tag := reflect.StructTag(fieldTag(cf.owner, field))
+		for _, decoder := range decoderTags {
+			value, ok := tag.Lookup(decoder.key)
+			if !ok || value == "-" || decodesItself(cf.owner, decoder.methods) {
+				continue
+			}
+			key, _, _ := strings.Cut(value, ",")
+			if key == "" {
+				key = field.Name()
+			}
+			name := span{cf.pos, cf.pos + token.Pos(len(field.Name()))}
+			reportRelated(pass, name, CategoryDecode, cf.markerPos(), "field marked const here",
+				"const field %s.%s has a %s tag, so decoding can overwrite it as %q",
+				cf.owner.Name(), field.Name(), decoder.key, key)
+		}
+	}
+}
+
+// fieldTag returns the struct tag of a field of owner.
+func fieldTag(owner *types.TypeName, field *types.Var) string {
+	st, ok := owner.Type().Underlying().(*types.Struct)
+	if !ok {
+		return ""
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i) == field {
+			return st.Tag(i)
+		}
+	}
+	return ""
+}
+
+// decodesItself reports whether *T has one of the given decoding methods.
+func decodesItself(owner *types.TypeName, methods []string) bool {
+	mset := types.NewMethodSet(types.NewPointer(owner.Type()))
+	for _, method := range methods {
+		if mset.Lookup(owner.Pkg(), method) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// libraryFunc identifies a function or method of a library, e.g.
+// encoding/json's (*Decoder).Decode, by package path, receiver type name (""
+// for functions) and name.
+type libraryFunc struct {
+	pkg, recv, name string
+}
+
+// libraryCallee returns the library function a call calls, if any.
+func libraryCallee(pass *analysis.Pass, call *ast.CallExpr) (*types.Func, libraryFunc, bool) {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return nil, libraryFunc{}, false
+	}
+	key := libraryFunc{pkg: fn.Pkg().Path(), name: fn.Name()}
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		if named := namedType(recv.Type()); named != nil {
+			key.recv = named.Name()
+		}
+	}
+	return fn, key, true
+}
+
+// calledName returns the name of a library function as called, e.g.
+// json.Unmarshal or (*json.Decoder).Decode.
+func calledName(fn *types.Func, key libraryFunc) string {
+	if key.recv != "" {
+		return "(*" + fn.Pkg().Name() + "." + key.recv + ")." + fn.Name()
+	}
+	return fn.Pkg().Name() + "." + fn.Name()
+}
+
+// decoders maps decoding functions and methods to the index of the argument
+// they decode into.
+var decoders = map[libraryFunc]int{
+	{"encoding/json", "", "Unmarshal"}:                       1,
+	{"encoding/json", "Decoder", "Decode"}:                   0,
+	{"encoding/xml", "", "Unmarshal"}:                        1,
+	{"encoding/xml", "Decoder", "Decode"}:                    0,
+	{"encoding/xml", "Decoder", "DecodeElement"}:             0,
+	{"encoding/gob", "Decoder", "Decode"}:                    0,
+	{"encoding/binary", "", "Read"}:                          2,
+	{"gopkg.in/yaml.v2", "", "Unmarshal"}:                    1,
+	{"gopkg.in/yaml.v2", "Decoder", "Decode"}:                0,
+	{"gopkg.in/yaml.v3", "", "Unmarshal"}:                    1,
+	{"gopkg.in/yaml.v3", "Decoder", "Decode"}:                0,
+	{"sigs.k8s.io/yaml", "", "Unmarshal"}:                    1,
+	{"github.com/BurntSushi/toml", "", "Unmarshal"}:          1,
+	{"github.com/BurntSushi/toml", "", "Decode"}:             1,
+	{"github.com/BurntSushi/toml", "", "DecodeFile"}:         1,
+	{"github.com/BurntSushi/toml", "Decoder", "Decode"}:      0,
+	{"github.com/pelletier/go-toml/v2", "", "Unmarshal"}:     1,
+	{"github.com/pelletier/go-toml/v2", "Decoder", "Decode"}: 0,
+	{"github.com/mitchellh/mapstructure", "", "Decode"}:      1,
+	{"github.com/go-viper/mapstructure/v2", "", "Decode"}:    1,
+}
+
+// checkDecodeCalls reports calls decoding into values of struct types with
+// const fields, such as json.Unmarshal(data, &cfg), outside of functions
+// instantiating the type: the decoder overwrites the const fields of a value
+// that already exists. Types decoding themselves with UnmarshalJSON and the
+// like are not reported.
+func checkDecodeCalls(pass *analysis.Pass, inspector *astinspector.Inspector,
+	constFields map[*types.Var]constField, instantiators map[instantiation]token.Pos) {
+	fieldsByOwner := constFieldNames(constFields)
+	inspector.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		call := n.(*ast.CallExpr)
+		fn, key, ok := libraryCallee(pass, call)
+		if !ok {
+			return true
+		}
+		index, ok := decoders[key]
+		if !ok || index >= len(call.Args) {
+			return true
+		}
+
+		owner := decodedOwner(pass.TypesInfo.TypeOf(call.Args[index]), fieldsByOwner)
+		if owner == nil || decodesItself(owner, decoderMethods(fn.Pkg().Name())) ||
+			isCachedInstanciator(pass, enclosingFuncDecl(stack), owner, instantiators) {
+			return true
+		}
+		report(pass, call, CategoryDecode, "%s decodes into %s, overwriting its const field(s) %s",
+			calledName(fn, key), owner.Name(), strings.Join(fieldsByOwner[owner], ", "))
+		return true
+	})
+}
+
+// constFieldNames returns the sorted names of the const fields of each struct
+// type declaring some.
+func constFieldNames(constFields map[*types.Var]constField) map[*types.TypeName][]string {
+	fieldsByOwner := make(map[*types.TypeName][]string)
+	for field, cf := range constFields {
+		fieldsByOwner[cf.owner] = append(fieldsByOwner[cf.owner], field.Name())
+	}
+	for _, names := range fieldsByOwner {
+		sort.Strings(names)
+	}
+	return fieldsByOwner
+}
+
+// decodedOwner returns the struct type with const fields that decoding into a
+// value of type t writes: t itself, or the elements of the pointers, slices,
+// arrays and maps it consists of.
+func decodedOwner(t types.Type, fieldsByOwner map[*types.TypeName][]string) *types.TypeName {
+	for t != nil {
+		if named, ok := t.(*types.Named); ok && len(fieldsByOwner[named.Origin().Obj()]) > 0 {
+			return named.Origin().Obj()
+		}
+		switch u := t.Underlying().(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		case *types.Map:
+			t = u.Elem()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// decoderMethods returns the methods through which a type takes over its own
+// decoding from the decoders of the named package.
+func decoderMethods(pkgName string) []string {
+	if pkgName == "gob" {
+		return []string{"GobDecode", "UnmarshalBinary"}
+	}
+	for _, tag := range decoderTags {
+		if tag.key == pkgName {
+			return tag.methods
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// disableDirective turns the checks off for the type declaration it documents,
+// its fields, and the declarations and receivers of its methods, for types in
+// the middle of a refactoring; writes the methods make to other const fields
+// are still reported. The rest of the line says why:
+//
+//	//constlint:disable moving to value objects, see #412
+//	type Order struct { ... }
+//
+// Anywhere else, it turns them off up to the next enableDirective of the file.
+const (
+	disableDirective = "//constlint:disable"
+	enableDirective  = "//constlint:enable"
+)
+
+// disableAnalyzer finds what the disable directives of a package turn off,
+// for Analyzer to leave out, the way inspect.Analyzer gives it the inspector
+// of the package.
+var disableAnalyzer = &analysis.Analyzer{
+	Name:             "constdisable",
+	Doc:              "finds the code //constlint:disable directives turn the const checks off for",
+	Run:              disableChecks,
+	RunDespiteErrors: true,
+	ResultType:       reflect.TypeOf((*DisabledCode)(nil)),
+}
+
+// DisabledCode is what the disable directives of a package turn off.
+// Drivers reporting diagnostics of their own, such as the whole-program check
+// of the constlint command, leave out those it disables.
+type DisabledCode struct {
+	types     map[*types.TypeName]bool // carrying a directive, for which no const fields are collected
+	receivers map[*types.Var]bool      // of the methods of types, about which emit drops diagnostics
+	ranges    []span                   // in which emit drops diagnostics
+	problems  []analysis.Diagnostic    // about malformed directives, for Analyzer to report
+}
+
+// problem records a diagnostic about a malformed directive at rng.
+func (dc *DisabledCode) problem(rng analysis.Range, format string, args ...any) {
+	dc.problems = append(dc.problems, analysis.Diagnostic{
+		Pos:      rng.Pos(),
+		End:      rng.End(),
+		Category: CategoryDirective,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// disableChecks returns the types and ranges the disable directives of the
+// package turn off, along with the problems of the directives.
+func disableChecks(pass *analysis.Pass) (any, error) {
+	return FindDisabledCode(pass.Fset, pass.Files, pass.TypesInfo), nil
+}
+
+// FindDisabledCode returns what the disable directives of the files of a
+// package, which info describes, turn off.
+func FindDisabledCode(fset *token.FileSet, files []*ast.File, info *types.Info) *DisabledCode {
+	// The helpers shared with the analyzer only use these of the pass.
+	pass := &analysis.Pass{Fset: fset, Files: files, TypesInfo: info}
+	dc := &DisabledCode{types: make(map[*types.TypeName]bool), receivers: make(map[*types.Var]bool)}
+	typeDirectives := make(map[*ast.Comment]bool)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				// An unparenthesized declaration carries its doc comment on
+				// the GenDecl.
+				var node ast.Node = spec
+				doc := spec.Doc
+				if !decl.Lparen.IsValid() {
+					node, doc = decl, decl.Doc
+				}
+				directive, ok := findDirective(doc, disableDirective)
+				if !ok {
+					continue
+				}
+				typeDirectives[directive] = true
+				if typeName, ok := pass.TypesInfo.Defs[spec.Name].(*types.TypeName); ok {
+					dc.types[typeName] = true
+				}
+				dc.ranges = append(dc.ranges, span{doc.Pos(), node.End()})
+				if !hasReason(directive) {
+					dc.problem(directive,
+						"%s on %s must say why the type is exempt, e.g. %s moving to value objects",
+						disableDirective, spec.Name.Name, disableDirective)
+				}
+			}
+		}
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || !dc.types[receiverType(pass, funcDecl)] {
+				continue
+			}
+			// The body stays checked, but for writes to the receiver.
+			var start ast.Node = funcDecl
+			if funcDecl.Doc != nil {
+				start = funcDecl.Doc
+			}
+			end := funcDecl.End()
+			if funcDecl.Body != nil {
+				end = funcDecl.Body.Lbrace
+			}
+			dc.ranges = append(dc.ranges, span{start.Pos(), end})
+			if recv := methodReceiver(pass, funcDecl); recv != nil {
+				dc.receivers[recv] = true
+			}
+		}
+	}
+
+	for _, file := range pass.Files {
+		dc.ranges = append(dc.ranges, disabledRegions(pass, dc, file, typeDirectives)...)
+	}
+	return dc
+}
+
+// disabledRegions returns the regions of file between a disable directive
+// and the next enable directive, other than the directives on types. Unbalanced
+// directives are problems of dc and turn nothing off.
+func disabledRegions(pass *analysis.Pass, dc *DisabledCode, file *ast.File,
+	typeDirectives map[*ast.Comment]bool) []span {
+	var regions []span
+	var open *ast.Comment
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			switch {
+			case typeDirectives[comment]:
+			case isDirective(comment, disableDirective):
+				if open != nil {
+					dc.problem(comment, "%s inside the region disabled at line %d; end it with %s first",
+						disableDirective, pass.Fset.Position(open.Pos()).Line, enableDirective)
+					continue
+				}
+				if !hasReason(comment) {
+					dc.problem(comment,
+						"%s must say why the region is exempt, e.g. %s legacy migration, see #412",
+						disableDirective, disableDirective)
+				}
+				open = comment
+			case isDirective(comment, enableDirective):
+				if open == nil {
+					dc.problem(comment, "%s without a preceding %s", enableDirective, disableDirective)
+					continue
+				}
+				regions = append(regions, span{open.Pos(), comment.End()})
+				open = nil
+			}
+		}
+	}
+	if open != nil {
+		dc.problem(open, "%s has no matching %s", disableDirective, enableDirective)
+	}
+	return regions
+}
+
+// findDirective returns the directive comment of a doc comment.
+func findDirective(doc *ast.CommentGroup, directive string) (*ast.Comment, bool) {
+	if doc == nil {
+		return nil, false
+	}
+	for _, comment := range doc.List {
+		if isDirective(comment, directive) {
+			return comment, true
+		}
+	}
+	return nil, false
+}
+
+// isDirective reports whether comment is the given directive, possibly
+// followed by text.
+func isDirective(comment *ast.Comment, directive string) bool {
+	rest, ok := strings.CutPrefix(comment.Text, directive)
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// hasReason reports whether the text following a disable directive says
+// something. A nested // comment is not a reason.
+func hasReason(comment *ast.Comment) bool {
+	reason, _, _ := strings.Cut(strings.TrimPrefix(comment.Text, disableDirective), "//")
+	return strings.TrimSpace(reason) != ""
+}
+
+// isDisabled reports whether a disable directive turns off the diagnostics of
+// the pass at pos about obj, declared by owner for fields, either of which may
+// be nil.
+func isDisabled(pass *analysis.Pass, pos token.Pos, obj types.Object, owner *types.TypeName) bool {
+	dc, ok := pass.ResultOf[disableAnalyzer].(*DisabledCode)
+	return ok && dc.Disables(pos, obj, owner)
+}
+
+// Disables reports whether the directives turn off the diagnostics at pos
+// about obj, declared by owner for fields, either of which may be nil.
+func (dc *DisabledCode) Disables(pos token.Pos, obj types.Object, owner *types.TypeName) bool {
+	if recv, ok := obj.(*types.Var); ok && dc.receivers[recv] || owner != nil && dc.types[owner] {
+		return true
+	}
+	for _, r := range dc.ranges {
+		if r.pos <= pos && pos < r.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// fieldWrite identifies a const field of a particular value, e.g. p.Name.
+type fieldWrite struct {
+	base  string // the value holding the field, as written
+	field *types.Var
+}
+
+// checkDoubleWrites reports const fields that a constructor writes more than
+// once on the same value. Only statements at the top level of the function body
+// are considered, so defaults overridden in a conditional are not reported.
+func checkDoubleWrites(pass *analysis.Pass, funcDecl *ast.FuncDecl,
+	constFields map[*types.Var]constField, instantiators map[instantiation]token.Pos) {
+	writes := make(map[fieldWrite]token.Pos)
+
+	record := func(base string, field *types.Var, node ast.Node) {
+		cf, exists := constFields[field]
+		if !exists || !isCachedInstanciator(pass, funcDecl, cf.owner, instantiators) {
+			return
+		}
+
+		key := fieldWrite{base: base, field: field}
+		if prev, seen := writes[key]; seen {
+			reportRelated(pass, node, CategoryDoubleWrite, prev, "previous assignment here",
+				"const field %s.%s of %s is assigned more than once in %s",
+				cf.owner.Name(), field.Name(), base, funcDecl.Name.Name)
+			return
+		}
+		writes[key] = node.Pos()
+	}
+
+	// assign records the write of rhs into lhs. A new value starts over,
+	// recording the fields set by its composite literal, if any.
+	assign := func(lhs, rhs ast.Expr) {
+		if selExpr, ok := lhs.(*ast.SelectorExpr); ok {
+			if base, ok := valuePath(selExpr.X); ok {
+				if _, field, _, ok := selectConstField(pass, selExpr, constFields); ok {
+					record(base, field, selExpr)
+				}
+			}
+		}
+
+		path, ok := valuePath(lhs)
+		if !ok {
+			return
+		}
+		for key := range writes {
+			if key.base == path || strings.HasPrefix(key.base, path+".") {
+				delete(writes, key)
+			}
+		}
+		if lit := compositeLit(rhs); lit != nil {
+			eachLiteralField(pass, lit, func(field *types.Var, elt ast.Expr) {
+				record(path, field, elt)
+			})
+		}
+	}
+
+	for _, stmt := range funcDecl.Body.List {
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range stmt.Lhs {
+				var rhs ast.Expr
+				if len(stmt.Lhs) == len(stmt.Rhs) {
+					rhs = stmt.Rhs[i]
+				}
+				assign(lhs, rhs)
+			}
+		case *ast.DeclStmt:
+			decl, ok := stmt.Decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				for i, name := range spec.Names {
+					var rhs ast.Expr
+					if len(spec.Names) == len(spec.Values) {
+						rhs = spec.Values[i]
+					}
+					assign(name, rhs)
+				}
+			}
+		}
+	}
+}
+
+// compositeLit returns the composite literal expr builds, T{...} or &T{...}.
+func compositeLit(expr ast.Expr) *ast.CompositeLit {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	lit, _ := expr.(*ast.CompositeLit)
+	return lit
+}
+
+// valuePath returns the textual form of a chain of identifiers and field
+// selections such as p or o.P, which identify the same value each time they are
+// evaluated within a sequence of assignments.
+func valuePath(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name, true
+	case *ast.SelectorExpr:
+		if _, ok := valuePath(e.X); ok {
+			return types.ExprString(e), true
+		}
+	case *ast.ParenExpr:
+		return valuePath(e.X)
+	case *ast.StarExpr:
+		return valuePath(e.X)
+	}
+	return "", false
+}
@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// checkElementWrites reports assignments replacing an element of a slice,
+// array or map whose elements are structs with const fields, such as
+// people[i] = Person{Name: "x"}: the field checks never see the const fields
+// the new element overwrites. Collections the function creates itself, with
+// make, a composite literal or a var declaration, are being built rather than
+// modified and may be filled in.
+func checkElementWrites(pass *analysis.Pass, inspector *astinspector.Inspector, constFields map[*types.Var]constField) {
+	fieldsByOwner := constFieldNames(constFields)
+	check := func(lhs ast.Expr, funcDecl *ast.FuncDecl) {
+		index, ok := ast.Unparen(lhs).(*ast.IndexExpr)
+		if !ok {
+			return
+		}
+		switch t := pass.TypesInfo.TypeOf(index.X).Underlying().(type) {
+		case *types.Slice, *types.Array, *types.Map:
+		case *types.Pointer:
+			if _, ok := t.Elem().Underlying().(*types.Array); !ok {
+				return
+			}
+		default:
+			return
+		}
+		named, ok := pass.TypesInfo.TypeOf(index).(*types.Named)
+		if !ok {
+			return
+		}
+		owner := named.Origin().Obj()
+		if len(fieldsByOwner[owner]) == 0 || createdLocally(pass, funcDecl, index.X) {
+			return
+		}
+		report(pass, lhs, CategoryElementWrite, "assignment replaces an element of type %s, overwriting its const field(s) %s",
+			owner.Name(), strings.Join(fieldsByOwner[owner], ", "))
+	}
+
+	filter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.RangeStmt)(nil),
+	}
+	inspector.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		funcDecl := enclosingFuncDecl(stack)
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if node.Tok == token.ASSIGN {
+				for _, lhs := range node.Lhs {
+					check(lhs, funcDecl)
+				}
+			}
+		case *ast.RangeStmt:
+			if node.Tok == token.ASSIGN && node.Value != nil {
+				check(node.Value, funcDecl)
+			}
+		}
+		return true
+	})
+}
+
+// createdLocally reports whether the collection expr is held by a variable
+// declared in the body of funcDecl, or by a field of one, holding a value the
+// function created: with make, new, a composite literal, or nothing at all.
+func createdLocally(pass *analysis.Pass, funcDecl *ast.FuncDecl, expr ast.Expr) bool {
+	root := rootIdent(expr)
+	if root == nil || funcDecl == nil || funcDecl.Body == nil {
+		return false
+	}
+	v, ok := pass.TypesInfo.Uses[root].(*types.Var)
+	if !ok || v.Pos() < funcDecl.Body.Pos() || v.Pos() >= funcDecl.Body.End() {
+		return false
+	}
+
+	created := func(value ast.Expr) bool {
+		switch value := ast.Unparen(value).(type) {
+		case *ast.CompositeLit:
+			return true
+		case *ast.UnaryExpr:
+			_, ok := ast.Unparen(value.X).(*ast.CompositeLit)
+			return value.Op == token.AND && ok
+		case *ast.CallExpr:
+			builtin, ok := typeutil.Callee(pass.TypesInfo, value).(*types.Builtin)
+			return ok && (builtin.Name() == "make" || builtin.Name() == "new")
+		}
+		return false
+	}
+	found := false
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && pass.TypesInfo.Defs[id] == v {
+					found = created(n.Rhs[i])
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if pass.TypesInfo.Defs[name] == v {
+					found = len(n.Values) == 0 || i < len(n.Values) && created(n.Values[i])
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
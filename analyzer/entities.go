@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// entityIdentity returns the identity fields listed by an +entity marker on a
+// struct type, mapped to their position in the marker. Names that are
+// repeated or that aren't fields of the struct are reported.
+func entityIdentity(pass *analysis.Pass, typeName *types.TypeName, structType *ast.StructType,
+	markers []marker) map[string]token.Pos {
+	names, found := entityFields(markers)
+	if !found {
+		return nil
+	}
+	return listedFields(pass, typeName, structType, names, entityMarker)
+}
+
+// listedFields maps the names a marker of a struct type lists to their
+// position in the marker. Names that are repeated or that aren't fields of the
+// struct are reported.
+func listedFields(pass *analysis.Pass, typeName *types.TypeName, structType *ast.StructType,
+	names []markerName, marker string) map[string]token.Pos {
+	fields := make(map[string]bool)
+	for _, field := range structType.Fields.List {
+		for _, name := range field.Names {
+			fields[name.Name] = true
+		}
+	}
+
+	listed := make(map[string]token.Pos, len(names))
+	for _, name := range names {
+		switch {
+		case listed[name.name].IsValid():
+			report(pass, name, CategoryMarker, "%s marker lists field %s of %s more than once",
+				marker, name.name, typeName.Name())
+		case !fields[name.name]:
+			report(pass, name, CategoryMarker, "%s marker lists %s, which is not a field of %s",
+				marker, name.name, typeName.Name())
+		default:
+			listed[name.name] = name.pos
+		}
+	}
+	return listed
+}
+
+// constFieldList returns the fields listed by the +const:[...] markers on a
+// struct type, as // +const:[ID,CreatedAt], and the first reason they give.
+// The lists of several markers add up.
+func constFieldList(markers []marker) (names []markerName, reason string, found bool) {
+	for _, m := range markers {
+		if m.name != constMarker || !isMarkerList(m.arg) {
+			continue
+		}
+		names = append(names, splitMarkerList(m.arg[1:len(m.arg)-1], m.argPos+1)...)
+		if reason == "" {
+			reason = m.reason
+		}
+		found = true
+	}
+	return names, reason, found
+}
+
+// listedConstFields returns the fields listed by the +const:[...] markers on
+// a struct type, mapped to their position in the marker, and the reason the
+// markers give. Listing fields alongside a bare +const, which marks every
+// field, is reported as conflicting.
+func listedConstFields(pass *analysis.Pass, typeName *types.TypeName, structType *ast.StructType,
+	markers []marker) (map[string]token.Pos, string) {
+	names, reason, found := constFieldList(markers)
+	if !found {
+		return nil, ""
+	}
+	for _, bare := range markers {
+		if bare.name != constMarker || bare.arg != "" {
+			continue
+		}
+		for _, list := range markers {
+			if list.name == constMarker && isMarkerList(list.arg) {
+				reportClashes(pass, []markerClash{{bare, list}}, typeName.Name())
+			}
+		}
+	}
+	return listedFields(pass, typeName, structType, names, constMarker+":[...]"), reason
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// WriteSite is a write to a const field the analyzer would report.
+type WriteSite struct {
+	// Expr is the expression written, such as o.ID in o.ID = id.
+	Expr ast.Expr
+	// Field is the const field written, and Owner the struct type declaring
+	// it.
+	Field *types.Var
+	Owner *types.TypeName
+	// Func is the function declaration making the write, nil for writes
+	// outside of function declarations.
+	Func *ast.FuncDecl
+}
+
+// exemption is a predicate registered with RegisterExemption.
+type exemption struct {
+	name  string
+	allow func(pass *analysis.Pass, site WriteSite) bool
+}
+
+var exemptions []*exemption
+
+// RegisterExemption adds a project-specific rule allowing writes to const
+// fields, for programs embedding the analyzer, such as
+//
+//	analyzer.RegisterExemption("bootstrap", func(pass *analysis.Pass, site analyzer.WriteSite) bool {
+//		return strings.HasSuffix(pass.Pkg.Path(), "/bootstrap")
+//	})
+//
+// allow is called for every write the analyzer would otherwise report, and
+// the write is allowed if it returns true; -debug-exemptions and -audit then
+// give name as the reason. Exemptions must be registered before the analyzer
+// runs, typically from an init function. The function returned removes the
+// exemption again, as tests do once they are done; it mustn't be called while
+// the analyzer runs either.
+func RegisterExemption(name string, allow func(pass *analysis.Pass, site WriteSite) bool) (unregister func()) {
+	e := &exemption{name, allow}
+	exemptions = append(exemptions, e)
+	return func() {
+		exemptions = slices.DeleteFunc(exemptions, func(other *exemption) bool { return other == e })
+	}
+}
+
+// exemptedBy returns the name of the first registered exemption allowing a
+// write.
+func exemptedBy(pass *analysis.Pass, site WriteSite) (string, bool) {
+	for _, e := range exemptions {
+		if e.allow(pass, site) {
+			return e.name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"go/types"
+	"log/slog"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// externalConstFact marks a struct field declared +const:external: the code
+// of its own package may write it freely, that of the packages importing it
+// may not.
+type externalConstFact struct {
+	Owner  string // the struct type declaring the field
+	Deep   bool   // +deepconst: the data the field references is const too
+	Reason string // why the field is const, if its marker says
+}
+
+func (*externalConstFact) AFact() {}
+
+func (f *externalConstFact) String() string {
+	if f.Deep {
+		return "const:external deep"
+	}
+	return "const:external"
+}
+
+// exportExternalConst exports the fact of a +const:external field of owner.
+func exportExternalConst(pass *analysis.Pass, field *types.Var, owner *types.TypeName, constness fieldConstness) {
+	exportFact(pass, field, &externalConstFact{
+		Owner:  owner.Name(),
+		Deep:   constness.mode == constDeep,
+		Reason: constness.reason,
+	})
+}
+
+// importedExternalFields returns the +const:external fields of the packages
+// this one imports, which it must treat as const.
+func importedExternalFields(pass *analysis.Pass) map[*types.Var]constField {
+	fields := make(map[*types.Var]constField)
+	for _, f := range pass.AllObjectFacts() {
+		fact, ok := f.Fact.(*externalConstFact)
+		if !ok || f.Object.Pkg() == nil || f.Object.Pkg() == pass.Pkg {
+			continue
+		}
+		field, ok := f.Object.(*types.Var)
+		if !ok {
+			continue
+		}
+		owner, ok := field.Pkg().Scope().Lookup(fact.Owner).(*types.TypeName)
+		if !ok || !typeInFocus(owner) || !fieldInFocus(owner, field.Name()) {
+			continue
+		}
+		mode := constShallow
+		if fact.Deep {
+			mode = constDeep
+		}
+		trace(pass, slog.LevelDebug, field.Pos(), "fact imported", "object", field.Pkg().Path()+"."+field.Name(),
+			"fact", fact)
+		fields[field] = constField{
+			owner:    owner,
+			pos:      field.Pos(),
+			mode:     mode,
+			reason:   fact.Reason,
+			external: true,
+		}
+	}
+	return fields
+}
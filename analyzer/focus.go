@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	"go/types"
+	"strings"
+)
+
+// listFlag is a flag.Value holding a comma-separated list.
+type listFlag []string
+
+func (f *listFlag) Set(value string) error {
+	*f = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*f = append(*f, item)
+		}
+	}
+	return nil
+}
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// focused reports whether -type or -field scope the run to some const fields,
+// leaving out the rules that aren't about them, such as marker checks and
+// const parameters.
+func focused() bool {
+	return len(typeFilter) > 0 || len(fieldFilter) > 0
+}
+
+// typeInFocus reports whether -type selects the struct type t: by name, by
+// name qualified with its package name (mypkg.Config), or with its package
+// path (example.com/mypkg.Config).
+func typeInFocus(t *types.TypeName) bool {
+	if len(typeFilter) == 0 {
+		return true
+	}
+	for _, pattern := range typeFilter {
+		if matchesType(pattern, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldInFocus reports whether -type and -field select the field named name of
+// the struct type t. Fields are selected by name (APIKey) or by name qualified
+// with a type as -type takes it (Config.APIKey, mypkg.Config.APIKey).
+func fieldInFocus(t *types.TypeName, name string) bool {
+	if !typeInFocus(t) {
+		return false
+	}
+	if len(fieldFilter) == 0 {
+		return true
+	}
+	for _, pattern := range fieldFilter {
+		typ, field, qualified := cutLast(pattern, ".")
+		if field == name && (!qualified || matchesType(typ, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesType reports whether pattern names t, as typeInFocus takes it.
+func matchesType(pattern string, t *types.TypeName) bool {
+	pkg, name, qualified := cutLast(pattern, ".")
+	if name != t.Name() {
+		return false
+	}
+	if !qualified {
+		return true
+	}
+	return t.Pkg() != nil && (pkg == t.Pkg().Name() || pkg == t.Pkg().Path())
+}
+
+// cutLast slices s around the last instance of sep, returning s itself as
+// after if sep doesn't appear.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return "", s, false
+}
@@ -0,0 +1,199 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// constGlobalFact marks a package-level variable declared const, so that
+// writes to it are reported in every package of the program.
+type constGlobalFact struct {
+	// Shallow is set by +const:shallow: only assigning the variable itself is
+	// a write, not modifying the map, slice or pointee it refers to.
+	Shallow bool
+}
+
+func (*constGlobalFact) AFact() {}
+
+func (f *constGlobalFact) String() string {
+	if f.Shallow {
+		return "const:shallow"
+	}
+	return "const"
+}
+
+// exportConstGlobals exports a fact for every package-level variable marked
+// const. Go has no way to freeze a map or a slice, so the markers make a
+// variable deep const unless they say shallow.
+func exportConstGlobals(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.ValueSpec)
+				// An unparenthesized declaration carries its doc comment on
+				// the GenDecl.
+				doc := spec.Doc
+				if !decl.Lparen.IsValid() {
+					doc = decl.Doc
+				}
+				constness, _ := fieldMarkers(collectMarkers(doc, spec.Comment))
+				if !constness.found || constness.mutable {
+					continue
+				}
+				for _, name := range spec.Names {
+					if v, ok := pass.TypesInfo.Defs[name].(*types.Var); ok && name.Name != "_" {
+						shallow := constness.explicit && constness.mode == constShallow
+						exportFact(pass, v, &constGlobalFact{Shallow: shallow})
+					}
+				}
+			}
+		}
+	}
+}
+
+// checkGlobalWrites reports writes to const package-level variables, of this
+// package or of the packages it imports: assigning them, and, unless they are
+// shallow, modifying what they refer to, with index or pointer assignments,
+// increments, delete or clear.
+//
+// The declaring package may still set its variables up in its init functions
+// and var initializers, unless strict is set.
+func checkGlobalWrites(pass *analysis.Pass, inspector *astinspector.Inspector, strict bool) {
+	check := func(v *types.Var, indirect bool, stack []ast.Node) bool {
+		var fact constGlobalFact
+		if !importFact(pass, v, &fact) || indirect && fact.Shallow {
+			return false
+		}
+		return strict || v.Pkg() != pass.Pkg || !initializing(stack)
+	}
+	assign := func(lhs ast.Expr, stack []ast.Node) {
+		if v, id, indirect := writtenGlobal(pass, lhs); v != nil && check(v, indirect, stack) {
+			reportWrite(pass, lhs, CategoryGlobalWrite, v, nil, v.Pos(), "variable declared const here",
+				"assignment to const variable %s", globalName(pass, v, id))
+		}
+	}
+
+	filter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.IncDecStmt)(nil),
+		(*ast.RangeStmt)(nil),
+		(*ast.CallExpr)(nil),
+	}
+	inspector.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch node := n.(type) {
+		case *ast.AssignStmt:
+			if node.Tok != token.DEFINE {
+				for _, lhs := range node.Lhs {
+					assign(lhs, stack)
+				}
+			}
+		case *ast.IncDecStmt:
+			assign(node.X, stack)
+		case *ast.RangeStmt:
+			if node.Tok == token.ASSIGN {
+				for _, lhs := range []ast.Expr{node.Key, node.Value} {
+					if lhs != nil {
+						assign(lhs, stack)
+					}
+				}
+			}
+		case *ast.CallExpr:
+			builtin, ok := typeutil.Callee(pass.TypesInfo, node).(*types.Builtin)
+			if !ok || builtin.Name() != "delete" && builtin.Name() != "clear" || len(node.Args) == 0 {
+				return true
+			}
+			// delete and clear modify what the variable refers to.
+			if v, id, _ := writtenGlobal(pass, node.Args[0]); v != nil && check(v, true, stack) {
+				reportWrite(pass, node, CategoryGlobalWrite, v, nil, v.Pos(), "variable declared const here",
+					"%s modifies const variable %s", builtin.Name(), globalName(pass, v, id))
+			}
+		}
+		return true
+	})
+}
+
+// initializing reports whether the node at the top of stack runs while its
+// package initializes: in an init function or a package-level var
+// initializer, such as a function literal called by one.
+func initializing(stack []ast.Node) bool {
+	if len(stack) < 2 {
+		return false
+	}
+	switch decl := stack[1].(type) {
+	case *ast.FuncDecl:
+		return decl.Recv == nil && decl.Name.Name == "init"
+	case *ast.GenDecl:
+		return decl.Tok == token.VAR
+	}
+	return false
+}
+
+// writtenGlobal returns the package-level variable an assignment to expr
+// writes, with the identifier naming it, and whether the write goes through
+// a map, slice or pointer the variable refers to rather than to the variable
+// itself, as in m[k] = v.
+func writtenGlobal(pass *analysis.Pass, expr ast.Expr) (v *types.Var, id *ast.Ident, indirect bool) {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			indirect = true
+			expr = e.X
+		case *ast.IndexExpr:
+			switch pass.TypesInfo.TypeOf(e.X).Underlying().(type) {
+			case *types.Slice, *types.Map, *types.Pointer:
+				indirect = true
+			}
+			expr = e.X
+		case *ast.SelectorExpr:
+			selection, ok := pass.TypesInfo.Selections[e]
+			if !ok {
+				// A qualified identifier, pkg.Var.
+				return globalVar(pass, e.Sel), e.Sel, indirect
+			}
+			if selection.Kind() != types.FieldVal {
+				return nil, nil, false
+			}
+			if selection.Indirect() {
+				indirect = true
+			}
+			expr = e.X
+		case *ast.Ident:
+			return globalVar(pass, e), e, indirect
+		default:
+			return nil, nil, false
+		}
+	}
+}
+
+// globalVar returns the package-level variable id refers to, of any package,
+// or nil.
+func globalVar(pass *analysis.Pass, id *ast.Ident) *types.Var {
+	v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return nil
+	}
+	return v
+}
+
+// globalName returns the name of a variable as written in the package being
+// analyzed: qualified with its package name when declared elsewhere.
+func globalName(pass *analysis.Pass, v *types.Var, id *ast.Ident) string {
+	if v.Pkg() == pass.Pkg {
+		return id.Name
+	}
+	return v.Pkg().Name() + "." + id.Name
+}
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// constMethodFact marks an interface method declared +const:receiver, so that
+// calls through the interface in other packages know its implementations
+// leave their receiver unchanged.
+type constMethodFact struct{}
+
+func (*constMethodFact) AFact() {}
+
+func (*constMethodFact) String() string { return "const:receiver" }
+
+// interfaceMutators lists the methods of well-known interfaces that modify
+// the value implementing them, keyed by the interface they are called
+// through: hash.Hash's Write comes from io.Writer, which doesn't say as much.
+var interfaceMutators = map[libraryFunc]bool{
+	{"hash", "Hash", "Write"}:                            true,
+	{"hash", "Hash", "Reset"}:                            true,
+	{"hash", "Hash32", "Write"}:                          true,
+	{"hash", "Hash32", "Reset"}:                          true,
+	{"hash", "Hash64", "Write"}:                          true,
+	{"hash", "Hash64", "Reset"}:                          true,
+	{"flag", "Value", "Set"}:                             true,
+	{"flag", "Getter", "Set"}:                            true,
+	{"sort", "Interface", "Swap"}:                        true,
+	{"container/heap", "Interface", "Swap"}:              true,
+	{"container/heap", "Interface", "Push"}:              true,
+	{"container/heap", "Interface", "Pop"}:               true,
+	{"encoding", "TextUnmarshaler", "UnmarshalText"}:     true,
+	{"encoding", "BinaryUnmarshaler", "UnmarshalBinary"}: true,
+	{"encoding/json", "Unmarshaler", "UnmarshalJSON"}:    true,
+}
+
+// exportConstMethods exports a fact for every interface method marked
+// +const:receiver.
+func exportConstMethods(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			iface, ok := n.(*ast.InterfaceType)
+			if !ok {
+				return true
+			}
+			for _, method := range iface.Methods.List {
+				if _, ok := constMethodMarker(method.Doc); !ok || len(method.Names) == 0 {
+					continue
+				}
+				if fn, ok := pass.TypesInfo.Defs[method.Names[0]].(*types.Func); ok {
+					exportFact(pass, fn, &constMethodFact{})
+				}
+			}
+			return true
+		})
+	}
+}
+
+// interfaceMutator is a call of a method modifying its receiver through an
+// interface, such as h.Reset() on a hash.Hash.
+type interfaceMutator struct {
+	name string // the method as called, e.g. (hash.Hash).Reset
+	recv ast.Expr
+}
+
+// interfaceMutatorCall returns the call of a known mutating method through an
+// interface: a method of interfaceMutators, or one whose name matches the
+// mutator pattern, unless it is marked +const:receiver.
+func interfaceMutatorCall(pass *analysis.Pass, call *ast.CallExpr) (interfaceMutator, bool) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return interfaceMutator{}, false
+	}
+	selection, ok := pass.TypesInfo.Selections[sel]
+	if !ok || selection.Kind() != types.MethodVal || !types.IsInterface(selection.Recv()) {
+		return interfaceMutator{}, false
+	}
+	fn, ok := selection.Obj().(*types.Func)
+	if !ok || importFact(pass, fn, new(constMethodFact)) {
+		return interfaceMutator{}, false
+	}
+
+	name := fn.Name()
+	key := libraryFunc{name: fn.Name()}
+	if iface := namedType(selection.Recv()); iface != nil && iface.Pkg() != nil {
+		key.pkg, key.recv = iface.Pkg().Path(), iface.Name()
+		name = "(" + iface.Pkg().Name() + "." + iface.Name() + ")." + fn.Name()
+	}
+	if !interfaceMutators[key] && !mutatorPattern.MatchString(fn.Name()) {
+		return interfaceMutator{}, false
+	}
+	return interfaceMutator{name: name, recv: ast.Unparen(sel.X)}, true
+}
+
+// checkInterfaceMutator reports a mutating method called on the value a
+// +deepconst interface field holds, outside of the functions instantiating
+// its struct type. A shallow field only keeps the value from being replaced.
+func checkInterfaceMutator(pass *analysis.Pass, m interfaceMutator, funcDecl *ast.FuncDecl,
+	constFields map[*types.Var]constField, instantiators map[instantiation]token.Pos) {
+	_, field, cf, ok := selectConstField(pass, m.recv, constFields)
+	if !ok || cf.mode != constDeep {
+		return
+	}
+	checkMutatorFieldWrite(pass, m.name, m.recv, field, cf, funcDecl, instantiators)
+}
@@ -0,0 +1,197 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// Inventory lists the const declarations of a package. It is the result of
+// Analyzer, so tools built on top of the linter see exactly the markers it
+// enforces.
+type Inventory struct {
+	Structs []*ConstStruct
+	Funcs   []*ConstFunc
+}
+
+// ConstStruct is a struct type with at least one const field.
+type ConstStruct struct {
+	Type   *types.TypeName
+	Fields []*ConstField // in declaration order
+}
+
+// ConstField is a struct field marked const.
+type ConstField struct {
+	Var        *types.Var
+	Deep       bool        // marked +deepconst
+	Secret     bool        // marked +secret
+	WriteOnce  bool        // marked +writeonce
+	Reason     string      // why the field is const, as its marker's reason attribute says
+	Pos        token.Pos   // position of the field name
+	Marker     token.Pos   // position of the marker making the field const
+	Inits      []token.Pos // where the package sets the field, in source order
+	Violations []token.Pos // writes to the field reported in the package, in source order
+}
+
+// ConstFunc is a function or method with parameters marked const.
+type ConstFunc struct {
+	Func   *types.Func
+	Params []*types.Var // in declaration order
+}
+
+// IsConst reports whether param is one of the function's const parameters.
+func (f *ConstFunc) IsConst(param *types.Var) bool {
+	for _, p := range f.Params {
+		if p == param.Origin() {
+			return true
+		}
+	}
+	return false
+}
+
+// Func returns the const parameters of fn, or nil if it has none. fn may be
+// an instantiation of a generic function or a method of an instantiated type,
+// as found by FuncOf.
+func (inv *Inventory) Func(fn *types.Func) *ConstFunc {
+	if fn == nil {
+		return nil
+	}
+	for _, cf := range inv.Funcs {
+		if cf.Func == fn.Origin() {
+			return cf
+		}
+	}
+	return nil
+}
+
+// FuncOf returns the function or method an expression denotes, whether it is
+// called or not: f, pkg.F, the method value p.Update, the method expression
+// (*T).Update, or an instantiation such as F[int]. It returns nil for other
+// expressions, including calls of func-typed variables.
+func FuncOf(info *types.Info, expr ast.Expr) *types.Func {
+	expr = ast.Unparen(expr)
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = ast.Unparen(e.X)
+	case *ast.IndexListExpr:
+		expr = ast.Unparen(e.X)
+	}
+
+	var obj types.Object
+	switch e := expr.(type) {
+	case *ast.Ident:
+		obj = info.Uses[e]
+	case *ast.SelectorExpr:
+		if selection, ok := info.Selections[e]; ok {
+			obj = selection.Obj()
+		} else {
+			obj = info.Uses[e.Sel]
+		}
+	}
+	if fn, ok := obj.(*types.Func); ok {
+		return fn.Origin()
+	}
+	return nil
+}
+
+// IsConst reports whether field is one of the struct's const fields.
+func (s *ConstStruct) IsConst(field *types.Var) bool {
+	return s.Field(field) != nil
+}
+
+// Field returns the const field for field, or nil if it isn't const.
+func (s *ConstStruct) Field(field *types.Var) *ConstField {
+	for _, cf := range s.Fields {
+		if cf.Var == field.Origin() {
+			return cf
+		}
+	}
+	return nil
+}
+
+// Marker is a marker the analyzer understands, its own or a registered one,
+// found in a comment.
+type Marker struct {
+	Name   string    // with the leading +, such as "+const", the marker a synonym stands for
+	Arg    string    // the text following the colon, as in +const:shallow
+	Text   string    // the marker as written, which may be a synonym
+	Reason string    // the reason attribute following the marker, unquoted
+	Pos    token.Pos // position of the leading +
+}
+
+// Markers returns the markers the analyzer understands in the comments of
+// file, in source order, leaving out misspelled ones and other tools' +
+// directives.
+func Markers(file *ast.File) []Marker {
+	var markers []Marker
+	for _, m := range collectMarkers(file.Comments...) {
+		if isKnownMarker(m.name) {
+			markers = append(markers, Marker{Name: m.name, Arg: m.arg, Text: m.String(), Reason: m.reason, Pos: m.pos})
+		}
+	}
+	return markers
+}
+
+// newInventory builds the inventory for the const fields and parameters found
+// in a package, together with the positions where each field is initialized
+// and illegally written.
+func newInventory(constFields map[*types.Var]constField, constParams map[*types.Var]constParam,
+	initialized, violations map[*types.Var][]token.Pos) *Inventory {
+	structs := make(map[*types.TypeName]*ConstStruct)
+	for field, cf := range constFields {
+		s, ok := structs[cf.owner]
+		if !ok {
+			s = &ConstStruct{Type: cf.owner}
+			structs[cf.owner] = s
+		}
+		s.Fields = append(s.Fields, &ConstField{
+			Var:        field,
+			Deep:       cf.mode == constDeep,
+			Secret:     cf.secret,
+			WriteOnce:  cf.writeOnce,
+			Reason:     cf.reason,
+			Pos:        cf.pos,
+			Marker:     cf.marker,
+			Inits:      sortedPositions(initialized[field]),
+			Violations: sortedPositions(violations[field]),
+		})
+	}
+
+	inventory := &Inventory{}
+	for _, s := range structs {
+		sort.Slice(s.Fields, func(i, j int) bool { return s.Fields[i].Pos < s.Fields[j].Pos })
+		inventory.Structs = append(inventory.Structs, s)
+	}
+	sort.Slice(inventory.Structs, func(i, j int) bool {
+		return inventory.Structs[i].Type.Pos() < inventory.Structs[j].Type.Pos()
+	})
+
+	funcs := make(map[*types.Func]*ConstFunc)
+	for param, cp := range constParams {
+		if cp.fn == nil {
+			continue
+		}
+		f, ok := funcs[cp.fn]
+		if !ok {
+			f = &ConstFunc{Func: cp.fn}
+			funcs[cp.fn] = f
+			inventory.Funcs = append(inventory.Funcs, f)
+		}
+		f.Params = append(f.Params, param)
+	}
+	for _, f := range inventory.Funcs {
+		sort.Slice(f.Params, func(i, j int) bool { return f.Params[i].Pos() < f.Params[j].Pos() })
+	}
+	sort.Slice(inventory.Funcs, func(i, j int) bool {
+		return inventory.Funcs[i].Func.Pos() < inventory.Funcs[j].Func.Pos()
+	})
+	return inventory
+}
+
+// sortedPositions returns a sorted copy of positions.
+func sortedPositions(positions []token.Pos) []token.Pos {
+	sorted := append([]token.Pos(nil), positions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
@@ -0,0 +1,491 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+const (
+	// constMarker marks a struct field, or every parameter of a function, as const.
+	// A list form, // +const:[a, b], marks only the named parameters. On a struct
+	// type it marks every field.
+	constMarker = "+const"
+	// shallowArg explicitly records that only a field itself is const, not the
+	// data it references: // +const:shallow
+	shallowArg = "shallow"
+	// receiverArg marks a method as const: it must not modify its receiver,
+	// whatever its name: // +const:receiver
+	receiverArg = "receiver"
+	// externalArg marks a field as const for the code of other packages only,
+	// freely mutable inside its own: // +const:external
+	externalArg = "external"
+	// beginArg and endArg delimit a block of struct fields that are const as
+	// if each was marked +const, in comments of their own between the fields:
+	// // +const:begin ... // +const:end
+	beginArg = "begin"
+	endArg   = "end"
+	// deepConstMarker marks a field as const together with everything reachable
+	// through it.
+	deepConstMarker = "+deepconst"
+	// writeOnceMarker marks a field as const once set: besides the functions
+	// creating its struct, only a lazy initialization may write it, guarded by
+	// the field being unset (if t.cache == nil { t.cache = build() }) or run
+	// by a sync.Once.
+	writeOnceMarker = "+writeonce"
+	// mutableMarker exempts a field from a struct level marker.
+	mutableMarker = "+mutable"
+	// secretMarker marks a field as const and as holding a secret, such as an
+	// API key, that must not be printed or logged.
+	secretMarker = "+secret"
+	// valueObjectMarker marks a struct type as a value object: every field is
+	// deep const and methods take value receivers.
+	valueObjectMarker = "+valueobject"
+	// entityMarker marks a struct type as an entity whose identity fields,
+	// listed after entityIDArg, are const: // +entity:id=ID,CreatedAt
+	entityMarker = "+entity"
+	// entityIDArg introduces the identity field list of an +entity marker.
+	entityIDArg = "id="
+	// optionMarker marks a function, or a function type, as a functional
+	// option of the struct type it names: // +option:Server. Options may write
+	// the const fields of the value they are applied to.
+	optionMarker = "+option"
+	// packageMarker raises the enforcement of a whole package from its doc
+	// comment: // +constlint:strict enables the optional rules checking const
+	// fields and variables, and // +constlint:mode=deep makes bare +const
+	// markers deep.
+	packageMarker = "+constlint"
+	// strictArg and deepModeArg are the arguments of packageMarker.
+	strictArg   = "strict"
+	deepModeArg = "mode=deep"
+	// reasonAttr follows a marker to say why it was put there, as a quoted
+	// string: // +const reason="audit trail must be immutable"
+	reasonAttr = "reason="
+)
+
+// marker is a single directive parsed from a comment: a marker name, such
+// as +const, and an optional argument, as in +const:shallow or the list
+// of +const:[a, b].
+type marker struct {
+	name    string    // marker name including the leading +, e.g. "+const"
+	arg     string    // text following the colon, e.g. "shallow" or "[a, b]"
+	pos     token.Pos // position of the leading +
+	argPos  token.Pos // position of arg
+	synonym string    // the marker as written, for a synonym standing for name
+	reason  string    // the unquoted reason attribute following the marker
+}
+
+// String returns the marker as written.
+func (m marker) String() string {
+	if m.synonym != "" {
+		return m.synonym
+	}
+	if m.arg == "" {
+		return m.name
+	}
+	return m.name + ":" + m.arg
+}
+
+// Pos and End return the extent of the marker, so it can be reported as a range.
+func (m marker) Pos() token.Pos { return m.pos }
+func (m marker) End() token.Pos { return m.pos + token.Pos(len(m.String())) }
+
+// parseMarkers returns the directives in a comment. A directive comment starts
+// with a marker; several markers may follow each other separated by spaces,
+// each optionally followed by a reason attribute. Parsing stops at the first
+// word that is neither, so trailing prose or a nested // comment is ignored.
+func parseMarkers(comment *ast.Comment) []marker {
+	text, offset := comment.Text, 0
+	switch {
+	case strings.HasPrefix(text, "//"):
+		text, offset = text[2:], 2
+	case strings.HasPrefix(text, "/*"):
+		text, offset = strings.TrimSuffix(text[2:], "*/"), 2
+	default:
+		return nil
+	}
+
+	var markers []marker
+	for {
+		trimmed := strings.TrimLeft(text, " \t")
+		offset += len(text) - len(trimmed)
+		text = trimmed
+		if reason, n, ok := parseReason(text); ok && len(markers) > 0 {
+			markers[len(markers)-1].reason = reason
+			text = text[n:]
+			offset += n
+			continue
+		}
+		if !strings.HasPrefix(text, "+") {
+			return markers
+		}
+
+		word := text[:markerEnd(text)]
+		m := marker{pos: comment.Pos() + token.Pos(offset)}
+		if name, arg, ok := strings.Cut(word, ":"); ok {
+			m.name, m.arg = name, arg
+			m.argPos = m.pos + token.Pos(len(name)+1)
+		} else {
+			m.name = word
+		}
+		markers = append(markers, markerSynonyms.resolve(m))
+
+		text = text[len(word):]
+		offset += len(word)
+	}
+}
+
+// parseReason returns the reason of the reason attribute at the start of text,
+// and the length of the attribute.
+func parseReason(text string) (string, int, bool) {
+	if !strings.HasPrefix(text, reasonAttr) {
+		return "", 0, false
+	}
+	quoted, err := strconv.QuotedPrefix(text[len(reasonAttr):])
+	if err != nil {
+		return "", 0, false
+	}
+	reason, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", 0, false
+	}
+	return reason, len(reasonAttr) + len(quoted), true
+}
+
+// markerEnd returns the length of the marker at the start of text. A marker
+// ends at white space, unless the space is inside a [...] list.
+func markerEnd(text string) int {
+	depth := 0
+	for i, r := range text {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case ' ', '\t':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(text)
+}
+
+// collectMarkers returns every directive in the given comment groups.
+func collectMarkers(groups ...*ast.CommentGroup) []marker {
+	var markers []marker
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			markers = append(markers, parseMarkers(comment)...)
+		}
+	}
+	return markers
+}
+
+// packageDirectives is the enforcement a package asks for with +constlint
+// markers in its doc comment.
+type packageDirectives struct {
+	strict bool // the strict argument
+	deep   bool // the mode=deep argument
+}
+
+// collectPackageDirectives returns the directives in the package doc comments
+// of files.
+func collectPackageDirectives(files []*ast.File) packageDirectives {
+	var d packageDirectives
+	for _, file := range files {
+		for _, m := range collectMarkers(file.Doc) {
+			if m.name != packageMarker {
+				continue
+			}
+			switch m.arg {
+			case strictArg:
+				d.strict = true
+			case deepModeArg:
+				d.deep = true
+			}
+		}
+	}
+	return d
+}
+
+// isAnnotated reports whether any comment in files holds a marker the
+// analyzer understands.
+func isAnnotated(files []*ast.File) bool {
+	for _, file := range files {
+		for _, group := range file.Comments {
+			for _, m := range collectMarkers(group) {
+				if isKnownMarker(m.name) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// constMode describes how deeply a const field is protected.
+type constMode int
+
+const (
+	// constShallow protects the field itself, which for reference types is
+	// only the pointer, slice or map header.
+	constShallow constMode = iota
+	// constDeep also protects the data the field references.
+	constDeep
+)
+
+// fieldConstness is the constness requested by the markers on a field or
+// struct type.
+type fieldConstness struct {
+	found     bool      // a const or mutable marker is present
+	mutable   bool      // explicitly +mutable
+	mode      constMode // protection depth of a const field
+	explicit  bool      // depth was spelled out rather than implied by a bare +const
+	secret    bool      // +secret: the field must not reach print or log calls
+	writeOnce bool      // +writeonce: a lazy initialization may set the field
+	external  bool      // +const:external: only other packages may not write the field
+	pos       token.Pos // position of the marker deciding the constness
+	reason    string    // why the field is const, from a reason attribute
+
+	valueObject token.Pos // position of a +valueobject marker, if any
+}
+
+// markerClash is a pair of markers on the same declaration that don't go
+// together: the second contradicts or repeats the first.
+type markerClash [2]marker
+
+// duplicate reports whether the second marker repeats the first, possibly
+// through a synonym.
+func (c markerClash) duplicate() bool {
+	return c[0].name == c[1].name && c[0].arg == c[1].arg
+}
+
+// fieldMarkers resolves the markers on a field or struct type, combining
+// those that go together, such as +deepconst +secret. Every pair of markers
+// that contradict or repeat each other is returned so they can be reported;
+// the stricter interpretation wins.
+func fieldMarkers(markers []marker) (c fieldConstness, clashes []markerClash) {
+	var constant, shallow, deep, mutable, secret, valueObject, writeOnce, external *marker
+	for i := range markers {
+		m := &markers[i]
+		var kind **marker
+		switch {
+		case m.name == deepConstMarker && m.arg == "":
+			kind = &deep
+		case m.name == constMarker && m.arg == shallowArg:
+			kind = &shallow
+		case m.name == constMarker && m.arg == "":
+			kind = &constant
+		case m.name == constMarker && m.arg == externalArg:
+			kind = &external
+		case m.name == mutableMarker && m.arg == "":
+			kind = &mutable
+		case m.name == secretMarker && m.arg == "":
+			kind = &secret
+		case m.name == valueObjectMarker && m.arg == "":
+			kind = &valueObject
+		case m.name == writeOnceMarker && m.arg == "":
+			kind = &writeOnce
+		default:
+			continue
+		}
+		if *kind != nil {
+			clashes = append(clashes, markerClash{**kind, *m})
+			continue
+		}
+		*kind = m
+	}
+
+	for _, m := range []*marker{deep, valueObject} {
+		if m != nil && shallow != nil {
+			clashes = append(clashes, markerClash{*shallow, *m})
+		}
+	}
+	// Const fields are only set by the functions creating their struct.
+	for _, m := range []*marker{constant, shallow, valueObject} {
+		if m != nil && writeOnce != nil {
+			clashes = append(clashes, markerClash{*m, *writeOnce})
+		}
+	}
+	// An external field is const outside its package only, which a +const
+	// or +writeonce says of every package. +deepconst extends it to the data
+	// the field references.
+	for _, m := range []*marker{constant, shallow, valueObject, writeOnce} {
+		if m != nil && external != nil {
+			clashes = append(clashes, markerClash{*m, *external})
+		}
+	}
+	for _, m := range []*marker{constant, shallow, deep, secret, valueObject, writeOnce, external} {
+		if m != nil && mutable != nil {
+			clashes = append(clashes, markerClash{*m, *mutable})
+		}
+	}
+
+	switch {
+	case deep != nil:
+		c = fieldConstness{found: true, mode: constDeep, explicit: true, pos: deep.pos}
+	case valueObject != nil:
+		c = fieldConstness{found: true, mode: constDeep, explicit: true, pos: valueObject.pos}
+	case shallow != nil:
+		c = fieldConstness{found: true, mode: constShallow, explicit: true, pos: shallow.pos}
+	case constant != nil:
+		c = fieldConstness{found: true, mode: constShallow, pos: constant.pos}
+	case external != nil:
+		c = fieldConstness{found: true, mode: constShallow, explicit: true, pos: external.pos}
+	case writeOnce != nil:
+		// A field set lazily holds what it is set to, such as a cache, whose
+		// data isn't protected unless it says so.
+		c = fieldConstness{found: true, mode: constShallow, explicit: true, pos: writeOnce.pos}
+	case secret != nil:
+		c = fieldConstness{found: true, mode: constShallow, pos: secret.pos}
+	case mutable != nil:
+		c = fieldConstness{found: true, mutable: true, pos: mutable.pos}
+	}
+	c.secret = secret != nil
+	c.writeOnce = writeOnce != nil && constant == nil && shallow == nil && valueObject == nil && mutable == nil
+	c.external = external != nil && constant == nil && shallow == nil && valueObject == nil && writeOnce == nil &&
+		mutable == nil
+	if valueObject != nil {
+		c.valueObject = valueObject.pos
+	}
+	if !c.mutable {
+		for _, m := range []*marker{deep, valueObject, shallow, constant, writeOnce, external, secret} {
+			if m != nil && m.reason != "" && c.reason == "" {
+				c.reason = m.reason
+			}
+		}
+	}
+	return c, clashes
+}
+
+// entityFields returns the identity fields listed by the first well-formed
+// +entity marker among markers.
+func entityFields(markers []marker) ([]markerName, bool) {
+	for _, m := range markers {
+		if m.name == entityMarker && strings.HasPrefix(m.arg, entityIDArg) {
+			return splitMarkerList(m.arg[len(entityIDArg):], m.argPos+token.Pos(len(entityIDArg))), true
+		}
+	}
+	return nil, false
+}
+
+// optionTarget returns the first +option marker naming a type among markers.
+func optionTarget(markers []marker) (marker, bool) {
+	for _, m := range markers {
+		if m.name == optionMarker && m.arg != "" {
+			return m, true
+		}
+	}
+	return marker{}, false
+}
+
+// paramMarker is a +const marker found in a function doc comment.
+type paramMarker struct {
+	pos    token.Pos      // position of the first marker
+	all    bool           // bare marker: every parameter is const
+	lists  [][]markerName // names listed in each +const:[...] marker
+	reason string         // the first reason attribute of the markers
+}
+
+// markerName is a single entry of a +const:[...] list.
+type markerName struct {
+	name string
+	pos  token.Pos
+}
+
+// Pos and End return the extent of the name within its marker.
+func (n markerName) Pos() token.Pos { return n.pos }
+func (n markerName) End() token.Pos { return n.pos + token.Pos(len(n.name)) }
+
+// funcMarker scans a function doc comment for +const markers. The lists of all
+// list markers in the doc comment are merged. A bare marker alongside lists
+// clashes with each of them, and the bare marker wins; a repeated bare marker
+// clashes with the first.
+func funcMarker(doc *ast.CommentGroup) (pm paramMarker, found bool, clashes []markerClash) {
+	var bare *marker
+	var lists []marker
+	markers := collectMarkers(doc)
+	for i := range markers {
+		m := &markers[i]
+		if m.name != constMarker {
+			continue
+		}
+		switch {
+		case m.arg == "" && bare != nil:
+			clashes = append(clashes, markerClash{*bare, *m})
+		case m.arg == "":
+			bare = m
+		case isMarkerList(m.arg):
+			lists = append(lists, *m)
+		}
+	}
+
+	if bare != nil {
+		for _, list := range lists {
+			clashes = append(clashes, markerClash{*bare, list})
+		}
+	}
+
+	var reason string
+	if bare != nil {
+		reason = bare.reason
+	}
+	for _, list := range lists {
+		if reason == "" {
+			reason = list.reason
+		}
+	}
+
+	switch {
+	case bare != nil:
+		return paramMarker{pos: bare.pos, all: true, reason: reason}, true, clashes
+	case len(lists) > 0:
+		pm = paramMarker{pos: lists[0].pos, reason: reason}
+		for _, list := range lists {
+			pm.lists = append(pm.lists, splitMarkerList(list.arg[1:len(list.arg)-1], list.argPos+1))
+		}
+		return pm, true, clashes
+	}
+	return paramMarker{}, false, clashes
+}
+
+// isMarkerList reports whether a marker argument is a [...] list.
+func isMarkerList(arg string) bool {
+	return strings.HasPrefix(arg, "[") && strings.HasSuffix(arg, "]")
+}
+
+// splitMarkerList splits a comma separated marker list into trimmed, non-empty
+// names. pos is the position of the first byte of list.
+func splitMarkerList(list string, pos token.Pos) []markerName {
+	var names []markerName
+	offset := 0
+	for {
+		item, rest, more := strings.Cut(list[offset:], ",")
+		if name := strings.TrimSpace(item); name != "" {
+			lead := len(item) - len(strings.TrimLeft(item, " \t"))
+			names = append(names, markerName{name: name, pos: pos + token.Pos(offset+lead)})
+		}
+		if !more {
+			return names
+		}
+		offset = len(list) - len(rest)
+	}
+}
+
+// constMarkerPos returns the position of the first +const or +deepconst
+// directive in the given comment groups.
+func constMarkerPos(groups ...*ast.CommentGroup) (token.Pos, bool) {
+	for _, m := range collectMarkers(groups...) {
+		if m.name == constMarker || m.name == deepConstMarker {
+			return m.pos, true
+		}
+	}
+	return token.NoPos, false
+}
@@ -0,0 +1,142 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+)
+
+// mutators maps library functions writing into one of their arguments, such
+// as proto.Merge(dst, src), to the index of that argument.
+var mutators = map[libraryFunc]int{
+	{"google.golang.org/protobuf/proto", "", "Merge"}:      0,
+	{"google.golang.org/protobuf/proto", "", "Reset"}:      0,
+	{"github.com/golang/protobuf/proto", "", "Merge"}:      0,
+	{"github.com/golang/protobuf/proto", "", "Reset"}:      0,
+	{"dario.cat/mergo", "", "Merge"}:                       0,
+	{"dario.cat/mergo", "", "MergeWithOverwrite"}:          0,
+	{"dario.cat/mergo", "", "Map"}:                         0,
+	{"dario.cat/mergo", "", "MapWithOverwrite"}:            0,
+	{"github.com/imdario/mergo", "", "Merge"}:              0,
+	{"github.com/imdario/mergo", "", "MergeWithOverwrite"}: 0,
+	{"github.com/imdario/mergo", "", "Map"}:                0,
+	{"github.com/imdario/mergo", "", "MapWithOverwrite"}:   0,
+	{"github.com/jinzhu/copier", "", "Copy"}:               0,
+	{"github.com/jinzhu/copier", "", "CopyWithOption"}:     0,
+}
+
+// checkMutatorCalls reports calls of library functions writing into their
+// destination argument when the destination is const:
+//
+//   - a const parameter, passed by address (mergo.Merge(&p, src)) or, if it
+//     holds a reference, as is (proto.Merge(p, src));
+//   - a const field, passed by address (copier.Copy(&x.F, src)), or the data a
+//     +deepconst field references (proto.Merge(x.Msg, src)), outside of the
+//     functions instantiating its struct type;
+//   - a value of a struct type with const fields, such as mergo.Merge(cfg,
+//     defaults), outside of the functions instantiating the type.
+//
+// The Reset, SetX and ClearX methods protoc-gen-go generates for messages
+// count as library functions writing into their receiver.
+//
+// So do the known mutating methods of interfaces, called on the value a
+// +deepconst interface field holds.
+func checkMutatorCalls(pass *analysis.Pass, inspector *astinspector.Inspector, constFields map[*types.Var]constField,
+	constParams map[*types.Var]constParam, instantiators map[instantiation]token.Pos) {
+	fieldsByOwner := constFieldNames(constFields)
+
+	inspector.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		call := n.(*ast.CallExpr)
+		funcDecl := enclosingFuncDecl(stack)
+		if m, ok := protoMutatorCall(pass, call); ok {
+			checkProtoMutator(pass, m, funcDecl, constFields, constParams, fieldsByOwner, instantiators)
+			return true
+		}
+		if m, ok := interfaceMutatorCall(pass, call); ok {
+			checkInterfaceMutator(pass, m, funcDecl, constFields, instantiators)
+			return true
+		}
+		fn, key, ok := libraryCallee(pass, call)
+		if !ok {
+			return true
+		}
+		index, ok := mutators[key]
+		if !ok || index >= len(call.Args) {
+			return true
+		}
+		dst := ast.Unparen(call.Args[index])
+		name := calledName(fn, key)
+
+		// The destination is written as *dst.
+		var written ast.Expr = &ast.StarExpr{Star: dst.Pos(), X: dst}
+		if addr, ok := dst.(*ast.UnaryExpr); ok && addr.Op == token.AND {
+			written = ast.Unparen(addr.X)
+		}
+		if checkMutatorWrite(pass, name, dst, written, funcDecl, constFields, constParams, instantiators) {
+			return true
+		}
+
+		owner := decodedOwner(pass.TypesInfo.TypeOf(dst), fieldsByOwner)
+		if owner == nil || isCachedInstanciator(pass, funcDecl, owner, instantiators) {
+			return true
+		}
+		report(pass, dst, CategoryFieldWrite, "%s writes into %s, overwriting its const field(s) %s",
+			name, owner.Name(), strings.Join(fieldsByOwner[owner], ", "))
+		return true
+	})
+}
+
+// checkMutatorWrite reports the mutator name writing written, the
+// destination dst as the function it is called in sees it, when it is a const
+// parameter or field. It reports whether written is one, even if the function
+// may write it.
+func checkMutatorWrite(pass *analysis.Pass, name string, dst, written ast.Expr, funcDecl *ast.FuncDecl,
+	constFields map[*types.Var]constField, constParams map[*types.Var]constParam,
+	instantiators map[instantiation]token.Pos) bool {
+	if id, ok := written.(*ast.Ident); ok {
+		v, _ := pass.TypesInfo.Uses[id].(*types.Var)
+		if param, ok := constParams[v]; ok {
+			reportWrite(pass, dst, CategoryParamWrite, v, nil, param.marker,
+				"parameter marked const here", "%s writes into const parameter %s%s", name, id.Name, because(param.reason))
+			return true
+		}
+	}
+	if star, ok := written.(*ast.StarExpr); ok {
+		if id, ok := ast.Unparen(star.X).(*ast.Ident); ok {
+			v, _ := pass.TypesInfo.Uses[id].(*types.Var)
+			if param, ok := constParams[v]; ok && holdsReferences(v.Type()) {
+				reportWrite(pass, dst, CategoryParamWrite, v, nil, param.marker,
+					"parameter marked const here", "%s writes through const parameter %s%s", name, id.Name,
+					because(param.reason))
+				return true
+			}
+		}
+	}
+
+	if _, field, cf, ok := writtenConstField(pass, written, constFields, nil); ok {
+		checkMutatorFieldWrite(pass, name, dst, field, cf, funcDecl, instantiators)
+		return true
+	}
+	return false
+}
+
+// checkMutatorFieldWrite reports the mutator name writing the const field
+// through dst, outside of the functions instantiating its struct type.
+func checkMutatorFieldWrite(pass *analysis.Pass, name string, dst ast.Expr, field *types.Var, cf constField,
+	funcDecl *ast.FuncDecl, instantiators map[instantiation]token.Pos) {
+	if isCachedInstanciator(pass, funcDecl, cf.owner, instantiators) {
+		return
+	}
+	if _, exempt := exemptedBy(pass, WriteSite{Expr: dst, Field: field, Owner: cf.owner, Func: funcDecl}); exempt {
+		return
+	}
+	reportWrite(pass, dst, CategoryFieldWrite, field, cf.owner, cf.markerPos(), "field marked const here",
+		"%s writes into const field %s.%s%s", name, cf.owner.Name(), field.Name(), because(cf.reason))
+}
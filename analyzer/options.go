@@ -0,0 +1,240 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+)
+
+// functionalOptions records the functional options of a package: functions a
+// constructor applies to the value it builds, which may therefore write the
+// value's const fields.
+type functionalOptions struct {
+	types map[*types.TypeName]optionOf // option func types
+	funcs map[*ast.FuncDecl]optionOf   // functions marked +option:T
+}
+
+// optionOf names the struct type an option configures, and where that was
+// decided: the +option marker, or the constructor taking the option type.
+type optionOf struct {
+	owner *types.TypeName
+	pos   token.Pos
+}
+
+// collectOptions finds the functional options of a package. Option types are
+// func types taking a *T, which a constructor of T accepts as a variadic
+// parameter (NewServer(opts ...Option)) or which are marked +option:T;
+// functions marked +option:T are options too, as are the function literals
+// they return.
+func collectOptions(pass *analysis.Pass, inspector *astinspector.Inspector,
+	constFields map[*types.Var]constField) functionalOptions {
+	owners := make(map[*types.TypeName]bool)
+	for _, cf := range constFields {
+		owners[cf.owner] = true
+	}
+	opts := functionalOptions{
+		types: make(map[*types.TypeName]optionOf),
+		funcs: make(map[*ast.FuncDecl]optionOf),
+	}
+
+	// target resolves the type named by an +option marker.
+	target := func(m marker) (*types.TypeName, bool) {
+		typeName, ok := pass.Pkg.Scope().Lookup(m.arg).(*types.TypeName)
+		if !ok {
+			report(pass, m, CategoryMarker, "+option marker names %s, which is not a type of this package", m.arg)
+			return nil, false
+		}
+		return typeName, true
+	}
+
+	inspector.Preorder([]ast.Node{(*ast.GenDecl)(nil), (*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		switch decl := n.(type) {
+		case *ast.FuncDecl:
+			if m, found := optionTarget(collectMarkers(decl.Doc)); found {
+				if owner, ok := target(m); ok {
+					opts.funcs[decl] = optionOf{owner: owner, pos: m.pos}
+				}
+			}
+
+			params := decl.Type.Params.List
+			if len(params) == 0 {
+				return
+			}
+			variadic, ok := params[len(params)-1].Type.(*ast.Ellipsis)
+			if !ok {
+				return
+			}
+			named, ok := pass.TypesInfo.TypeOf(variadic.Elt).(*types.Named)
+			if !ok {
+				return
+			}
+			if owner := optionOwner(named); owners[owner] && isInstanciator(pass, decl, owner) {
+				if _, marked := opts.types[named.Obj()]; !marked {
+					opts.types[named.Obj()] = optionOf{owner: owner, pos: variadic.Pos()}
+				}
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				spec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				doc := spec.Doc
+				if !decl.Lparen.IsValid() {
+					doc = decl.Doc
+				}
+				m, found := optionTarget(collectMarkers(doc))
+				if !found {
+					continue
+				}
+				if _, ok := spec.Type.(*ast.FuncType); !ok {
+					report(pass, m, CategoryMarker, "+option marker has no effect on %s type %s",
+						describeTypeExpr(spec.Type), spec.Name.Name)
+					continue
+				}
+				if owner, ok := target(m); ok {
+					opts.types[pass.TypesInfo.Defs[spec.Name].(*types.TypeName)] = optionOf{owner: owner, pos: m.pos}
+				}
+			}
+		}
+	})
+	return opts
+}
+
+// optionOwner returns T for a func type whose first parameter is a *T.
+func optionOwner(named *types.Named) *types.TypeName {
+	sig, ok := named.Underlying().(*types.Signature)
+	if !ok || sig.Params().Len() == 0 {
+		return nil
+	}
+	ptr, ok := sig.Params().At(0).Type().(*types.Pointer)
+	if !ok {
+		return nil
+	}
+	if elem, ok := ptr.Elem().(*types.Named); ok {
+		return elem.Obj()
+	}
+	return nil
+}
+
+// appliedOption reports whether a write to a const field of owner through
+// base happens in a functional option of owner applied to base: base must be
+// a parameter of the innermost function on the stack, which must be an
+// option of owner. It returns what made the function an option.
+func appliedOption(pass *analysis.Pass, base ast.Expr, stack []ast.Node, owner *types.TypeName,
+	opts functionalOptions) (optionOf, bool) {
+	ident, ok := ast.Unparen(base).(*ast.Ident)
+	if !ok {
+		return optionOf{}, false
+	}
+	param, ok := pass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok {
+		return optionOf{}, false
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		var funcType *ast.FuncType
+		switch fn := stack[i].(type) {
+		case *ast.FuncDecl:
+			funcType = fn.Type
+		case *ast.FuncLit:
+			funcType = fn.Type
+		default:
+			continue
+		}
+		if param.Pos() < funcType.Params.Pos() || param.Pos() > funcType.Params.End() {
+			return optionOf{}, false
+		}
+
+		// The parameter belongs to this function: it is an option if it is
+		// marked as one, returned by one, or used as a value of an option type.
+		for _, outer := range stack[:i+1] {
+			if decl, ok := outer.(*ast.FuncDecl); ok {
+				if opt, ok := opts.funcs[decl]; ok && opt.owner == owner {
+					return opt, true
+				}
+			}
+		}
+		if i > 0 {
+			if named, ok := expectedType(pass, stack[i], stack[:i]).(*types.Named); ok {
+				if opt, ok := opts.types[named.Obj()]; ok && opt.owner == owner {
+					return opt, true
+				}
+			}
+		}
+		return optionOf{}, false
+	}
+	return optionOf{}, false
+}
+
+// expectedType returns the type the context of expr, the last node of stack
+// being its parent, requires of it: the result type it is returned as, the
+// type it is converted or assigned to, or the parameter type it is passed as.
+func expectedType(pass *analysis.Pass, expr ast.Node, stack []ast.Node) types.Type {
+	switch parent := stack[len(stack)-1].(type) {
+	case *ast.ReturnStmt:
+		index := indexOf(parent.Results, expr)
+		for i := len(stack) - 2; i >= 0 && index >= 0; i-- {
+			var sig *types.Signature
+			switch fn := stack[i].(type) {
+			case *ast.FuncDecl:
+				if obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+					sig = obj.Type().(*types.Signature)
+				}
+			case *ast.FuncLit:
+				sig, _ = pass.TypesInfo.TypeOf(fn).(*types.Signature)
+			default:
+				continue
+			}
+			if sig != nil && index < sig.Results().Len() {
+				return sig.Results().At(index).Type()
+			}
+			return nil
+		}
+
+	case *ast.CallExpr:
+		if tv, ok := pass.TypesInfo.Types[parent.Fun]; ok && tv.IsType() {
+			return tv.Type
+		}
+		index := indexOf(parent.Args, expr)
+		sig, ok := pass.TypesInfo.TypeOf(parent.Fun).(*types.Signature)
+		if !ok || index < 0 {
+			return nil
+		}
+		if sig.Variadic() && index >= sig.Params().Len()-1 {
+			last := sig.Params().At(sig.Params().Len() - 1).Type()
+			if parent.Ellipsis.IsValid() {
+				return last
+			}
+			return last.(*types.Slice).Elem()
+		}
+		if index < sig.Params().Len() {
+			return sig.Params().At(index).Type()
+		}
+
+	case *ast.AssignStmt:
+		if index := indexOf(parent.Rhs, expr); index >= 0 && len(parent.Lhs) == len(parent.Rhs) {
+			return pass.TypesInfo.TypeOf(parent.Lhs[index])
+		}
+
+	case *ast.ValueSpec:
+		if parent.Type != nil {
+			return pass.TypesInfo.TypeOf(parent.Type)
+		}
+	}
+	return nil
+}
+
+// indexOf returns the index of node in exprs, or -1.
+func indexOf(exprs []ast.Expr, node ast.Node) int {
+	for i, expr := range exprs {
+		if expr == node {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkRedundantConstParams reports const parameters whose type holds no
+// references, such as ints, strings and structs of them. The function
+// receives a copy of their value, so the marker only keeps it from
+// reassigning its own copy and promises callers nothing.
+func checkRedundantConstParams(pass *analysis.Pass, constParams map[*types.Var]constParam) {
+	var params []*types.Var
+	for v := range constParams {
+		params = append(params, v)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Pos() < params[j].Pos() })
+
+	for _, v := range params {
+		if holdsReferences(v.Type()) {
+			continue
+		}
+		param := constParams[v]
+		reportRelated(pass, span{v.Pos(), v.Pos() + token.Pos(len(v.Name()))}, CategoryRedundantConst,
+			param.marker, "parameter marked const here",
+			"+const on by-value parameter %s of %s only keeps %s from reassigning its own copy; "+
+				"drop it unless that is deliberate", v.Name(), param.fn.Name(), param.fn.Name())
+	}
+}
+
+// holdsReferences reports whether values of t may refer to data a copy of the
+// value shares: pointers, slices, maps, channels, functions and interfaces,
+// directly or in struct fields and array elements. Type parameters may be
+// instantiated with any of them.
+func holdsReferences(t types.Type) bool {
+	switch t := t.Underlying().(type) {
+	case *types.Basic:
+		return t.Kind() == types.UnsafePointer
+	case *types.Array:
+		return holdsReferences(t.Elem())
+	case *types.Struct:
+		for i := range t.NumFields() {
+			if holdsReferences(t.Field(i).Type()) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
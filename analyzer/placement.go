@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkMarkerPlacement reports +const markers attached to declarations where
+// they have no effect, so misplaced annotations don't go unnoticed.
+func checkMarkerPlacement(pass *analysis.Pass, file *ast.File) {
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			checkGenDeclPlacement(pass, decl)
+		case *ast.FuncDecl:
+			if m, found := constMethodMarker(decl.Doc); found && decl.Recv == nil {
+				report(pass, m, CategoryMarker, "%s marker has no effect on function %s without a receiver",
+					m, decl.Name.Name)
+			}
+			if decl.Type.Params.NumFields() > 0 {
+				continue
+			}
+			if marker, found, _ := funcMarker(decl.Doc); found && marker.all {
+				report(pass, at(marker.pos), CategoryMarker,
+					"+const marker has no effect on function %s without parameters", decl.Name.Name)
+			}
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		iface, ok := n.(*ast.InterfaceType)
+		if !ok {
+			return true
+		}
+		for _, method := range iface.Methods.List {
+			if _, ok := constMethodMarker(method.Doc); ok && len(method.Names) > 0 {
+				continue
+			}
+			if pos, found := constMarkerPos(method.Doc, method.Comment); found {
+				report(pass, at(pos), CategoryMarker, "+const marker has no effect on interface %s",
+					describeInterfaceElem(method))
+			}
+		}
+		return true
+	})
+
+	for _, group := range file.Comments {
+		if group == file.Doc {
+			continue
+		}
+		for _, m := range collectMarkers(group) {
+			if m.name == packageMarker {
+				report(pass, m, CategoryMarker, "%s marker has no effect outside the package doc comment", m)
+			}
+		}
+	}
+}
+
+func checkGenDeclPlacement(pass *analysis.Pass, decl *ast.GenDecl) {
+	// An unparenthesized declaration carries its doc comment on the GenDecl.
+	var declDoc *ast.CommentGroup
+	if !decl.Lparen.IsValid() {
+		declDoc = decl.Doc
+	} else if pos, found := constMarkerPos(decl.Doc); found {
+		report(pass, at(pos), CategoryMarker, "+const marker has no effect on a %s block", decl.Tok)
+	}
+
+	for _, spec := range decl.Specs {
+		switch spec := spec.(type) {
+		case *ast.ImportSpec:
+			if pos, found := constMarkerPos(declDoc, spec.Doc, spec.Comment); found {
+				report(pass, at(pos), CategoryMarker, "+const marker has no effect on import %s", spec.Path.Value)
+			}
+		case *ast.TypeSpec:
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				continue
+			}
+			if pos, found := constMarkerPos(declDoc, spec.Doc, spec.Comment); found {
+				report(pass, at(pos), CategoryMarker, "+const marker has no effect on %s type %s",
+					describeTypeExpr(spec.Type), spec.Name.Name)
+			}
+		case *ast.ValueSpec:
+			if decl.Tok == token.VAR {
+				continue // const variables
+			}
+			if pos, found := constMarkerPos(declDoc, spec.Doc, spec.Comment); found {
+				report(pass, at(pos), CategoryMarker, "+const marker has no effect on %s %s",
+					decl.Tok, spec.Names[0].Name)
+			}
+		}
+	}
+}
+
+// describeTypeExpr names the kind of a non-struct type expression for diagnostics.
+func describeTypeExpr(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return "slice"
+		}
+		return "array"
+	case *ast.ChanType:
+		return "channel"
+	case *ast.FuncType:
+		return "func"
+	case *ast.StarExpr:
+		return "pointer"
+	case *ast.ParenExpr:
+		return describeTypeExpr(expr.X)
+	default:
+		return "defined"
+	}
+}
+
+// describeInterfaceElem names an interface method or embedded element.
+func describeInterfaceElem(field *ast.Field) string {
+	if len(field.Names) > 0 {
+		return "method " + field.Names[0].Name
+	}
+	return "element " + types.ExprString(field.Type)
+}
@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// protoMutator is a call of a method protoc-gen-go generates to modify a
+// message: Reset, or the SetX and ClearX methods of the opaque API.
+type protoMutator struct {
+	name  string // the method as called, e.g. (*orderpb.Order).Reset
+	field string // the field a SetX or ClearX method writes, e.g. X
+	recv  ast.Expr
+	// embedded lists the fields of recv the method is promoted through,
+	// outermost first, as in w.Reset() for a wrapper embedding *orderpb.Order.
+	embedded []*types.Var
+}
+
+// protoMutatorCall returns the generated mutator a call calls, if any.
+func protoMutatorCall(pass *analysis.Pass, call *ast.CallExpr) (protoMutator, bool) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return protoMutator{}, false
+	}
+	selection, ok := pass.TypesInfo.Selections[sel]
+	if !ok || selection.Kind() != types.MethodVal {
+		return protoMutator{}, false
+	}
+	fn, ok := selection.Obj().(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return protoMutator{}, false
+	}
+	field, ok := generatedMutatorField(fn.Name())
+	if !ok {
+		return protoMutator{}, false
+	}
+	message := namedType(fn.Type().(*types.Signature).Recv().Type())
+	if message == nil || !isProtoMessage(message.Type()) {
+		return protoMutator{}, false
+	}
+
+	return protoMutator{
+		name:     "(*" + fn.Pkg().Name() + "." + message.Name() + ")." + fn.Name(),
+		field:    field,
+		recv:     ast.Unparen(sel.X),
+		embedded: embeddedFields(selection),
+	}, true
+}
+
+// generatedMutatorField reports whether a method name is that of a generated
+// mutator, and returns the field SetX and ClearX write.
+func generatedMutatorField(name string) (string, bool) {
+	if name == "Reset" {
+		return "", true
+	}
+	for _, prefix := range []string{"Set", "Clear"} {
+		field, ok := strings.CutPrefix(name, prefix)
+		if r, _ := utf8.DecodeRuneInString(field); ok && unicode.IsUpper(r) {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// isProtoMessage reports whether values of type t, through a pointer, are
+// protobuf messages of either API: they have a ProtoReflect method, or a
+// ProtoMessage method.
+func isProtoMessage(t types.Type) bool {
+	methods := types.NewMethodSet(types.NewPointer(t))
+	for i := range methods.Len() {
+		if name := methods.At(i).Obj().Name(); name == "ProtoReflect" || name == "ProtoMessage" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProtoMutator reports a generated mutator writing const data, like the
+// library mutators of checkMutatorCalls: a message held by value in a const
+// field or by reference in a +deepconst one, possibly embedded in a wrapper,
+// or passed as a const parameter. Functions instantiating a wrapper may set
+// up its messages. Reset also writes the const fields of a message type, and
+// SetX and ClearX its const field X.
+func checkProtoMutator(pass *analysis.Pass, m protoMutator, funcDecl *ast.FuncDecl,
+	constFields map[*types.Var]constField, constParams map[*types.Var]constParam,
+	fieldsByOwner map[*types.TypeName][]string, instantiators map[instantiation]token.Pos) {
+	// Walking from the message out, the outermost embedded const field that
+	// holds it by value, or by reference and is deep, is written.
+	indirect := false
+	var written *types.Var
+	for i := len(m.embedded) - 1; i >= 0; i-- {
+		f := m.embedded[i]
+		if _, ok := f.Type().Underlying().(*types.Pointer); ok {
+			indirect = true
+		}
+		if cf, ok := constFields[f.Origin()]; ok && (!indirect || cf.mode == constDeep) {
+			written = f.Origin()
+		}
+	}
+	if written != nil {
+		checkMutatorFieldWrite(pass, m.name, m.recv, written, constFields[written], funcDecl, instantiators)
+		return
+	}
+
+	// The receiver is written as recv, or as *recv if it is a pointer or the
+	// method is promoted through one.
+	var recv ast.Expr = m.recv
+	if _, ok := pass.TypesInfo.TypeOf(m.recv).Underlying().(*types.Pointer); ok || indirect {
+		recv = &ast.StarExpr{Star: m.recv.Pos(), X: m.recv}
+	}
+	if checkMutatorWrite(pass, m.name, m.recv, recv, funcDecl, constFields, constParams, instantiators) {
+		return
+	}
+
+	var message types.Type = pass.TypesInfo.TypeOf(m.recv)
+	if len(m.embedded) > 0 {
+		message = m.embedded[len(m.embedded)-1].Type()
+	}
+	owner := namedType(message)
+	if owner == nil || isCachedInstanciator(pass, funcDecl, owner, instantiators) {
+		return
+	}
+	switch fields := fieldsByOwner[owner]; {
+	case m.field == "" && len(fields) > 0:
+		report(pass, m.recv, CategoryFieldWrite, "%s writes into %s, overwriting its const field(s) %s",
+			m.name, owner.Name(), strings.Join(fields, ", "))
+	case m.field != "" && slices.Contains(fields, m.field):
+		report(pass, m.recv, CategoryFieldWrite, "%s writes into const field %s.%s", m.name, owner.Name(), m.field)
+	}
+}
@@ -0,0 +1,158 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// publication is the point where a constructor shares the value it builds.
+type publication struct {
+	pos token.Pos
+	how string // e.g. "sent on a channel"
+}
+
+// checkPublication reports writes to const fields that a constructor makes
+// after sharing the value it builds: sending it on a channel, storing it in a
+// package-level variable or handing it to a goroutine. From then on other
+// goroutines may observe the write, so the exemption for constructors no
+// longer applies.
+func checkPublication(pass *analysis.Pass, funcDecl *ast.FuncDecl,
+	constFields map[*types.Var]constField, instantiators map[instantiation]token.Pos) {
+	published := make(map[*types.Var]publication)
+	publish := func(v *types.Var, pos token.Pos, how string) {
+		if _, seen := published[v]; !seen {
+			published[v] = publication{pos: pos, how: how}
+		}
+	}
+
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SendStmt:
+			for _, v := range sharedVars(pass, node.Value) {
+				publish(v, node.Pos(), "sent on a channel")
+			}
+
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				global := packageVar(pass, lhs)
+				if global == nil || len(node.Lhs) != len(node.Rhs) {
+					continue
+				}
+				for _, v := range sharedVars(pass, node.Rhs[i]) {
+					publish(v, node.Pos(), "stored in package-level variable "+global.Name())
+				}
+			}
+
+		case *ast.GoStmt:
+			for _, arg := range node.Call.Args {
+				for _, v := range sharedVars(pass, arg) {
+					publish(v, node.Pos(), "passed to a goroutine")
+				}
+			}
+			if lit, ok := node.Call.Fun.(*ast.FuncLit); ok {
+				ast.Inspect(lit.Body, func(n ast.Node) bool {
+					if ident, ok := n.(*ast.Ident); ok {
+						if v, ok := pass.TypesInfo.Uses[ident].(*types.Var); ok && isLocal(funcDecl, v) {
+							publish(v, node.Pos(), "captured by a goroutine")
+						}
+					}
+					return true
+				})
+			}
+		}
+		return true
+	})
+	if len(published) == 0 {
+		return
+	}
+
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || assign.Tok == token.DEFINE {
+			return true
+		}
+		for _, lhs := range assign.Lhs {
+			selExpr, field, cf, ok := writtenConstField(pass, lhs, constFields, nil)
+			if !ok || !isCachedInstanciator(pass, funcDecl, cf.owner, instantiators) {
+				continue
+			}
+			ident, ok := ast.Unparen(selExpr.X).(*ast.Ident)
+			if !ok {
+				continue
+			}
+			v, _ := pass.TypesInfo.Uses[ident].(*types.Var)
+			if p, ok := published[v]; ok && p.pos < lhs.Pos() {
+				reportRelated(pass, lhs, CategoryPublication, p.pos, ident.Name+" published here",
+					"const field %s.%s is written after %s was %s", cf.owner.Name(), field.Name(), ident.Name, p.how)
+			}
+		}
+		return true
+	})
+}
+
+// sharedVars returns the local variables whose value an expression shares
+// with its recipient: pointers themselves, and variables whose address is
+// taken, directly or as arguments and elements (append(s, p), []*T{p}).
+func sharedVars(pass *analysis.Pass, expr ast.Expr) []*types.Var {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		if v, ok := pass.TypesInfo.Uses[e].(*types.Var); ok {
+			if _, ok := v.Type().Underlying().(*types.Pointer); ok {
+				return []*types.Var{v}
+			}
+		}
+	case *ast.UnaryExpr:
+		if ident, ok := ast.Unparen(e.X).(*ast.Ident); ok && e.Op == token.AND {
+			if v, ok := pass.TypesInfo.Uses[ident].(*types.Var); ok {
+				return []*types.Var{v}
+			}
+		}
+	case *ast.CallExpr:
+		var vars []*types.Var
+		for _, arg := range e.Args {
+			vars = append(vars, sharedVars(pass, arg)...)
+		}
+		return vars
+	case *ast.CompositeLit:
+		var vars []*types.Var
+		for _, elt := range e.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				elt = kv.Value
+			}
+			vars = append(vars, sharedVars(pass, elt)...)
+		}
+		return vars
+	}
+	return nil
+}
+
+// packageVar returns the package-level variable an assignment target stores
+// into: the variable itself, or an element or field of it.
+func packageVar(pass *analysis.Pass, expr ast.Expr) *types.Var {
+	for {
+		switch e := ast.Unparen(expr).(type) {
+		case *ast.Ident:
+			v, ok := pass.TypesInfo.Uses[e].(*types.Var)
+			if ok && v.Parent() == pass.Pkg.Scope() {
+				return v
+			}
+			return nil
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// isLocal reports whether v is declared inside funcDecl.
+func isLocal(funcDecl *ast.FuncDecl, v *types.Var) bool {
+	return funcDecl.Pos() <= v.Pos() && v.Pos() < funcDecl.End()
+}
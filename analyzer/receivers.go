@@ -0,0 +1,135 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+)
+
+// regexpFlag is a flag.Value holding a regular expression, checked when the
+// flag is set.
+type regexpFlag struct {
+	*regexp.Regexp
+}
+
+func (f *regexpFlag) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return err
+	}
+	f.Regexp = re
+	return nil
+}
+
+func (f *regexpFlag) String() string {
+	if f.Regexp == nil {
+		return ""
+	}
+	return f.Regexp.String()
+}
+
+// checkConstReceivers reports writes to the receiver of methods whose name
+// doesn't match mutatorPattern: in this mode every other method is assumed to
+// leave its receiver unchanged.
+func checkConstReceivers(pass *analysis.Pass, inspector *astinspector.Inspector) {
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		if mutatorPattern.MatchString(funcDecl.Name.Name) {
+			return
+		}
+		typeName := receiverType(pass, funcDecl)
+		if typeName == nil {
+			return
+		}
+		recv := methodReceiver(pass, funcDecl)
+		for _, lhs := range receiverWrites(pass, funcDecl) {
+			emit(pass, analysis.Diagnostic{
+				Pos:      lhs.Pos(),
+				End:      lhs.End(),
+				Category: CategoryReceiver,
+				Message: fmt.Sprintf("method %s.%s modifies its receiver, but its name doesn't match the mutator pattern %s",
+					typeName.Name(), funcDecl.Name.Name, mutatorPattern.String()),
+			}, recv, nil)
+		}
+	})
+}
+
+// methodReceiver returns the named receiver of a method declaration with a
+// body, or nil.
+func methodReceiver(pass *analysis.Pass, funcDecl *ast.FuncDecl) *types.Var {
+	if funcDecl.Recv == nil || funcDecl.Body == nil || len(funcDecl.Recv.List[0].Names) == 0 {
+		return nil
+	}
+	recv, _ := pass.TypesInfo.Defs[funcDecl.Recv.List[0].Names[0]].(*types.Var)
+	return recv
+}
+
+// receiverWrites returns the expressions a method assigns or increments that
+// modify its receiver as the caller sees it: through a pointer receiver, or
+// through a slice, map or pointer held by a value receiver.
+func receiverWrites(pass *analysis.Pass, funcDecl *ast.FuncDecl) []ast.Expr {
+	recv := methodReceiver(pass, funcDecl)
+	if recv == nil {
+		return nil
+	}
+	var writes []ast.Expr
+	check := func(lhs ast.Expr) {
+		if writesThrough(pass, lhs, recv) {
+			writes = append(writes, lhs)
+		}
+	}
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt:
+			if stmt.Tok != token.DEFINE {
+				for _, lhs := range stmt.Lhs {
+					check(lhs)
+				}
+			}
+		case *ast.IncDecStmt:
+			check(stmt.X)
+		}
+		return true
+	})
+	return writes
+}
+
+// writesThrough reports whether assigning to expr modifies data reachable
+// from v rather than v itself: expr must be rooted at v and dereference a
+// pointer, slice or map on the way.
+func writesThrough(pass *analysis.Pass, expr ast.Expr, v *types.Var) bool {
+	indirect := false
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			indirect = true
+			expr = e.X
+		case *ast.IndexExpr:
+			switch pass.TypesInfo.TypeOf(e.X).Underlying().(type) {
+			case *types.Slice, *types.Map, *types.Pointer:
+				indirect = true
+			}
+			expr = e.X
+		case *ast.SelectorExpr:
+			selection, ok := pass.TypesInfo.Selections[e]
+			if !ok || selection.Kind() != types.FieldVal {
+				return false
+			}
+			if selection.Indirect() {
+				indirect = true
+			}
+			expr = e.X
+		case *ast.Ident:
+			return indirect && pass.TypesInfo.Uses[e] == v
+		default:
+			return false
+		}
+	}
+}
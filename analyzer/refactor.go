@@ -0,0 +1,181 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// setterRefactoring returns a fix for the write lhs to the const field of
+// owner when it is the whole body of an obvious setter,
+//
+//	func (u *User) SetEmail(email string) { u.Email = email }
+//
+// that removes the setter and threads its parameter through the nearest
+// constructor of owner instead: the constructor gains the parameter, and its
+// keyed literal of owner the field. Callers of either are left to update.
+func setterRefactoring(pass *analysis.Pass, funcDecl *ast.FuncDecl, lhs ast.Expr, field *types.Var,
+	owner *types.TypeName) (analysis.SuggestedFix, bool) {
+	param, ok := setterParam(pass, funcDecl, lhs, field, owner)
+	if !ok {
+		return analysis.SuggestedFix{}, false
+	}
+	ctor, lit, ok := nearestConstructor(pass, funcDecl, field, owner)
+	if !ok {
+		return analysis.SuggestedFix{}, false
+	}
+	// The parameter may not shadow or clash with a name the constructor uses.
+	name := param.Names[0].Name
+	clash := false
+	ast.Inspect(ctor, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == name {
+			clash = true
+		}
+		return !clash
+	})
+	if clash {
+		return analysis.SuggestedFix{}, false
+	}
+
+	decl := name + " " + types.ExprString(param.Type)
+	var paramEdit analysis.TextEdit
+	switch params := ctor.Type.Params.List; {
+	case len(params) == 0:
+		paramEdit = analysis.TextEdit{Pos: ctor.Type.Params.Closing, End: ctor.Type.Params.Closing, NewText: []byte(decl)}
+	case isVariadic(params[len(params)-1]):
+		// The variadic parameter stays last.
+		last := params[len(params)-1]
+		paramEdit = analysis.TextEdit{Pos: last.Pos(), End: last.Pos(), NewText: []byte(decl + ", ")}
+	default:
+		paramEdit = analysis.TextEdit{Pos: ctor.Type.Params.Closing, End: ctor.Type.Params.Closing,
+			NewText: []byte(", " + decl)}
+	}
+
+	elt := field.Name() + ": " + name
+	var litEdit analysis.TextEdit
+	switch {
+	case len(lit.Elts) == 0:
+		litEdit = analysis.TextEdit{Pos: lit.Rbrace, End: lit.Rbrace, NewText: []byte(elt)}
+	case pass.Fset.Position(lit.Rbrace).Line > pass.Fset.Position(lit.Elts[len(lit.Elts)-1].End()).Line:
+		// A literal spanning lines ends its last element with a comma.
+		litEdit = analysis.TextEdit{Pos: lit.Rbrace, End: lit.Rbrace, NewText: []byte(elt + ",\n")}
+	default:
+		end := lit.Elts[len(lit.Elts)-1].End()
+		litEdit = analysis.TextEdit{Pos: end, End: end, NewText: []byte(", " + elt)}
+	}
+
+	start := funcDecl.Pos()
+	if funcDecl.Doc != nil {
+		start = funcDecl.Doc.Pos()
+	}
+	return analysis.SuggestedFix{
+		Message: "Remove " + funcDecl.Name.Name + " and pass " + field.Name() + " to " + ctor.Name.Name,
+		TextEdits: []analysis.TextEdit{
+			{Pos: start, End: funcDecl.End()},
+			paramEdit,
+			litEdit,
+		},
+	}, true
+}
+
+// setterParam returns the parameter of funcDecl if it is an obvious setter of
+// field: a method of owner named Set<Field>, without results, whose body only
+// assigns its single parameter to the field with lhs.
+func setterParam(pass *analysis.Pass, funcDecl *ast.FuncDecl, lhs ast.Expr, field *types.Var,
+	owner *types.TypeName) (*ast.Field, bool) {
+	if funcDecl == nil || funcDecl.Body == nil || receiverType(pass, funcDecl) != owner {
+		return nil, false
+	}
+	suffix, ok := strings.CutPrefix(funcDecl.Name.Name, "Set")
+	if !ok || !strings.EqualFold(suffix, field.Name()) || funcDecl.Type.Results.NumFields() > 0 {
+		return nil, false
+	}
+	params := funcDecl.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) != 1 || params[0].Names[0].Name == "_" || isVariadic(params[0]) {
+		return nil, false
+	}
+	if len(funcDecl.Body.List) != 1 {
+		return nil, false
+	}
+	assign, ok := funcDecl.Body.List[0].(*ast.AssignStmt)
+	if !ok || assign.Tok != token.ASSIGN || len(assign.Lhs) != 1 || assign.Lhs[0] != lhs {
+		return nil, false
+	}
+	rhs, ok := ast.Unparen(assign.Rhs[0]).(*ast.Ident)
+	if !ok || pass.TypesInfo.Uses[rhs] != pass.TypesInfo.Defs[params[0].Names[0]] {
+		return nil, false
+	}
+	return params[0], true
+}
+
+// nearestConstructor returns the constructor of owner declared closest to
+// funcDecl, preferring its file, that builds owner with a single keyed
+// composite literal not setting field yet, and that literal.
+func nearestConstructor(pass *analysis.Pass, funcDecl *ast.FuncDecl, field *types.Var,
+	owner *types.TypeName) (*ast.FuncDecl, *ast.CompositeLit, bool) {
+	var (
+		best     *ast.FuncDecl
+		bestLit  *ast.CompositeLit
+		bestDist token.Pos
+	)
+	file := pass.Fset.File(funcDecl.Pos())
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			ctor, ok := decl.(*ast.FuncDecl)
+			if !ok || ctor.Recv != nil || ctor.Body == nil || constructedType(pass, ctor) != owner {
+				continue
+			}
+			lit, ok := constructorLiteral(pass, ctor, field, owner)
+			if !ok {
+				continue
+			}
+			dist := ctor.Pos() - funcDecl.Pos()
+			if dist < 0 {
+				dist = -dist
+			}
+			if pass.Fset.File(ctor.Pos()) != file {
+				// Constructors of other files come after those of this one.
+				dist += token.Pos(file.Size())
+			}
+			if best == nil || dist < bestDist {
+				best, bestLit, bestDist = ctor, lit, dist
+			}
+		}
+	}
+	return best, bestLit, best != nil
+}
+
+// constructorLiteral returns the composite literal of owner in ctor, if it
+// has exactly one, every element of which is keyed and none sets field.
+func constructorLiteral(pass *analysis.Pass, ctor *ast.FuncDecl, field *types.Var,
+	owner *types.TypeName) (*ast.CompositeLit, bool) {
+	var lits []*ast.CompositeLit
+	ast.Inspect(ctor.Body, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.CompositeLit); ok && namedType(pass.TypesInfo.TypeOf(lit)) == owner {
+			lits = append(lits, lit)
+		}
+		return true
+	})
+	if len(lits) != 1 {
+		return nil, false
+	}
+	for _, elt := range lits[0].Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, false
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok && key.Name == field.Name() {
+			return nil, false
+		}
+	}
+	return lits[0], true
+}
+
+// isVariadic reports whether a parameter is variadic, ...T.
+func isVariadic(param *ast.Field) bool {
+	_, ok := param.Type.(*ast.Ellipsis)
+	return ok
+}
@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Diagnostic categories, naming the rule that produced a diagnostic so output
+// can be grouped and filtered by rule, and violation handlers and tools can
+// tell the rules apart.
+const (
+	CategoryFieldWrite     = "field-write"            // write to a const field
+	CategoryParamWrite     = "param-write"            // write to a const parameter
+	CategoryDoubleWrite    = "double-write"           // const field set twice in a constructor
+	CategoryShallowConst   = "shallow-const"          // bare +const on a reference type, with -shallow-const
+	CategoryMarker         = "marker"                 // malformed, misplaced or conflicting markers
+	CategoryDeadMarker     = "dead-marker"            // const field never initialized
+	CategoryConstructor    = "incomplete-constructor" // constructor leaving const fields unset
+	CategorySetter         = "setter"                 // setter-shaped method on a const field
+	CategorySecretLeak     = "secret-leak"            // +secret field reaching print or log calls
+	CategoryValueObject    = "value-object"           // +valueobject rules
+	CategoryExemption      = "exemption"              // allowed write, with -debug-exemptions or -audit
+	CategoryPublication    = "publication"            // const field set after its value is shared
+	CategoryAlias          = "alias"                  // pointer to or slice of a const field returned
+	CategoryConcurrency    = "concurrency"            // write reachable from a goroutine, with -concurrency-audit
+	CategoryReceiver       = "receiver"               // receiver modified by a method, with -const-receivers
+	CategoryDecode         = "decode"                 // const field a decoder can overwrite
+	CategoryChannel        = "channel"                // send or close on a const channel, with -channel-ownership
+	CategoryRedundantConst = "redundant-const"        // const parameter passed by value, with -redundant-const-params
+	CategoryConsolidate    = "consolidate"            // fields all marked alike, with -consolidate-markers
+	CategoryUndecided      = "undecided"              // exported field without +const or +mutable, with -require-markers
+	CategoryConstMethod    = "const-method"           // receiver modified by a method marked +const:receiver
+	CategoryConstCandidate = "const-method-candidate" // method that could be +const:receiver, with -suggest-const-methods
+	CategoryValueReceiver  = "value-receiver"         // pointer receiver on a type of const fields, with -value-receivers
+	CategoryGlobalWrite    = "global-write"           // write to a const package-level variable
+	CategoryCopyWrite      = "copy-write"             // write to a const field of a copy, with -copy-writes
+	CategoryElementWrite   = "element-write"          // collection element replaced, with -element-writes
+	CategoryDirective      = "directive"              // malformed //constlint directive
+	CategoryBestEffort     = "best-effort"            // write matched by name, with -best-effort on type errors
+)
+
+// span is a source range for diagnostics reported without a node.
+type span struct {
+	pos, end token.Pos
+}
+
+func (s span) Pos() token.Pos { return s.pos }
+func (s span) End() token.Pos { return s.end }
+
+// at returns the empty range at pos.
+func at(pos token.Pos) span {
+	return span{pos: pos}
+}
+
+// report reports a diagnostic covering rng under the given category, unless
+// the category's rule is less confident than -min-confidence.
+func report(pass *analysis.Pass, rng analysis.Range, category, format string, args ...any) {
+	emit(pass, analysis.Diagnostic{
+		Pos:      rng.Pos(),
+		End:      rng.End(),
+		Category: category,
+		Message:  fmt.Sprintf(format, args...),
+	}, nil, nil)
+}
+
+// reportRelated reports a diagnostic covering rng with a secondary location,
+// such as the marker a write violates, which editors render as a link.
+func reportRelated(pass *analysis.Pass, rng analysis.Range, category string, relatedPos token.Pos, related,
+	format string, args ...any) {
+	reportWrite(pass, rng, category, nil, nil, relatedPos, related, format, args...)
+}
+
+// because returns the end of the message of a write to a const declaration
+// quoting the reason its marker gives, if any.
+func because(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return " (const because: " + reason + ")"
+}
+
+// reportWrite is reportRelated for a write to the const field, parameter or
+// variable obj, declared by owner for fields, which violation handlers
+// receive.
+func reportWrite(pass *analysis.Pass, rng analysis.Range, category string, obj types.Object, owner *types.TypeName,
+	relatedPos token.Pos, related, format string, args ...any) {
+	emit(pass, writeDiagnostic(rng, category, relatedPos, related, format, args...), obj, owner)
+}
+
+// writeDiagnostic returns the diagnostic reportWrite reports, for callers
+// adding fixes to it.
+func writeDiagnostic(rng analysis.Range, category string, relatedPos token.Pos, related, format string,
+	args ...any) analysis.Diagnostic {
+	return analysis.Diagnostic{
+		Pos:      rng.Pos(),
+		End:      rng.End(),
+		Category: category,
+		Message:  fmt.Sprintf(format, args...),
+		Related:  []analysis.RelatedInformation{{Pos: relatedPos, Message: related}},
+	}
+}
+
+// emit passes a diagnostic to the violation handlers and reports it, unless
+// its rule is less confident than -min-confidence, a disable directive turns
+// it off, it lies in code cgo synthesized or a handler takes it over. Disable
+// directives don't turn off the problems reported about them. Fixes
+// editing the copies cgo compiles are dropped.
+func emit(pass *analysis.Pass, d analysis.Diagnostic, obj types.Object, owner *types.TypeName) {
+	confidence := RuleConfidence(d.Category)
+	var suppressed string
+	switch {
+	case confidence < minConfidence:
+		suppressed = "less confident than -min-confidence"
+	case d.Category != CategoryDirective && isDisabled(pass, d.Pos, obj, owner):
+		suppressed = "disabled by a directive"
+	case cgoSynthesized(pass.Fset, d.Pos):
+		suppressed = "in code cgo synthesized"
+	}
+	if suppressed != "" {
+		trace(pass, slog.LevelInfo, d.Pos, "diagnostic suppressed", "rule", d.Category, "message", d.Message,
+			"reason", suppressed)
+		return
+	}
+	d.SuggestedFixes = slices.DeleteFunc(d.SuggestedFixes, func(fix analysis.SuggestedFix) bool {
+		return editsCopy(pass.Fset, fix)
+	})
+	keep := true
+	for _, h := range violationHandlers {
+		if !h.handle(pass, Violation{Diagnostic: d, Confidence: confidence, Object: obj, Owner: owner}) {
+			keep = false
+		}
+	}
+	if !keep {
+		trace(pass, slog.LevelInfo, d.Pos, "diagnostic suppressed", "rule", d.Category, "message", d.Message,
+			"reason", "taken over by a violation handler")
+	}
+	if keep {
+		pass.Report(d)
+	}
+}
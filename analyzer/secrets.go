@@ -0,0 +1,136 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// checkSecretLeaks reports +secret fields reaching print and log calls, either
+// directly or as part of a struct value printed as a whole, whose fields fmt
+// and slog would format one by one.
+func checkSecretLeaks(pass *analysis.Pass, inspector *astinspector.Inspector, constFields map[*types.Var]constField) {
+	secrets := make(map[*types.TypeName][]string)
+	for field, cf := range constFields {
+		if cf.secret {
+			secrets[cf.owner] = append(secrets[cf.owner], field.Name())
+		}
+	}
+	if len(secrets) == 0 {
+		return
+	}
+	for _, names := range secrets {
+		sort.Strings(names)
+	}
+
+	inspector.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		sink, ok := printSink(pass, call)
+		if !ok {
+			return
+		}
+
+		for _, arg := range call.Args {
+			for _, sel := range leakedSecrets(pass, arg, constFields) {
+				_, field, cf, _ := selectConstField(pass, sel, constFields)
+				report(pass, sel, CategorySecretLeak, "secret field %s.%s passed to %s",
+					cf.owner.Name(), field.Name(), sink)
+			}
+
+			t := pass.TypesInfo.TypeOf(arg)
+			if t == nil || formatsItself(t) {
+				continue
+			}
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if named, ok := t.(*types.Named); ok {
+				if names := secrets[named.Origin().Obj()]; len(names) > 0 {
+					report(pass, arg, CategorySecretLeak, "%s passed to %s prints its secret field(s) %s",
+						named.Obj().Name(), sink, strings.Join(names, ", "))
+				}
+			}
+		}
+	})
+}
+
+// printSink returns the name of the function called, if it prints or logs its
+// arguments: the builtin print functions, fmt's print functions, and the
+// functions and methods of log and log/slog.
+func printSink(pass *analysis.Pass, call *ast.CallExpr) (string, bool) {
+	switch callee := typeutil.Callee(pass.TypesInfo, call).(type) {
+	case *types.Builtin:
+		return callee.Name(), callee.Name() == "print" || callee.Name() == "println"
+	case *types.Func:
+		if callee.Pkg() == nil {
+			return "", false
+		}
+		name := callee.Name()
+		if recv := callee.Type().(*types.Signature).Recv(); recv != nil {
+			name = types.TypeString(recv.Type(), (*types.Package).Name) + "." + name
+		} else {
+			name = callee.Pkg().Name() + "." + name
+		}
+
+		switch callee.Pkg().Path() {
+		case "fmt":
+			return name, !strings.Contains(callee.Name(), "scan")
+		case "log":
+			for _, prefix := range []string{"Print", "Fatal", "Panic", "Output"} {
+				if strings.HasPrefix(callee.Name(), prefix) {
+					return name, true
+				}
+			}
+		case "log/slog":
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// leakedSecrets returns the selections of secret fields whose value an
+// argument carries: the field itself, possibly converted, sliced or
+// concatenated with other strings. Values computed from a secret by other
+// calls, such as its length or hash, are not leaks.
+func leakedSecrets(pass *analysis.Pass, expr ast.Expr, constFields map[*types.Var]constField) []*ast.SelectorExpr {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return leakedSecrets(pass, e.X, constFields)
+	case *ast.StarExpr:
+		return leakedSecrets(pass, e.X, constFields)
+	case *ast.SliceExpr:
+		return leakedSecrets(pass, e.X, constFields)
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return append(leakedSecrets(pass, e.X, constFields), leakedSecrets(pass, e.Y, constFields)...)
+		}
+	case *ast.CallExpr:
+		if tv, ok := pass.TypesInfo.Types[e.Fun]; ok && tv.IsType() && len(e.Args) == 1 {
+			return leakedSecrets(pass, e.Args[0], constFields)
+		}
+	case *ast.SelectorExpr:
+		if _, _, cf, ok := selectConstField(pass, e, constFields); ok && cf.secret {
+			return []*ast.SelectorExpr{e}
+		}
+	}
+	return nil
+}
+
+// formatsItself reports whether values of t control how they are printed,
+// through a String, GoString, Format or LogValue method, so their fields are
+// not printed one by one.
+func formatsItself(t types.Type) bool {
+	methods := types.NewMethodSet(t)
+	for _, name := range []string{"String", "GoString", "Format", "LogValue"} {
+		if methods.Lookup(nil, name) != nil {
+			return true
+		}
+	}
+	return false
+}
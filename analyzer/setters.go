@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+)
+
+// checkSetters reports exported methods shaped like a setter of a const field,
+// Set<Field>, whose mere presence advertises a mutation the linter forbids.
+// They are reported whether or not their body writes the field. Only the
+// methods of types selected by include are checked.
+func checkSetters(pass *analysis.Pass, inspector *astinspector.Inspector, constFields map[*types.Var]constField,
+	include func(owner *types.TypeName) bool) {
+	// Fields are matched by name regardless of case, so SetName matches name.
+	byOwner := make(map[*types.TypeName]map[string]*types.Var)
+	for field, cf := range constFields {
+		if byOwner[cf.owner] == nil {
+			byOwner[cf.owner] = make(map[string]*types.Var)
+		}
+		byOwner[cf.owner][strings.ToLower(field.Name())] = field
+	}
+
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		if funcDecl.Recv == nil || !funcDecl.Name.IsExported() {
+			return
+		}
+		suffix, ok := strings.CutPrefix(funcDecl.Name.Name, "Set")
+		if !ok || suffix == "" || !unicode.IsUpper([]rune(suffix)[0]) {
+			return
+		}
+
+		owner := receiverType(pass, funcDecl)
+		if owner == nil || !include(owner) {
+			return
+		}
+		field, ok := byOwner[owner][strings.ToLower(suffix)]
+		if !ok {
+			return
+		}
+		report(pass, funcDecl.Name, CategorySetter, "method %s.%s looks like a setter for const field %s.%s",
+			owner.Name(), funcDecl.Name.Name, owner.Name(), field.Name())
+	})
+}
+
+// receiverType returns the named type a method is declared on.
+func receiverType(pass *analysis.Pass, funcDecl *ast.FuncDecl) *types.TypeName {
+	fn, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+
+	t := recv.Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj()
+	}
+	return nil
+}
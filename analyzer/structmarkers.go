@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// structMarkers returns the markers on a struct type spec as a whole, from
+// its doc or trailing comment. A struct marked +const, +const:shallow or
+// +deepconst makes each of its fields const that has no marker of its own;
+// +mutable on a field opts it out.
+func structMarkers(decl *ast.GenDecl, spec *ast.TypeSpec) []marker {
+	// An unparenthesized declaration carries its doc comment on the GenDecl.
+	doc := spec.Doc
+	if !decl.Lparen.IsValid() {
+		doc = decl.Doc
+	}
+	return collectMarkers(doc, spec.Comment)
+}
+
+// checkConstStructMethods reports the +const:[...] markers on the methods of
+// struct types marked const as a whole, which conflict with the struct's
+// marker: whichever was meant, the type mixes two conventions. The listed
+// parameters stay const.
+func checkConstStructMethods(pass *analysis.Pass) {
+	structs := make(map[*types.TypeName]marker)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if _, ok := spec.Type.(*ast.StructType); !ok {
+					continue
+				}
+				markers := structMarkers(decl, spec)
+				constness, _ := fieldMarkers(markers)
+				typeName, ok := pass.TypesInfo.Defs[spec.Name].(*types.TypeName)
+				if !ok || !constness.found || constness.mutable {
+					continue
+				}
+				for _, m := range markers {
+					if m.pos == constness.pos {
+						structs[typeName] = m
+					}
+				}
+			}
+		}
+	}
+	if len(structs) == 0 {
+		return
+	}
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil {
+				continue
+			}
+			typeName := receiverType(pass, funcDecl)
+			structMarker, ok := structs[typeName]
+			if !ok {
+				continue
+			}
+			for _, m := range collectMarkers(funcDecl.Doc) {
+				if m.name == constMarker && isMarkerList(m.arg) {
+					report(pass, m, CategoryMarker, "conflicting constlint markers %s on %s and %s on its method %s.%s",
+						structMarker, typeName.Name(), m, typeName.Name(), funcDecl.Name.Name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// synonymFlag is a flag.Value holding marker synonyms, as a comma-separated
+// list of synonym=marker pairs such as +readonly=+const,+frozen=+deepconst.
+// A synonym stands for its marker wherever it is written, so that code
+// annotated by a homegrown convention is checked as is.
+type synonymFlag struct {
+	synonyms map[string]marker // by synonym, the marker it stands for
+	value    string
+}
+
+func (f *synonymFlag) Set(value string) error {
+	synonyms := make(map[string]marker)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		synonym, target, ok := strings.Cut(pair, "=")
+		synonym, target = strings.TrimSpace(synonym), strings.TrimSpace(target)
+		if !ok || synonym == "" || target == "" {
+			return fmt.Errorf("%q is not of the form +synonym=+marker", pair)
+		}
+		if !strings.HasPrefix(synonym, "+") || strings.ContainsAny(synonym, ": \t") {
+			return fmt.Errorf("synonym %q must be a + followed by a name, such as +readonly", synonym)
+		}
+		if isKnownMarker(synonym) {
+			return fmt.Errorf("synonym %s is a constlint marker already", synonym)
+		}
+		m := marker{name: target}
+		if name, arg, ok := strings.Cut(target, ":"); ok {
+			m.name, m.arg = name, arg
+		}
+		if !isKnownMarker(m.name) || m.name == packageMarker {
+			return fmt.Errorf("%s is not a constlint marker of fields, types or functions", m.name)
+		}
+		if isMarkerList(m.arg) {
+			return fmt.Errorf("synonym %s can't stand for a list marker such as %s", synonym, target)
+		}
+		if previous, ok := synonyms[synonym]; ok && previous != m {
+			return fmt.Errorf("synonym %s stands for both %s and %s", synonym, previous, m)
+		}
+		synonyms[synonym] = m
+	}
+	f.synonyms, f.value = synonyms, value
+	return nil
+}
+
+func (f *synonymFlag) String() string {
+	return f.value
+}
+
+// resolve returns m, or the marker it stands for if m is a synonym. The
+// argument of a synonym, as in +readonly:shallow, is kept unless the marker
+// it stands for has one of its own.
+func (f *synonymFlag) resolve(m marker) marker {
+	target, ok := f.synonyms[m.name]
+	if !ok {
+		return m
+	}
+	switch {
+	case target.arg == "":
+		m.synonym, m.name = m.String(), target.name
+	case m.arg == "":
+		m.synonym, m.name, m.arg = m.name, target.name, target.arg
+	}
+	return m
+}
+
+// synonymNames returns the synonyms of the markers, sorted.
+func (f *synonymFlag) synonymNames() []string {
+	var names []string
+	for name := range f.synonyms {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,63 @@
+package a
+
+// Card is modified through local aliases of its fields.
+type Card struct {
+	// +const
+	Title string
+	// +const
+	Slot Location
+	// +const
+	Pips [4]int
+	// +deepconst
+	Votes []int
+	// +const:shallow
+	Labels []string
+}
+
+// NewCard may write through aliases of the card it builds.
+func NewCard(title string) *Card {
+	c := &Card{}
+	t := &c.Title
+	*t = title
+	return c
+}
+
+// Retitle writes the title through a pointer to it.
+func (c *Card) Retitle(title string) {
+	t := &c.Title
+	*t = title // want `assignment to const field Card.Title through t$`
+	u := t
+	*u = title // want `assignment to const field Card.Title through u$`
+	t2 := &(c.Title)
+	t2 = new(string)
+	*t2 = title
+}
+
+// Move writes into a field stored by value through a pointer to it.
+func (c *Card) Move(city string) {
+	slot := &c.Slot
+	slot.City = city // want `assignment to const field Card.Slot through slot$`
+	copied := c.Slot
+	copied.City = city
+}
+
+// Tally writes elements through slices sharing their storage.
+func (c *Card) Tally() {
+	pips := c.Pips[:]
+	pips[0] = 1 // want `assignment to const field Card.Pips through pips$`
+	votes := c.Votes
+	votes[1] = 2 // want `assignment to const field Card.Votes through votes$`
+	var first = &c.Votes[0]
+	*first = 3 // want `assignment to const field Card.Votes through first$`
+	labels := c.Labels
+	labels[0] = "shallow"
+}
+
+// Rename writes a const parameter through a pointer to it.
+//
+// +const:[name]
+func Rename(name string) string {
+	ptr := &name
+	*ptr = "renamed" // want `assignment to const parameter name through ptr$`
+	return name
+}
@@ -0,0 +1,82 @@
+package a
+
+// Profile hands out references to its fields.
+type Profile struct {
+	// +const
+	Name string
+	// +const:shallow
+	Nicknames []string
+	// +deepconst
+	Scores []int
+	// +const
+	Grid [3]int
+	// +const
+	Home  Location
+	Notes []string
+}
+
+// Location is stored by value in Profile.
+type Location struct {
+	City string
+}
+
+// NewProfile may alias the profile it builds.
+func NewProfile(name string) (*Profile, *string) {
+	p := &Profile{Name: name}
+	return p, &p.Name
+}
+
+// NameRef hands out the name itself.
+func (p *Profile) NameRef() *string {
+	return &p.Name // want `NameRef returns a pointer into const field Profile.Name, through which callers can modify it$`
+}
+
+// CityRef reaches into a field stored by value.
+func (p *Profile) CityRef() *string {
+	return &(p.Home.City) // want `CityRef returns a pointer into const field Profile.Home`
+}
+
+// Cells slices an array field, sharing its storage.
+func (p *Profile) Cells() []int {
+	return p.Grid[:] // want `Cells returns a slice of const field Profile.Grid`
+}
+
+// TopScores shares the elements of a deep field.
+func (p *Profile) TopScores() []int {
+	if len(p.Scores) == 0 {
+		return nil
+	}
+	return p.Scores[:1] // want `TopScores returns a slice of const field Profile.Scores`
+}
+
+// FirstScore points into the elements of a deep field.
+func (p *Profile) FirstScore() *int {
+	return &p.Scores[0] // want `FirstScore returns a pointer into const field Profile.Scores`
+}
+
+// NicknamesRef points at the slice header itself.
+func (p *Profile) NicknamesRef() *[]string {
+	return &p.Nicknames // want `NicknamesRef returns a pointer into const field Profile.Nicknames`
+}
+
+// FirstNicknames shares elements a shallow marker leaves mutable.
+func (p *Profile) FirstNicknames() ([]string, *string) {
+	return p.Nicknames[:1], &p.Nicknames[0]
+}
+
+// Initial slices a string, which copies nothing writable.
+func (p *Profile) Initial() string {
+	return p.Name[:1]
+}
+
+// NotesRef points at a mutable field.
+func (p *Profile) NotesRef() *[]string {
+	return &p.Notes
+}
+
+// Lookup returns from a function literal too.
+func (p *Profile) Lookup() func() *int {
+	return func() *int {
+		return &p.Grid[1] // want `Lookup returns a pointer into const field Profile.Grid`
+	}
+}
@@ -0,0 +1,63 @@
+package a
+
+// Account has conflicting markers on some of its fields.
+type Account struct {
+	// +const +mutable // want "conflicting constlint markers \\+const and \\+mutable on Account.ID"
+	ID string
+
+	// +const:shallow
+	// +deepconst // want "conflicting constlint markers \\+const:shallow and \\+deepconst on Account.Owners"
+	Owners []string
+
+	Balance int // +mutable
+}
+
+// Adjust mutates Account.
+func (a *Account) Adjust() {
+	a.ID = "x"        // want "assignment to const field Account.ID"
+	a.Owners[0] = "x" // want "assignment to const field Account.Owners"
+	a.Balance = 1     // OK: not const
+}
+
+// Both uses a bare marker and a list.
+// +const
+// +const:[a] // want "conflicting constlint markers \\+const and \\+const:\\[a\\] on Both"
+func Both(a, b int) {
+	a = 1 // want "assignment to const parameter"
+	b = 2 // want "assignment to const parameter"
+}
+
+// BareAndLists uses a bare marker and several lists; the bare marker wins.
+// +const:[a] // want "conflicting constlint markers \\+const and \\+const:\\[a\\] on BareAndLists"
+// +const
+// +const:[b] // want "conflicting constlint markers \\+const and \\+const:\\[b\\] on BareAndLists"
+func BareAndLists(a, b, c int) {
+	a = 1 // want "assignment to const parameter"
+	c = 3 // want "assignment to const parameter"
+}
+
+// Repeated marks a function twice.
+//
+// +const
+// +const // want "duplicate constlint marker \\+const on Repeated"
+func Repeated(a int) {
+	a = 1 // want "assignment to const parameter"
+}
+
+// Keyring combines markers; each clash is reported.
+type Keyring struct {
+	// +deepconst +secret
+	Keys []string
+
+	// +const +const // want "duplicate constlint marker \\+const on Keyring.Name"
+	Name string
+
+	// +const:shallow +deepconst +mutable // want "conflicting constlint markers \\+const:shallow and \\+deepconst on Keyring.Owners" "conflicting constlint markers \\+const:shallow and \\+mutable on Keyring.Owners" "conflicting constlint markers \\+deepconst and \\+mutable on Keyring.Owners"
+	Owners []string
+}
+
+// Rotate mutates the keyring.
+func (k *Keyring) Rotate() {
+	k.Keys[0] = "x" // want "assignment to const field Keyring.Keys"
+	k.Owners = nil  // want "assignment to const field Keyring.Owners"
+}
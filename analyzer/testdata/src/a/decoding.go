@@ -0,0 +1,69 @@
+package a
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+)
+
+// Wallet is decoded from JSON and YAML.
+type Wallet struct {
+	// +const
+	ID string `json:"id" yaml:"id"` // want `const field Wallet.ID has a json tag, so decoding can overwrite it as "id"$` `const field Wallet.ID has a yaml tag, so decoding can overwrite it as "id"$`
+	// +const
+	Owner string `json:",omitempty"` // want `const field Wallet.Owner has a json tag, so decoding can overwrite it as "Owner"$`
+	// +const
+	Internal string `json:"-"`
+	// +const
+	hidden string `json:"hidden"`
+
+	Balance int `json:"balance"`
+}
+
+// Receipt decodes itself, keeping its const fields under its control.
+type Receipt struct {
+	// +const
+	Number string `json:"number"`
+}
+
+// UnmarshalJSON decodes a receipt.
+func (r *Receipt) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Number string `json:"number"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = Receipt{Number: raw.Number}
+	return nil
+}
+
+// LoadWallet creates the wallet it decodes into.
+func LoadWallet(data []byte) (*Wallet, error) {
+	var w Wallet
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Reload decodes into an existing wallet.
+func (w *Wallet) Reload(data []byte) error {
+	return json.Unmarshal(data, w) // want `json.Unmarshal decodes into Wallet, overwriting its const field\(s\) ID, Internal, Owner, hidden$`
+}
+
+// ReloadAll decodes into existing wallets.
+func ReloadAll(r io.Reader, wallets []Wallet) error {
+	return gob.NewDecoder(r).Decode(&wallets) // want `\(\*gob.Decoder\).Decode decodes into Wallet`
+}
+
+// ReloadXML decodes into an existing wallet.
+func ReloadXML(r io.Reader, w *Wallet) error {
+	return xml.NewDecoder(r).Decode(w) // want `\(\*xml.Decoder\).Decode decodes into Wallet`
+}
+
+// ReloadReceipt decodes into a type decoding itself.
+func ReloadReceipt(data []byte, r *Receipt) error {
+	return json.Unmarshal(data, r)
+}
@@ -0,0 +1,62 @@
+package a
+
+type conn struct{ open bool }
+
+type pool struct {
+	// +const
+	size int
+}
+
+// Serve writes its const parameters in deferred and nested function
+// literals, which capture them.
+//
+// +const
+func Serve(c *conn, retries int) {
+	defer func() {
+		c = nil // want `assignment to const parameter c$`
+	}()
+	defer func() { retries++ }() // want `assignment to const parameter retries$`
+	go func() {
+		retries -= 1 // want `assignment to const parameter retries$`
+	}()
+	cleanup := func() {
+		c, retries = nil, 0 // want `assignment to const parameter c$` `assignment to const parameter retries$`
+	}
+	cleanup()
+	defer func() {
+		func() {
+			c = nil // want `assignment to const parameter c$`
+		}()
+	}()
+
+	// A literal's own parameters shadow those of the function.
+	func(c *conn, retries int) {
+		c = nil
+		retries--
+	}(c, retries)
+	defer func() {
+		retries := retries
+		retries++
+	}()
+}
+
+// Close writes its const parameter through pointers to it.
+//
+// +const
+func Close(c *conn) {
+	ptr := &c
+	defer func() {
+		*ptr = nil // want `assignment to const parameter c through ptr$`
+	}()
+	defer func() {
+		alias := &c
+		*alias = nil // want `assignment to const parameter c through alias$`
+	}()
+}
+
+// Grow increments a const field.
+func (p *pool) Grow() {
+	defer func() {
+		p.size++ // want `assignment to const field pool.size$`
+	}()
+}
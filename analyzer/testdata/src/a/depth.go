@@ -0,0 +1,57 @@
+package a
+
+// Address is stored by value in Contact.
+type Address struct {
+	City string
+}
+
+// Contact shows how +const protects fields of different types.
+type Contact struct {
+	// +const
+	Home Address
+
+	// +const
+	Tags []string
+
+	// +const:shallow
+	Aliases []string
+
+	// +deepconst
+	Labels map[string]string
+
+	// +deepconst
+	Manager *Person
+
+	// +const:shallow
+	Assistant *Person
+
+	// +const
+	Scores [3]int
+}
+
+// NewContact builds a contact, writes within it are allowed.
+func NewContact() *Contact {
+	c := &Contact{}
+	c.Home.City = "Paris"
+	c.Labels = map[string]string{}
+	c.Labels["team"] = "core"
+	return c
+}
+
+// Relocate writes inside the value held by const fields.
+func (c *Contact) Relocate(city string) {
+	c.Home.City = city // want "assignment to const field Contact.Home"
+	c.Scores[0] = 1    // want "assignment to const field Contact.Scores"
+}
+
+// Retag writes through references held by const fields.
+func (c *Contact) Retag() {
+	c.Tags[0] = "x"             // OK: shallow const, only the slice header is protected
+	c.Aliases[0] = "y"          // OK: explicitly shallow
+	c.Labels["team"] = "ops"    // want "assignment to const field Contact.Labels"
+	c.Manager.Age = 50          // want "assignment to const field Contact.Manager"
+	*c.Manager = *c.Assistant   // want "assignment to const field Contact.Manager"
+	c.Assistant.Age = 30        // OK: explicitly shallow
+	c.Manager.Name = "x"        // want "assignment to const field Person.Name"
+	(c.Labels)["other"] = "dev" // want "assignment to const field Contact.Labels"
+}
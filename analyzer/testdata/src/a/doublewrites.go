@@ -0,0 +1,37 @@
+package a
+
+// NewPersonTwice writes Name twice on the same value.
+func NewPersonTwice(name string) *Person {
+	p := &Person{}
+	p.Name = name
+	p.Email = "a@example.com"
+	p.Name = "x" // want "const field Person.Name of p is assigned more than once in NewPersonTwice"
+	return p
+}
+
+// NewPersonLiteralThenAssign overwrites a field set by the literal.
+func NewPersonLiteralThenAssign(name string) *Person {
+	var p = &Person{Name: name}
+	p.Name = "x" // want "const field Person.Name of p is assigned more than once in NewPersonLiteralThenAssign"
+	return p
+}
+
+// NewPersonDefaults overrides a default conditionally, which is fine.
+func NewPersonDefaults(name string) *Person {
+	p := &Person{Name: "anonymous"}
+	if name != "" {
+		p.Name = name
+	}
+	return p
+}
+
+// NewPeople builds two people, each written once.
+func NewPeople() (*Person, *Person) {
+	p := &Person{}
+	p.Name = "a"
+	p = &Person{}
+	p.Name = "b" // OK: a new value
+	q := &Person{}
+	q.Name = "c"
+	return p, q
+}
@@ -0,0 +1,38 @@
+package a
+
+import "time"
+
+// Order is an entity: only its identity is const.
+// +entity:id=ID,CreatedAt
+type Order struct {
+	ID        string
+	CreatedAt time.Time
+	Status    string
+	// +const
+	Customer string
+}
+
+// NewOrder creates an order.
+func NewOrder(id, customer string) *Order {
+	return &Order{ID: id, CreatedAt: time.Now(), Customer: customer}
+}
+
+// Ship changes the mutable state of the order.
+func (o *Order) Ship() {
+	o.Status = "shipped"
+	o.ID = ""                // want `assignment to const field Order.ID$`
+	o.CreatedAt = time.Now() // want `assignment to const field Order.CreatedAt$`
+	o.Customer = ""          // want `assignment to const field Order.Customer$`
+}
+
+// Shipment lists a field that doesn't exist and a field twice.
+// +entity:id=ID,Number,ID // want `\+entity marker lists Number, which is not a field of Shipment` `\+entity marker lists field ID of Shipment more than once`
+type Shipment struct {
+	ID string
+}
+
+// Invoice has an entity marker without identity fields.
+// +entity // want `marker \+entity must list the identity fields, e.g. \+entity:id=ID`
+type Invoice struct {
+	Number string
+}
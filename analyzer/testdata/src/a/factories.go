@@ -0,0 +1,28 @@
+package a
+
+// Cache is implemented by memoryCache.
+type Cache interface {
+	Size() int
+}
+
+type memoryCache struct {
+	// +const
+	limit int
+}
+
+func (c *memoryCache) Size() int { return c.limit }
+
+// NewCache builds the concrete type behind an interface, so it may set its
+// const fields.
+func NewCache(limit int) Cache {
+	c := &memoryCache{}
+	c.limit = limit
+	return c
+}
+
+// Shrink doesn't build a cache.
+func Shrink(cache Cache) {
+	if c, ok := cache.(*memoryCache); ok {
+		c.limit = 0 // want "assignment to const field memoryCache.limit"
+	}
+}
@@ -0,0 +1,65 @@
+package a
+
+import "iter"
+
+// Batch is filled from range-over-func iterators.
+type Batch struct {
+	// +const
+	ID string
+	// +const
+	Size int
+
+	Items []string
+}
+
+// NewBatch writes const fields in the body of a range-over-func loop, which
+// runs as the yield callback but still belongs to the constructor.
+func NewBatch(items iter.Seq[string]) *Batch {
+	b := &Batch{}
+	for item := range items {
+		b.Size++
+		b.Items = append(b.Items, item)
+		b.ID = item
+	}
+	return b
+}
+
+// Rename writes a const field in the loop body of a method.
+func (b *Batch) Rename(names iter.Seq[string]) {
+	for name := range names {
+		b.ID = name // want "assignment to const field Batch.ID"
+	}
+}
+
+// Each returns an iterator whose function literal writes the receiver.
+func (b *Batch) Each() iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		for i, item := range b.Items {
+			b.Size = i // want "assignment to const field Batch.Size"
+			if !yield(i, item) {
+				return
+			}
+		}
+	}
+}
+
+// Relabel assigns the iterated values straight to a const field.
+func (b *Batch) Relabel(names iter.Seq[string]) {
+	for b.ID = range names { // want "assignment to const field Batch.ID"
+	}
+}
+
+// CollectBatch assigns a const parameter inside an iterator loop.
+//
+// +const:[limit]
+func CollectBatch(items iter.Seq[string], limit int) []string {
+	var out []string
+	for item := range items {
+		if len(out) == limit {
+			limit = 0 // want "assignment to const parameter"
+			break
+		}
+		out = append(out, item)
+	}
+	return out
+}
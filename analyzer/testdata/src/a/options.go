@@ -0,0 +1,93 @@
+package a
+
+import "time"
+
+// Client is configured with functional options.
+type Client struct {
+	// +const
+	endpoint string
+	// +const
+	timeout time.Duration
+	// +const
+	retries int
+}
+
+// ClientOption configures a Client while NewClient builds it.
+type ClientOption func(*Client)
+
+// NewClient applies its options to the client it builds, so they may set its
+// const fields.
+func NewClient(endpoint string, opts ...ClientOption) *Client {
+	c := &Client{endpoint: endpoint}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithTimeout returns an option setting the timeout.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithRetries converts a literal to the option type.
+func WithRetries(n int) ClientOption {
+	opt := ClientOption(func(c *Client) {
+		c.retries = n
+	})
+	return opt
+}
+
+// WithDefaults is a named option, marked as one.
+// +option:Client
+func WithDefaults(c *Client) {
+	c.timeout = time.Second
+	c.retries = 3
+}
+
+// WithFallback returns an option that also writes another client.
+func WithFallback(fallback *Client) ClientOption {
+	return func(c *Client) {
+		c.retries = fallback.retries
+		fallback.retries = 0 // want "assignment to const field Client.retries"
+	}
+}
+
+// Retune is no option: the literal is just a func(*Client).
+func Retune(c *Client) {
+	retune := func(c *Client) {
+		c.timeout = 0 // want "assignment to const field Client.timeout"
+	}
+	retune(c)
+}
+
+// Mirror is an interface style option.
+type Mirror struct{ endpoint string }
+
+// apply configures a client.
+// +option:Client
+func (m Mirror) apply(c *Client) {
+	c.endpoint = m.endpoint
+}
+
+// +option:Clinet // want `\+option marker names Clinet, which is not a type of this package`
+func misnamedOption(c *Client) {
+	c.retries = 1 // want "assignment to const field Client.retries"
+}
+
+// Tweak is an option type no constructor takes, marked as one.
+// +option:Client
+type Tweak func(*Client)
+
+// WithEndpoint returns a Tweak.
+func WithEndpoint(endpoint string) Tweak {
+	return func(c *Client) { c.endpoint = endpoint }
+}
+
+// +option:Client // want `\+option marker has no effect on map type ClientSettings`
+type ClientSettings map[string]string
+
+// +option // want `marker \+option must name the type it configures, e.g. \+option:Server`
+func noTarget() {}
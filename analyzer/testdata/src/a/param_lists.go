@@ -0,0 +1,97 @@
+package a
+
+// MisspelledParam lists a parameter name that doesn't exist.
+// +const:[naem] // want "\\+const marker lists naem, which is not a parameter of MisspelledParam"
+func MisspelledParam(name string) {
+	name = "changed" // OK: the marker doesn't name this parameter
+}
+
+// DuplicateParam lists the same parameter twice.
+// +const:[name, age, name] // want "\\+const marker lists parameter name of DuplicateParam more than once"
+func DuplicateParam(name string, age int) {
+	name = "changed" // want "assignment to const parameter"
+	age = 1          // want "assignment to const parameter"
+}
+
+// Rename lists its receiver, which is not a parameter.
+// +const:[p, name] // want "\\+const marker lists p, which is not a parameter of Rename"
+func (p *Person) Rename(name string) {
+	name = "changed" // want "assignment to const parameter"
+}
+
+// GeneratedHandler lists its parameters by position.
+// +const:[0, 2]
+func GeneratedHandler(arg0 string, arg1 int, arg2 []byte) {
+	arg0 = ""  // want "assignment to const parameter arg0"
+	arg1 = 0   // OK: not listed
+	arg2 = nil // want "assignment to const parameter arg2"
+}
+
+// UnnamedParams counts unnamed parameters too.
+// +const:[1]
+func UnnamedParams(int, string) {}
+
+// OutOfRange lists a position past its last parameter.
+// +const:[0, 2] // want "\\+const marker lists parameter 2, which is out of range for the 2 parameter\\(s\\) of OutOfRange"
+func OutOfRange(name string, age int) {
+	name = "changed" // want "assignment to const parameter"
+}
+
+// MixedParam lists a parameter both by position and by name.
+// +const:[0, name] // want "\\+const marker lists parameter name of MixedParam more than once"
+func MixedParam(name string) {
+	name = "changed" // want "assignment to const parameter"
+}
+
+// MergedLists spreads its const parameters over several marker lines.
+// +const:[name]
+// +const:[age, name]
+// +const:[2]
+func MergedLists(name string, age int, email, phone string) {
+	name = "changed"  // want "assignment to const parameter name"
+	age = 1           // want "assignment to const parameter age"
+	email = "changed" // want "assignment to const parameter email"
+	phone = "changed" // OK: not listed
+}
+
+// RepeatedInOneLine repeats a parameter within one of its lists.
+// +const:[name]
+// +const:[age, age] // want "\\+const marker lists parameter age of RepeatedInOneLine more than once"
+func RepeatedInOneLine(name string, age int) {
+	name = "changed" // want "assignment to const parameter"
+	age = 1          // want "assignment to const parameter"
+}
+
+// InlineMarkers marks parameters on their own lines.
+func InlineMarkers(
+	from *Person, // +const
+	to *Person,
+	first, last string, // +const
+	amount int, // OK: not a marker +const
+) {
+	from = nil // want "assignment to const parameter from"
+	to = nil   // OK: not marked
+	first = "" // want "assignment to const parameter first"
+	last = ""  // want "assignment to const parameter last"
+	amount = 0 // OK: not marked
+}
+
+// InlineAndList combines inline markers with a doc comment list.
+// +const:[name]
+func InlineAndList(
+	name string,
+	age int, // +const
+	email string,
+) {
+	name = ""  // want "assignment to const parameter name"
+	age = 0    // want "assignment to const parameter age"
+	email = "" // OK: not marked
+}
+
+// StrayInlineMarker has a marker on a line of its own.
+func StrayInlineMarker(
+	// +const // want "\\+const marker in the parameter list of StrayInlineMarker must follow a parameter on its line"
+	name string,
+) {
+	name = "" // OK: the marker is ignored
+}
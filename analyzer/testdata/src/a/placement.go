@@ -0,0 +1,30 @@
+package a
+
+import (
+	// +const // want "\\+const marker has no effect on import \"strings\""
+	"strings"
+)
+
+// Labels can't be marked const, only struct fields can.
+// +const // want "\\+const marker has no effect on map type Labels"
+type Labels map[string]string
+
+// Namer is an interface, its methods can't be const.
+type Namer interface {
+	// +const // want "\\+const marker has no effect on interface method Name"
+	Name() string
+}
+
+// Version is a package constant, the marker is redundant.
+const Version = "1.0" // +const // want "\\+const marker has no effect on const Version"
+
+// Reset takes no parameters, so there is nothing to mark.
+// +const // want "\\+const marker has no effect on function Reset without parameters"
+func (p *Person) Reset() {
+	p.Age = 0
+}
+
+// Upper mentions +const in prose, which is not a marker.
+func Upper(s string) string {
+	return strings.ToUpper(s)
+}
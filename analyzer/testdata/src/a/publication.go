@@ -0,0 +1,66 @@
+package a
+
+// Worker is shared with other goroutines while it is being built.
+type Worker struct {
+	// +const
+	ID int
+	// +const
+	Name string
+}
+
+var registry = map[int]*Worker{}
+
+var lastWorker *Worker
+
+// NewRegisteredWorker registers the worker before setting its name.
+func NewRegisteredWorker(id int) *Worker {
+	w := &Worker{ID: id}
+	registry[id] = w
+	w.Name = "worker" // want `const field Worker.Name is written after w was stored in package-level variable registry$`
+	return w
+}
+
+// NewAnnouncedWorker sends the worker on a channel too early.
+func NewAnnouncedWorker(id int, ready chan<- *Worker) *Worker {
+	w := &Worker{ID: id}
+	ready <- w
+	w.Name = "announced" // want `const field Worker.Name is written after w was sent on a channel$`
+	return w
+}
+
+// NewWatchedWorker starts a goroutine reading the worker.
+func NewWatchedWorker(id int) Worker {
+	var w Worker
+	w.ID = id
+	go func() {
+		println(w.Name)
+	}()
+	w.Name = "watched" // want `const field Worker.Name is written after w was captured by a goroutine$`
+	return w
+}
+
+// NewNotifiedWorker hands the worker's address to a goroutine.
+func NewNotifiedWorker(id int) Worker {
+	w := Worker{ID: id}
+	go notify(&w)
+	w.Name = "notified" // want `const field Worker.Name is written after w was passed to a goroutine$`
+	return w
+}
+
+// NewLateWorker publishes only once the worker is complete.
+func NewLateWorker(id int) *Worker {
+	w := &Worker{ID: id}
+	w.Name = "late"
+	lastWorker = w
+	return w
+}
+
+// NewCopiedWorker sends a copy, which later writes don't affect.
+func NewCopiedWorker(id int, copies chan<- Worker) Worker {
+	w := Worker{ID: id}
+	copies <- w
+	w.Name = "copied"
+	return w
+}
+
+func notify(w *Worker) {}
@@ -0,0 +1,78 @@
+package a
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Credentials holds a secret next to public data.
+type Credentials struct {
+	User string
+	// +secret
+	Token string
+	// +secret
+	Key []byte
+}
+
+// NewCredentials creates credentials.
+func NewCredentials(user, token string) *Credentials {
+	return &Credentials{User: user, Token: token}
+}
+
+// Rotate writes a secret field, which is const.
+func (c *Credentials) Rotate(token string) {
+	c.Token = token // want `assignment to const field Credentials.Token$`
+}
+
+// Leaks passes secrets to print and log calls.
+func (c *Credentials) Leaks(logger *slog.Logger) error {
+	fmt.Println("token:", c.Token)                  // want `secret field Credentials.Token passed to fmt.Println`
+	fmt.Printf("%s\n", "Bearer "+c.Token)           // want `secret field Credentials.Token passed to fmt.Printf`
+	fmt.Fprintf(os.Stderr, "%x\n", c.Key[:4])       // want `secret field Credentials.Key passed to fmt.Fprintf`
+	log.Printf("key %s", string(c.Key))             // want `secret field Credentials.Key passed to log.Printf`
+	slog.Info("login", "token", c.Token)            // want `secret field Credentials.Token passed to slog.Info`
+	logger.Info("login", slog.String("t", c.Token)) // want `secret field Credentials.Token passed to slog.String`
+	println(c.Token)                                // want `secret field Credentials.Token passed to println`
+
+	fmt.Printf("%+v\n", c)          // want `Credentials passed to fmt.Printf prints its secret field\(s\) Key, Token`
+	log.Println(*c)                 // want `Credentials passed to log.Println prints its secret field\(s\) Key, Token`
+	return fmt.Errorf("bad: %v", c) // want `Credentials passed to fmt.Errorf prints its secret field\(s\) Key, Token`
+}
+
+// Safe uses secrets without disclosing them.
+func (c *Credentials) Safe() error {
+	fmt.Println("user:", c.User)
+	fmt.Println("token length:", len(c.Token))
+	fmt.Println(strings.Repeat("*", len(c.Token)))
+	var token string
+	fmt.Sscan("x", &token)
+	return errors.New(c.User)
+}
+
+// Masked prints itself without its secret.
+type Masked struct {
+	Name string
+	// +secret
+	Password string
+}
+
+// String hides the password.
+func (m Masked) String() string {
+	return m.Name + ":***"
+}
+
+// PrintMasked prints a value whose String method hides the secret.
+func PrintMasked(m Masked) {
+	fmt.Printf("%+v\n", m)
+	fmt.Println(&m)
+}
+
+// Vault marks a field both secret and mutable.
+type Vault struct {
+	// +secret +mutable // want `conflicting constlint markers \+secret and \+mutable on Vault.Combination`
+	Combination string
+}
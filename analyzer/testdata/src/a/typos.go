@@ -0,0 +1,43 @@
+package a
+
+// Typos carries misspelled markers, none of which mark a field const.
+type Typos struct {
+	// +Const // want "unknown marker \\+Const, did you mean \\+const\\?"
+	A string
+
+	// + const // want "malformed marker \"\\+ const\", did you mean \\+const\\?"
+	B string
+
+	C string // +cosnt // want "unknown marker \\+cosnt, did you mean \\+const\\?"
+
+	// +deep-const // want "unknown marker \\+deep-const, did you mean \\+deepconst\\?"
+	D []string
+
+	// +const:shalow // want "unknown argument \"shalow\" to \\+const, did you mean \\+const:shallow\\?"
+	E []string
+
+	// +mutabel // want "unknown marker \\+mutabel, did you mean \\+mutable\\?"
+	F int
+
+	// +kubebuilder:validation:Required
+	G string
+}
+
+// TypoParams has a misspelled list marker.
+// +consts:[x] // want "unknown marker \\+consts:\\[x\\], did you mean \\+const\\?"
+func TypoParams(x int) {
+	x = 1 // OK: the marker is misspelled
+}
+
+// Unterminated has a list marker missing its closing bracket.
+// +const:[x // want "unterminated list in marker \\+const:\\[x"
+func Unterminated(x int) {
+	x = 1
+}
+
+// Writes shows the typos above have no effect.
+func (t *Typos) Writes() {
+	t.A = ""
+	t.B = ""
+	t.C = ""
+}
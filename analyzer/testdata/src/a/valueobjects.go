@@ -0,0 +1,52 @@
+package a
+
+// Money is a value object: every field is deep const.
+// +valueobject
+type Money struct {
+	Amount   int64
+	Currency string
+	Splits   []int64
+	// +mutable
+	Label string // want `value object Money exports mutable field Label`
+	// +mutable
+	cache string
+}
+
+// NewMoney creates money.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Add returns a new value rather than changing m.
+func (m Money) Add(other Money) Money {
+	return NewMoney(m.Amount+other.Amount, m.Currency)
+}
+
+// Double changes its receiver.
+func (m Money) Double() {
+	m.Amount *= 2   // want `assignment to const field Money.Amount$`
+	m.Splits[0] = 0 // want `assignment to const field Money.Splits$`
+	m.cache = ""
+}
+
+// Reset replaces the value behind the pointer.
+func (m *Money) Reset() { // want `method Reset of value object Money has a pointer receiver`
+	*m = Money{}
+}
+
+// SetCurrency advertises a mutation.
+func (m Money) SetCurrency(currency string) Money { // want `method Money.SetCurrency looks like a setter for const field Money.Currency`
+	return NewMoney(m.Amount, currency)
+}
+
+// Point has a value object marker on a field, where it does nothing.
+type Point struct {
+	// +valueobject // want `\+valueobject marker has no effect on a field; place it on the struct type`
+	X int
+}
+
+// Range conflicts with a shallow value object.
+// +valueobject +const:shallow // want `conflicting constlint markers \+const:shallow and \+valueobject on Range`
+type Range struct {
+	Low, High int
+}
@@ -0,0 +1,42 @@
+package a
+
+// Ticket is built from its zero value rather than a composite literal.
+type Ticket struct {
+	// +const
+	Number int
+	// +const
+	Queue string
+
+	Assignee string
+}
+
+// NewTicket instantiates with new.
+func NewTicket(number int) *Ticket {
+	t := new(Ticket)
+	t.Number = number
+	t.Queue = "default"
+	return t
+}
+
+// MakeTicket instantiates with a zero-value declaration.
+func MakeTicket(number int, queue string) Ticket {
+	var t Ticket
+	t.Number = number
+	t.Queue = queue
+	return t
+}
+
+// ReopenTicket declares a pointer, which creates no Ticket.
+func ReopenTicket(old *Ticket) *Ticket {
+	var t *Ticket
+	t = old
+	t.Queue = "reopened" // want "assignment to const field Ticket.Queue"
+	return t
+}
+
+// NewOtherTicket creates a value of another type with new.
+func NewOtherTicket(t *Ticket) *OtherObject {
+	o := new(OtherObject)
+	t.Number = 0 // want "assignment to const field Ticket.Number"
+	return o
+}
@@ -0,0 +1,48 @@
+package audit
+
+// Server has const fields set every way the linter allows.
+type Server struct {
+	// +const
+	Addr string
+	// +const
+	Timeout int
+
+	requests int
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithTimeout is an option, so it may set const fields.
+func WithTimeout(timeout int) Option {
+	return func(s *Server) {
+		s.Timeout = timeout // want `assignment to const field Server.Timeout allowed: written by an option of Server$`
+	}
+}
+
+// NewServer builds a server.
+func NewServer(addr string, opts ...Option) *Server {
+	s := &Server{Addr: addr} // want `initialization of const field Server.Addr allowed: composite literal of Server$`
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Default builds a server with positional fields.
+func Default() Server {
+	return Server{"localhost", 30, 0} // want `initialization of const field Server.Addr allowed` `initialization of const field Server.Timeout allowed`
+}
+
+// Reset builds a server anew.
+func Reset() *Server {
+	s := new(Server)
+	s.Addr = ":80" // want `assignment to const field Server.Addr allowed: Reset instantiates Server$`
+	return s
+}
+
+// Move doesn't build a server.
+func (s *Server) Move(addr string) {
+	s.Addr = addr // want `assignment to const field Server.Addr$`
+	s.requests = 0
+}
@@ -0,0 +1,40 @@
+package besteffort
+
+// Order is being refactored: its package has type errors.
+type Order struct {
+	ID    string // +const
+	Items []Item
+	Total Money
+}
+
+type Item struct {
+	ID  string // +const
+	Qty int
+}
+
+// NewOrder may set the ID.
+func NewOrder(id string) *Order {
+	o := &Order{}
+	o.ID = id
+	return o
+}
+
+// Rename writes the ID through a resolved selector.
+func (o *Order) Rename(id string) {
+	o.ID = id // want `assignment to const field Order.ID`
+}
+
+// Reset writes through a value whose type is unknown.
+func Reset(o *Order) {
+	x := lookup(o) // the undefined function leaves x untyped
+	x.ID = ""      // want `possible assignment to const field Order.ID or Item.ID: the type of x is unknown`
+	x.Qty = 0
+}
+
+// Retry writes a const parameter.
+//
+// +const:[attempts]
+func Retry(attempts int) {
+	attempts-- // want `assignment to const parameter attempts`
+	Total = undefinedToo
+}
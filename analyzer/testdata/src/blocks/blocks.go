@@ -0,0 +1,62 @@
+package blocks
+
+type Order struct {
+	// +const:begin
+	ID       string
+	Customer string
+	Total    int // +mutable
+	// +const:end
+
+	Status string
+	Notes  []string
+}
+
+func NewOrder(id, customer string) *Order {
+	return &Order{ID: id, Customer: customer}
+}
+
+func (o *Order) Update() {
+	o.ID = ""       // want `assignment to const field Order.ID`
+	o.Customer = "" // want `assignment to const field Order.Customer`
+	o.Total = 0
+	o.Status = "updated"
+}
+
+type Shipment struct {
+	Carrier string
+
+	// +const:begin reason="labels are printed"
+	Label string
+	Meta  struct {
+		// +const:begin
+		Weight int
+		// +const:end
+		Zone string
+	}
+	// +const:end
+}
+
+func (s *Shipment) Relabel() {
+	s.Carrier = "post"
+	s.Label = ""      // want `assignment to const field Shipment.Label \(const because: labels are printed\)`
+	s.Meta.Weight = 0 // want `assignment to const field Shipment.Meta`
+	s.Meta.Zone = ""  // want `assignment to const field Shipment.Meta`
+}
+
+type Unbalanced struct {
+	// +const:end // want `\+const:end without a matching \+const:begin`
+	A int
+	// +const:begin // want `\+const:begin without a matching \+const:end; the block extends to the end of the struct`
+	B int
+	// +const:begin // want `\+const:begin inside an open block; blocks don't nest`
+	C int
+}
+
+func (u *Unbalanced) Reset() {
+	u.A = 0
+	u.B = 0 // want `assignment to const field Unbalanced.B`
+	u.C = 0 // want `assignment to const field Unbalanced.C`
+}
+
+// +const:begin // want `\+const:begin marker has no effect outside the fields of a struct`
+var counter int
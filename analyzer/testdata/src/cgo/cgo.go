@@ -0,0 +1,33 @@
+package cgo
+
+/*
+#include <stdlib.h>
+
+typedef struct { int len; } cbuf;
+
+static int twice(int x) { return 2 * x; }
+*/
+import "C"
+
+import "unsafe"
+
+// Buffer wraps memory allocated by C.
+type Buffer struct { // want `every field of Buffer is marked \+const:shallow`
+	Size int     // +const:shallow
+	ptr  *C.cbuf // +const:shallow
+}
+
+func NewBuffer(n int) *Buffer {
+	return &Buffer{Size: int(C.twice(C.int(n))), ptr: (*C.cbuf)(C.malloc(C.size_t(unsafe.Sizeof(C.cbuf{}))))}
+}
+
+// Positions in the copy cgo rewrites are those of the original.
+func (b *Buffer) Grow() {
+	b.ptr.len = C.twice(b.ptr.len)
+	b.Size = int(C.twice(C.int(b.Size))) // want `assignment to const field Buffer.Size`
+}
+
+func (b *Buffer) Free() {
+	C.free(unsafe.Pointer(b.ptr))
+	b.ptr = nil // want `assignment to const field Buffer.ptr`
+}
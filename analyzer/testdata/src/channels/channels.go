@@ -0,0 +1,63 @@
+package channels
+
+// Event is published by a Bus.
+type Event struct {
+	Name string
+}
+
+// Bus owns its channels: only its methods and constructors send and close.
+type Bus struct {
+	// +const
+	events chan Event
+	// +const
+	done chan struct{}
+
+	pending chan Event
+}
+
+// NewBus creates a bus, which may prime its channels.
+func NewBus() *Bus {
+	b := &Bus{events: make(chan Event, 1), done: make(chan struct{}), pending: make(chan Event, 1)}
+	b.events <- Event{Name: "started"}
+	return b
+}
+
+// Publish sends on the bus's own channel.
+func (b *Bus) Publish(e Event) {
+	b.events <- e
+}
+
+// Close closes the bus's own channels, also from a function literal.
+func (b *Bus) Close() {
+	defer func() { close(b.done) }()
+	close(b.events)
+}
+
+// Inject sends on a bus it doesn't own.
+func Inject(b *Bus, e Event) {
+	b.events <- e // want `Inject sends on const channel Bus.events, which only methods and constructors of Bus may do$`
+	b.pending <- e
+}
+
+// Shutdown closes the channels of a bus it doesn't own.
+func Shutdown(b *Bus) {
+	close(b.done)     // want `Shutdown closes const channel Bus.done, which only methods and constructors of Bus may do$`
+	close((b.events)) // want `Shutdown closes const channel Bus.events`
+	close(b.pending)
+}
+
+// Wait only receives, which the rule allows.
+func Wait(b *Bus) Event {
+	<-b.done
+	return <-b.events
+}
+
+// Monitor watches a bus.
+type Monitor struct {
+	bus *Bus
+}
+
+// Stop closes a channel of another type.
+func (m *Monitor) Stop() {
+	close(m.bus.done) // want `Stop closes const channel Bus.done`
+}
@@ -0,0 +1,52 @@
+package concurrency
+
+import "net/http"
+
+// Settings are read by every request without locks.
+type Settings struct {
+	// +const
+	Limit int
+}
+
+var settings = &Settings{Limit: 10}
+
+// Start runs a watcher on another goroutine.
+func Start() {
+	go watch()
+	go func() {
+		settings.Limit = 30 // want `assignment to const field Settings.Limit$` `const field Settings.Limit is written on a goroutine, reachable from a go statement$`
+	}()
+}
+
+func watch() {
+	reload()
+}
+
+func reload() {
+	settings.Limit = 20 // want `assignment to const field Settings.Limit$` `const field Settings.Limit is written on a goroutine, reachable from a go statement$`
+}
+
+// Serve registers the handlers.
+func Serve(mux *http.ServeMux) {
+	http.HandleFunc("/limit", setLimit)
+	mux.Handle("/reset", resetHandler{})
+}
+
+func setLimit(w http.ResponseWriter, r *http.Request) {
+	settings.Limit = 0 // want `assignment to const field Settings.Limit$` `const field Settings.Limit is written on a goroutine, reachable from an HTTP handler$`
+}
+
+type resetHandler struct{}
+
+func (resetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reset()
+}
+
+func reset() {
+	settings.Limit = 10 // want `assignment to const field Settings.Limit$` `const field Settings.Limit is written on a goroutine, reachable from an HTTP handler$`
+}
+
+// Configure runs before any goroutine starts.
+func Configure(limit int) {
+	settings.Limit = limit // want `assignment to const field Settings.Limit$`
+}
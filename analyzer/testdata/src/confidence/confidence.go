@@ -0,0 +1,19 @@
+package confidence
+
+// Limits is shared with a background goroutine.
+type Limits struct {
+	// +const
+	Max int
+}
+
+// SetMax looks like a setter, which is only probably a problem.
+func (l *Limits) SetMax(max int) { // want `method Limits.SetMax looks like a setter for const field Limits.Max`
+}
+
+// Tune writes the limit from a goroutine: the write is definite, the race only
+// possible.
+func Tune(l *Limits) {
+	go func() {
+		l.Max = 1 // want `assignment to const field Limits.Max$`
+	}()
+}
@@ -0,0 +1,55 @@
+package consolidate
+
+// Money is an amount in a currency.
+type Money struct { // want `every field of Money is marked \+const; mark the struct instead, so fields added later are \+const too`
+	// +const
+	Amount int64
+	// +const
+	Currency string
+}
+
+type Range struct { // want `every field of Range is marked \+const`
+	Lo, Hi int // +const
+	Step   int // +const
+}
+
+type (
+	// Snapshot is a copy of the state of a store.
+	Snapshot struct { // want `every field of Snapshot is marked \+deepconst`
+		Keys   []string          // +deepconst
+		Values map[string][]byte `index:"values"` // +deepconst
+	}
+
+	// Mixed marks its fields differently.
+	Mixed struct {
+		ID   int      // +const
+		Tags []string // +deepconst
+	}
+)
+
+// Partial leaves a field mutable.
+type Partial struct {
+	ID    int // +const
+	Count int
+}
+
+// Explained has a marker inside prose.
+type Explained struct {
+	// ID never changes. +const
+	ID int
+	// +const
+	Name string
+}
+
+// Single has a lone field.
+type Single struct {
+	ID int // +const
+}
+
+// Marked is already const as a whole.
+//
+// +const
+type Marked struct {
+	ID   int // +const
+	Name string
+}
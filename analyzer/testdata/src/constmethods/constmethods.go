@@ -0,0 +1,90 @@
+package constmethods
+
+import (
+	"fmt"
+	"sync"
+)
+
+type Cart struct {
+	mu    sync.Mutex
+	owner string
+	items []string
+	qty   map[string]int
+	notes *string
+}
+
+// Owner returns who the cart belongs to.
+func (c *Cart) Owner() string { // want `method Cart.Owner never modifies its receiver; mark it \+const:receiver`
+	return c.owner
+}
+
+func (c *Cart) Len() int { // want `method Cart.Len never modifies its receiver`
+	return len(c.items)
+}
+
+// Total sums the quantities.
+func (c *Cart) Total() int { // want `method Cart.Total never modifies its receiver`
+	total := 0
+	for _, n := range c.qty {
+		total += n
+	}
+	if c.notes != nil && len(c.items) > 0 {
+		total++
+	}
+	return total + len(c.Describe())
+}
+
+// Describe is already marked.
+//
+// +const:receiver
+func (c *Cart) Describe() string {
+	return fmt.Sprint(c.owner, len(c.items))
+}
+
+// Add modifies the cart.
+func (c *Cart) Add(item string) {
+	c.items = append(c.items, item)
+}
+
+// Items hands out the slice, which callers may modify.
+func (c *Cart) Items() []string {
+	return c.items
+}
+
+// Lock changes the state of the mutex.
+func (c *Cart) Lock() {
+	c.mu.Lock()
+}
+
+// Print passes the receiver on.
+func (c *Cart) Print() {
+	fmt.Println(c)
+}
+
+// Notes hands out the address of a field.
+func (c *Cart) Notes() **string {
+	return &c.notes
+}
+
+// Rename is marked const, but isn't.
+//
+// +const:receiver
+func (c *Cart) Rename(owner string) {
+	c.owner = owner // want `const method Cart.Rename modifies its receiver`
+}
+
+// Clear is marked const, but isn't.
+//
+// +const:receiver
+func (c Cart) Clear() {
+	c.qty["a"] = 0 // want `const method Cart.Clear modifies its receiver`
+	c.owner = ""
+}
+
+// Value receivers get a copy anyway.
+func (c Cart) Copy() string {
+	return c.owner
+}
+
+// +const:receiver // want `\+const:receiver marker has no effect on function helper without a receiver`
+func helper() {}
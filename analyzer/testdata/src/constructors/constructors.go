@@ -0,0 +1,83 @@
+package constructors
+
+// Server has several const fields that every constructor must set.
+type Server struct {
+	// +const
+	Host string
+	// +const
+	Port int
+	// +const
+	Name string
+
+	Verbose bool
+}
+
+// NewServer sets everything through a keyed literal.
+func NewServer(host string, port int, name string) *Server {
+	return &Server{Host: host, Port: port, Name: name}
+}
+
+// NewServerWithDefaults combines a literal with assignments.
+func NewServerWithDefaults(name string) *Server {
+	s := &Server{Name: name}
+	s.Host = "localhost"
+	s.Port = 8080
+	return s
+}
+
+// NewLocalServer forgot to set the name.
+func NewLocalServer(port int) *Server { // want "constructor NewLocalServer does not initialize const field Server.Name"
+	return &Server{Host: "localhost", Port: port}
+}
+
+// NewEmptyServer sets none of the const fields.
+func NewEmptyServer() Server { // want "constructor NewEmptyServer does not initialize const fields Server.Host, Server.Name, Server.Port"
+	return Server{Verbose: true}
+}
+
+// NewPositionalServer sets every field positionally.
+func NewPositionalServer() Server {
+	return Server{"localhost", 80, "web", false}
+}
+
+// NewDefaultServer delegates to another constructor and is not checked.
+func NewDefaultServer() *Server {
+	return NewServer("localhost", 80, "default")
+}
+
+// buildServer is not a designated constructor.
+func buildServer() *Server {
+	return &Server{}
+}
+
+// Storage is implemented by store.
+type Storage interface {
+	Get(key string) string
+}
+
+type store struct {
+	// +const
+	dsn string
+	// +const
+	table string
+}
+
+func (s *store) Get(key string) string { return s.dsn + s.table + key }
+
+// NewStore returns the store behind an interface.
+func NewStore(dsn string) (Storage, error) { // want "constructor NewStore does not initialize const field store.table"
+	if dsn == "" {
+		return nil, nil
+	}
+	s := &store{}
+	s.dsn = dsn
+	return s, nil
+}
+
+// NewTableStore sets every field of the store it returns as an interface.
+func NewTableStore(dsn, table string) Storage {
+	s := &store{}
+	s.dsn = dsn
+	s.table = table
+	return s
+}
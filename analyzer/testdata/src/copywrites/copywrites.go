@@ -0,0 +1,58 @@
+package copywrites
+
+type User struct {
+	Name string // +const
+	Role string
+}
+
+type Order struct {
+	ID string // +const
+}
+
+type Account struct {
+	Owner User
+}
+
+// Renamed returns a renamed copy of u, next to a fresh user.
+func Renamed(p *User, name string) (User, *User) {
+	cp := *p
+	cp.Name = name // want `assignment to const field User.Name of a copy of an existing User`
+	cp.Role = "renamed"
+	return cp, &User{Name: name}
+}
+
+// Reset builds a fresh user over u.
+func (u User) Reset(name string) User {
+	u.Name = name // want `assignment to const field User.Name of a copy of an existing User`
+	fresh := User{}
+	fresh.Name = name
+	return fresh
+}
+
+func Transfer(a Account, users []User) (result User) {
+	owner := a
+	owner.Owner.Name = "x" // want `assignment to const field User.Name of a copy of an existing User`
+	for _, u := range users {
+		u.Name = "y" // want `assignment to const field User.Name of a copy of an existing User`
+	}
+	result.Name = "z"
+	var created = User{Role: "new"}
+	created.Name = "w"
+	return User{}
+}
+
+// Order isn't selected: writes to its copies are allowed where an Order is
+// instantiated, as they are by default.
+func Reorder(o *Order) Order {
+	cp := *o
+	cp.ID = "copy"
+	_ = Order{}
+	return cp
+}
+
+// Outside of functions instantiating a type, writes to copies are reported
+// as any other write.
+func Relabel(o Order) Order {
+	o.ID = "copy" // want `assignment to const field Order.ID`
+	return o
+}
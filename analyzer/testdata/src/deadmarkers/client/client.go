@@ -0,0 +1,8 @@
+package client
+
+import "deadmarkers/options"
+
+// Dial initializes the exported const field of options.Options.
+func Dial() options.Options {
+	return options.Options{Timeout: 30}
+}
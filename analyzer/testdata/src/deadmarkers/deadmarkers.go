@@ -0,0 +1,44 @@
+package deadmarkers
+
+// Config has const fields initialized in various ways, and one that never is.
+type Config struct {
+	// +const
+	Name string
+
+	// +const
+	Host string
+
+	Port int // +const
+
+	// +const
+	forgotten string // want "const field Config.forgotten is never initialized"
+
+	// Mutable fields are not tracked
+	Retries int
+}
+
+// NewConfig initializes Name through a keyed literal.
+func NewConfig(name string) *Config {
+	c := &Config{Name: name}
+	c.Host = "localhost" // initialized by assignment
+	return c
+}
+
+// Pair is initialized positionally.
+type Pair struct {
+	// +const
+	Left int
+	// +const
+	Right int
+}
+
+// NewPair initializes every field with a positional literal.
+func NewPair(l, r int) Pair {
+	return Pair{l, r}
+}
+
+// SetPort writes Port outside a constructor, which is a violation but still
+// counts as an initialization site.
+func (c *Config) SetPort(port int) {
+	c.Port = port // want "assignment to const field"
+}
@@ -0,0 +1,15 @@
+package options
+
+// Options are built by the packages using them.
+type Options struct {
+	// +const
+	Timeout int // OK: initialized by importers
+
+	// +const
+	retries int // want "const field Options.retries is never initialized"
+}
+
+// Retries returns the retries, which are always 0.
+func (o Options) Retries() int {
+	return o.retries
+}
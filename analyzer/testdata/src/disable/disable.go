@@ -0,0 +1,54 @@
+package disable
+
+// Order is being split into value objects.
+//
+//constlint:disable moving to value objects, see #412
+type Order struct {
+	ID    string // +const
+	Total int    // +cosnt
+}
+
+// Rename changes the ID of an order.
+func (o *Order) Rename(id string, c *Customer) {
+	o.ID = id
+	c.Name = id // want `assignment to const field Customer.Name`
+}
+
+// Scale changes the total.
+// +const
+func (o Order) Scale(factor int) {
+	factor = 1
+	_ = factor
+}
+
+// Recount recounts the total.
+//
+// +const:receiver
+func (o *Order) Recount(c *Customer) {
+	o.Total = len(c.Name)
+}
+
+type Customer struct {
+	Name string // +const
+}
+
+func Rename(o *Order, c *Customer) {
+	o.ID = "renamed"
+	c.Name = "renamed" // want `assignment to const field Customer.Name`
+}
+
+type (
+	Invoice struct {
+		Number string // +const
+	}
+
+	//constlint:disable // want `//constlint:disable on Receipt must say why the type is exempt`
+	Receipt struct {
+		Number string // +const
+	}
+)
+
+func Reissue(i *Invoice, r *Receipt) {
+	i.Number = "" // want `assignment to const field Invoice.Number`
+	r.Number = ""
+}
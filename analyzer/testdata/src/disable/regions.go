@@ -0,0 +1,27 @@
+package disable
+
+type Account struct {
+	Owner string // +const
+}
+
+func Migrate(a *Account) {
+	//constlint:disable legacy migration, removed after the 2.0 cutover
+	a.Owner = "migrated"
+	a.Owner += "!"
+	//constlint:enable
+	a.Owner = "" // want `assignment to const field Account.Owner`
+}
+
+func Backfill(a *Account) {
+	//constlint:disable // want `//constlint:disable must say why the region is exempt`
+	a.Owner = "backfilled"
+	//constlint:disable nested // want `//constlint:disable inside the region disabled at line 16; end it with //constlint:enable first`
+	//constlint:enable
+	//constlint:enable // want `//constlint:enable without a preceding //constlint:disable`
+	a.Owner = "" // want `assignment to const field Account.Owner`
+}
+
+func Forgotten(a *Account) {
+	//constlint:disable never closed // want `//constlint:disable has no matching //constlint:enable`
+	a.Owner = "" // want `assignment to const field Account.Owner`
+}
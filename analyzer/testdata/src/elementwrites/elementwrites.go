@@ -0,0 +1,60 @@
+package elementwrites
+
+type Person struct {
+	ID   string // +const
+	Name string // +const
+	Age  int
+}
+
+type Tag struct {
+	Label string
+}
+
+type Roster struct {
+	people []Person
+	byID   map[string]Person
+	slots  [4]Person
+	refs   []*Person
+	tags   []Tag
+}
+
+func (r *Roster) Rename(i int, name string) {
+	r.people[i] = Person{ID: r.people[i].ID, Name: name} // want `assignment replaces an element of type Person, overwriting its const field\(s\) ID, Name`
+	r.byID["x"] = Person{Name: name}                     // want `assignment replaces an element of type Person, overwriting its const field\(s\) ID, Name`
+	r.slots[0] = r.slots[1]                              // want `assignment replaces an element of type Person, overwriting its const field\(s\) ID, Name`
+	r.refs[i] = &Person{Name: name}
+	r.tags[i] = Tag{Label: name}
+}
+
+func Swap(people []Person, i, j int) {
+	people[i], people[j] = people[j], people[i] // want `assignment replaces` `assignment replaces`
+}
+
+func Last(people []Person, next []Person) {
+	var i int
+	for i, people[0] = range next { // want `assignment replaces an element of type Person`
+	}
+	_ = i
+}
+
+// Collections created by the function are being built.
+
+func NewRoster(names []string) *Roster {
+	r := &Roster{byID: map[string]Person{}}
+	r.people = make([]Person, len(names))
+	for i, name := range names {
+		r.people[i] = Person{ID: name, Name: name}
+		r.byID[name] = Person{ID: name, Name: name}
+	}
+	return r
+}
+
+func Index(people []Person) map[string]Person {
+	index := make(map[string]Person, len(people))
+	var slots [2]Person
+	for _, p := range people {
+		index[p.ID] = p
+		slots[0] = p
+	}
+	return index
+}
@@ -0,0 +1,23 @@
+package exemptapi
+
+// Schema has a const field that migrations may rewrite.
+type Schema struct {
+	// +const
+	Version int
+}
+
+// MigrateV2 is allowed to write by the exemption the test registers.
+func MigrateV2(s *Schema) {
+	s.Version = 2 // want `assignment to const field Schema.Version allowed: exempted by migrations$`
+}
+
+// Bump isn't a migration.
+func Bump(s *Schema) {
+	s.Version = s.Version + 1 // want `assignment to const field Schema.Version$`
+}
+
+var current = &Schema{Version: 1}
+
+func init() {
+	current.Version = 0 // want `assignment to const field Schema.Version$`
+}
@@ -0,0 +1,22 @@
+package exemptions
+
+// Order has a const field.
+type Order struct {
+	// +const
+	ID string
+
+	Total int
+}
+
+// NewOrder builds an order, so it may set its const fields.
+func NewOrder(id string) *Order {
+	o := &Order{}
+	o.ID = id // want `assignment to const field Order.ID allowed: NewOrder instantiates Order$`
+	o.Total = 0
+	return o
+}
+
+// Rename doesn't build an order.
+func (o *Order) Rename(id string) {
+	o.ID = id // want `assignment to const field Order.ID$`
+}
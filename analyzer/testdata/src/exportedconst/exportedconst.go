@@ -0,0 +1,30 @@
+package exportedconst
+
+// Config exposes its settings as exported fields, which are const by default.
+type Config struct {
+	Host  string
+	Ports []int
+	// +mutable
+	Debug bool
+
+	retries int
+}
+
+// Token is annotated as usual, so the package opts into the default.
+type Token struct {
+	// +secret
+	Value string
+}
+
+// NewConfig may set every field.
+func NewConfig(host string) *Config {
+	return &Config{Host: host, Ports: []int{80}}
+}
+
+// Tune writes fields of an existing config.
+func (c *Config) Tune() {
+	c.Host = "localhost" // want `assignment to const field Config.Host$`
+	c.Ports[0] = 8080
+	c.Debug = true
+	c.retries = 3
+}
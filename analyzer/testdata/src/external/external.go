@@ -0,0 +1,19 @@
+package external
+
+// Account keeps its invariants internally; other packages only read it.
+type Account struct {
+	ID      string   // +const:external // want ID:"const:external"
+	Balance int      // +const:external reason="use Deposit" // want Balance:"const:external"
+	Tags    []string // +deepconst +const:external // want Tags:"const:external deep"
+	Note    string
+	Owner   string // +const +const:external // want `conflicting constlint markers \+const and \+const:external on Account.Owner`
+}
+
+func (a *Account) Deposit(n int) {
+	a.Balance += n
+	a.Tags[0] = "active"
+}
+
+func (a *Account) Rekey(id string) {
+	a.ID = id
+}
@@ -0,0 +1,19 @@
+package externaluse
+
+import "external"
+
+func Open(id string) *external.Account {
+	a := &external.Account{ID: id}
+	a.Balance = 10
+	return a
+}
+
+func Tamper(a *external.Account) {
+	a.ID = ""       // want `assignment to const field Account.ID outside package external`
+	a.Balance++     // want `assignment to const field Account.Balance outside package external \(const because: use Deposit\)`
+	a.Tags[0] = "x" // want `assignment to const field Account.Tags outside package external`
+	a.Tags = nil    // want `assignment to const field Account.Tags outside package external`
+	a.Note = "fine"
+	a.Owner = "x"
+	a.Deposit(1)
+}
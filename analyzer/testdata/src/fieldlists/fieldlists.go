@@ -0,0 +1,36 @@
+package fieldlists
+
+// User declares its contract in one place.
+//
+// +const:[ID, CreatedAt] reason="identity"
+// +const:[Email]
+type User struct {
+	ID        string
+	CreatedAt int64
+	Email     string // +mutable
+	Name      string
+}
+
+func (u *User) Rename(name string) {
+	u.ID = ""       // want `assignment to const field User.ID \(const because: identity\)`
+	u.CreatedAt = 0 // want `assignment to const field User.CreatedAt`
+	u.Email = ""
+	u.Name = name
+}
+
+// +const:[Key, Missing, Key] // want `\+const:\[...\] marker lists Missing, which is not a field of Entry` `\+const:\[...\] marker lists field Key of Entry more than once`
+type Entry struct {
+	Key   string
+	Value string
+}
+
+func (e *Entry) Set(v string) {
+	e.Key = v // want `assignment to const field Entry.Key`
+	e.Value = v
+}
+
+// +const
+// +const:[A] // want `conflicting constlint markers \+const and \+const:\[A\] on Pair`
+type Pair struct {
+	A, B int
+}
@@ -0,0 +1,34 @@
+package focus
+
+// Config is the contract under investigation.
+type Config struct {
+	// +const
+	APIKey string
+	// +const
+	Region string
+	// +const
+	Tags []string
+}
+
+// Session is out of focus.
+type Session struct {
+	// +const
+	ID string
+}
+
+// Rotate writes the focused field and others.
+func Rotate(c *Config, s *Session) {
+	c.APIKey = "rotated" // want `assignment to const field Config.APIKey$`
+	c.Region = "eu"
+	s.ID = "new"
+}
+
+// Rename writes a const parameter, which a focused run doesn't check.
+//
+// +const
+func Rename(name string) {
+	name = "renamed"
+}
+
+// +Const
+type misspelled struct{}
@@ -0,0 +1,52 @@
+package globals
+
+// DefaultHeaders are sent with every request.
+var DefaultHeaders = map[string]string{ // want DefaultHeaders:"const"
+	"Accept": "application/json",
+} // +const
+
+var (
+	// Retries lists the delays between attempts.
+	//
+	// +const
+	Retries = []int{1, 2, 4} // want Retries:"const"
+
+	// Fallback is the server used when none is configured.
+	Fallback = &Server{Host: "localhost"} // +const:shallow // want Fallback:"const:shallow"
+
+	// Mirrors can be changed freely.
+	Mirrors = []string{"a", "b"}
+)
+
+type Server struct {
+	Host string
+}
+
+func configure() {
+	DefaultHeaders["User-Agent"] = "globals" // want `assignment to const variable DefaultHeaders`
+	Retries[0] = 0                           // want `assignment to const variable Retries`
+	Retries = nil                            // want `assignment to const variable Retries`
+	Fallback.Host = "example.com"
+	Fallback = nil // want `assignment to const variable Fallback`
+	Mirrors[0] = "c"
+
+	for i := range Retries {
+		Retries[i]++ // want `assignment to const variable Retries`
+	}
+	delete(DefaultHeaders, "Accept") // want `delete modifies const variable DefaultHeaders`
+
+	headers := DefaultHeaders
+	headers["X"] = "y"
+}
+
+// Variables may be set up while the package initializes.
+
+var _ = func() bool {
+	DefaultHeaders["Accept-Encoding"] = "gzip"
+	return true
+}()
+
+func init() {
+	Retries = append(Retries, 8)
+	DefaultHeaders["Connection"] = "keep-alive"
+}
@@ -0,0 +1,17 @@
+package globaluse
+
+import "globals"
+
+func override() {
+	globals.DefaultHeaders["X"] = "y"            // want `assignment to const variable globals.DefaultHeaders`
+	globals.DefaultHeaders = map[string]string{} // want `assignment to const variable globals.DefaultHeaders`
+	clear(globals.DefaultHeaders)                // want `clear modifies const variable globals.DefaultHeaders`
+	globals.Retries[1]--                         // want `assignment to const variable globals.Retries`
+	globals.Fallback.Host = "example.com"
+	*globals.Fallback = globals.Server{}
+	globals.Mirrors = nil
+}
+
+func init() {
+	globals.DefaultHeaders["Origin"] = "globaluse" // want `assignment to const variable globals.DefaultHeaders`
+}
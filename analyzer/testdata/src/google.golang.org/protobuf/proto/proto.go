@@ -0,0 +1,8 @@
+// Package proto stubs the protobuf functions the analyzer knows about.
+package proto
+
+type Message interface{ ProtoReflect() }
+
+func Merge(dst, src Message) {}
+
+func Reset(m Message) {}
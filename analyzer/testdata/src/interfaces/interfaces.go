@@ -0,0 +1,55 @@
+package interfaces
+
+import (
+	"flag"
+	"hash"
+	"hash/fnv"
+)
+
+// Store keeps entries by key.
+type Store interface {
+	// Get doesn't change the store.
+	//
+	// +const:receiver
+	Get(key string) string // want Get:"const:receiver"
+	// SetDefault is an accessor despite its name.
+	//
+	// +const:receiver
+	SetDefault() string // want SetDefault:"const:receiver"
+	Add(key, value string)
+	// Put may keep its entry.
+	Put(key, value string)
+	Reset()
+}
+
+// Index checksums the entries of a store it must not change.
+type Index struct {
+	Store Store      // +deepconst
+	Sum   hash.Hash  // +deepconst
+	Level flag.Value // +deepconst
+	Cache Store      // +const
+	Log   Store
+}
+
+// NewIndex may set up the values it is given.
+func NewIndex(s Store) *Index {
+	idx := &Index{Store: s, Sum: fnv.New64()}
+	idx.Store.Reset()
+	idx.Sum.Write([]byte("seed"))
+	return idx
+}
+
+func (idx *Index) Lookup(key string) string {
+	_ = idx.Store.SetDefault()
+	idx.Store.Add(key, "")     // want `\(interfaces.Store\).Add writes into const field Index.Store`
+	idx.Store.Put(key, "")     // not known to modify the store
+	idx.Store.Reset()          // want `\(interfaces.Store\).Reset writes into const field Index.Store`
+	idx.Sum.Write([]byte(key)) // want `\(hash.Hash\).Write writes into const field Index.Sum`
+	_ = idx.Sum.Sum(nil)
+	_ = idx.Level.Set("debug") // want `\(flag.Value\).Set writes into const field Index.Level`
+	_ = idx.Level.String()
+	idx.Cache.Add(key, "") // shallow: the store isn't const
+	idx.Log.Reset()
+	idx.Store = nil // want `assignment to const field Index.Store`
+	return idx.Store.Get(key)
+}
@@ -0,0 +1,50 @@
+package inventory
+
+// Token is an API token.
+type Token struct {
+	// +secret
+	Value string
+	// +deepconst
+	Scopes []string
+
+	Uses int
+}
+
+// NewToken creates a token.
+func NewToken(value string) *Token {
+	t := &Token{Value: value}
+	t.Scopes = []string{"read"}
+	return t
+}
+
+// Revoke drops the scopes of a token.
+func (t *Token) Revoke() {
+	t.Scopes = nil // want `assignment to const field Token.Scopes$`
+}
+
+// Grant adds scopes to a token.
+//
+// +const:[scope]
+func (t *Token) Grant(scope string, times int) {
+	times = 0
+	t.Uses += times
+	scope = "" // want `assignment to const parameter scope$`
+}
+
+// Check reports whether v is among values.
+//
+// +const
+func Check[T comparable](values []T, v T) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	grant    = (&Token{}).Grant
+	grantTo  = (*Token).Grant
+	checkInt = Check[int]
+)
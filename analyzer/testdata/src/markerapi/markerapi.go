@@ -0,0 +1,24 @@
+package markerapi
+
+import "sync"
+
+// Cache guards its entries with its mutexes.
+type Cache struct {
+	mu sync.Mutex
+	// +locked:mu
+	entries map[string]string
+	// +locked:lock // want `field hits of Cache is guarded by lock, which isn't a mutex field of Cache`
+	hits int
+	size int // +locked:mu
+	// +lokced:mu // want `unknown marker \+lokced:mu, did you mean \+locked\?`
+	misses int
+}
+
+// Lookup can't be guarded by a mutex.
+//
+// +locked:mu // want `\+locked marker only applies to fields`
+func (c *Cache) Lookup(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key]
+}
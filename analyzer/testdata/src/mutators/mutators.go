@@ -0,0 +1,63 @@
+package mutators
+
+import (
+	"dario.cat/mergo"
+	"github.com/jinzhu/copier"
+	"google.golang.org/protobuf/proto"
+)
+
+type Event struct{ Name string }
+
+func (*Event) ProtoReflect() {}
+
+type Config struct {
+	// +const
+	Name string
+	// +const
+	Limits Limits
+	// +deepconst
+	Last *Event
+	// +const:shallow
+	Next *Event
+
+	Extra Limits
+}
+
+type Limits struct {
+	Max int
+}
+
+// NewConfig may fill in the fields of the value it creates.
+func NewConfig(defaults Config) *Config {
+	c := &Config{}
+	_ = mergo.Merge(c, defaults)
+	_ = copier.Copy(&c.Limits, defaults.Limits)
+	return c
+}
+
+// Apply overwrites an existing config.
+func (c *Config) Apply(overrides Config) {
+	_ = mergo.MergeWithOverwrite(c, overrides)   // want `mergo.MergeWithOverwrite writes into Config, overwriting its const field\(s\) Last, Limits, Name, Next`
+	_ = copier.Copy(&c.Limits, overrides.Limits) // want `copier.Copy writes into const field Config.Limits`
+	_ = copier.Copy(&c.Name, overrides.Name)     // want `copier.Copy writes into const field Config.Name`
+	proto.Merge(c.Last, overrides.Last)          // want `proto.Merge writes into const field Config.Last`
+	proto.Merge(c.Next, overrides.Next)          // shallow: the event isn't const
+	_ = copier.Copy(&c.Extra, overrides.Extra)
+}
+
+// Forward must not change its message.
+//
+// +const:[ev]
+func Forward(ev *Event, limits Limits, update *Event) {
+	proto.Merge(ev, update) // want `proto.Merge writes through const parameter ev`
+	proto.Reset(update)
+	_ = mergo.Merge(&limits, Limits{}) // allowed: limits isn't const
+}
+
+// Tune must not change its arguments.
+//
+// +const
+func Tune(limits Limits, defaults map[string]int) {
+	_ = mergo.Merge(&limits, Limits{Max: 1})    // want `mergo.Merge writes into const parameter limits`
+	_ = mergo.Merge(defaults, map[string]int{}) // want `mergo.Merge writes through const parameter defaults`
+}
@@ -0,0 +1,34 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: order.proto
+
+// Package orderpb stubs the code protoc-gen-go generates for a message with
+// the opaque API.
+package orderpb
+
+type Order struct {
+	xxx_hidden_Id    string
+	xxx_hidden_Total int64
+}
+
+func (x *Order) Reset()                { *x = Order{} }
+func (x *Order) ProtoReflect() any     { return x }
+func (x *Order) GetId() string         { return x.xxx_hidden_Id }
+func (x *Order) SetId(v string)        { x.xxx_hidden_Id = v }
+func (x *Order) GetTotal() int64       { return x.xxx_hidden_Total }
+func (x *Order) SetTotal(v int64)      { x.xxx_hidden_Total = v }
+func (x *Order) ClearTotal()           { x.xxx_hidden_Total = 0 }
+func (x *Order) Settle() bool          { return false }
+func (x *Order) SetupDefaults()        {}
+func (x *Order) CopyFrom(other *Order) { *x = *other }
+
+type Order_builder struct {
+	Id    string
+	Total int64
+}
+
+func (b Order_builder) Build() *Order {
+	x := &Order{}
+	x.SetId(b.Id)
+	x.SetTotal(b.Total)
+	return x
+}
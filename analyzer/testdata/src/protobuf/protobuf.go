@@ -0,0 +1,82 @@
+package protobuf
+
+import "protobuf/orderpb"
+
+// Receipt wraps an order that must not change once the receipt is issued.
+type Receipt struct {
+	*orderpb.Order // +deepconst
+
+	Number int // +const
+}
+
+// NewReceipt may set up the order it wraps.
+func NewReceipt(id string) *Receipt {
+	r := &Receipt{Order: &orderpb.Order{}}
+	r.SetId(id)
+	r.Order.SetTotal(0)
+	return r
+}
+
+func (r *Receipt) Void() {
+	r.Reset()            // want `\(\*orderpb.Order\).Reset writes into const field Receipt.Order`
+	r.SetTotal(0)        // want `\(\*orderpb.Order\).SetTotal writes into const field Receipt.Order`
+	r.Order.ClearTotal() // want `\(\*orderpb.Order\).ClearTotal writes into const field Receipt.Order`
+	_ = r.GetTotal()
+	_ = r.Settle()
+	r.SetupDefaults()
+	r.Order = nil // want `assignment to const field Receipt.Order`
+}
+
+// Invoice holds its orders by value and by reference.
+type Invoice struct {
+	Lines orderpb.Order  // +const
+	Ref   *orderpb.Order // +const:shallow
+	Draft orderpb.Order
+}
+
+func (inv *Invoice) Amend(total int64) {
+	inv.Lines.SetTotal(total) // want `\(\*orderpb.Order\).SetTotal writes into const field Invoice.Lines`
+	inv.Ref.SetTotal(total)   // shallow: the order isn't const
+	inv.Draft.Reset()
+	order := orderpb.Order_builder{Total: total}.Build()
+	order.SetId("draft")
+}
+
+// Archive keeps an invoice whose lines can't change.
+type Archive struct {
+	Invoice // +const
+}
+
+func (a *Archive) Purge() {
+	a.Lines.Reset() // want `\(\*orderpb.Order\).Reset writes into const field Invoice.Lines`
+	a.Ref.Reset()
+	a.Draft = orderpb.Order{} // want `assignment to const field Archive.Invoice`
+	a.Ref = nil               // want `assignment to const field Invoice.Ref`
+}
+
+// Ship must not change its order.
+//
+// +const:[order]
+func Ship(order *orderpb.Order, draft *orderpb.Order) {
+	order.SetId("shipped") // want `\(\*orderpb.Order\).SetId writes through const parameter order`
+	draft.Reset()
+}
+
+// Total is an order total mirrored from a message, with a const currency.
+type Total struct {
+	Currency string // +const
+	Amount   int64
+}
+
+func (*Total) ProtoMessage() {}
+func (t *Total) Reset()      { *t = Total{} }
+func (t *Total) SetCurrency(c string) {
+	t.Currency = c // want `assignment to const field Total.Currency`
+}
+func (t *Total) SetAmount(a int64) { t.Amount = a }
+
+func Convert(t *Total) {
+	t.Reset()            // want `\(\*protobuf.Total\).Reset writes into Total, overwriting its const field\(s\) Currency`
+	t.SetCurrency("EUR") // want `\(\*protobuf.Total\).SetCurrency writes into const field Total.Currency`
+	t.SetAmount(1)
+}
@@ -0,0 +1,37 @@
+package reasons
+
+type Ledger struct {
+	Opening int    // +const reason="regulatory: audit trail must be immutable"
+	ID      string // +const:shallow reason="keys the ledger in storage" trailing prose
+	Note    string // +const
+}
+
+// Settings is frozen once loaded.
+//
+// +const reason="reloaded only on restart"
+type Settings struct {
+	Region string
+}
+
+func (l *Ledger) Rewrite(id string) {
+	l.Opening = 0 // want `assignment to const field Ledger.Opening \(const because: regulatory: audit trail must be immutable\)`
+	l.ID = id     // want `assignment to const field Ledger.ID \(const because: keys the ledger in storage\)`
+	l.Note = ""   // want `assignment to const field Ledger.Note$`
+}
+
+func Move(s *Settings) {
+	s.Region = "eu" // want `assignment to const field Settings.Region \(const because: reloaded only on restart\)`
+}
+
+// Charge bills an account.
+// +const:[amount] reason="amounts are signed off upstream"
+func Charge(account string, amount int) {
+	account = ""
+	amount = 0 // want `assignment to const parameter amount \(const because: amounts are signed off upstream\)`
+}
+
+// Escaped carries quotes in its reason.
+// +const reason="the \"golden\" copy"
+func Escaped(v int) {
+	v = 1 // want `assignment to const parameter v \(const because: the "golden" copy\)`
+}
@@ -0,0 +1,57 @@
+package receivers
+
+// Cart is mostly immutable: only mutator-named methods change it.
+type Cart struct {
+	items []string
+	total int
+	owner *Owner
+}
+
+// Owner is referenced by a cart.
+type Owner struct {
+	name string
+}
+
+// AddItem matches the mutator pattern.
+func (c *Cart) AddItem(item string) {
+	c.items = append(c.items, item)
+	c.total++
+}
+
+// Reset matches the mutator pattern.
+func (c *Cart) Reset() {
+	*c = Cart{}
+}
+
+// Total recomputes its cache, which is a mutation.
+func (c *Cart) Total() int {
+	c.total = len(c.items) // want `method Cart.Total modifies its receiver, but its name doesn't match the mutator pattern \^\(Set\|Add\|Remove\|Reset\)$`
+	return c.total
+}
+
+// Count increments a counter.
+func (c *Cart) Count() {
+	c.total++ // want `method Cart.Count modifies its receiver`
+}
+
+// First writes through the slice of a value receiver.
+func (c Cart) First(item string) {
+	c.items[0] = item // want `method Cart.First modifies its receiver`
+}
+
+// Rename writes through a pointer held by a value receiver.
+func (c Cart) Rename(name string) {
+	c.owner.name = name // want `method Cart.Rename modifies its receiver`
+}
+
+// WithTotal only changes its own copy.
+func (c Cart) WithTotal(total int) Cart {
+	c.total = total
+	return c
+}
+
+// Swap reassigns the receiver variable, not the cart.
+func (c *Cart) Swap(other *Cart) *Cart {
+	c = other
+	return c
+}
@@ -0,0 +1,43 @@
+package redundant
+
+import "time"
+
+// Point holds no references.
+type Point struct {
+	X, Y int
+}
+
+// Path holds a slice.
+type Path struct {
+	Points []Point
+}
+
+// Move takes its arguments by value.
+//
+// +const:[p, dx, label]
+func Move(p Point, dx int, label string) Point { // want `\+const on by-value parameter p of Move only keeps Move from reassigning its own copy` `\+const on by-value parameter dx of Move` `\+const on by-value parameter label of Move`
+	p.X += dx
+	return p
+}
+
+// Extend shares data with its caller through every parameter.
+//
+// +const
+func Extend(path Path, points []Point, to *Point, lookup map[string]Point, done func()) {
+}
+
+// Wait mixes both.
+func Wait(
+	d time.Duration, // +const // want `\+const on by-value parameter d of Wait`
+	stop <-chan struct{}, // +const
+	grid [4]Point, // +const // want `\+const on by-value parameter grid of Wait`
+	paths [2]Path, // +const
+) {
+}
+
+// Max is generic: T may be instantiated with a reference type.
+//
+// +const
+func Max[T any](a, b T) T {
+	return a
+}
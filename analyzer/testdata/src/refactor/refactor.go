@@ -0,0 +1,50 @@
+package refactor
+
+type User struct {
+	Name  string   // +const
+	Email string   // +const
+	Roles []string // +const:shallow
+	Age   int
+}
+
+func NewUser(name string) *User {
+	return &User{
+		Name: name,
+	}
+}
+
+// SetEmail changes the address of the user.
+func (u *User) SetEmail(email string) {
+	u.Email = email // want `assignment to const field User.Email`
+}
+
+// SetName can't move: NewUser sets the name already.
+func (u *User) SetName(name string) {
+	u.Name = name // want `assignment to const field User.Name`
+}
+
+// SetRoles does more than setting the field.
+func (u *User) SetRoles(roles []string) {
+	u.Roles = append(roles, "user") // want `assignment to const field User.Roles`
+}
+
+func (u *User) SetAge(age int) {
+	u.Age = age
+}
+
+type Account struct {
+	ID    string // +const
+	Owner *User  // +const:shallow
+}
+
+func NewAccount(id string, opts ...func(*Account)) Account {
+	a := Account{ID: id}
+	for _, opt := range opts {
+		opt(&a)
+	}
+	return a
+}
+
+func (a *Account) SetOwner(owner *User) {
+	a.Owner = owner // want `assignment to const field Account.Owner`
+}
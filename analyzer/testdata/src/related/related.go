@@ -0,0 +1,19 @@
+package related
+
+// Point has a const field.
+type Point struct {
+	// +const
+	X int
+}
+
+// Move writes the const field.
+func (p *Point) Move() {
+	p.X = 1 // want "assignment to const field Point.X$"
+}
+
+// Scale writes a const parameter.
+// +const:[factor]
+func Scale(factor int) int {
+	factor = 2 // want "assignment to const parameter factor$"
+	return factor
+}
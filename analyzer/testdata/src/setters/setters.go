@@ -0,0 +1,50 @@
+package setters
+
+// Account has const and mutable fields.
+type Account struct {
+	// +const
+	ID string
+	// +const
+	owner string
+
+	Balance int
+}
+
+// NewAccount creates an account.
+func NewAccount(id, owner string) *Account {
+	return &Account{ID: id, owner: owner}
+}
+
+// SetID advertises a mutation of a const field, though it never performs one.
+func (a *Account) SetID(id string) { // want `method Account.SetID looks like a setter for const field Account.ID`
+	panic("IDs are immutable")
+}
+
+// SetOwner matches the unexported field regardless of case.
+func (a Account) SetOwner(owner string) Account { // want `method Account.SetOwner looks like a setter for const field Account.owner`
+	return *NewAccount(a.ID, owner)
+}
+
+// SetBalance sets a mutable field.
+func (a *Account) SetBalance(balance int) {
+	a.Balance = balance
+}
+
+// Settle is not a setter.
+func (a *Account) Settle() {}
+
+// setID is unexported, so it isn't part of the API.
+func (a *Account) setID(id string) {}
+
+// Label is const as a whole.
+// +const
+type Label struct {
+	Text string
+}
+
+// SetText advertises a mutation of a field made const by its struct's marker.
+func (l *Label) SetText(text string) { // want `method Label.SetText looks like a setter for const field Label.Text`
+}
+
+// SetSize has no matching field.
+func (l *Label) SetSize(size int) {}
@@ -0,0 +1,30 @@
+package shallowconst
+
+// Address is stored by value in Contact.
+type Address struct {
+	City string
+}
+
+// Contact has const fields of every kind of type.
+type Contact struct {
+	// +const
+	Home Address
+
+	// +const
+	Tags []string // want `\+const on Contact.Tags protects the slice but not the data it references; mark it \+deepconst or \+const:shallow to record the intent`
+
+	// +const
+	Labels map[string]string // want `\+const on Contact.Labels protects the map but not the data it references`
+
+	// +const
+	Manager *Contact // want `\+const on Contact.Manager protects the pointer but not the data it references`
+
+	// +const:shallow
+	Aliases []string
+
+	// +deepconst
+	Notes []string
+
+	// +const
+	Scores [3]int
+}
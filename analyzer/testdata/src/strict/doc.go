@@ -0,0 +1,7 @@
+// Package strict opts into stronger guarantees than the global configuration
+// asks for.
+//
+// +constlint:strict
+// +constlint:mode=deep
+// +constlint:strct // want `unknown argument "strct" to \+constlint, did you mean \+constlint:strict\?`
+package strict
@@ -0,0 +1,35 @@
+package strict
+
+// Roster is checked by every optional rule, and its bare markers are deep.
+type Roster struct {
+	// +const
+	Team string
+
+	// +const
+	Members []string
+
+	// +const:shallow
+	Guests []string
+
+	// +const
+	forgotten string // want "const field Roster.forgotten is never initialized"
+
+	// +constlint:strict // want `\+constlint:strict marker has no effect outside the package doc comment`
+	Size int
+}
+
+// NewRoster leaves a const field unset.
+func NewRoster(team string, members []string) *Roster { // want "constructor NewRoster does not initialize const field Roster.forgotten"
+	return &Roster{Team: team, Members: members, Guests: nil}
+}
+
+// SetTeam looks like a setter of a const field.
+func (r *Roster) SetTeam(team string) { // want `method Roster.SetTeam looks like a setter for const field Roster.Team`
+	panic("teams are fixed")
+}
+
+// Rename writes inside the const slices.
+func (r *Roster) Rename(i int, name string) {
+	r.Members[i] = name // want "assignment to const field Roster.Members"
+	r.Guests[i] = name  // OK: explicitly shallow
+}
@@ -0,0 +1,13 @@
+package strictglobals
+
+var limits = map[string]int{"default": 10} // +const // want limits:"const"
+
+var _ = func() bool {
+	limits["burst"] = 20 // want `assignment to const variable limits`
+	return true
+}()
+
+func init() {
+	limits = nil          // want `assignment to const variable limits`
+	delete(limits, "max") // want `delete modifies const variable limits`
+}
@@ -0,0 +1,47 @@
+package structconst
+
+// Settings is const as a whole, except for fields marked +mutable.
+// +const
+type Settings struct {
+	Theme string
+
+	// +mutable
+	Volume int
+}
+
+// Ledger can't be both const and mutable.
+// +const
+// +mutable // want "conflicting constlint markers \\+const and \\+mutable on Ledger"
+type Ledger struct {
+	Total int
+}
+
+// Limits is deep const as a whole, but a field can be marked shallow.
+// +deepconst
+type Limits struct {
+	Quotas []int
+
+	// +const:shallow
+	Tags []string
+}
+
+// Adjust mutates Settings and Limits.
+func Adjust(s *Settings, l *Limits) {
+	s.Theme = "dark" // want "assignment to const field Settings.Theme"
+	s.Volume = 11    // OK: exempted with +mutable
+	l.Quotas[0] = 1  // want "assignment to const field Limits.Quotas"
+	l.Tags[0] = "x"  // OK: shallow
+}
+
+// Apply lists a const parameter, though Settings is const as a whole.
+// +const:[theme] // want `conflicting constlint markers \+const on Settings and \+const:\[theme\] on its method Settings.Apply`
+func (s *Settings) Apply(theme string) {
+	theme = "light" // want "assignment to const parameter theme"
+	s.Volume = 0
+}
+
+// Reset lists a parameter by position, though Limits is const as a whole.
+// +const:[0] // want `conflicting constlint markers \+deepconst on Limits and \+const:\[0\] on its method Limits.Reset`
+func (l *Limits) Reset(quota int) {
+	l.Tags = nil // want "assignment to const field Limits.Tags"
+}
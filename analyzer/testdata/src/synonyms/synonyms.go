@@ -0,0 +1,35 @@
+package synonyms
+
+// Invoice is annotated with a homegrown convention.
+type Invoice struct {
+	Number string // +readonly
+
+	// +frozen
+	Lines []string
+
+	// +readonly:shallow
+	Payers []string
+
+	Note string // +readonly +writable // want `conflicting constlint markers \+readonly and \+writable on Invoice.Note`
+
+	Total int // +readonly +const // want `duplicate constlint marker \+const on Invoice.Total`
+
+	Paid bool // +readonyl // want `unknown marker \+readonyl, did you mean \+readonly\?`
+}
+
+// +readonly
+type Customer struct {
+	Name string
+
+	Email string // +writable
+}
+
+func (i *Invoice) Amend(c *Customer) {
+	i.Number = "x"   // want `assignment to const field Invoice.Number`
+	i.Lines[0] = "x" // want `assignment to const field Invoice.Lines`
+	i.Payers[0] = "x"
+	i.Note = "x" // want `assignment to const field Invoice.Note`
+	i.Paid = true
+	c.Name = "x" // want `assignment to const field Customer.Name`
+	c.Email = "x"
+}
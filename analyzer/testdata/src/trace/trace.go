@@ -0,0 +1,22 @@
+package trace
+
+// Limit is the default limit of an account.
+// +const
+var Limit = 10 // want Limit:"const"
+
+type Account struct {
+	Owner string // +const
+}
+
+func NewAccount(owner string) *Account {
+	a := &Account{}
+	a.Owner = owner
+	return a
+}
+
+func Migrate(a *Account) {
+	//constlint:disable legacy migration
+	a.Owner = "migrated"
+	//constlint:enable
+	a.Owner = "" // want `assignment to const field Account.Owner`
+}
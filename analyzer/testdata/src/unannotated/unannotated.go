@@ -0,0 +1,11 @@
+package unannotated
+
+// Config has no markers, and neither has its package.
+type Config struct {
+	Host string
+}
+
+// Tune writes a field, which is not const.
+func (c *Config) Tune() {
+	c.Host = "localhost"
+}
@@ -0,0 +1,52 @@
+package undecided
+
+// Account leaves the mutability of some of its fields open.
+type Account struct {
+	// +const
+	ID      string
+	Owner   string // want `exported field Account.Owner must be marked \+const or \+mutable`
+	Balance int64  // +mutable
+	// +deepconst
+	Limits     []int64
+	Tags       []string // want `exported field Account.Tags must be marked \+const or \+mutable`
+	Name, Nick string   // want `exported field Account.Name must be marked` `exported field Account.Nick must be marked`
+
+	Embedded
+	notes string
+}
+
+// Embedded fields are never const, so they need no decision.
+type Embedded struct {
+	// +secret
+	Token string
+}
+
+// Money is marked as a whole, deciding for every field.
+//
+// +valueobject
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// Settings are const as a whole, but for Verbose.
+//
+// +const
+type Settings struct {
+	Host string
+	// +mutable
+	Verbose bool
+}
+
+// Customer decides for its identity fields only.
+//
+// +entity:id=ID
+type Customer struct {
+	ID    string
+	Email string // want `exported field Customer.Email must be marked`
+}
+
+// cache is unexported, so not part of the API.
+type cache struct {
+	Entries map[string]string
+}
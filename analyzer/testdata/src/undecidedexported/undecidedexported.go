@@ -0,0 +1,20 @@
+package undecidedexported
+
+// Server leaves its exported fields unmarked, which -exported-const makes
+// const, so -require-markers has nothing to ask for.
+type Server struct {
+	Addr string
+	// +mutable
+	Conns int
+	// +secret
+	Key string
+
+	retries int
+}
+
+// Serve writes an implicitly const field of an existing server.
+func (s *Server) Serve() {
+	s.Addr = ":8080" // want `assignment to const field Server.Addr$`
+	s.Conns++
+	s.retries = 3
+}
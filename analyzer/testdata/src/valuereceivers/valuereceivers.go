@@ -0,0 +1,72 @@
+package valuereceivers
+
+import "sync"
+
+// Point never changes after construction.
+//
+// +const
+type Point struct {
+	X, Y int
+}
+
+func (p *Point) Sum() int { // want `method Point.Sum never modifies its receiver and every field of Point is const; use a value receiver`
+	return p.X + p.Y
+}
+
+func (p *Point) Less(q Point) bool { // want `method Point.Less never modifies its receiver`
+	return p.X < q.X || p.X == q.X && p.Y < q.Y
+}
+
+// Value receivers are fine already.
+func (p Point) Norm() int {
+	return p.X*p.X + p.Y*p.Y
+}
+
+// IsZero handles nil receivers, which a value receiver can't.
+func (p *Point) IsZero() bool {
+	return p == nil || p.X == 0 && p.Y == 0
+}
+
+// Reset replaces the value behind its holder's back.
+func (p *Point) Reset() {
+	*p = Point{}
+}
+
+// XRef hands out a pointer into the value.
+func (p *Point) XRef() *int {
+	return &p.X // want `XRef returns a pointer into const field Point.X`
+}
+
+// Route has a mutable field.
+type Route struct {
+	// +const
+	From Point
+	Hops int
+}
+
+func (r *Route) Start() Point {
+	return r.From
+}
+
+// Counter can't be copied.
+//
+// +const
+type Counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *Counter) Count() int {
+	return c.n
+}
+
+// Money is a value object, reported by its own rule.
+//
+// +valueobject
+type Money struct {
+	Amount int64
+}
+
+func (m *Money) Cents() int64 { // want `method Cents of value object Money has a pointer receiver`
+	return m.Amount
+}
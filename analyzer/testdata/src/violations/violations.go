@@ -0,0 +1,20 @@
+package violations
+
+// Job is handed over to the test's violation handler.
+type Job struct {
+	// +const
+	ID string
+}
+
+// Retry writes a const field, which the handler takes over.
+func (j *Job) Retry(id string) {
+	j.ID = id
+}
+
+// Schedule writes a const parameter, which the handler lets the analyzer
+// report too.
+//
+// +const
+func Schedule(delay int) {
+	delay = 0 // want `assignment to const parameter delay`
+}
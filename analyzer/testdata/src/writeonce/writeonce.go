@@ -0,0 +1,125 @@
+package writeonce
+
+import "sync"
+
+type Index struct {
+	Terms map[string][]int
+}
+
+type Catalog struct {
+	Name string // +const
+
+	index *Index // +writeonce
+	size  int    // +writeonce
+	ready bool   // +writeonce
+
+	once  sync.Once
+	mu    sync.Mutex
+	title string // +writeonce reason="derived from Name"
+}
+
+func NewCatalog(name string) *Catalog {
+	c := &Catalog{Name: name}
+	c.index = nil
+	return c
+}
+
+func build() *Index { return &Index{} }
+
+// Index builds the index the first time it is asked for.
+func (c *Catalog) Index() *Index {
+	if c.index == nil {
+		c.index = build()
+	}
+	return c.index
+}
+
+// LockedIndex checks again under the lock.
+func (c *Catalog) LockedIndex() *Index {
+	if c.index == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if nil == c.index {
+			c.index = build()
+		}
+	}
+	return c.index
+}
+
+func (c *Catalog) Size() int {
+	if c.size == 0 && c.index != nil {
+		c.size = len(c.index.Terms)
+	}
+	return c.size
+}
+
+func (c *Catalog) Ready() bool {
+	if !c.ready {
+		c.ready = true
+	}
+	return c.ready
+}
+
+func (c *Catalog) Title() string {
+	c.once.Do(func() {
+		c.title = "Catalog " + c.Name
+	})
+	return c.title
+}
+
+func (c *Catalog) Sum() func() {
+	return sync.OnceFunc(func() {
+		c.size = 1
+	})
+}
+
+func (c *Catalog) Reset() {
+	c.index = nil // want `assignment to write-once field Catalog.index outside its lazy initialization$`
+	c.size = 0    // want `assignment to write-once field Catalog.size outside its lazy initialization$`
+}
+
+func (c *Catalog) Rebuild() {
+	if c.index == nil {
+		c.index = build()
+	} else {
+		c.index = build() // want `assignment to write-once field Catalog.index outside its lazy initialization$`
+	}
+	if c.index != nil {
+		c.index = build() // want `assignment to write-once field Catalog.index outside its lazy initialization$`
+	}
+	if c.index == nil || c.size > 0 {
+		c.size = 1 // want `assignment to write-once field Catalog.size outside its lazy initialization$`
+	}
+}
+
+func (c *Catalog) Rename(other *Catalog) {
+	if c.title == "" {
+		other.title = c.Name // want `assignment to write-once field Catalog.title outside its lazy initialization \(const because: derived from Name\)`
+		c.Name = "x"         // want `assignment to const field Catalog.Name$`
+	}
+	if c.index == nil {
+		go func() {
+			c.index = build() // want `assignment to write-once field Catalog.index outside its lazy initialization$`
+		}()
+	}
+	var once sync.Once
+	once.Do(func() {
+		c.Name = "y" // want `assignment to const field Catalog.Name$`
+	})
+}
+
+type Lazy struct {
+	cache []int // +writeonce +const:shallow // want `conflicting constlint markers \+const:shallow and \+writeonce on Lazy.cache`
+	deep  []int // +deepconst +writeonce
+}
+
+func (l *Lazy) Deep() []int {
+	if l.deep == nil {
+		l.deep = []int{1}
+	}
+	l.deep[0] = 2 // want `assignment to write-once field Lazy.deep$`
+	if l.cache == nil {
+		l.cache = []int{} // want `assignment to const field Lazy.cache$`
+	}
+	return l.deep
+}
@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// knownMarkers lists the marker names understood by the analyzer.
+var knownMarkers = []string{
+	constMarker, deepConstMarker, mutableMarker, secretMarker, writeOnceMarker, valueObjectMarker, entityMarker,
+	optionMarker, packageMarker,
+}
+
+// knownPackageArgs lists the arguments accepted by +constlint.
+var knownPackageArgs = []string{strictArg, deepModeArg}
+
+// knownConstArgs lists the arguments accepted by +const, besides [...] lists.
+var knownConstArgs = []string{shallowArg, receiverArg, beginArg, endArg, externalArg}
+
+// checkMarkerTypos reports comments that look like a misspelled marker, which
+// would otherwise be silently ignored.
+func checkMarkerTypos(pass *analysis.Pass, file *ast.File) {
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			checkSpacedMarker(pass, comment)
+
+			for _, m := range parseMarkers(comment) {
+				checkMarker(pass, m)
+			}
+		}
+	}
+}
+
+// checkMarker reports a marker whose name or argument is close to, but not
+// exactly, one the analyzer understands.
+func checkMarker(pass *analysis.Pass, m marker) {
+	if !isKnownMarker(m.name) {
+		if suggestion, ok := closestMarker(m.name); ok {
+			report(pass, m, CategoryMarker, "unknown marker %s, did you mean %s?", m, suggestion)
+		}
+		return
+	}
+
+	if m.name == entityMarker {
+		if !strings.HasPrefix(m.arg, entityIDArg) {
+			report(pass, m, CategoryMarker, "marker %s must list the identity fields, e.g. %s:%sID",
+				m, entityMarker, entityIDArg)
+		}
+		return
+	}
+	if m.name == optionMarker {
+		if m.arg == "" {
+			report(pass, m, CategoryMarker, "marker %s must name the type it configures, e.g. %s:Server",
+				m, optionMarker)
+		}
+		return
+	}
+	if m.name == packageMarker {
+		checkMarkerArg(pass, m, knownPackageArgs)
+		return
+	}
+	if m.name != constMarker || m.arg == "" || isMarkerList(m.arg) {
+		return
+	}
+	if strings.HasPrefix(m.arg, "[") {
+		report(pass, m, CategoryMarker, "unterminated list in marker %s", m)
+		return
+	}
+	checkMarkerArg(pass, m, knownConstArgs)
+}
+
+// checkMarkerArg reports a marker whose argument isn't one of known,
+// suggesting the closest one.
+func checkMarkerArg(pass *analysis.Pass, m marker, known []string) {
+	for _, arg := range known {
+		if m.arg == arg {
+			return
+		}
+	}
+	if suggestion, ok := closest(m.arg, known); ok {
+		report(pass, span{m.argPos, m.End()}, CategoryMarker, "unknown argument %q to %s, did you mean %s:%s?",
+			m.arg, m.name, m.name, suggestion)
+		return
+	}
+	report(pass, span{m.argPos, m.End()}, CategoryMarker, "unknown argument %q to %s", m.arg, m.name)
+}
+
+// checkSpacedMarker reports a marker with white space after the plus sign,
+// e.g. // + const.
+func checkSpacedMarker(pass *analysis.Pass, comment *ast.Comment) {
+	text, ok := strings.CutPrefix(comment.Text, "//")
+	if !ok {
+		return
+	}
+	trimmed := strings.TrimLeft(text, " \t")
+	rest, ok := strings.CutPrefix(trimmed, "+")
+	if !ok || rest == "" || !unicode.IsSpace(rune(rest[0])) {
+		return
+	}
+
+	word := strings.Fields(rest)[0]
+	if name, _, _ := strings.Cut(word, ":"); name != "" {
+		if suggestion, ok := closestMarker("+" + name); ok {
+			pos := comment.Pos() + 2 + token.Pos(len(text)-len(trimmed))
+			report(pass, at(pos), CategoryMarker, "malformed marker \"+ %s\", did you mean %s?", word, suggestion)
+		}
+	}
+}
+
+// isKnownMarker reports whether name is a marker the analyzer understands, or
+// one registered with RegisterMarker.
+func isKnownMarker(name string) bool {
+	for _, known := range knownMarkers {
+		if name == known {
+			return true
+		}
+	}
+	return customMarker(name) != nil
+}
+
+// closestMarker returns the known or registered marker, or the synonym, that
+// name is most likely a misspelling of.
+func closestMarker(name string) (string, bool) {
+	candidates := append([]string(nil), knownMarkers...)
+	for _, def := range customMarkers {
+		candidates = append(candidates, def.Name)
+	}
+	candidates = append(candidates, markerSynonyms.synonymNames()...)
+	return closest(name, candidates)
+}
+
+// closest returns the candidate that word is most likely a misspelling of. The
+// comparison ignores case and allows one edit for every four characters of
+// the candidate, with a minimum of one.
+func closest(word string, candidates []string) (string, bool) {
+	word = strings.ToLower(word)
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		limit := max(1, len(strings.TrimPrefix(candidate, "+"))/4)
+		d := editDistance(word, strings.ToLower(candidate))
+		if d <= limit && (bestDistance == -1 || d < bestDistance) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best, bestDistance != -1
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and transpositions of
+// adjacent characters needed to turn a into b.
+func editDistance(a, b string) int {
+	// Three rolling rows: two rows back is needed for transpositions.
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkUndecidedFields reports the exported fields of an exported struct type
+// that carry no decision about their mutability: no marker of their own, such
+// as +const or +mutable, none of their struct type, such as +valueobject, and
+// no place among the fields an entity or +const:[...] marker lists. Embedded
+// fields are never const and aren't reported.
+//
+// There is deliberately no fix: the point of the rule is that the author of
+// the API makes the decision.
+func checkUndecidedFields(pass *analysis.Pass, decl *ast.GenDecl, spec *ast.TypeSpec) {
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok || !spec.Name.IsExported() {
+		return
+	}
+	typeName, ok := pass.TypesInfo.Defs[spec.Name].(*types.TypeName)
+	if !ok {
+		return
+	}
+	typeMarkers := structMarkers(decl, spec)
+	if typeConstness, _ := fieldMarkers(typeMarkers); typeConstness.found {
+		return
+	}
+	identity, _ := entityFields(typeMarkers)
+	listed, _, _ := constFieldList(typeMarkers)
+	identity = append(identity, listed...)
+
+	blocks, _ := constBlocks(fileOf(pass, spec.Pos()), structType)
+
+	for _, field := range structType.Fields.List {
+		if constness, _ := fieldMarkers(collectMarkers(field.Doc, field.Comment)); constness.found {
+			continue
+		}
+		if _, ok := blockConstness(blocks, field.Pos()); ok {
+			continue
+		}
+	names:
+		for _, name := range field.Names {
+			if !name.IsExported() || !fieldInFocus(typeName, name.Name) {
+				continue
+			}
+			for _, id := range identity {
+				if id.name == name.Name {
+					continue names
+				}
+			}
+			report(pass, name, CategoryUndecided, "exported field %s.%s must be marked +const or +mutable",
+				typeName.Name(), name.Name)
+		}
+	}
+}
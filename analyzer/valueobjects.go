@@ -0,0 +1,31 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+)
+
+// checkValueObjectMethods reports pointer receivers on the methods of value
+// objects. A value object is replaced rather than changed, and a pointer
+// receiver is what allows replacing it behind its holder's back (*v = V{}),
+// which the const field rules don't see.
+func checkValueObjectMethods(pass *analysis.Pass, inspector *astinspector.Inspector,
+	valueObjects map[*types.TypeName]bool) {
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+			return
+		}
+		owner := receiverType(pass, funcDecl)
+		if !valueObjects[owner] {
+			return
+		}
+		if _, ok := pass.TypesInfo.TypeOf(funcDecl.Recv.List[0].Type).(*types.Pointer); ok {
+			report(pass, funcDecl.Name, CategoryValueObject, "method %s of value object %s has a pointer receiver",
+				funcDecl.Name.Name, owner.Name())
+		}
+	})
+}
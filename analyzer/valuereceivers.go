@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	astinspector "golang.org/x/tools/go/ast/inspector"
+)
+
+// checkValueReceivers reports pointer receivers on the methods of struct types
+// whose fields are all const, when the method provably leaves its receiver
+// unchanged, with a fix switching to a value receiver. Such a type has nothing
+// to change after construction, and a pointer receiver only lets a method
+// replace the value behind its holder's back (*t = T{}) or hand out pointers
+// into it.
+//
+// Methods comparing their receiver to nil are left alone, as a value receiver
+// would have them panic instead, and so are types holding locks, which must
+// not be copied. Value objects are reported by their own rule.
+func checkValueReceivers(pass *analysis.Pass, inspector *astinspector.Inspector,
+	constFields map[*types.Var]constField, valueObjects map[*types.TypeName]bool) {
+	allConst := make(map[*types.TypeName]bool)
+	for _, cf := range constFields {
+		if _, seen := allConst[cf.owner]; !seen {
+			allConst[cf.owner] = fieldsAllConst(cf.owner, constFields)
+		}
+	}
+
+	constMethods := markedConstMethods(pass, inspector)
+	inspector.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		funcDecl := n.(*ast.FuncDecl)
+		recv := methodReceiver(pass, funcDecl)
+		if recv == nil || recv.Name() == "_" {
+			return
+		}
+		star, ok := funcDecl.Recv.List[0].Type.(*ast.StarExpr)
+		if !ok {
+			return
+		}
+		owner := receiverType(pass, funcDecl)
+		if owner == nil || !allConst[owner] || valueObjects[owner] {
+			return
+		}
+		if len(receiverWrites(pass, funcDecl)) > 0 || !readsOnly(pass, funcDecl.Body, recv, constMethods) ||
+			comparesReceiver(pass, funcDecl.Body, recv) {
+			return
+		}
+
+		emit(pass, analysis.Diagnostic{
+			Pos:      star.Pos(),
+			End:      star.End(),
+			Category: CategoryValueReceiver,
+			Message: "method " + owner.Name() + "." + funcDecl.Name.Name + " never modifies its receiver and every field of " +
+				owner.Name() + " is const; use a value receiver",
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message:   "Use a value receiver",
+				TextEdits: []analysis.TextEdit{{Pos: star.Star, End: star.Star + token.Pos(len("*"))}},
+			}},
+		}, nil, owner)
+	})
+}
+
+// fieldsAllConst reports whether every field of the struct type owner is
+// const. Embedded fields are never const, and blank fields don't count.
+func fieldsAllConst(owner *types.TypeName, constFields map[*types.Var]constField) bool {
+	structType, ok := owner.Type().Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := range structType.NumFields() {
+		field := structType.Field(i)
+		if field.Name() == "_" {
+			continue
+		}
+		if _, ok := constFields[field]; !ok || field.Embedded() || holdsLock(field.Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// holdsLock reports whether values of t embed a lock by value, such as a
+// sync.Mutex, that copying a value would copy along.
+func holdsLock(t types.Type) bool {
+	if _, ok := t.Underlying().(*types.Pointer); ok {
+		return false
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		return false
+	}
+	methods := types.NewMethodSet(types.NewPointer(t))
+	if methods.Lookup(nil, "Lock") != nil && methods.Lookup(nil, "Unlock") != nil {
+		return true
+	}
+	switch t := t.Underlying().(type) {
+	case *types.Struct:
+		for i := range t.NumFields() {
+			if holdsLock(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Array:
+		return holdsLock(t.Elem())
+	}
+	return false
+}
+
+// comparesReceiver reports whether body compares recv itself, as in
+// recv == nil.
+func comparesReceiver(pass *analysis.Pass, body *ast.BlockStmt, recv *types.Var) bool {
+	compares := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if binary, ok := n.(*ast.BinaryExpr); ok {
+			for _, operand := range []ast.Expr{binary.X, binary.Y} {
+				if id, ok := ast.Unparen(operand).(*ast.Ident); ok && pass.TypesInfo.Uses[id] == recv {
+					compares = true
+				}
+			}
+		}
+		return !compares
+	})
+	return compares
+}
@@ -0,0 +1,45 @@
+package analyzer
+
+import (
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Violation is a diagnostic of the analyzer, with the declaration it is about.
+type Violation struct {
+	analysis.Diagnostic
+	// Confidence is the confidence of the diagnostic's rule.
+	Confidence Confidence
+	// Object is the const field, parameter or variable written, for the
+	// diagnostics of the field-write, copy-write, param-write and global-write
+	// rules, the receiver modified for those of the receiver and const-method
+	// rules, and nil for other diagnostics.
+	Object types.Object
+	// Owner is the struct type declaring the const field written.
+	Owner *types.TypeName
+}
+
+// violationHandler is a function registered with HandleViolations.
+type violationHandler struct {
+	handle func(pass *analysis.Pass, v Violation) bool
+}
+
+var violationHandlers []*violationHandler
+
+// HandleViolations registers handle to receive every diagnostic of the
+// analyzer that -min-confidence keeps, for tools consuming them as data, such
+// as editor plugins and CI bots. The diagnostic is still reported through the
+// pass unless handle returns false. Handlers must be registered before the
+// analyzer runs, typically from an init function; a driver analyzing packages
+// in parallel calls them concurrently. The function returned removes the
+// handler again, as tests do once they are done; it mustn't be called while
+// the analyzer runs either.
+func HandleViolations(handle func(pass *analysis.Pass, v Violation) bool) (unregister func()) {
+	h := &violationHandler{handle}
+	violationHandlers = append(violationHandlers, h)
+	return func() {
+		violationHandlers = slices.DeleteFunc(violationHandlers, func(other *violationHandler) bool { return other == h })
+	}
+}
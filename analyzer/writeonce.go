@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// kind returns what diagnostics call the field, and for assignments of a
+// +writeonce field itself, rather than of what it holds, where they were made.
+func (cf constField) kind(assigned bool) (kind, outside string) {
+	switch {
+	case cf.external:
+		return "const field", " outside package " + cf.owner.Pkg().Name()
+	case cf.writeOnce && assigned:
+		return "write-once field", " outside its lazy initialization"
+	case cf.writeOnce:
+		return "write-once field", ""
+	}
+	return "const field", ""
+}
+
+// lazyInit returns what makes an assignment to a +writeonce field, the
+// selector sel, its lazy initialization: an enclosing if statement that only
+// runs while the field is unset,
+//
+//	if t.cache == nil {
+//		t.cache = build()
+//	}
+//
+// or the sync.Once Do call or sync.OnceFunc running the function literal the
+// assignment is in. stack ends at the assignment. Assignments to part of the
+// field, or made through an alias of it, aren't lazy initializations.
+func lazyInit(info *types.Info, expr ast.Expr, sel *ast.SelectorExpr, stack []ast.Node) (ast.Node, bool) {
+	if ast.Unparen(expr) != sel {
+		return nil, false
+	}
+	for i := len(stack) - 1; i > 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.IfStmt:
+			if i+1 < len(stack) && stack[i+1] == n.Body && guardsUnset(info, n.Cond, sel) {
+				return n, true
+			}
+		case *ast.FuncLit:
+			// Function literals run when they are called, unguarded unless
+			// a sync.Once calls them.
+			call, ok := stack[i-1].(*ast.CallExpr)
+			if ok && len(call.Args) == 1 && call.Args[0] == n && isOnceCall(info, call) {
+				return call, true
+			}
+			return nil, false
+		case *ast.FuncDecl:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// LazyInit reports whether the field name at pos in file, which info
+// describes, is assigned there by the lazy initialization the analyzer allows
+// for +writeonce fields, as in t.cache = build() under a guard only running
+// while the field is unset, or in a function literal a sync.Once runs.
+func LazyInit(info *types.Info, file *ast.File, pos token.Pos) bool {
+	path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+	if len(path) < 3 {
+		return false
+	}
+	sel, ok := path[1].(*ast.SelectorExpr)
+	if !ok || sel.Sel != path[0] {
+		return false
+	}
+	assign, ok := path[2].(*ast.AssignStmt)
+	if !ok || !slices.Contains(assign.Lhs, ast.Expr(sel)) {
+		return false
+	}
+	// lazyInit takes the stack from the file down to the assignment.
+	stack := slices.Clone(path[2:])
+	slices.Reverse(stack)
+	_, ok = lazyInit(info, sel, sel, stack)
+	return ok
+}
+
+// guardsUnset reports whether cond only holds while the field sel selects is
+// unset: it compares the field to its zero value, negates a bool field, or is
+// a conjunction of which either operand does.
+func guardsUnset(info *types.Info, cond ast.Expr, sel *ast.SelectorExpr) bool {
+	switch c := ast.Unparen(cond).(type) {
+	case *ast.BinaryExpr:
+		switch c.Op {
+		case token.LAND:
+			return guardsUnset(info, c.X, sel) || guardsUnset(info, c.Y, sel)
+		case token.EQL:
+			return sameField(c.X, sel) && isZeroValue(info, c.Y) || sameField(c.Y, sel) && isZeroValue(info, c.X)
+		}
+	case *ast.UnaryExpr:
+		return c.Op == token.NOT && sameField(c.X, sel)
+	}
+	return false
+}
+
+// sameField reports whether expr selects the same field of the same value as
+// sel, written alike.
+func sameField(expr ast.Expr, sel *ast.SelectorExpr) bool {
+	return types.ExprString(ast.Unparen(expr)) == types.ExprString(sel)
+}
+
+// isZeroValue reports whether expr is nil or a constant zero value: 0, "" or
+// false.
+func isZeroValue(info *types.Info, expr ast.Expr) bool {
+	tv, ok := info.Types[expr]
+	if !ok {
+		return false
+	}
+	if tv.IsNil() {
+		return true
+	}
+	switch v := tv.Value; {
+	case v == nil:
+		return false
+	case v.Kind() == constant.String:
+		return constant.StringVal(v) == ""
+	case v.Kind() == constant.Bool:
+		return !constant.BoolVal(v)
+	case v.Kind() == constant.Unknown:
+		return false
+	}
+	return constant.Sign(tv.Value) == 0
+}
+
+// isOnceCall reports whether call runs its function argument at most once:
+// a call of the Do method of sync.Once, or of sync.OnceFunc.
+func isOnceCall(info *types.Info, call *ast.CallExpr) bool {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok {
+		return false
+	}
+	name := fn.FullName()
+	return name == "(*sync.Once).Do" || name == "sync.OnceFunc"
+}
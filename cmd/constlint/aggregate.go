@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+)
+
+// aggregateFieldWrites replaces the field-write diagnostics of every const
+// field written more than once by a single diagnostic at the field, listing
+// the writes as related locations, e.g.
+//
+//	shop/order.go:12:2: const field Order.ID is written at 37 places
+//
+// The aggregate is as confident as its most confident write. Diagnostics are
+// kept sorted by position.
+func aggregateFieldWrites(diags []diagnostic, decls map[token.Position]constDecl) []diagnostic {
+	writes := make(map[token.Position][]diagnostic)
+	for _, d := range diags {
+		if d.Category == analyzer.CategoryFieldWrite && d.decl.IsValid() {
+			writes[d.decl] = append(writes[d.decl], d)
+		}
+	}
+
+	var aggregated []diagnostic
+	for _, d := range diags {
+		if len(writes[d.decl]) < 2 || d.Category != analyzer.CategoryFieldWrite {
+			aggregated = append(aggregated, d)
+		}
+	}
+	for decl, sites := range writes {
+		if len(sites) < 2 {
+			continue
+		}
+		a := diagnostic{
+			Package:  decls[decl].pkg,
+			Posn:     decl.String(),
+			End:      decl.String(),
+			Category: analyzer.CategoryFieldWrite,
+			Message:  fmt.Sprintf("const field %s is written at %d places", sites[0].Field, len(sites)),
+			Field:    sites[0].Field,
+			position: decl,
+			end:      decl,
+			decl:     decl,
+		}
+		confidence := analyzer.Possible
+		for _, d := range sites {
+			if c, err := analyzer.ParseConfidence(d.Confidence); err == nil && c > confidence {
+				confidence = c
+			}
+			a.Related = append(a.Related, related{Posn: d.Posn, Message: d.Message, position: d.position})
+		}
+		a.Confidence = confidence.String()
+		a.aggregate = true
+		aggregated = append(aggregated, a)
+	}
+	return dedupe(aggregated)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAggregateFieldWrites(t *testing.T) {
+	noSettings := func(string) (map[string]string, error) { return nil, nil }
+	diags, decls, err := lint([]string{"./testdata/wholeprogram/..."}, false, nil, "vta", noSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeText(&out, aggregateFieldWrites(diags, decls), ""); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := filepath.Abs("testdata/wholeprogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.ReplaceAll(out.String(), dir+string(filepath.Separator), "")
+	want := "store/store.go:6:2: const field Item.SKU is written at 4 places\n" +
+		"\tapp/app.go:8:5: assignment to const field Item.SKU of a value Restock did not create\n" +
+		"\tapp/getters.go:7:2: assignment to const field Item.SKU through the result of (*Item).SKURef\n" +
+		"\tapp/getters.go:10:2: assignment to const field Item.SKU through the result of (*Item).SKURef\n" +
+		"\tstore/store.go:21:6: assignment to const field Item.SKU after construction: Clone is called with an existing Item\n" +
+		"store/store.go:8:2: const field Item.Tags is written at 3 places\n" +
+		"\tapp/app.go:9:9: assignment to const field Item.Tags of a value Restock did not create\n" +
+		"\tapp/getters.go:8:15: assignment to const field Item.Tags through the result of (*Item).TagsView\n" +
+		"\tstore/store.go:33:2: assignment to const field Item.Tags\n" +
+		"store/store.go:38:9: SKURef returns a pointer into const field Item.SKU, through which callers can modify it\n" +
+		"store/store.go:43:9: TagsView returns a slice of const field Item.Tags, through which callers can modify it\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/build/constraint"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// buildConfig is a build configuration to check packages in, such as
+// windows/arm64+integration: a GOOS, a GOARCH and build tags. Empty GOOS and
+// GOARCH are those of the go command.
+type buildConfig struct {
+	goos, goarch string
+	tags         []string
+}
+
+// knownOS and knownArch list the values of GOOS and GOARCH, which files name
+// in their build constraints and file name suffixes.
+var (
+	knownOS = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js", "linux",
+		"nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos"}
+	knownArch = []string{"386", "amd64", "amd64p32", "arm", "armbe", "arm64", "arm64be", "loong64", "mips", "mipsle",
+		"mips64", "mips64le", "mips64p32", "mips64p32le", "ppc", "ppc64", "ppc64le", "riscv", "riscv64", "s390",
+		"s390x", "sparc", "sparc64", "wasm"}
+	unixOS = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "linux", "netbsd",
+		"openbsd", "solaris"}
+)
+
+// defaultBuild and autoBuilds are the -build-matrix entries standing for the
+// go command's configuration and for the configurations the files it leaves
+// out need.
+const (
+	defaultBuild = "default"
+	autoBuilds   = "auto"
+)
+
+// String formats the configuration as -build-matrix takes it.
+func (b buildConfig) String() string {
+	if b.goos == "" && b.goarch == "" && len(b.tags) == 0 {
+		return defaultBuild
+	}
+	s := b.goos
+	if b.goarch != "" {
+		s += "/" + b.goarch
+	}
+	for _, tag := range b.tags {
+		s += "+" + tag
+	}
+	return s
+}
+
+// packagesConfig returns the go/packages configuration loading packages in
+// the build configuration.
+func (b buildConfig) packagesConfig(tests bool) *packages.Config {
+	cfg := &packages.Config{Tests: tests, Overlay: overlay}
+	if b.goos != "" || b.goarch != "" {
+		cfg.Env = os.Environ()
+		if b.goos != "" {
+			cfg.Env = append(cfg.Env, "GOOS="+b.goos)
+		}
+		if b.goarch != "" {
+			cfg.Env = append(cfg.Env, "GOARCH="+b.goarch)
+		}
+	}
+	if len(b.tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(b.tags, ",")}
+	}
+	return cfg
+}
+
+// load loads the packages matching patterns in the configuration like
+// loadPackages. Packages none of whose files build in it are left out, unless
+// that leaves none.
+func (b buildConfig) load(tests bool, patterns []string) ([]*packages.Package, error) {
+	cfg := b.packagesConfig(tests)
+	cfg.Mode = packages.LoadAllSyntax
+	loaded, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	var initial []*packages.Package
+	for _, pkg := range loaded {
+		if len(pkg.GoFiles) > 0 || len(pkg.IgnoredFiles) == 0 {
+			initial = append(initial, pkg)
+		}
+	}
+	if len(initial) == 0 {
+		initial = loaded
+	}
+	return checkLoaded(initial, patterns)
+}
+
+// mergeBuilds appends the configurations of more to builds that aren't in it
+// already.
+func mergeBuilds(builds, more []buildConfig) []buildConfig {
+	for _, b := range more {
+		if !slices.ContainsFunc(builds, func(other buildConfig) bool { return other.String() == b.String() }) {
+			builds = append(builds, b)
+		}
+	}
+	return builds
+}
+
+// parseBuildMatrix parses the comma-separated configurations of
+// -build-matrix: goos, goos/goarch or nothing for the go command's, each
+// followed by +tag for every build tag to set, defaultBuild or autoBuilds.
+func parseBuildMatrix(value string) (builds []buildConfig, auto bool, err error) {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == autoBuilds {
+			auto = true
+			continue
+		}
+		if entry == defaultBuild {
+			builds = append(builds, buildConfig{})
+			continue
+		}
+		parts := strings.Split(entry, "+")
+		var b buildConfig
+		b.goos, b.goarch, _ = strings.Cut(parts[0], "/")
+		if b.goos != "" && !slices.Contains(knownOS, b.goos) {
+			return nil, false, fmt.Errorf("-build-matrix: unknown GOOS %q in %s", b.goos, entry)
+		}
+		if b.goarch != "" && !slices.Contains(knownArch, b.goarch) {
+			return nil, false, fmt.Errorf("-build-matrix: unknown GOARCH %q in %s", b.goarch, entry)
+		}
+		for _, tag := range parts[1:] {
+			if tag == "" {
+				return nil, false, fmt.Errorf("-build-matrix: empty build tag in %s", entry)
+			}
+			b.tags = append(b.tags, tag)
+		}
+		builds = append(builds, b)
+	}
+	return builds, auto, nil
+}
+
+// discoverBuilds returns the configurations in which the files the go command
+// leaves out of the packages matching patterns build: one per GOOS, GOARCH
+// and tags their build constraints and file names ask for. Files needing cgo
+// or the ignore tag are left out.
+func discoverBuilds(patterns []string, tests bool) ([]buildConfig, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles, Tests: tests}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var builds []buildConfig
+	for _, pkg := range pkgs {
+		for _, file := range pkg.IgnoredFiles {
+			if !tests && strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			b, ok, err := fileBuild(file)
+			if err != nil {
+				return nil, err
+			}
+			if ok && !seen[b.String()] {
+				seen[b.String()] = true
+				builds = append(builds, b)
+			}
+		}
+	}
+	sort.Slice(builds, func(i, j int) bool { return builds[i].String() < builds[j].String() })
+	return builds, nil
+}
+
+// fileBuild returns a configuration in which a file builds, trying the GOOS
+// and GOARCH its name and constraint mention with all of the other tags of
+// the constraint set and then with none of them.
+func fileBuild(file string) (buildConfig, bool, error) {
+	expr, err := buildConstraint(file)
+	if err != nil {
+		return buildConfig{}, false, err
+	}
+	nameOS, nameArch := fileNameConstraint(filepath.Base(file))
+
+	oses, arches := []string{""}, []string{""}
+	var tags []string
+	if expr != nil {
+		expr.Eval(func(tag string) bool {
+			switch {
+			case slices.Contains(knownOS, tag):
+				oses = append(oses, tag)
+			case slices.Contains(knownArch, tag):
+				arches = append(arches, tag)
+			case tag == "unix":
+				oses = append(oses, "linux")
+			case tag != "cgo" && tag != "ignore" && tag != "gc" && tag != "gccgo" && !strings.HasPrefix(tag, "go1."):
+				tags = append(tags, tag)
+			}
+			return false
+		})
+	}
+	if nameOS != "" {
+		oses = []string{nameOS}
+	}
+	if nameArch != "" {
+		arches = []string{nameArch}
+	}
+	sort.Strings(tags)
+	tags = slices.Compact(tags)
+
+	for _, goos := range oses {
+		for _, goarch := range arches {
+			for _, set := range [][]string{tags, nil} {
+				b := buildConfig{goos: goos, goarch: goarch, tags: set}
+				if expr == nil || expr.Eval(b.satisfies) {
+					return b, b.goos != "" || b.goarch != "" || len(b.tags) > 0, nil
+				}
+			}
+		}
+	}
+	return buildConfig{}, false, nil
+}
+
+// satisfies reports whether a build tag is set in the configuration, with
+// the release tags of the go command.
+func (b buildConfig) satisfies(tag string) bool {
+	goos, goarch := b.goos, b.goarch
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	switch {
+	case tag == goos, tag == goarch, tag == "gc":
+		return true
+	case tag == "unix":
+		return slices.Contains(unixOS, goos)
+	case strings.HasPrefix(tag, "go1."):
+		return true
+	}
+	return slices.Contains(b.tags, tag)
+}
+
+// buildConstraint returns the //go:build constraint of a file, or nil.
+func buildConstraint(file string) (constraint.Expr, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if constraint.IsGoBuild(line) {
+			return constraint.Parse(line)
+		}
+		// Constraints precede the package clause.
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+	}
+	return nil, scanner.Err()
+}
+
+// fileNameConstraint returns the GOOS and GOARCH a file name restricts the
+// file to, as in order_windows.go or order_linux_arm64_test.go.
+func fileNameConstraint(name string) (goos, goarch string) {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_test")
+	parts := strings.Split(name, "_")
+	n := len(parts)
+	if n >= 3 && slices.Contains(knownOS, parts[n-2]) && slices.Contains(knownArch, parts[n-1]) {
+		return parts[n-2], parts[n-1]
+	}
+	if n >= 2 && slices.Contains(knownOS, parts[n-1]) {
+		return parts[n-1], ""
+	}
+	if n >= 2 && slices.Contains(knownArch, parts[n-1]) {
+		return "", parts[n-1]
+	}
+	return "", ""
+}
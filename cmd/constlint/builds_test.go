@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseBuildMatrix(t *testing.T) {
+	builds, auto, err := parseBuildMatrix("default, windows,linux/arm64+integration,+e2e+slow,auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range builds {
+		got = append(got, b.String())
+	}
+	if want := "default windows linux/arm64+integration +e2e+slow"; strings.Join(got, " ") != want || !auto {
+		t.Errorf("got %s, auto %v, want %s, auto true", strings.Join(got, " "), auto, want)
+	}
+
+	for _, matrix := range []string{"windwos", "linux/amd46", "linux+"} {
+		if _, _, err := parseBuildMatrix(matrix); err == nil {
+			t.Errorf("%s: no error", matrix)
+		}
+	}
+}
+
+func TestBuildMatrix(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) { writeFile(t, dir, name, content) }
+	write("go.mod", "module example.com/shop\n\ngo 1.22\n")
+	write("order/order.go", `package order
+
+type Order struct {
+	ID string // +const
+}
+`)
+	// Neither file builds on the default configuration of the test.
+	other := "windows"
+	if other == runtime.GOOS {
+		other = "linux"
+	}
+	write("order/rename_"+other+".go", `package order
+
+func Rename(o *Order) {
+	o.ID = "renamed"
+}
+`)
+	write("order/reset.go", `//go:build integration
+
+package order
+
+func Reset(o *Order) {
+	o.ID = ""
+}
+`)
+	write("order/cgo.go", `//go:build cgo && ignore
+
+package order
+`)
+	chdir(t, dir)
+
+	discovered, err := discoverBuilds([]string{"./..."}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(discovered), fmt.Sprintf("[+integration %s]", other); got != want {
+		t.Errorf("discovered %s, want %s", got, want)
+	}
+
+	noSettings := func(string) (map[string]string, error) { return nil, nil }
+	for _, test := range []struct {
+		builds []buildConfig
+		want   []string
+	}{
+		{nil, nil},
+		{
+			append([]buildConfig{{}}, discovered...),
+			[]string{
+				"rename_" + other + ".go:4: assignment to const field Order.ID",
+				"reset.go:6: assignment to const field Order.ID",
+			},
+		},
+		// The field is declared in every configuration but reported once.
+		{
+			[]buildConfig{{goos: other, tags: []string{"integration"}}, {goos: other}},
+			[]string{
+				"rename_" + other + ".go:4: assignment to const field Order.ID",
+				"reset.go:6: assignment to const field Order.ID",
+			},
+		},
+	} {
+		diags, decls, err := lint([]string{"./..."}, true, test.builds, "", noSettings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range diags {
+			message, _, _ := strings.Cut(d.Message, " (")
+			got = append(got, fmt.Sprintf("%s:%d: %s", filepath.Base(d.position.Filename), d.position.Line, message))
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%v: got\n%s\nwant\n%s", test.builds, strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+		}
+		if len(decls) != 1 {
+			t.Errorf("%v: %d const declarations, want 1", test.builds, len(decls))
+		}
+	}
+}
+
+// chdir changes the working directory to dir for the rest of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/packages"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the name of the configuration file of the lint command.
+const configFile = ".constlint.yaml"
+
+// setting is the value a configuration file gives a flag.
+type setting struct {
+	value  string
+	source string // file:line the value comes from
+}
+
+// config maps flag names to their settings.
+type config map[string]setting
+
+// configLoader resolves the effective configuration of directories: the
+// configuration files of a directory and all its parents, merged so that
+// settings of a directory override those of its parents. The profiles a file
+// maps a directory to override the settings of the file itself.
+type configLoader struct {
+	flags     *flag.FlagSet
+	files     map[string]*fileConfig // by absolute directory, nil for none
+	effective map[string]config      // by absolute directory
+}
+
+func newConfigLoader(flags *flag.FlagSet) *configLoader {
+	return &configLoader{flags: flags, files: make(map[string]*fileConfig), effective: make(map[string]config)}
+}
+
+// load returns the effective configuration of dir.
+func (l *configLoader) load(dir string) (config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := l.effective[dir]; ok {
+		return c, nil
+	}
+
+	// dir and its parents, from the root down.
+	dirs := []string{dir}
+	for d := dir; filepath.Dir(d) != d; d = filepath.Dir(d) {
+		dirs = append([]string{filepath.Dir(d)}, dirs...)
+	}
+	c := make(config)
+	for _, d := range dirs {
+		f, err := l.file(d)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			continue
+		}
+		maps.Copy(c, f.settings)
+		rel, err := filepath.Rel(d, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range f.paths {
+			if p.matches(filepath.ToSlash(rel)) {
+				maps.Copy(c, f.profiles[p.profile])
+			}
+		}
+	}
+	l.effective[dir] = c
+	return c, nil
+}
+
+// file returns the configuration file of dir, or nil if it has none.
+func (l *configLoader) file(dir string) (*fileConfig, error) {
+	if f, ok := l.files[dir]; ok {
+		return f, nil
+	}
+	var f *fileConfig
+	path := filepath.Join(dir, configFile)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		if f, err = readConfig(l.flags, path); err != nil {
+			return nil, err
+		}
+	}
+	l.files[dir] = f
+	return f, nil
+}
+
+// fileConfig is the content of a configuration file.
+type fileConfig struct {
+	settings config
+	profiles map[string]config
+	paths    []profilePath // in file order, later ones overriding earlier ones
+}
+
+// profilePath maps the directories matching a pattern to a profile.
+type profilePath struct {
+	pattern, profile string
+}
+
+// matches reports whether the pattern of p matches dir, a slash-separated
+// path relative to the directory of the configuration file. Like package
+// patterns, domain/... matches domain and every directory below it; other
+// patterns match a single directory, with path.Match wildcards.
+func (p profilePath) matches(dir string) bool {
+	if prefix, ok := strings.CutSuffix(p.pattern, "..."); ok {
+		prefix = strings.TrimSuffix(prefix, "/")
+		return prefix == "" || prefix == "." || dir == prefix || strings.HasPrefix(dir, prefix+"/")
+	}
+	matched, _ := path.Match(p.pattern, dir)
+	return matched
+}
+
+// readConfig reads a configuration file: a YAML mapping of flag names to
+// values, such as dead-markers: true, which may also define profiles, named
+// mappings of flag names to values, and map directory patterns to them:
+//
+//	profiles:
+//	  domain:
+//	    complete-constructors: true
+//	paths:
+//	  internal/domain/...: domain
+func readConfig(flags *flag.FlagSet, file string) (*fileConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	source := relativePath(file)
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	f := &fileConfig{settings: make(config), profiles: make(map[string]config)}
+	if len(doc.Content) == 0 {
+		return f, nil // empty, or only comments
+	}
+	settings := doc.Content[0]
+	if settings.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: configuration must be a mapping of flag names to values", source, settings.Line)
+	}
+
+	var paths *yaml.Node
+	var rest []*yaml.Node
+	for i := 0; i+1 < len(settings.Content); i += 2 {
+		key, value := settings.Content[i], settings.Content[i+1]
+		switch key.Value {
+		case "profiles":
+			if value.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s:%d: profiles must map profile names to settings", source, value.Line)
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				name, profile := value.Content[j], value.Content[j+1]
+				if profile.Kind != yaml.MappingNode {
+					return nil, fmt.Errorf("%s:%d: profile %q must be a mapping of flag names to values",
+						source, profile.Line, name.Value)
+				}
+				c, err := readSettings(flags, source, fmt.Sprintf(" (profile %s)", name.Value), profile.Content)
+				if err != nil {
+					return nil, err
+				}
+				f.profiles[name.Value] = c
+			}
+		case "paths":
+			if value.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("%s:%d: paths must map directory patterns to profile names", source, value.Line)
+			}
+			paths = value
+		default:
+			rest = append(rest, key, value)
+		}
+	}
+	if f.settings, err = readSettings(flags, source, "", rest); err != nil {
+		return nil, err
+	}
+	if paths == nil {
+		return f, nil
+	}
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		pattern, profile := paths.Content[i], paths.Content[i+1]
+		if _, err := path.Match(pattern.Value, ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: directory pattern %q: %v", source, pattern.Line, pattern.Value, err)
+		}
+		if _, ok := f.profiles[profile.Value]; !ok || profile.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s:%d: unknown profile %q", source, profile.Line, profile.Value)
+		}
+		f.paths = append(f.paths, profilePath{pattern.Value, profile.Value})
+	}
+	return f, nil
+}
+
+// readSettings reads the settings of a mapping of flag names to values, whose
+// keys and values alternate in nodes. origin is added to the source of each
+// setting.
+func readSettings(flags *flag.FlagSet, source, origin string, nodes []*yaml.Node) (config, error) {
+	c := make(config)
+	for i := 0; i+1 < len(nodes); i += 2 {
+		key, value := nodes[i], nodes[i+1]
+		f := flags.Lookup(key.Value)
+		if f == nil || slices.Contains(commandLineFlags, key.Value) {
+			return nil, fmt.Errorf("%s:%d: unknown setting %q", source, key.Line, key.Value)
+		}
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s:%d: setting %q must be a single value", source, value.Line, key.Value)
+		}
+		if err := validate(f, value.Value); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %v", source, value.Line, key.Value, err)
+		}
+		c[key.Value] = setting{value: value.Value, source: fmt.Sprintf("%s:%d%s", source, key.Line, origin)}
+	}
+	return c, nil
+}
+
+// validate reports whether value is acceptable for f, without changing f.
+func validate(f *flag.Flag, value string) error {
+	previous := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		return err
+	}
+	return f.Value.Set(previous)
+}
+
+// apply sets the flags named by c, except those given on the command line.
+func (c config) apply(flags *flag.FlagSet, commandLine map[string]string) error {
+	for _, name := range c.names() {
+		if _, given := commandLine[name]; given {
+			continue
+		}
+		if err := flags.Set(name, c[name].value); err != nil {
+			return fmt.Errorf("%s: %s: %v", c[name].source, name, err)
+		}
+	}
+	return nil
+}
+
+// names returns the flag names c sets, sorted.
+func (c config) names() []string {
+	var names []string
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// analyzerSettings returns the value of every analyzer flag under c: the
+// command line's when given there, else the configuration's, else the
+// default.
+func (c config) analyzerSettings(commandLine map[string]string) map[string]string {
+	settings := make(map[string]string)
+	analyzer.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		settings[f.Name] = f.DefValue
+		if s, ok := c[f.Name]; ok {
+			settings[f.Name] = s.value
+		}
+		if value, given := commandLine[f.Name]; given {
+			settings[f.Name] = value
+		}
+	})
+	return settings
+}
+
+// writeEffectiveConfig writes the settings of every configurable flag under
+// c, each with where its value comes from.
+func writeEffectiveConfig(w io.Writer, flags *flag.FlagSet, c config, commandLine map[string]string) error {
+	var buf bytes.Buffer
+	flags.VisitAll(func(f *flag.Flag) {
+		if slices.Contains(commandLineFlags, f.Name) {
+			return
+		}
+		value, source := f.DefValue, "default"
+		if s, ok := c[f.Name]; ok {
+			value, source = s.value, s.source
+		}
+		if v, ok := commandLine[f.Name]; ok {
+			value, source = v, "command line"
+		}
+		fmt.Fprintf(&buf, "\t%s: %s # %s\n", f.Name, yamlValue(f, value), source)
+	})
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeConfigTemplate writes a configuration file setting every configurable
+// flag to its default, each explained by its usage.
+func writeConfigTemplate(w io.Writer, flags *flag.FlagSet) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Configuration of constlint, written by constlint init.\n")
+	fmt.Fprintf(&buf, "#\n")
+	fmt.Fprintf(&buf, "# constlint reads %s from the directory of each package and all its\n", configFile)
+	fmt.Fprintf(&buf, "# parents; settings closer to the package override those further up. Each\n")
+	fmt.Fprintf(&buf, "# setting is a command-line flag of constlint, which overrides the setting when\n")
+	fmt.Fprintf(&buf, "# given. The values below are the defaults.\n")
+	flags.VisitAll(func(f *flag.Flag) {
+		if slices.Contains(commandLineFlags, f.Name) {
+			return
+		}
+		_, usage := flag.UnquoteUsage(f)
+		fmt.Fprintf(&buf, "\n# %s\n%s: %s\n", usage, f.Name, yamlValue(f, f.DefValue))
+	})
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// yamlValue formats a value of a flag as a YAML value.
+func yamlValue(f *flag.Flag, value string) string {
+	if getter, ok := f.Value.(flag.Getter); ok {
+		switch getter.Get().(type) {
+		case bool, int:
+			return value
+		}
+	}
+	return strconv.Quote(value)
+}
+
+// relativePath returns path relative to the working directory, when it is
+// below it.
+func relativePath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// settingsKey identifies a set of flag values, for grouping packages
+// analyzed with the same settings.
+func settingsKey(values map[string]string) string {
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var key strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&key, "%s=%q\n", name, values[name])
+	}
+	return key.String()
+}
+
+// restoreFlags records the values of flags and returns a function restoring
+// them.
+func restoreFlags(flags *flag.FlagSet) func() {
+	values := make(map[string]string)
+	flags.VisitAll(func(f *flag.Flag) { values[f.Name] = f.Value.String() })
+	return func() {
+		for name, value := range values {
+			flags.Set(name, value)
+		}
+	}
+}
+
+// packageDir returns the directory of a package's files. Of the files a
+// packages driver reports, it prefers those in the working directory to the
+// generated files of an output tree.
+func packageDir(pkg *packages.Package) string {
+	wd, _ := os.Getwd()
+	for _, files := range [][]string{pkg.GoFiles, pkg.CompiledGoFiles, pkg.OtherFiles} {
+		for _, file := range files {
+			if dir := filepath.Dir(sourcePath(file)); within(wd, dir) && !inOutputTree(dir) {
+				return dir
+			}
+		}
+		if len(files) > 0 {
+			return filepath.Dir(sourcePath(files[0]))
+		}
+	}
+	return "."
+}
+
+// showConfig writes the effective configuration of each package matching
+// patterns.
+func showConfig(w io.Writer, flags *flag.FlagSet, configs *configLoader, commandLine map[string]string,
+	patterns []string) error {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles}, patterns...)
+	if err != nil {
+		return err
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+	for _, pkg := range pkgs {
+		c, err := configs.load(packageDir(pkg))
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s:\n", pkg.PkgPath); err != nil {
+			return err
+		}
+		if err := writeEffectiveConfig(w, flags, c, commandLine); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigTemplate(t *testing.T) {
+	flags, opts := lintFlags()
+	dir := t.TempDir()
+	var template bytes.Buffer
+	if err := writeConfigTemplate(&template, flags); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, configFile), template.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(template.String(), "cpuprofile") {
+		t.Errorf("template sets a profiling flag:\n%s", template.String())
+	}
+
+	// The template holds the defaults, so applying it changes nothing.
+	c, err := newConfigLoader(flags).load(dir)
+	if err == nil {
+		err = c.apply(flags, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.format != "text" || !opts.tests || flags.Lookup("mutator-pattern").Value.String() != "^(Set|Add|Remove|Reset)" {
+		t.Errorf("template changed the defaults: %+v", opts)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	for _, test := range []struct {
+		config string
+		err    string
+	}{
+		{"format: json\nmax-issues: 10\n", ""},
+		{"# nothing set\n", ""},
+		{"formt: json\n", `:1: unknown setting "formt"`},
+		{"cpuprofile: cpu.out\n", `:1: unknown setting "cpuprofile"`},
+		{"format: json\nquiet: maybe\n", `:2: quiet: parse error`},
+		{"mutator-pattern: \"(\"\n", `:1: mutator-pattern: error parsing regexp`},
+		{"group: [file, rule]\n", `:1: setting "group" must be a single value`},
+		{"- format\n", `:1: configuration must be a mapping`},
+		{"profiles:\n  domain:\n    setters: true\npaths:\n  domain/...: domain\n", ""},
+		{"profiles:\n  domain:\n    setter: true\n", `:3: unknown setting "setter"`},
+		{"profiles:\n  domain: strict\n", `:2: profile "domain" must be a mapping`},
+		{"paths:\n  domain/...: domain\n", `:2: unknown profile "domain"`},
+		{"profiles:\n  domain: {}\npaths:\n  \"[\": domain\n", `:4: directory pattern "["`},
+	} {
+		path := filepath.Join(t.TempDir(), configFile)
+		if err := os.WriteFile(path, []byte(test.config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		flags, _ := lintFlags()
+		_, err := readConfig(flags, path)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%q: unexpected error %v", test.config, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%q: got error %v, want %s", test.config, err, test.err)
+		}
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	flags, opts := lintFlags()
+	c := config{"format": {value: "json"}, "max-issues": {value: "10"}}
+	if err := c.apply(flags, map[string]string{"max-issues": "5"}); err != nil {
+		t.Fatal(err)
+	}
+	if opts.format != "json" || opts.maxIssues != 0 {
+		t.Errorf("got format %s and max-issues %d, want json and the command line's value", opts.format, opts.maxIssues)
+	}
+}
+
+func TestConfigInheritance(t *testing.T) {
+	root := t.TempDir()
+	write := func(dir, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if content == "" {
+			return
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, configFile), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".", "dead-markers: true\nsetters: true\n")
+	write("pkg/domain", "complete-constructors: true\n")
+	write("pkg/domain/order", "")
+	write("internal/tools", "dead-markers: false\nsetters: false\n")
+
+	flags, _ := lintFlags()
+	configs := newConfigLoader(flags)
+	for _, test := range []struct {
+		dir, want string
+	}{
+		{".", "dead-markers=true setters=true"},
+		{"pkg/domain/order", "complete-constructors=true dead-markers=true setters=true"},
+		{"internal/tools", "dead-markers=false setters=false"},
+	} {
+		c, err := configs.load(filepath.Join(root, test.dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, name := range c.names() {
+			got = append(got, name+"="+c[name].value)
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Errorf("%s: got %s, want %s", test.dir, strings.Join(got, " "), test.want)
+		}
+	}
+
+	c, _ := configs.load(filepath.Join(root, "pkg/domain/order"))
+	settings := c.analyzerSettings(map[string]string{"setters": "false"})
+	if settings["complete-constructors"] != "true" || settings["setters"] != "false" || settings["const-receivers"] != "false" {
+		t.Errorf("got analyzer settings %v", settings)
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	root := t.TempDir()
+	config := `dead-markers: true
+profiles:
+  domain:
+    complete-constructors: true
+    setters: true
+  adapters:
+    dead-markers: false
+paths:
+  internal/domain/...: domain
+  internal/*/adapters: adapters
+  internal/domain/legacy: adapters
+`
+	if err := os.WriteFile(filepath.Join(root, configFile), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "internal/domain/legacy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A file below a profile's directories overrides the profile.
+	if err := os.WriteFile(filepath.Join(root, "internal/domain/legacy", configFile), []byte("setters: false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags, _ := lintFlags()
+	configs := newConfigLoader(flags)
+	for _, test := range []struct {
+		dir, want string
+	}{
+		{".", "dead-markers=true"},
+		{"internal", "dead-markers=true"},
+		{"internal/domain", "complete-constructors=true dead-markers=true setters=true"},
+		{"internal/domain/order", "complete-constructors=true dead-markers=true setters=true"},
+		{"internal/billing/adapters", "dead-markers=false"},
+		{"internal/domain/legacy", "complete-constructors=true dead-markers=false setters=false"},
+	} {
+		c, err := configs.load(filepath.Join(root, test.dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, name := range c.names() {
+			got = append(got, name+"="+c[name].value)
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Errorf("%s: got %s, want %s", test.dir, strings.Join(got, " "), test.want)
+		}
+	}
+
+	c, _ := configs.load(filepath.Join(root, "internal/domain"))
+	if source := c["setters"].source; !strings.HasSuffix(source, ":5 (profile domain)") {
+		t.Errorf("got source %s for a profile's setting", source)
+	}
+}
@@ -0,0 +1,686 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"go/types"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"github.com/bunniesandbeatings/constlint/gen"
+	"golang.org/x/tools/go/packages"
+)
+
+// defaultSocket is the socket the daemon listens on unless -socket says
+// otherwise, in the working directory.
+const defaultSocket = ".constlint.sock"
+
+// daemonMain serves re-check requests over a Unix socket, keeping the packages
+// it checks loaded in between, or with -check asks a running daemon to check
+// them and prints its diagnostics like the lint command.
+func daemonMain(args []string) int {
+	flags := flag.NewFlagSet("constlint daemon", flag.ExitOnError)
+	analyzer.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		flags.Var(f.Value, f.Name, f.Usage)
+	})
+	socket := flags.String("socket", defaultSocket, "listen on, or with -check connect to, the Unix socket `file`")
+	tests := flags.Bool("test", true, "also check test files")
+	check := flags.Bool("check", false, "ask the running daemon to check its packages and print the diagnostics, only those of the given files if any")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint daemon [-socket file] [-test] [-flag] [package...]\n")
+		fmt.Fprintf(os.Stderr, "       constlint daemon -check [-socket file] [file...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *check {
+		return daemonCheck(*socket, flags.Args())
+	}
+
+	commandLine := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) { commandLine[f.Name] = f.Value.String() })
+	configs := newConfigLoader(flags)
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	d := &daemon{
+		patterns: patterns,
+		tests:    *tests,
+		settings: func(dir string) (map[string]string, error) {
+			c, err := configs.load(dir)
+			if err != nil {
+				return nil, err
+			}
+			return c.analyzerSettings(commandLine), nil
+		},
+	}
+	if err := d.load(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	listener, err := listen(*socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "constlint: %v\n", err)
+		return 1
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "constlint: checking %s, listening on %s\n", strings.Join(patterns, " "), *socket)
+	server := rpc.NewServer()
+	if err := server.RegisterName("Daemon", &daemonService{d}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "constlint: %v\n", err)
+			return 1
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// listen listens on the Unix socket file, replacing a socket left behind by a
+// daemon that is no longer running.
+func listen(socket string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+	if info, err := os.Lstat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(socket)
+	}
+	return net.Listen("unix", socket)
+}
+
+// daemonCheck asks the daemon listening on socket to check its packages and
+// prints the diagnostics in files, or all of them, like the lint command.
+func daemonCheck(socket string, files []string) int {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "constlint: no daemon listening on %s: %v\n", socket, err)
+		return 1
+	}
+	client := jsonrpc.NewClient(conn)
+	defer client.Close()
+
+	args := CheckArgs{}
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		args.Files = append(args.Files, abs)
+	}
+	var reply CheckReply
+	if err := client.Call("Daemon.Check", args, &reply); err != nil {
+		fmt.Fprintf(os.Stderr, "constlint: %v\n", err)
+		return 1
+	}
+	if err := writeText(os.Stderr, reply.Diagnostics, ""); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(reply.Diagnostics) > 0 {
+		return 3
+	}
+	return 0
+}
+
+// daemon keeps the packages matching its patterns loaded, with their
+// dependencies, diagnostics and the facts the analyzer exported for them all,
+// and checks again only the packages a change affects: those holding a
+// changed file and those importing them, directly or not. Those are parsed
+// and type-checked against the dependencies it holds and analyzed with their
+// facts; a change bringing in packages it hasn't loaded yet loads them all
+// again.
+type daemon struct {
+	dir      string // working directory of the packages, the current one if empty
+	patterns []string
+	tests    bool
+	settings func(dir string) (map[string]string, error)
+
+	mu      sync.Mutex
+	fset    *token.FileSet
+	pkgs    map[string]*packages.Package // every package loaded, by ID; nil to load them again
+	roots   map[string]bool              // IDs of the packages matching the patterns
+	facts   *factStore
+	results map[string][]*packageResult // of the packages matching the patterns by path, one per variant
+	diags   map[string][]diagnostic     // by package ID
+	files   map[string]fileStamp        // of the editable packages and dependencies
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// load loads and checks all the packages of the daemon.
+func (d *daemon) load() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.loadAll()
+}
+
+// loadAll loads and checks all the packages of the daemon, replacing those it
+// held. d.mu must be held.
+func (d *daemon) loadAll() error {
+	d.pkgs = nil
+	initial, err := loadPackages(&packages.Config{Mode: packages.NeedModule, Dir: d.dir, Tests: d.tests}, d.patterns)
+	if err != nil {
+		return err
+	}
+	roots, err := runAnalyzer(initial, d.settings)
+	if err != nil {
+		return err
+	}
+	results, err := actionResults(roots)
+	if err != nil {
+		return err
+	}
+
+	d.fset = initial[0].Fset
+	d.roots = make(map[string]bool)
+	d.facts = newFactStore()
+	d.results = make(map[string][]*packageResult)
+	d.diags = make(map[string][]diagnostic)
+	for _, root := range roots {
+		d.roots[root.Package.ID] = true
+		d.facts.addAction(root)
+	}
+	d.update(results)
+	pkgs := make(map[string]*packages.Package)
+	packages.Visit(initial, nil, func(pkg *packages.Package) { pkgs[pkg.ID] = pkg })
+	d.pkgs = pkgs
+	return nil
+}
+
+// update replaces the results of packages matching the patterns, and their
+// diagnostics, with those of a new check, and notes the versions of the files
+// checked.
+func (d *daemon) update(results []*packageResult) {
+	for _, result := range results {
+		// Test mains are generated, and rebuilt with the package they test.
+		if strings.HasSuffix(result.pkg.ID, ".test") {
+			continue
+		}
+		path := testedPath(result.pkg)
+		variants := slices.DeleteFunc(d.results[path], func(r *packageResult) bool { return r.pkg.ID == result.pkg.ID })
+		d.results[path] = append(variants, result)
+		diags, _ := analysisResults([]*packageResult{result})
+		resolveSourcePaths(diags, nil)
+		d.diags[result.pkg.ID] = diags
+	}
+
+	d.files = make(map[string]fileStamp)
+	for _, variants := range d.results {
+		packages.Visit(resultPackages(variants), nil, func(pkg *packages.Package) {
+			if !editable(pkg, d.dir) {
+				return
+			}
+			for _, file := range packageFiles(pkg) {
+				if _, ok := d.files[file]; !ok {
+					d.files[file] = stamp(file)
+				}
+			}
+		})
+	}
+}
+
+// check re-checks the packages affected by the files changed since they were
+// last checked, and returns the paths of those packages and the diagnostics
+// of all of them.
+func (d *daemon) check() (rechecked []string, diags []diagnostic, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	rechecked, err = d.refresh()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pkgDiags := range d.diags {
+		diags = append(diags, pkgDiags...)
+	}
+	diags = dedupe(diags)
+	fingerprint(diags)
+	return rechecked, diags, nil
+}
+
+// refresh re-checks the packages affected by the files changed since they
+// were last checked and returns the paths of those matching the patterns.
+// d.mu must be held.
+func (d *daemon) refresh() ([]string, error) {
+	if d.pkgs == nil {
+		// The last check failed halfway.
+		return d.rootPaths(nil), d.loadAll()
+	}
+	changed := make(map[string]bool)
+	for file, s := range d.files {
+		if stamp(file) != s {
+			changed[file] = true
+		}
+	}
+	// A file added to a package changes its directory.
+	dirs := make(map[string]bool)
+	for file := range d.files {
+		dirs[filepath.Dir(file)] = true
+	}
+	for dir := range dirs {
+		entries, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+		for _, file := range entries {
+			if !d.tests && strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			if _, ok := d.files[file]; !ok {
+				changed[dir] = true
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	affected := d.affected(changed)
+	rechecked := d.rootPaths(affected)
+	if len(affected) == 0 {
+		return nil, nil
+	}
+	err := d.recheck(affected)
+	if errors.Is(err, errNewPackages) {
+		return d.rootPaths(nil), d.loadAll()
+	}
+	if err != nil {
+		d.pkgs = nil
+	}
+	return rechecked, err
+}
+
+// rootPaths returns the sorted paths of the packages matching the patterns
+// among ids, or all of them if ids is nil, the paths of tested packages for
+// their test variants.
+func (d *daemon) rootPaths(ids map[string]bool) []string {
+	paths := make(map[string]bool)
+	for id := range d.roots {
+		if (ids == nil || ids[id]) && !strings.HasSuffix(id, ".test") {
+			if pkg, ok := d.pkgs[id]; ok {
+				paths[testedPath(pkg)] = true
+			}
+		}
+	}
+	var sorted []string
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// affected returns the IDs of the loaded packages holding one of the changed
+// files or directories, and of those importing them, directly or not.
+func (d *daemon) affected(changed map[string]bool) map[string]bool {
+	importers := make(map[string][]string)
+	var queue []string
+	for id, pkg := range d.pkgs {
+		for _, imp := range pkg.Imports {
+			importers[imp.ID] = append(importers[imp.ID], id)
+		}
+		for _, file := range packageFiles(pkg) {
+			if changed[file] || changed[filepath.Dir(file)] {
+				queue = append(queue, id)
+				break
+			}
+		}
+	}
+	affected := make(map[string]bool)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if !affected[id] {
+			affected[id] = true
+			queue = append(queue, importers[id]...)
+		}
+	}
+	return affected
+}
+
+// recheck loads the files and imports of the affected packages again, and
+// type-checks and analyzes them, dependencies first, against the packages and
+// facts the daemon holds for the others. It returns errNewPackages if they
+// need packages it doesn't hold. d.mu must be held.
+func (d *daemon) recheck(affected map[string]bool) error {
+	var patterns []string
+	if packagesDriver() != "" {
+		// Drivers may not take import paths, but all take files.
+		var files []string
+		for id := range affected {
+			files = append(files, packageFiles(d.pkgs[id])...)
+		}
+		patterns = filePatterns(files)
+	} else {
+		paths := make(map[string]bool)
+		for id := range affected {
+			// Test variants and test mains are loaded with the package they test.
+			paths[strings.TrimSuffix(testedPath(d.pkgs[id]), ".test")] = true
+		}
+		for path := range paths {
+			patterns = append(patterns, path)
+		}
+		sort.Strings(patterns)
+	}
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports |
+		packages.NeedModule | packages.NeedTypesSizes | packages.NeedEmbedFiles
+	loaded, err := packages.Load(&packages.Config{Mode: mode, Dir: d.dir, Tests: d.tests},
+		patterns...)
+	if err != nil {
+		return err
+	}
+	metas := make(map[string]*packages.Package)
+	for _, meta := range loaded {
+		metas[meta.ID] = meta
+	}
+	for id := range affected {
+		if metas[id] == nil {
+			return errNewPackages
+		}
+	}
+
+	// Dependencies first, so their importers get their new types.
+	var order []string
+	visited := make(map[string]bool)
+	var visit func(id string)
+	visit = func(id string) {
+		if visited[id] || !affected[id] {
+			return
+		}
+		visited[id] = true
+		for _, imp := range metas[id].Imports {
+			visit(imp.ID)
+		}
+		order = append(order, id)
+	}
+	ids := make([]string, 0, len(affected))
+	for id := range affected {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		visit(id)
+	}
+
+	var checked []*packages.Package
+	old := make(map[string]*types.Package)
+	for _, id := range order {
+		pkg, err := typeCheck(metas[id], d.pkgs, d.fset)
+		if err != nil {
+			return err
+		}
+		old[id] = d.pkgs[id].Types
+		d.pkgs[id] = pkg
+		checked = append(checked, pkg)
+	}
+	if _, err := checkLoaded(checked, patterns); err != nil {
+		return err
+	}
+
+	// The analyzer reads its flags from package variables, so runs with
+	// other settings must wait for this one to finish.
+	analyzerRuns.Lock()
+	defer analyzerRuns.Unlock()
+	reset := restoreFlags(&analyzer.Analyzer.Flags)
+	defer reset()
+	var results []*packageResult
+	for _, pkg := range checked {
+		reset()
+		values, err := d.settings(packageDir(pkg))
+		if err != nil {
+			return err
+		}
+		for name, value := range values {
+			if err := analyzer.Analyzer.Flags.Set(name, value); err != nil {
+				return err
+			}
+		}
+		d.facts.forget(old[pkg.ID])
+		result, err := analyzePackage(pkg, d.facts)
+		if err != nil {
+			return err
+		}
+		if d.roots[pkg.ID] {
+			results = append(results, result)
+		}
+	}
+	d.update(results)
+	return nil
+}
+
+// resultPackages returns the packages of results.
+func resultPackages(results []*packageResult) []*packages.Package {
+	pkgs := make([]*packages.Package, len(results))
+	for i, result := range results {
+		pkgs[i] = result.pkg
+	}
+	return pkgs
+}
+
+// editable reports whether the files of a package may change while the
+// daemon runs: those of the main module and of modules replaced by a
+// directory, unlike the standard library and the module cache. Packages a
+// packages driver loads have no module, and are editable in dir.
+func editable(pkg *packages.Package, dir string) bool {
+	if pkg.Module == nil {
+		if packagesDriver() == "" {
+			return false
+		}
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+		files := packageFiles(pkg)
+		return len(files) > 0 && within(dir, sourcePath(files[0]))
+	}
+	return pkg.Module.Main || pkg.Module.Replace != nil && pkg.Module.Replace.Version == ""
+}
+
+// testedPath returns the path of a package, or for a test variant or an
+// external test package, of the package it tests, which loads them all.
+func testedPath(pkg *packages.Package) string {
+	if pkg.ForTest != "" {
+		return pkg.ForTest
+	}
+	return pkg.PkgPath
+}
+
+// packageFiles returns the Go files of a package, as absolute paths.
+func packageFiles(pkg *packages.Package) []string {
+	var files []string
+	for _, file := range append(pkg.GoFiles, pkg.IgnoredFiles...) {
+		if abs, err := filepath.Abs(file); err == nil {
+			files = append(files, abs)
+		}
+	}
+	return files
+}
+
+// stamp returns the current version of a file, the zero fileStamp if it no
+// longer exists.
+func stamp(file string) fileStamp {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{info.Size(), info.ModTime()}
+}
+
+// CheckArgs are the arguments of Daemon.Check.
+type CheckArgs struct {
+	// Files restricts the diagnostics returned to those of these files,
+	// given as absolute paths.
+	Files []string
+}
+
+// CheckReply is the reply of Daemon.Check.
+type CheckReply struct {
+	Diagnostics []diagnostic
+	// Rechecked lists the packages checked again because of changes.
+	Rechecked []string
+}
+
+// daemonService is the RPC service of the daemon, registered as Daemon.
+type daemonService struct {
+	d *daemon
+}
+
+// Check re-checks the packages affected by changes since the last check and
+// returns the diagnostics of all the packages of the daemon.
+func (s *daemonService) Check(args CheckArgs, reply *CheckReply) error {
+	rechecked, diags, err := s.d.check()
+	if err != nil {
+		return err
+	}
+	if len(args.Files) > 0 {
+		files := make(map[string]bool)
+		for _, file := range args.Files {
+			files[file] = true
+		}
+		diags = inFiles(diags, files)
+	}
+	reply.Diagnostics = diags
+	if reply.Diagnostics == nil {
+		reply.Diagnostics = []diagnostic{}
+	}
+	reply.Rechecked = rechecked
+	return nil
+}
+
+// ConstnessArgs are the arguments of Daemon.Constness.
+type ConstnessArgs struct {
+	// Symbol is a package path followed by Type.Field, Func.param,
+	// Type.Method.param or Var, e.g. example.com/shop.Order.ID.
+	Symbol string
+}
+
+// Constness returns whether the field, parameter or package-level variable
+// a symbol names is const, with where its marker is and why.
+func (s *daemonService) Constness(args ConstnessArgs, reply *Constness) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, err := s.d.refresh(); err != nil {
+		return err
+	}
+	c, err := lookupConstness(s.d.results, args.Symbol, token.Position.String)
+	if err != nil {
+		return err
+	}
+	*reply = c
+	return nil
+}
+
+// ViolationsArgs are the arguments of Daemon.Violations.
+type ViolationsArgs struct {
+	Package string // package path
+}
+
+// ViolationsReply is the reply of Daemon.Violations.
+type ViolationsReply struct {
+	Diagnostics []diagnostic
+}
+
+// Violations returns the diagnostics of a package.
+func (s *daemonService) Violations(args ViolationsArgs, reply *ViolationsReply) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, err := s.d.refresh(); err != nil {
+		return err
+	}
+	checked := false
+	var diags []diagnostic
+	for _, variants := range s.d.results {
+		for _, result := range variants {
+			if result.pkg.PkgPath == args.Package {
+				checked = true
+				diags = append(diags, s.d.diags[result.pkg.ID]...)
+			}
+		}
+	}
+	if !checked {
+		return fmt.Errorf("package %s is not checked by the daemon", args.Package)
+	}
+	reply.Diagnostics = append([]diagnostic{}, dedupe(diags)...)
+	fingerprint(reply.Diagnostics)
+	return nil
+}
+
+// MarkersArgs are the arguments of Daemon.Markers.
+type MarkersArgs struct {
+	File string // absolute path
+}
+
+// MarkersReply is the reply of Daemon.Markers.
+type MarkersReply struct {
+	Markers []markerInfo
+}
+
+// markerInfo is a marker found in a file.
+type markerInfo struct {
+	Marker string // as written, e.g. +const:shallow
+	Name   string // the marker a synonym stands for, e.g. +const
+	Arg    string
+	Reason string
+	Posn   string
+}
+
+// Markers returns the markers the analyzer understands in a file of the
+// checked packages.
+func (s *daemonService) Markers(args MarkersArgs, reply *MarkersReply) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, err := s.d.refresh(); err != nil {
+		return err
+	}
+	for _, variants := range s.d.results {
+		for _, result := range variants {
+			fset := result.pkg.Fset
+			for _, file := range result.pkg.Syntax {
+				if (&gen.Package{Package: result.pkg}).SourceFile(file) != args.File {
+					continue
+				}
+				reply.Markers = []markerInfo{}
+				for _, m := range analyzer.Markers(file) {
+					reply.Markers = append(reply.Markers, markerInfo{
+						Marker: m.Text,
+						Name:   m.Name,
+						Arg:    m.Arg,
+						Reason: m.Reason,
+						Posn:   fset.Position(m.Pos).String(),
+					})
+				}
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s is not a file of the packages checked by the daemon", args.File)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes a file of the module in dir for a daemon to pick up.
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Make the change visible to file systems with coarse timestamps.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveDaemon starts a daemon checking the packages of the module in dir and
+// returns a client connected to it.
+func serveDaemon(t *testing.T, dir string) *rpc.Client {
+	t.Helper()
+	d := &daemon{
+		dir:      dir,
+		patterns: []string{"./..."},
+		settings: func(string) (map[string]string, error) { return nil, nil },
+	}
+	if err := d.load(); err != nil {
+		t.Fatal(err)
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("Daemon", &daemonService{d}); err != nil {
+		t.Fatal(err)
+	}
+	serverConn, clientConn := net.Pipe()
+	go server.ServeCodec(jsonrpc.NewServerCodec(serverConn))
+	client := jsonrpc.NewClient(clientConn)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestDaemon(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) { writeFile(t, dir, name, content) }
+	write("go.mod", "module example.com/shop\n\ngo 1.22\n")
+	write("order/order.go", `package order
+
+type Order struct {
+	ID string // +const
+}
+
+func Rename(o *Order) {
+	o.ID = "renamed"
+}
+`)
+	write("app/app.go", `package app
+
+import "example.com/shop/order"
+
+func Reset(o *order.Order) {
+	*o = order.Order{}
+}
+`)
+
+	client := serveDaemon(t, dir)
+	check := func(wantRechecked []string, wantMessages ...string) {
+		t.Helper()
+		var reply CheckReply
+		if err := client.Call("Daemon.Check", CheckArgs{}, &reply); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Join(reply.Rechecked, " "), strings.Join(wantRechecked, " "); got != want {
+			t.Errorf("rechecked %q, want %q", got, want)
+		}
+		var messages []string
+		for _, diag := range reply.Diagnostics {
+			messages = append(messages, filepath.Base(diag.Posn)+": "+diag.Message)
+		}
+		if got, want := strings.Join(messages, "\n"), strings.Join(wantMessages, "\n"); got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	}
+
+	check(nil, "order.go:8:2: assignment to const field Order.ID")
+
+	// A change to a package checks it and its importers again.
+	write("order/order.go", `package order
+
+type Order struct {
+	ID string // +const
+}
+
+func Rename(o *Order) {
+	o.ID = "renamed"
+}
+
+func Clear(o *Order) {
+	o.ID = ""
+}
+`)
+	check([]string{"example.com/shop/app", "example.com/shop/order"},
+		"order.go:8:2: assignment to const field Order.ID",
+		"order.go:12:2: assignment to const field Order.ID")
+
+	// A file added to a package checks it again, but not the packages it
+	// imports.
+	write("app/cart.go", `package app
+
+type Cart struct {
+	Owner string // +const
+}
+
+func Transfer(c *Cart) {
+	c.Owner = "someone"
+}
+`)
+	check([]string{"example.com/shop/app"},
+		"cart.go:8:2: assignment to const field Cart.Owner",
+		"order.go:8:2: assignment to const field Order.ID",
+		"order.go:12:2: assignment to const field Order.ID")
+
+	check(nil,
+		"cart.go:8:2: assignment to const field Cart.Owner",
+		"order.go:8:2: assignment to const field Order.ID",
+		"order.go:12:2: assignment to const field Order.ID")
+}
+
+func TestDaemonKeepsDependencies(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/shop\n\ngo 1.22\n")
+	writeFile(t, dir, "order/order.go", `package order
+
+// +const
+var Limit = 10
+`)
+	writeFile(t, dir, "app/app.go", `package app
+
+import "example.com/shop/order"
+
+func Raise() {
+	order.Limit = 20
+}
+`)
+	d := &daemon{
+		dir:      dir,
+		patterns: []string{"./..."},
+		settings: func(string) (map[string]string, error) { return nil, nil },
+	}
+	if err := d.load(); err != nil {
+		t.Fatal(err)
+	}
+	before := d.pkgs["example.com/shop/order"]
+
+	// The change checks app again against the order it holds, with the
+	// facts it exported.
+	writeFile(t, dir, "app/app.go", `package app
+
+import "example.com/shop/order"
+
+func Raise(by int) {
+	order.Limit += by
+}
+`)
+	check := func(wantRechecked ...string) {
+		t.Helper()
+		rechecked, diags, err := d.check()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Join(rechecked, " "), strings.Join(wantRechecked, " "); got != want {
+			t.Errorf("rechecked %q, want %q", got, want)
+		}
+		if len(diags) != 1 || diags[0].Message != "assignment to const variable order.Limit" {
+			t.Errorf("diagnostics = %+v", diags)
+		}
+	}
+	check("example.com/shop/app")
+	if d.pkgs["example.com/shop/order"] != before {
+		t.Error("order was loaded again")
+	}
+
+	// A new import loads everything again.
+	writeFile(t, dir, "app/app.go", `package app
+
+import (
+	"strings"
+
+	"example.com/shop/order"
+)
+
+func Raise(by string) {
+	order.Limit += len(strings.TrimSpace(by))
+}
+`)
+	check("example.com/shop/app", "example.com/shop/order")
+	if d.pkgs["strings"] == nil {
+		t.Error("strings was not loaded")
+	}
+}
+
+func TestDaemonQueries(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "go.mod", "module example.com/shop\n\ngo 1.22\n")
+	writeFile(t, dir, "order/order.go", `package order
+
+type Order struct {
+	ID    string // +const reason="orders are looked up by ID"
+	Notes string
+}
+
+// +const:shallow
+var Default = &Order{}
+
+// Rename renames an order.
+// +const:[o]
+func Rename(o *Order, id string) {
+	o.ID = id
+}
+`)
+	client := serveDaemon(t, dir)
+
+	for _, test := range []struct {
+		symbol string
+		want   string
+	}{
+		{"example.com/shop/order.Order.ID", "field const shallow orders are looked up by ID order.go:4:2 order.go:4:18"},
+		{"example.com/shop/order.Order.Notes", "field mutable order.go:5:2"},
+		{"example.com/shop/order.Rename.o", "parameter const order.go:13:13"},
+		{"example.com/shop/order.Rename.id", "parameter mutable order.go:13:23"},
+		{"example.com/shop/order.Default", "variable const shallow order.go:9:5"},
+		{"example.com/shop/order.Order.Total", "error: example.com/shop/order declares no field, parameter or variable Order.Total"},
+		{"example.com/other.Order.ID", "error: no package checked declares example.com/other.Order.ID"},
+	} {
+		var c Constness
+		got := ""
+		if err := client.Call("Daemon.Constness", ConstnessArgs{Symbol: test.symbol}, &c); err != nil {
+			got = "error: " + err.Error()
+		} else {
+			fields := []string{c.Kind, "mutable"}
+			if c.Const {
+				fields[1] = "const"
+			}
+			for _, field := range []string{c.Mode, c.Reason, filepath.Base(c.Declared), filepath.Base(c.Marker)} {
+				if field != "" && field != "." {
+					fields = append(fields, field)
+				}
+			}
+			got = strings.Join(fields, " ")
+		}
+		if got != test.want {
+			t.Errorf("Constness(%s) = %q, want %q", test.symbol, got, test.want)
+		}
+	}
+
+	var violations ViolationsReply
+	if err := client.Call("Daemon.Violations", ViolationsArgs{Package: "example.com/shop/order"}, &violations); err != nil {
+		t.Fatal(err)
+	}
+	if len(violations.Diagnostics) != 1 || violations.Diagnostics[0].Message != "assignment to const field Order.ID "+
+		"(const because: orders are looked up by ID)" {
+		t.Errorf("Violations = %+v", violations.Diagnostics)
+	}
+
+	var markers MarkersReply
+	if err := client.Call("Daemon.Markers", MarkersArgs{File: filepath.Join(dir, "order", "order.go")}, &markers); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range markers.Markers {
+		got = append(got, filepath.Base(m.Posn)+" "+m.Marker+" "+m.Reason)
+	}
+	want := []string{
+		"order.go:4:18 +const orders are looked up by ID",
+		"order.go:8:4 +const:shallow ",
+		"order.go:12:4 +const:[o] ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Markers: got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
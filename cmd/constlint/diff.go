@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// diffMain compares the diagnostics of two revisions, each given as a file
+// written by -format json or as a git ref to analyze, and prints those the
+// second adds and removes. Like lint, it exits with 3 if there are new
+// diagnostics.
+func diffMain(args []string) int {
+	flags := flag.NewFlagSet("constlint diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the added and removed diagnostics as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint diff [-json] old new [-flag] [package...]\n\n"+
+			"old and new are JSON results of constlint -format json, or git refs to analyze with the\n"+
+			"flags and packages that follow them.\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return 2
+	}
+	lintArgs := flags.Args()[2:]
+	if len(lintArgs) > 0 && isFile(flags.Arg(0)) && isFile(flags.Arg(1)) {
+		fmt.Fprintf(os.Stderr, "constlint diff: flags and packages only apply to git refs, not result files\n")
+		return 2
+	}
+
+	var results [2][]diagnostic
+	for i, revision := range flags.Args()[:2] {
+		var err error
+		results[i], err = loadResults(revision, lintArgs)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	added, removed := diffResults(results[0], results[1])
+	var err error
+	if *asJSON {
+		err = writeDiffJSON(os.Stdout, added, removed)
+	} else {
+		err = writeDiff(os.Stdout, added, removed)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(added) > 0 {
+		return 3
+	}
+	return 0
+}
+
+// loadResults returns the diagnostics of a revision: those of the JSON result
+// file it names, or else those constlint reports, given lintArgs, in a
+// checkout of the git ref it names.
+func loadResults(revision string, lintArgs []string) ([]diagnostic, error) {
+	if isFile(revision) {
+		data, err := os.ReadFile(revision)
+		if err != nil {
+			return nil, err
+		}
+		return parseResults(revision, data)
+	}
+
+	commit, err := git("rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither a result file nor a git ref", revision)
+	}
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "constlint-diff-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	worktree := filepath.Join(tmp, "src")
+	if _, err := git("worktree", "add", "--detach", worktree, strings.TrimSpace(commit)); err != nil {
+		return nil, err
+	}
+	defer git("worktree", "remove", "--force", worktree)
+
+	dir := filepath.Join(worktree, filepath.FromSlash(strings.TrimSpace(prefix)))
+	diags, err := runLint(dir, revision, lintArgs)
+	if err != nil {
+		return nil, err
+	}
+	// The checkout is gone by the time the diagnostics are printed, so they
+	// are positioned relative to the directory it was analyzed from, which
+	// stands for the working directory.
+	relocate := func(posn *string) {
+		file, lineCol := splitPosn(*posn)
+		if rel, err := filepath.Rel(dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			*posn = rel + lineCol
+		}
+	}
+	for i := range diags {
+		relocate(&diags[i].Posn)
+		relocate(&diags[i].End)
+		for j := range diags[i].Related {
+			relocate(&diags[i].Related[j].Posn)
+		}
+	}
+	return diags, nil
+}
+
+// runLint returns the diagnostics constlint reports, given lintArgs, when run
+// in dir on what name refers to.
+func runLint(dir, name string, lintArgs []string) ([]diagnostic, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(exe, append([]string{"-format", "json", "-max-issues", "0"}, lintArgs...)...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// Like lint, the run exits with 3 when it reports diagnostics.
+	var exitErr *exec.ExitError
+	if err := cmd.Run(); err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 3) {
+		return nil, fmt.Errorf("analyzing %s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseResults(name, stdout.Bytes())
+}
+
+// isFile reports whether a revision names a result file rather than a git ref.
+func isFile(revision string) bool {
+	_, err := os.Stat(revision)
+	return err == nil
+}
+
+// parseResults decodes the diagnostics printed by -format json, without
+// -group.
+func parseResults(name string, data []byte) ([]diagnostic, error) {
+	var diags []diagnostic
+	if err := json.Unmarshal(data, &diags); err != nil {
+		return nil, fmt.Errorf("%s: not the JSON output of constlint without -group: %v", name, err)
+	}
+	return diags, nil
+}
+
+// diffResults returns the diagnostics of new that old doesn't have, and those
+// of old that new doesn't have. Diagnostics are told apart by their
+// fingerprints, or, when either side comes from a result file written before
+// constlint had them, by their rule, package, file name and message; not by
+// their line, so that code moving around a file isn't taken for fixed and new
+// violations, nor by the directory of the checkout analyzed. Of n identical
+// diagnostics in one and m < n in the other, the last n-m are added or
+// removed.
+func diffResults(old, new []diagnostic) (added, removed []diagnostic) {
+	fingerprinted := !slices.ContainsFunc(slices.Concat(old, new), func(d diagnostic) bool { return d.Fingerprint == "" })
+	key := func(d diagnostic) string {
+		if fingerprinted {
+			return d.Fingerprint
+		}
+		file, _ := splitPosn(d.Posn)
+		return d.Category + "\x00" + d.Package + "\x00" + filepath.Base(file) + "\x00" + d.Message
+	}
+	count := func(diags []diagnostic) map[string]int {
+		counts := make(map[string]int)
+		for _, d := range diags {
+			counts[key(d)]++
+		}
+		return counts
+	}
+	unmatched := func(diags []diagnostic, others map[string]int) []diagnostic {
+		var kept []diagnostic
+		for _, d := range diags {
+			if others[key(d)] > 0 {
+				others[key(d)]--
+				continue
+			}
+			kept = append(kept, d)
+		}
+		return kept
+	}
+	return unmatched(new, count(old)), unmatched(old, count(new))
+}
+
+// splitPosn splits a position formatted as file:line:col, or file:line, into
+// the file name and the rest.
+func splitPosn(posn string) (file, lineCol string) {
+	file = posn
+	for i := 0; i < 2; i++ {
+		j := strings.LastIndex(file, ":")
+		if j < 0 || strings.Trim(file[j+1:], "0123456789") != "" {
+			break
+		}
+		file = file[:j]
+	}
+	return file, posn[len(file):]
+}
+
+// writeDiff prints the added diagnostics prefixed with +, the removed ones
+// with -, and a line counting both, e.g.
+//
+//	3 new diagnostics, 5 fixed
+func writeDiff(w io.Writer, added, removed []diagnostic) error {
+	for _, d := range added {
+		if _, err := fmt.Fprintf(w, "+ %s: %s\n", relativePosn(d.Posn), d.Message); err != nil {
+			return err
+		}
+	}
+	for _, d := range removed {
+		if _, err := fmt.Fprintf(w, "- %s: %s\n", relativePosn(d.Posn), d.Message); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s, %d fixed\n", plural(len(added), "new diagnostic"), len(removed))
+	return err
+}
+
+// relativePosn is relativePosition for formatted positions.
+func relativePosn(posn string) string {
+	file, lineCol := splitPosn(posn)
+	return relativePath(file) + lineCol
+}
+
+// writeDiffJSON prints the added and removed diagnostics as a JSON object.
+func writeDiffJSON(w io.Writer, added, removed []diagnostic) error {
+	v := struct {
+		Added   []diagnostic `json:"added"`
+		Removed []diagnostic `json:"removed"`
+	}{[]diagnostic{}, []diagnostic{}}
+	v.Added = append(v.Added, added...)
+	v.Removed = append(v.Removed, removed...)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
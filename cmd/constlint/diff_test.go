@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	d := func(posn, message string) diagnostic {
+		return diagnostic{Package: "example.com/shop", Posn: posn, Category: "field-write", Message: message}
+	}
+	old := []diagnostic{
+		d("/old/shop/order.go:10:2", "assignment to const field Order.ID"),
+		d("/old/shop/order.go:20:2", "assignment to const field Order.ID"),
+		d("/old/shop/order.go:30:2", "assignment to const field Order.Items"),
+	}
+	new := []diagnostic{
+		// Moved down by the new lines above it, and checked out elsewhere.
+		d("/new/shop/order.go:14:2", "assignment to const field Order.ID"),
+		d("/new/shop/order.go:24:2", "assignment to const field Order.ID"),
+		d("/new/shop/order.go:28:2", "assignment to const field Order.ID"),
+		d("/new/shop/cart.go:5:2", "assignment to const field Order.Items"),
+	}
+
+	added, removed := diffResults(old, new)
+	var out bytes.Buffer
+	if err := writeDiff(&out, added, removed); err != nil {
+		t.Fatal(err)
+	}
+	want := "+ /new/shop/order.go:28:2: assignment to const field Order.ID\n" +
+		"+ /new/shop/cart.go:5:2: assignment to const field Order.Items\n" +
+		"- /old/shop/order.go:30:2: assignment to const field Order.Items\n" +
+		"2 new diagnostics, 1 fixed\n"
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestSplitPosn(t *testing.T) {
+	for posn, want := range map[string]string{
+		"shop/order.go:10:2":     "shop/order.go",
+		`C:\shop\order.go:10:2`:  `C:\shop\order.go`,
+		"shop/order.go:10":       "shop/order.go",
+		"shop/order.go":          "shop/order.go",
+		"shop/v1:2/order.go:3:4": "shop/v1:2/order.go",
+	} {
+		if file, lineCol := splitPosn(posn); file != want || file+lineCol != posn {
+			t.Errorf("splitPosn(%q) = %q, %q; want file %q", posn, file, lineCol, want)
+		}
+	}
+}
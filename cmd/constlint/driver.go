@@ -0,0 +1,162 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Build systems such as Bazel, Please and Pants load packages for go/packages
+// through a driver named by GOPACKAGESDRIVER, or found as gopackagesdriver on
+// the PATH. Their drivers report source files through the symbolic links of
+// a sandbox or execution root, and generated files in an output tree of their
+// own, which the workspace links to, as bazel-out and bazel-bin.
+
+// packagesDriver returns the driver go/packages loads packages with, or "" for
+// the go command.
+func packagesDriver() string {
+	driver := os.Getenv("GOPACKAGESDRIVER")
+	switch driver {
+	case "off":
+		return ""
+	case "":
+		driver, _ = exec.LookPath("gopackagesdriver")
+	}
+	return driver
+}
+
+// sourcePaths caches the paths sourcePath resolves, and the links of the
+// working directory to output trees, by their targets.
+var sourcePaths = struct {
+	sync.Mutex
+	resolved map[string]string
+	links    map[string]string
+}{resolved: make(map[string]string)}
+
+// sourcePath returns the path to show for a file a driver reported: the file
+// it links to, and for a file in an output tree the workspace links to, its
+// path through the link, as in bazel-out/k8-fastbuild/bin/order/order.pb.go.
+// With the go command, it returns name.
+func sourcePath(name string) string {
+	if name == "" || packagesDriver() == "" {
+		return name
+	}
+	sourcePaths.Lock()
+	defer sourcePaths.Unlock()
+	if path, ok := sourcePaths.resolved[name]; ok {
+		return path
+	}
+	path, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		path = name
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		path = name
+	}
+	if sourcePaths.links == nil {
+		sourcePaths.links = outputLinks()
+	}
+	if target, link := longestPrefix(sourcePaths.links, path); target != "" {
+		path = filepath.Join(link, strings.TrimPrefix(path, target))
+	}
+	sourcePaths.resolved[name] = path
+	return path
+}
+
+// outputLinks returns the symbolic links to directories outside the working
+// directory it holds, by their targets.
+func outputLinks() map[string]string {
+	links := make(map[string]string)
+	wd, err := os.Getwd()
+	if err != nil {
+		return links
+	}
+	entries, err := os.ReadDir(wd)
+	if err != nil {
+		return links
+	}
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		link := filepath.Join(wd, entry.Name())
+		target, err := filepath.EvalSymlinks(link)
+		if err != nil || within(wd, target) {
+			continue
+		}
+		if info, err := os.Stat(target); err == nil && info.IsDir() {
+			links[target] = link
+		}
+	}
+	return links
+}
+
+// longestPrefix returns the longest of the directories keying links that
+// holds path, and its link.
+func longestPrefix(links map[string]string, path string) (dir, link string) {
+	for target, l := range links {
+		if within(target, path) && len(target) > len(dir) {
+			dir, link = target, l
+		}
+	}
+	return dir, link
+}
+
+// inOutputTree reports whether a path sourcePath returned lies in an output
+// tree the working directory links to.
+func inOutputTree(path string) bool {
+	sourcePaths.Lock()
+	defer sourcePaths.Unlock()
+	for _, link := range sourcePaths.links {
+		if within(link, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path is dir or lies below it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveSourcePaths replaces the file names of the positions of diagnostics
+// and const declarations by the paths sourcePath returns for them.
+func resolveSourcePaths(diags []diagnostic, decls map[token.Position]constDecl) map[token.Position]constDecl {
+	if packagesDriver() == "" {
+		return decls
+	}
+	resolve := func(p *token.Position) { p.Filename = sourcePath(p.Filename) }
+	for i := range diags {
+		d := &diags[i]
+		resolve(&d.position)
+		resolve(&d.end)
+		resolve(&d.decl)
+		d.Posn, d.End = d.position.String(), d.end.String()
+		for j := range d.Related {
+			r := &d.Related[j]
+			resolve(&r.position)
+			r.Posn = r.position.String()
+		}
+	}
+	resolved := make(map[token.Position]constDecl, len(decls))
+	for position, decl := range decls {
+		resolve(&position)
+		resolved[position] = decl
+	}
+	return resolved
+}
+
+// filePatterns returns the file= patterns loading the packages of files,
+// which all drivers understand.
+func filePatterns(files []string) []string {
+	patterns := make([]string, len(files))
+	for i, file := range files {
+		patterns[i] = "file=" + file
+	}
+	return patterns
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackagesDriver(t *testing.T) {
+	driver := filepath.Join(t.TempDir(), "driver")
+	if out, err := exec.Command("go", "build", "-o", driver, "./testdata/driver").CombinedOutput(); err != nil {
+		t.Fatalf("building the driver: %v\n%s", err, out)
+	}
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	workspace, output, execroot := filepath.Join(root, "workspace"), filepath.Join(root, "output"), filepath.Join(root, "execroot")
+	writeFile(t, workspace, "go.mod", "module example.com/shop\n\ngo 1.22\n")
+	writeFile(t, workspace, "order/order.go", `package order
+
+type Order struct {
+	ID string // +const
+}
+
+func Rename(o *Order) {
+	o.ID = "renamed"
+}
+`)
+	writeFile(t, output, "bin/order/order_gen.go", `// Code generated by ordergen. DO NOT EDIT.
+
+package order
+
+func Reset(o *Order) {
+	o.ID = ""
+}
+`)
+	for link, target := range map[string]string{filepath.Join(workspace, "bazel-out"): output, execroot: workspace} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skip(err)
+		}
+	}
+	chdir(t, workspace)
+	t.Setenv("GOPACKAGESDRIVER", driver)
+	t.Setenv("DRIVER_EXECROOT", execroot)
+	t.Setenv("DRIVER_OUTPUT", output)
+	resetSourcePaths(t)
+
+	want := []string{
+		"bazel-out/bin/order/order_gen.go:6: assignment to const field Order.ID",
+		"order/order.go:8: assignment to const field Order.ID",
+	}
+	noSettings := func(string) (map[string]string, error) { return nil, nil }
+	for _, patterns := range [][]string{{"./..."}, packagePatterns([]string{filepath.Join(workspace, "order/order.go")})} {
+		diags, decls, err := lint(patterns, false, nil, "", noSettings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range diags {
+			rel, err := filepath.Rel(workspace, d.position.Filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			message, _, _ := strings.Cut(d.Message, " (")
+			got = append(got, fmt.Sprintf("%s:%d: %s", filepath.ToSlash(rel), d.position.Line, message))
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%v: got\n%s\nwant\n%s", patterns, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+		for position := range decls {
+			if position.Filename != filepath.Join(workspace, "order/order.go") {
+				t.Errorf("%v: const declaration at %s", patterns, position)
+			}
+		}
+	}
+}
+
+// resetSourcePaths empties the cache of sourcePath for the rest of the test.
+func resetSourcePaths(t *testing.T) {
+	t.Helper()
+	clear := func() {
+		sourcePaths.Lock()
+		defer sourcePaths.Unlock()
+		sourcePaths.resolved, sourcePaths.links = make(map[string]string), nil
+	}
+	clear()
+	t.Cleanup(clear)
+}
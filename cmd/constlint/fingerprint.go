@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// fingerprint sets the Fingerprint of the diagnostics, which are sorted by
+// position: a hash of their rule, package, file name, message and the code of
+// the line they are reported at, with blanks normalized. It doesn't change as
+// lines move around a file, nor with the directory the code is checked out
+// in. The diagnostics of a file that would share a fingerprint are numbered
+// in order, so each has its own.
+func fingerprint(diags []diagnostic) {
+	sources := make(map[string][][]byte)
+	seen := make(map[string]int)
+	for i := range diags {
+		d := &diags[i]
+		file := d.position.Filename
+		lines, ok := sources[file]
+		if !ok {
+			// A file that can't be read, as from a packages driver's
+			// remote cache, is fingerprinted without its code.
+			if src, err := readSource(file); err == nil {
+				lines = bytes.Split(src, []byte("\n"))
+			}
+			sources[file] = lines
+		}
+		var code string
+		if line := d.position.Line; line > 0 && line <= len(lines) {
+			code = strings.Join(strings.Fields(string(lines[line-1])), " ")
+		}
+		key := strings.Join([]string{d.Category, d.Package, filepath.Base(file), d.Message, code}, "\x00")
+		n := seen[key]
+		seen[key]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, n)))
+		d.Fingerprint = hex.EncodeToString(sum[:16])
+	}
+}
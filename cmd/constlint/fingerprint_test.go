@@ -0,0 +1,49 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	diags := func(dir, src string, lines ...int) []diagnostic {
+		file := filepath.Join(dir, "order.go")
+		if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var diags []diagnostic
+		for _, line := range lines {
+			diags = append(diags, diagnostic{
+				Package:  "example.com/shop",
+				Category: "field-write",
+				Message:  "assignment to const field Order.ID",
+				position: token.Position{Filename: file, Line: line, Column: 2},
+			})
+		}
+		fingerprint(diags)
+		return diags
+	}
+
+	old := diags(t.TempDir(), "package shop\n\nfunc f(o *Order) {\n\to.ID = 1\n\to.ID = 1\n\to.ID = 2\n}\n", 4, 5, 6)
+	// Moved down, reindented and checked out elsewhere.
+	new := diags(t.TempDir(), "package shop\n\n// f sets o.ID.\nfunc f(o *Order) {\n    o.ID  =  1\n\to.ID = 1\n\to.ID = 3\n}\n", 5, 6, 7)
+
+	if old[0].Fingerprint == old[1].Fingerprint {
+		t.Errorf("identical diagnostics share fingerprint %s", old[0].Fingerprint)
+	}
+	for i := 0; i < 2; i++ {
+		if old[i].Fingerprint != new[i].Fingerprint {
+			t.Errorf("diagnostic %d: fingerprint changed from %s to %s", i, old[i].Fingerprint, new[i].Fingerprint)
+		}
+	}
+	if old[2].Fingerprint == new[2].Fingerprint {
+		t.Errorf("fingerprint %s unchanged by the code of the line", old[2].Fingerprint)
+	}
+
+	added, removed := diffResults(old, new)
+	if len(added) != 1 || len(removed) != 1 || added[0].position.Line != 7 || removed[0].position.Line != 6 {
+		t.Errorf("got %d added and %d removed diagnostics, want the last line of each", len(added), len(removed))
+	}
+}
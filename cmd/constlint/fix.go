@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"slices"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// fixMain applies the fixes the analyzer suggests across the given packages,
+// with the analyzer flags of their configuration. -refactor adds the
+// refactorings changing APIs, such as turning the setters of const fields
+// into constructor parameters, whose callers are then left to update.
+func fixMain(args []string) int {
+	flags := flag.NewFlagSet("constlint fix", flag.ExitOnError)
+	refactor := flags.Bool("refactor", false,
+		"also apply the refactorings removing setters of const fields in favor of constructor parameters")
+	dryRun := flags.Bool("n", false, "print the fixes without rewriting files")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint fix [-refactor] [-n] [package...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	lintFlagSet, _ := lintFlags()
+	configs := newConfigLoader(lintFlagSet)
+	settings := func(dir string) (map[string]string, error) {
+		c, err := configs.load(dir)
+		if err != nil {
+			return nil, err
+		}
+		settings := c.analyzerSettings(nil)
+		if *refactor {
+			settings["refactor-setters"] = "true"
+		}
+		return settings, nil
+	}
+
+	// Fixes overlapping others, such as two setters moving into the same
+	// constructor, are applied by the passes that follow.
+	for pass := 0; pass < maxFixPasses; pass++ {
+		initial, err := buildConfig{}.load(false, patterns)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		roots, err := runAnalyzer(initial, settings)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		files, applied, err := applyFixes(roots)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, a := range applied {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", relativePosition(a.pos), a.message)
+		}
+		if *dryRun || len(applied) == 0 {
+			return 0
+		}
+		for _, name := range sortedKeys(files) {
+			if err := os.WriteFile(name, files[name], 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// maxFixPasses bounds the passes of constlint fix over the packages.
+const maxFixPasses = 10
+
+// appliedFix is a fix applyFixes applied, at the diagnostic suggesting it.
+type appliedFix struct {
+	pos     token.Position
+	message string
+}
+
+// fileEdit replaces the bytes between the offsets start and end of a file
+// with text.
+type fileEdit struct {
+	start, end int
+	text       string
+}
+
+// applyFixes applies the first fix of every diagnostic of the actions and
+// returns the formatted content of the files they change. A fix overlapping
+// one applied before it is skipped whole; a fix suggested twice, as for a
+// package and its test variant, is applied once.
+func applyFixes(roots []*checker.Action) (map[string][]byte, []appliedFix, error) {
+	edits := make(map[string][]fileEdit)
+	seen := make(map[string]bool)
+	var applied []appliedFix
+	for _, root := range roots {
+		fset := root.Package.Fset
+		for _, d := range root.Diagnostics {
+			if len(d.SuggestedFixes) == 0 {
+				continue
+			}
+			fix := d.SuggestedFixes[0]
+			pos := fset.Position(d.Pos)
+			key := fmt.Sprintf("%s:%d:%s", pos.Filename, pos.Offset, fix.Message)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if fixEdits, ok := resolveEdits(fset, fix, edits); ok {
+				for name, e := range fixEdits {
+					edits[name] = append(edits[name], e...)
+				}
+				applied = append(applied, appliedFix{pos: pos, message: fix.Message})
+			}
+		}
+	}
+
+	files := make(map[string][]byte)
+	for name, fileEdits := range edits {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.SliceStable(fileEdits, func(i, j int) bool { return fileEdits[i].start < fileEdits[j].start })
+		var out []byte
+		last := 0
+		for _, e := range fileEdits {
+			out = append(out, src[last:e.start]...)
+			out = append(out, e.text...)
+			last = e.end
+		}
+		out = append(out, src[last:]...)
+		formatted, err := format.Source(out)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		files[name] = formatted
+	}
+	sort.Slice(applied, func(i, j int) bool { return positionLess(applied[i].pos, applied[j].pos) })
+	return files, applied, nil
+}
+
+// resolveEdits returns the edits of fix by file, unless one of them overlaps
+// the edits accepted so far.
+func resolveEdits(fset *token.FileSet, fix analysis.SuggestedFix,
+	accepted map[string][]fileEdit) (map[string][]fileEdit, bool) {
+	fixEdits := make(map[string][]fileEdit)
+	for _, edit := range fix.TextEdits {
+		start, end := fset.Position(edit.Pos), fset.Position(edit.End)
+		if !edit.End.IsValid() {
+			end = start
+		}
+		e := fileEdit{start: start.Offset, end: end.Offset, text: string(edit.NewText)}
+		for _, other := range slices.Concat(accepted[start.Filename], fixEdits[start.Filename]) {
+			if e.start < other.end && other.start < e.end || e.start == other.start && e.end == other.end {
+				return nil, false
+			}
+		}
+		fixEdits[start.Filename] = append(fixEdits[start.Filename], e)
+	}
+	return fixEdits, true
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyFixes(t *testing.T) {
+	initial, err := buildConfig{}.load(false, []string{"./testdata/fix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := func(string) (map[string]string, error) {
+		return map[string]string{"refactor-setters": "true"}, nil
+	}
+	roots, err := runAnalyzer(initial, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, applied, err := applyFixes(roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both setters move into NewUser: the second overlaps the first and is
+	// left to the next pass.
+	if len(applied) != 1 || applied[0].message != "Remove SetEmail and pass Email to NewUser" {
+		t.Fatalf("got fixes %v, want SetEmail's only", applied)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d changed files, want 1", len(files))
+	}
+	for _, src := range files {
+		for _, want := range []string{
+			"func NewUser(name string, email string) *User {",
+			"return &User{Name: name, Email: email}",
+			"func (u *User) SetPhone(phone string) {",
+		} {
+			if !strings.Contains(string(src), want) {
+				t.Errorf("fixed file lacks %q:\n%s", want, src)
+			}
+		}
+		if strings.Contains(string(src), "SetEmail") {
+			t.Errorf("fixed file keeps SetEmail:\n%s", src)
+		}
+	}
+}
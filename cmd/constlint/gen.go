@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+)
+
+// generators maps generator names to functions returning the files they
+// produce for a package, keyed by file name.
+var generators = map[string]func(pkg *gen.Package) (map[string][]byte, error){
+	"clone":        gen.Clone,
+	"constructors": gen.Constructors,
+	"getters":      gen.Getters,
+	"guards":       gen.Guards,
+	"views":        gen.Views,
+	"with":         gen.With,
+}
+
+func genMain(args []string) int {
+	if len(args) == 0 || generators[args[0]] == nil {
+		fmt.Fprintf(os.Stderr, "usage: constlint gen <generator> [-n] [package...]\n\ngenerators: %s\n",
+			strings.Join(generatorNames(), ", "))
+		return 2
+	}
+	generate := generators[args[0]]
+
+	flags := flag.NewFlagSet("constlint gen "+args[0], flag.ExitOnError)
+	dryRun := flags.Bool("n", false, "print the generated files instead of writing them")
+	flags.Parse(args[1:])
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	pkgs, err := gen.Load("", patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, pkg := range pkgs {
+		files, err := generate(pkg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, name := range sortedKeys(files) {
+			if *dryRun {
+				fmt.Printf("// %s\n%s", name, files[name])
+				continue
+			}
+			if err := os.WriteFile(name, files[name], 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+func generatorNames() []string {
+	var names []string
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys[V any](files map[string]V) []string {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
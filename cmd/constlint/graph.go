@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+)
+
+// graphMain writes a Graphviz DOT graph of the const fields of the given
+// packages: a node per type with const fields, listing them, and an edge to
+// it from every function writing them, green for writes the analyzer allows,
+// such as those of constructors, and red for the violations it reports.
+func graphMain(args []string) int {
+	flags := flag.NewFlagSet("constlint graph", flag.ExitOnError)
+	output := flags.String("o", "", "write the graph to `file` instead of standard output")
+	violations := flags.Bool("violations", false, "only draw the functions violating const fields")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint graph [-o file] [-violations] [package...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs, err := gen.Load("", patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var buf bytes.Buffer
+	writeGraph(&buf, pkgs, *violations)
+	if *output == "" {
+		os.Stdout.Write(buf.Bytes())
+		return 0
+	}
+	if err := os.WriteFile(*output, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// writeSite is a write to a const field.
+type writeSite struct {
+	field    string
+	position string
+}
+
+// graphEdge gathers the writes of a function to the const fields of a type.
+type graphEdge struct {
+	from, to   string // node IDs
+	allowed    []writeSite
+	violations []writeSite
+}
+
+// writeGraph writes the DOT graph of pkgs, with a cluster per package. With
+// violationsOnly, functions only making allowed writes are left out.
+func writeGraph(w io.Writer, pkgs []*gen.Package, violationsOnly bool) {
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+
+	fmt.Fprintf(w, "digraph constlint {\n")
+	fmt.Fprintf(w, "\trankdir=LR;\n")
+	fmt.Fprintf(w, "\tnode [fontname=\"Helvetica\", fontsize=10];\n")
+	fmt.Fprintf(w, "\tedge [fontname=\"Helvetica\", fontsize=9];\n")
+
+	var edges []*graphEdge
+	for i, pkg := range pkgs {
+		if len(pkg.Inventory.Structs) == 0 {
+			continue
+		}
+		funcs := make(map[string]bool)
+		pkgEdges := make(map[[2]string]*graphEdge)
+		var order [][2]string
+
+		var nodes bytes.Buffer
+		for _, s := range pkg.Inventory.Structs {
+			typeID := pkg.PkgPath + "." + s.Type.Name()
+			var label strings.Builder
+			label.WriteString(s.Type.Name() + "\\n")
+			for _, cf := range s.Fields {
+				mode := "const"
+				if cf.Deep {
+					mode = "deepconst"
+				}
+				fmt.Fprintf(&label, "\\n%s (%s)\\l", cf.Var.Name(), mode)
+
+				// Writes through a field, such as o.Items[0] = x, are
+				// violations without being inits.
+				violating := make(map[token.Pos]bool)
+				for _, pos := range cf.Violations {
+					violating[pos] = true
+				}
+				writes := slices.Clone(cf.Violations)
+				for _, pos := range cf.Inits {
+					if !violating[pos] {
+						writes = append(writes, pos)
+					}
+				}
+				slices.Sort(writes)
+				for _, pos := range writes {
+					fn := enclosingFuncName(pkg, pos)
+					key := [2]string{pkg.PkgPath + "." + fn, typeID}
+					e, ok := pkgEdges[key]
+					if !ok {
+						e = &graphEdge{from: key[0], to: typeID}
+						pkgEdges[key] = e
+						order = append(order, key)
+					}
+					site := writeSite{cf.Var.Name(), relativePosition(pkg.Fset.Position(pos))}
+					if violating[pos] {
+						e.violations = append(e.violations, site)
+					} else {
+						e.allowed = append(e.allowed, site)
+					}
+				}
+			}
+			fmt.Fprintf(&nodes, "\t\t%s [shape=box, style=filled, fillcolor=\"#eef3fb\", label=%s];\n",
+				strconv.Quote(typeID), dotLabel(label.String()))
+		}
+		for _, key := range order {
+			e := pkgEdges[key]
+			if violationsOnly && len(e.violations) == 0 {
+				continue
+			}
+			if !funcs[e.from] {
+				funcs[e.from] = true
+				name := strings.TrimPrefix(e.from, pkg.PkgPath+".")
+				fmt.Fprintf(&nodes, "\t\t%s [shape=ellipse, label=%s];\n", strconv.Quote(e.from), strconv.Quote(name))
+			}
+			edges = append(edges, e)
+		}
+
+		fmt.Fprintf(w, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(w, "\t\tlabel=%s;\n", strconv.Quote(pkg.PkgPath))
+		w.Write(nodes.Bytes())
+		fmt.Fprintf(w, "\t}\n")
+	}
+
+	for _, e := range edges {
+		if len(e.allowed) > 0 && !violationsOnly {
+			fmt.Fprintf(w, "\t%s -> %s [color=\"#2e7d32\", label=%s, tooltip=%s];\n",
+				strconv.Quote(e.from), strconv.Quote(e.to), siteLabel(e.allowed), siteTooltip(e.allowed))
+		}
+		if len(e.violations) > 0 {
+			fmt.Fprintf(w, "\t%s -> %s [color=\"#c62828\", fontcolor=\"#c62828\", style=bold, label=%s, tooltip=%s];\n",
+				strconv.Quote(e.from), strconv.Quote(e.to), siteLabel(e.violations), siteTooltip(e.violations))
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+// enclosingFuncName names the function declaration containing pos, such as
+// NewOrder or (*Order).Reset, or returns "(package scope)" for writes in
+// package-level declarations. Writes in function literals belong to the
+// declaration around them.
+func enclosingFuncName(pkg *gen.Package, pos token.Pos) string {
+	file := pkg.File(pos)
+	if file == nil {
+		return "(package scope)"
+	}
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || pos < funcDecl.Pos() || pos >= funcDecl.End() {
+			continue
+		}
+		fn, ok := pkg.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+		if !ok {
+			return funcDecl.Name.Name
+		}
+		recv := fn.Type().(*types.Signature).Recv()
+		if recv == nil {
+			return fn.Name()
+		}
+		t := recv.Type()
+		ptr := ""
+		if p, ok := t.(*types.Pointer); ok {
+			t, ptr = p.Elem(), "*"
+		}
+		name := types.TypeString(t, func(*types.Package) string { return "" })
+		if named, ok := t.(*types.Named); ok {
+			name = named.Obj().Name()
+		}
+		if ptr != "" {
+			return fmt.Sprintf("(*%s).%s", name, fn.Name())
+		}
+		return name + "." + fn.Name()
+	}
+	return "(package scope)"
+}
+
+// siteLabel labels an edge with the fields written, each with its number of
+// writes when more than one.
+func siteLabel(sites []writeSite) string {
+	counts := make(map[string]int)
+	var fields []string
+	for _, s := range sites {
+		if counts[s.field] == 0 {
+			fields = append(fields, s.field)
+		}
+		counts[s.field]++
+	}
+	for i, field := range fields {
+		if counts[field] > 1 {
+			fields[i] = fmt.Sprintf("%s ×%d", field, counts[field])
+		}
+	}
+	return strconv.Quote(strings.Join(fields, ", "))
+}
+
+// siteTooltip lists the positions of the writes, shown on hover in SVG output.
+func siteTooltip(sites []writeSite) string {
+	var lines []string
+	for _, s := range sites {
+		lines = append(lines, s.field+" at "+s.position)
+	}
+	return dotLabel(strings.Join(lines, "\\n"))
+}
+
+// dotLabel quotes a label that already holds DOT escapes such as \n and \l,
+// escaping only its quotes.
+func dotLabel(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
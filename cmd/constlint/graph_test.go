@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+)
+
+func TestWriteGraph(t *testing.T) {
+	pkgs, err := gen.Load("", "./testdata/graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const pkg = "github.com/bunniesandbeatings/constlint/cmd/constlint/testdata/graph"
+
+	var out bytes.Buffer
+	writeGraph(&out, pkgs, false)
+	for _, want := range []string{
+		`"` + pkg + `.Order" [shape=box, style=filled, fillcolor="#eef3fb", label="Order\n\nID (const)\l\nItems (deepconst)\l"];`,
+		`"` + pkg + `.NewOrder" -> "` + pkg + `.Order" [color="#2e7d32", label="ID, Items", ` +
+			`tooltip="ID at testdata/graph/shop.go:16:14\nItems at testdata/graph/shop.go:17:2"];`,
+		`"` + pkg + `.(*Order).Reset" -> "` + pkg + `.Order" [color="#c62828", fontcolor="#c62828", style=bold, ` +
+			`label="ID, Items ×2", `,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("graph lacks\n%s\ngot\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Annotate") {
+		t.Errorf("graph draws Annotate, which writes no const field:\n%s", out.String())
+	}
+
+	out.Reset()
+	writeGraph(&out, pkgs, true)
+	if strings.Contains(out.String(), "NewOrder") || !strings.Contains(out.String(), "(*Order).Reset") {
+		t.Errorf("-violations graph should only draw Reset:\n%s", out.String())
+	}
+}
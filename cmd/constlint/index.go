@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"os"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+)
+
+// indexEntry describes the constness contract of a single struct field.
+type indexEntry struct {
+	Package   string   `json:"package"`
+	Type      string   `json:"type"`
+	Field     string   `json:"field"`
+	Mode      string   `json:"mode"` // "shallow" or "deep"
+	Secret    bool     `json:"secret,omitempty"`
+	WriteOnce bool     `json:"writeOnce,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	Declared  string   `json:"declared"`
+	Marker    string   `json:"marker"`
+	Inits     []string `json:"inits"`
+}
+
+// indexMain writes a JSON object mapping package.Type.Field to the constness
+// of every const field in the given packages, for documentation tools.
+func indexMain(args []string) int {
+	flags := flag.NewFlagSet("constlint index", flag.ExitOnError)
+	output := flags.String("o", "", "write the index to `file` instead of standard output")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint index [-o file] [package...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	pkgs, err := gen.Load("", patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	index := make(map[string]indexEntry)
+	for _, pkg := range pkgs {
+		position := func(pos token.Pos) string {
+			return relativePosition(pkg.Fset.Position(pos))
+		}
+		for _, s := range pkg.Inventory.Structs {
+			for _, cf := range s.Fields {
+				entry := indexEntry{
+					Package:   pkg.PkgPath,
+					Type:      s.Type.Name(),
+					Field:     cf.Var.Name(),
+					Mode:      "shallow",
+					Secret:    cf.Secret,
+					WriteOnce: cf.WriteOnce,
+					Reason:    cf.Reason,
+					Declared:  position(cf.Pos),
+					Marker:    position(cf.Marker),
+					Inits:     []string{},
+				}
+				if cf.Deep {
+					entry.Mode = "deep"
+				}
+				for _, pos := range cf.Inits {
+					entry.Inits = append(entry.Inits, position(pos))
+				}
+				index[pkg.PkgPath+"."+s.Type.Name()+"."+cf.Var.Name()] = entry
+			}
+		}
+	}
+
+	out, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	out = append(out, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(out)
+		return 0
+	}
+	if err := os.WriteFile(*output, out, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// relativePosition formats a position with its file name relative to the
+// working directory, when the file is below it.
+func relativePosition(pos token.Position) string {
+	pos.Filename = relativePath(pos.Filename)
+	return pos.String()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// golangciSnippet shows how to run constlint as a golangci-lint module plugin.
+const golangciSnippet = `# .custom-gcl.yml: build golangci-lint with constlint (golangci-lint custom)
+version: v1.64.6
+plugins:
+  - module: 'github.com/bunniesandbeatings/constlint'
+    import: 'github.com/bunniesandbeatings/constlint/plugin'
+    version: v1.0.0
+
+# .golangci.yml: enable it
+linters-settings:
+  custom:
+    constlint:
+      type: "module"
+      description: Checks for writes to struct fields marked with // +const
+
+linters:
+  enable:
+    - constlint
+`
+
+// initMain writes a starter configuration file to the current directory,
+// listing every setting with its default and an explanation.
+func initMain(args []string) int {
+	flags := flag.NewFlagSet("constlint init", flag.ExitOnError)
+	force := flags.Bool("f", false, "overwrite an existing "+configFile)
+	golangci := flags.Bool("golangci", false, "also print the golangci-lint configuration running constlint as a plugin")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint init [-f] [-golangci]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(configFile, mode, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		fmt.Fprintf(os.Stderr, "constlint: %s already exists; use -f to overwrite it\n", configFile)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	lint, _ := lintFlags()
+	err = writeConfigTemplate(f, lint)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "constlint: wrote %s\n", configFile)
+
+	if *golangci {
+		fmt.Print(golangciSnippet)
+	}
+	return 0
+}
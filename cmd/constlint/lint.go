@@ -0,0 +1,831 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// diagnostic is a diagnostic of the analyzer, resolved to file positions.
+type diagnostic struct {
+	Package     string    `json:"package"`
+	Posn        string    `json:"posn"`
+	End         string    `json:"end"`
+	Category    string    `json:"category"`
+	Message     string    `json:"message"`
+	Confidence  string    `json:"confidence"`      // definite, probable or possible
+	Field       string    `json:"field,omitempty"` // Type.Field written, for field writes
+	Related     []related `json:"related,omitempty"`
+	Fingerprint string    `json:"fingerprint"` // identifies the diagnostic across runs, see fingerprint
+
+	position, end token.Position
+	decl          token.Position // of the const field written, for field writes
+	aggregate     bool           // collapses the writes listed in Related, with -aggregate
+}
+
+type related struct {
+	Posn    string `json:"posn"`
+	Message string `json:"message"`
+
+	position token.Position
+}
+
+// groupings maps the values of -group to the key they group diagnostics by.
+var groupings = map[string]func(d diagnostic) string{
+	"file":    func(d diagnostic) string { return d.position.Filename },
+	"package": func(d diagnostic) string { return d.Package },
+	"rule":    func(d diagnostic) string { return d.Category },
+	"type": func(d diagnostic) string {
+		if typ, _, ok := strings.Cut(d.Field, "."); ok {
+			return typ
+		}
+		return ""
+	},
+	"field": func(d diagnostic) string { return d.Field },
+}
+
+// otherGroup names the group of diagnostics a grouping has no key for, such
+// as diagnostics other than field writes when grouping by type.
+const otherGroup = "(other)"
+
+// lintOptions holds the flags of the lint command besides the analyzer's.
+type lintOptions struct {
+	format, group, color              string
+	maxIssues                         int
+	quiet, tests, staged, showConfig  bool
+	cpuProfile, memProfile, traceFile string
+	metrics, metricsHistory           string
+	wholeProgram                      bool
+	callGraph                         string
+	buildMatrix                       string
+	aggregate                         bool
+	concurrency                       int
+	progress                          string
+	verbose, veryVerbose              bool
+}
+
+// commandLineFlags are the lint flags a configuration file can't set.
+var commandLineFlags = []string{"staged", "show-config", "cpuprofile", "memprofile", "trace"}
+
+// lintFlags returns the flag set of the lint command, which includes the
+// analyzer's flags, and the options it sets.
+func lintFlags() (*flag.FlagSet, *lintOptions) {
+	flags := flag.NewFlagSet("constlint", flag.ExitOnError)
+	analyzer.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		flags.Var(f.Value, f.Name, f.Usage)
+	})
+	opts := &lintOptions{}
+	flags.StringVar(&opts.format, "format", "text", "output `format`: text, pretty, json, tap or summary")
+	flags.StringVar(&opts.color, "color", "auto", "color the pretty format: auto, always or `never`")
+	flags.StringVar(&opts.group, "group", "", "group diagnostics by `key`: file, package, rule, type or field (summary default: rule)")
+	flags.BoolVar(&opts.aggregate, "aggregate", false, "report the writes to each const field as one diagnostic listing them, when there are several")
+	flags.IntVar(&opts.maxIssues, "max-issues", 0, "stop printing diagnostics after `n`, 0 for no limit (every format but summary)")
+	flags.BoolVar(&opts.quiet, "quiet", false, "only print the number of diagnostics")
+	flags.BoolVar(&opts.tests, "test", true, "also check test files")
+	flags.StringVar(&opts.metrics, "metrics", "", "write metrics of the run, such as diagnostics per rule and package and const field counts, as JSON to `file`")
+	flags.StringVar(&opts.metricsHistory, "metrics-history", "", "append the metrics of the run as a line of JSON to `file`, for charting trends")
+	flags.BoolVar(&opts.wholeProgram, "whole-program", false, "also check the packages as a whole program, through their call graph, for writes to const fields after construction that a package at a time can't show")
+	flags.StringVar(&opts.callGraph, "callgraph", "vta", "call graph `algorithm` of -whole-program: cha, rta or vta")
+	flags.StringVar(&opts.buildMatrix, "build-matrix", "", "check the packages in each of these comma-separated build `configurations`, such as windows, linux/arm64+integration or +integration, and merge the diagnostics; auto adds those the files the go command leaves out need to the default one")
+	flags.IntVar(&opts.concurrency, "concurrency", 0, "analyze at most `n` packages at once, 0 for as many as there are ready")
+	flags.StringVar(&opts.progress, "progress", "auto", "report the packages analyzed so far and the time left: auto, always or `never`")
+	flags.BoolVar(&opts.verbose, "v", false, "trace the const fields found and the writes allowed or suppressed, and list the time the analysis of each package took, slowest first")
+	flags.BoolVar(&opts.veryVerbose, "vv", false, "like -v, also tracing the markers parsed and the facts exchanged between packages")
+	flags.BoolVar(&opts.staged, "staged", false, "only report diagnostics in the Go files staged in git, checking them as staged, for pre-commit hooks")
+	flags.BoolVar(&opts.showConfig, "show-config", false, "print the effective configuration of each package instead of checking it")
+	flags.StringVar(&opts.cpuProfile, "cpuprofile", "", "write a CPU profile to `file`")
+	flags.StringVar(&opts.memProfile, "memprofile", "", "write a memory profile to `file`")
+	flags.StringVar(&opts.traceFile, "trace", "", "write an execution trace to `file`")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint [-flag] [package...]\n")
+		flags.PrintDefaults()
+	}
+	return flags, opts
+}
+
+// lintMain runs the analyzer over the given packages and prints its
+// diagnostics. Flags not given in args are set by the .constlint.yaml files of
+// the working directory and its parents, and the analyzer's flags by those of
+// each package's directory and its parents. Like singlechecker, it exits with
+// 3 if there were any diagnostics and with 1 if the packages couldn't be
+// analyzed.
+func lintMain(args []string) int {
+	flags, opts := lintFlags()
+	flags.Parse(args)
+
+	commandLine := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) { commandLine[f.Name] = f.Value.String() })
+	configs := newConfigLoader(flags)
+	wdConfig, err := configs.load(".")
+	if err == nil {
+		err = wdConfig.apply(flags, commandLine)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "constlint: %v\n", err)
+		return 2
+	}
+
+	switch opts.format {
+	case "text", "pretty", "json", "tap", "summary":
+	default:
+		fmt.Fprintf(os.Stderr, "constlint: unknown format %q\n", opts.format)
+		return 2
+	}
+	if opts.color != "auto" && opts.color != "always" && opts.color != "never" {
+		fmt.Fprintf(os.Stderr, "constlint: unknown color mode %q\n", opts.color)
+		return 2
+	}
+	if opts.progress != "auto" && opts.progress != "always" && opts.progress != "never" {
+		fmt.Fprintf(os.Stderr, "constlint: unknown progress mode %q\n", opts.progress)
+		return 2
+	}
+	if opts.concurrency < 0 {
+		fmt.Fprintf(os.Stderr, "constlint: -concurrency must not be negative\n")
+		return 2
+	}
+	if _, ok := callGraphs[opts.callGraph]; !ok {
+		fmt.Fprintf(os.Stderr, "constlint: unknown call graph algorithm %q\n", opts.callGraph)
+		return 2
+	}
+	builds, auto, err := parseBuildMatrix(opts.buildMatrix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "constlint: %v\n", err)
+		return 2
+	}
+	if opts.group == "" && opts.format == "summary" {
+		opts.group = "rule"
+	}
+	if _, ok := groupings[opts.group]; opts.group != "" && !ok {
+		fmt.Fprintf(os.Stderr, "constlint: unknown grouping %q\n", opts.group)
+		return 2
+	}
+
+	if opts.cpuProfile != "" {
+		f, err := os.Create(opts.cpuProfile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer pprof.StopCPUProfile()
+	}
+	if opts.traceFile != "" {
+		f, err := os.Create(opts.traceFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		if err := trace.Start(f); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer trace.Stop()
+	}
+	if opts.memProfile != "" {
+		defer func() {
+			f, err := os.Create(opts.memProfile)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return
+			}
+			defer f.Close()
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+	}
+
+	patterns := flags.Args()
+	var staged map[string]bool
+	if opts.staged {
+		if len(patterns) > 0 {
+			fmt.Fprintf(os.Stderr, "constlint: -staged checks the packages of the staged files, not %s\n",
+				strings.Join(patterns, " "))
+			return 2
+		}
+		files, err := stagedFiles()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(files) == 0 {
+			return 0
+		}
+		if overlay, err = indexOverlay(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer func() { overlay = nil }()
+		staged = make(map[string]bool)
+		for _, file := range files {
+			staged[file] = true
+		}
+		patterns = packagePatterns(files)
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	if opts.showConfig {
+		if err := showConfig(os.Stdout, flags, configs, commandLine, patterns); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	scheduling.concurrency = opts.concurrency
+	if opts.progress == "always" || opts.progress == "auto" && !opts.quiet && isTerminal(os.Stderr) {
+		scheduling.progress = os.Stderr
+	}
+	if opts.verbose || opts.veryVerbose {
+		scheduling.timings = os.Stderr
+		level := slog.LevelInfo
+		if opts.veryVerbose {
+			level = slog.LevelDebug
+		}
+		analyzer.SetLogger(newTraceLogger(os.Stderr, level))
+		defer analyzer.SetLogger(nil)
+	}
+	if auto {
+		discovered, err := discoverBuilds(patterns, opts.tests)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		builds = mergeBuilds(append([]buildConfig{{}}, builds...), discovered)
+	}
+	callGraph := ""
+	if opts.wholeProgram {
+		callGraph = opts.callGraph
+	}
+	diags, decls, err := lint(patterns, opts.tests, builds, callGraph, func(dir string) (map[string]string, error) {
+		c, err := configs.load(dir)
+		if err != nil {
+			return nil, err
+		}
+		return c.analyzerSettings(commandLine), nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if staged != nil {
+		diags = inFiles(diags, staged)
+	}
+	if opts.metrics != "" || opts.metricsHistory != "" {
+		m := collectMetrics(diags, decls, time.Now())
+		m.Commit = headCommit()
+		if opts.metrics != "" {
+			err = writeMetrics(opts.metrics, m)
+		}
+		if err == nil && opts.metricsHistory != "" {
+			err = appendMetrics(opts.metricsHistory, m)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	if opts.aggregate {
+		diags = aggregateFieldWrites(diags, decls)
+	}
+	fingerprint(diags)
+	shown := limitIssues(diags, opts.maxIssues, opts.format)
+	switch {
+	case opts.quiet:
+		err = writeCount(os.Stderr, diags)
+	case opts.format == "text":
+		err = writeText(os.Stderr, shown, opts.group)
+	case opts.format == "pretty":
+		err = writePretty(os.Stderr, shown, opts.group, useColor(opts.color, os.Stderr))
+	case opts.format == "json":
+		err = writeJSON(os.Stdout, shown, opts.group)
+	case opts.format == "tap":
+		err = writeTAP(os.Stdout, shown, opts.group)
+	case opts.format == "summary":
+		err = writeSummary(os.Stdout, diags, opts.group)
+	}
+	if err == nil && !opts.quiet && len(shown) < len(diags) {
+		_, err = fmt.Fprintf(os.Stderr, "constlint: showing %d of %d diagnostics; raise -max-issues to see more\n",
+			len(shown), len(diags))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(diags) > 0 {
+		return 3
+	}
+	return 0
+}
+
+// newTraceLogger returns the logger of -v and -vv, which writes the records
+// of level and above to w as text, without their time, e.g.
+//
+//	level=INFO msg="write allowed" package=example.com/shop pos=shop/order.go:12:2 type=Order field=ID reason="NewOrder instantiates Order"
+func newTraceLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch {
+			case len(groups) > 0:
+			case a.Key == slog.TimeKey:
+				return slog.Attr{}
+			case a.Key == "pos" || a.Key == "marker":
+				a.Value = slog.StringValue(relativePosn(a.Value.String()))
+			}
+			return a
+		},
+	}))
+}
+
+// limitIssues returns the diagnostics the format prints with -max-issues n:
+// the first n, or all of them for no limit and in the summary format, which
+// counts them all.
+func limitIssues(diags []diagnostic, n int, format string) []diagnostic {
+	if n > 0 && len(diags) > n && format != "summary" {
+		return diags[:n]
+	}
+	return diags
+}
+
+// lint loads and analyzes the packages matching patterns and returns the
+// diagnostics reported for them, sorted by position and without duplicates,
+// and their const declarations. settings returns the analyzer flags for the
+// packages of a directory; packages with the same settings are analyzed
+// together. With a callGraph algorithm, the packages are also checked as a
+// whole program. The packages are checked in each of builds, or in the go
+// command's default configuration if there are none, and the results merged.
+// Positions in the files of a packages driver are resolved by sourcePath.
+func lint(patterns []string, tests bool, builds []buildConfig, callGraph string,
+	settings func(dir string) (map[string]string, error)) ([]diagnostic, map[token.Position]constDecl, error) {
+	if len(builds) == 0 {
+		builds = []buildConfig{{}}
+	}
+	var diags []diagnostic
+	decls := make(map[token.Position]constDecl)
+	for _, b := range builds {
+		initial, err := b.load(tests, patterns)
+		if err != nil {
+			if len(builds) > 1 {
+				err = fmt.Errorf("%v (build %s)", err, b)
+			}
+			return nil, nil, err
+		}
+		buildDiags, buildDecls, err := analyze(initial, settings)
+		if err != nil {
+			return nil, nil, err
+		}
+		if callGraph != "" {
+			buildDiags = append(buildDiags, checkWholeProgram(initial, buildDecls, buildDiags, callGraph)...)
+		}
+		diags = append(diags, buildDiags...)
+		for position, decl := range buildDecls {
+			decls[position] = decl
+		}
+	}
+	decls = resolveSourcePaths(diags, decls)
+	return dedupe(diags), decls, nil
+}
+
+// constDecl is a const field or parameter of an analyzed package. The
+// declarations of a package and its test variant are keyed by the same
+// position, so each is counted once.
+type constDecl struct {
+	pkg                            string
+	param, deep, secret, writeOnce bool
+	reason                         string // why the field is const, if its marker says
+}
+
+// loadPackages loads the packages matching patterns with their syntax and
+// types, and what else cfg.Mode asks for, failing if any has errors.
+func loadPackages(cfg *packages.Config, patterns []string) ([]*packages.Package, error) {
+	cfg.Mode |= packages.LoadAllSyntax
+	initial, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	return checkLoaded(initial, patterns)
+}
+
+// checkLoaded fails if any of the packages loaded for patterns has errors,
+// other than parse and type errors with -best-effort, or if there are none.
+func checkLoaded(initial []*packages.Package, patterns []string) ([]*packages.Package, error) {
+	if packages.PrintErrors(initial) > 0 {
+		typeErrors := onlyTypeErrors(initial)
+		switch {
+		case typeErrors && analyzer.Analyzer.Flags.Lookup("best-effort").Value.String() == "true":
+			return initial, nil
+		case typeErrors:
+			return nil, fmt.Errorf("packages contain errors; -best-effort checks them anyway")
+		case needsCgo(initial):
+			return nil, fmt.Errorf("packages contain errors; packages using cgo need the C compiler of go env CC, or CGO_ENABLED=0 to check them without their cgo files")
+		}
+		return nil, fmt.Errorf("packages contain errors")
+	}
+	if len(initial) == 0 {
+		return nil, fmt.Errorf("no packages matching %s", strings.Join(patterns, " "))
+	}
+	return initial, nil
+}
+
+// onlyTypeErrors reports whether the errors of the packages and their
+// dependencies are all parse and type errors, which leave syntax to check.
+func onlyTypeErrors(initial []*packages.Package) bool {
+	only := true
+	packages.Visit(initial, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			if err.Kind != packages.ParseError && err.Kind != packages.TypeError {
+				only = false
+			}
+		}
+	})
+	return only
+}
+
+// needsCgo reports whether the packages or their dependencies failed to load
+// for want of cgo, as without a C compiler.
+func needsCgo(initial []*packages.Package) bool {
+	found := false
+	packages.Visit(initial, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			if strings.Contains(err.Msg, `could not import C`) {
+				found = true
+			}
+		}
+	})
+	return found
+}
+
+// analyze runs the analyzer over the loaded packages, with the flags settings
+// returns for their directories, like lint.
+func analyze(initial []*packages.Package,
+	settings func(dir string) (map[string]string, error)) ([]diagnostic, map[token.Position]constDecl, error) {
+	roots, err := runAnalyzer(initial, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := actionResults(roots)
+	if err != nil {
+		return nil, nil, err
+	}
+	diags, decls := analysisResults(results)
+	return diags, decls, nil
+}
+
+// analyzerRuns serializes the runs of runAnalyzer, which set the analyzer's
+// flags for each group of packages.
+var analyzerRuns sync.Mutex
+
+// runAnalyzer runs the analyzer over the loaded packages, with the flags
+// settings returns for their directories, and returns its actions for them.
+func runAnalyzer(initial []*packages.Package,
+	settings func(dir string) (map[string]string, error)) ([]*checker.Action, error) {
+	groups := make(map[string][]*packages.Package)
+	groupSettings := make(map[string]map[string]string)
+	var keys []string
+	for _, pkg := range initial {
+		values, err := settings(packageDir(pkg))
+		if err != nil {
+			return nil, err
+		}
+		key := settingsKey(values)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+			groupSettings[key] = values
+		}
+		groups[key] = append(groups[key], pkg)
+	}
+
+	var (
+		roots     []*checker.Action
+		monitored [][]*packages.Package
+	)
+	for _, key := range keys {
+		monitored = append(monitored, groups[key])
+	}
+	a, report := monitorRun(monitored)
+	defer report()
+	opts := &checker.Options{Sequential: scheduling.concurrency == 1}
+
+	// The analyzer reads its flags from package variables, so runs with
+	// other settings must wait for this one to finish.
+	analyzerRuns.Lock()
+	defer analyzerRuns.Unlock()
+	defer restoreFlags(&analyzer.Analyzer.Flags)()
+	for _, key := range keys {
+		for name, value := range groupSettings[key] {
+			if err := analyzer.Analyzer.Flags.Set(name, value); err != nil {
+				return nil, err
+			}
+		}
+		graph, err := checker.Analyze([]*analysis.Analyzer{a}, groups[key], opts)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, graph.Roots...)
+	}
+	return roots, nil
+}
+
+// packageResult is what the analyzer found in a package, taken from its
+// checker.Action or, for the packages the daemon checks again, from the
+// passes it runs itself.
+type packageResult struct {
+	pkg         *packages.Package
+	inventory   *analyzer.Inventory
+	diagnostics []analysis.Diagnostic
+	objectFacts []analysis.ObjectFact // of the package and its dependencies
+}
+
+// actionResults returns the results of the analyzer's actions, failing if
+// any failed.
+func actionResults(roots []*checker.Action) ([]*packageResult, error) {
+	var results []*packageResult
+	for _, root := range roots {
+		if root.Err != nil {
+			return nil, fmt.Errorf("%s: %w", root.Package.PkgPath, root.Err)
+		}
+		results = append(results, &packageResult{
+			pkg:         root.Package,
+			inventory:   root.Result.(*analyzer.Inventory),
+			diagnostics: root.Diagnostics,
+			objectFacts: root.AllObjectFacts(),
+		})
+	}
+	return results, nil
+}
+
+// analysisResults returns the diagnostics of the analyzer's results, sorted
+// by position and without duplicates, and the const declarations of their
+// packages.
+func analysisResults(results []*packageResult) ([]diagnostic, map[token.Position]constDecl) {
+	var diags []diagnostic
+	decls := make(map[token.Position]constDecl)
+	for _, result := range results {
+		pkg, fset := result.pkg, result.pkg.Fset
+		inventory := result.inventory
+		fields := writtenFields(inventory)
+		for _, s := range inventory.Structs {
+			for _, cf := range s.Fields {
+				decls[fset.Position(cf.Pos)] = constDecl{pkg: pkg.PkgPath, deep: cf.Deep, secret: cf.Secret,
+					writeOnce: cf.WriteOnce, reason: cf.Reason}
+			}
+		}
+		for _, f := range inventory.Funcs {
+			for _, param := range f.Params {
+				decls[fset.Position(param.Pos())] = constDecl{pkg: pkg.PkgPath, param: true}
+			}
+		}
+
+		for _, d := range result.diagnostics {
+			diag := diagnostic{
+				Package:    pkg.PkgPath,
+				Posn:       fset.Position(d.Pos).String(),
+				End:        fset.Position(d.End).String(),
+				Category:   d.Category,
+				Message:    d.Message,
+				Confidence: analyzer.RuleConfidence(d.Category).String(),
+				position:   fset.Position(d.Pos),
+				end:        fset.Position(d.End),
+			}
+			if field, ok := fields[d.Pos]; ok {
+				diag.Field, diag.decl = field.name, fset.Position(field.decl)
+			}
+			for _, r := range d.Related {
+				diag.Related = append(diag.Related, related{
+					Posn:     fset.Position(r.Pos).String(),
+					Message:  r.Message,
+					position: fset.Position(r.Pos),
+				})
+			}
+			diags = append(diags, diag)
+		}
+	}
+	return dedupe(diags), decls
+}
+
+// writeRules are the rules reporting writes, of which a single write only
+// needs reporting once.
+var writeRules = map[string]bool{
+	analyzer.CategoryFieldWrite: true,
+	analyzer.CategoryParamWrite: true,
+}
+
+// dedupe sorts diagnostics by position and drops those reported more than
+// once: for each of several packages sharing a file, such as a package and
+// its test variant, or for one write by more than one write rule. Of the
+// latter, the rule sorting first is kept.
+func dedupe(diags []diagnostic) []diagnostic {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if a.position != b.position {
+			return positionLess(a.position, b.position)
+		}
+		if a.end != b.end {
+			return positionLess(a.end, b.end)
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		return a.Message < b.Message
+	})
+
+	type key struct {
+		pos, end token.Position
+		rule     string
+		message  string
+	}
+	seen := make(map[key]bool)
+	var unique []diagnostic
+	for _, d := range diags {
+		k := key{d.position, d.end, d.Category, d.Message}
+		if writeRules[d.Category] {
+			k.rule, k.message = "write", ""
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		unique = append(unique, d)
+	}
+	return unique
+}
+
+// positionLess orders positions by file name, line and column.
+func positionLess(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
+
+// writtenField is a const field written by a reported write.
+type writtenField struct {
+	name string    // qualified by its type, as Type.Field
+	decl token.Pos // of the field
+}
+
+// writtenFields maps the position of every reported write to a const field of
+// a package to the field.
+func writtenFields(inventory *analyzer.Inventory) map[token.Pos]writtenField {
+	fields := make(map[token.Pos]writtenField)
+	for _, s := range inventory.Structs {
+		for _, cf := range s.Fields {
+			for _, pos := range cf.Violations {
+				fields[pos] = writtenField{s.Type.Name() + "." + cf.Var.Name(), cf.Pos}
+			}
+		}
+	}
+	return fields
+}
+
+// groupDiagnostics splits diags by the key of a grouping, keeping their
+// order within each group, and returns the keys in sorted order.
+func groupDiagnostics(diags []diagnostic, group string) ([]string, map[string][]diagnostic) {
+	groups := make(map[string][]diagnostic)
+	for _, d := range diags {
+		k := groupings[group](d)
+		if k == "" {
+			k = otherGroup
+		}
+		groups[k] = append(groups[k], d)
+	}
+
+	var keys []string
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, groups
+}
+
+// writeText prints one line per diagnostic, like go vet, followed by an
+// indented line per write for aggregated diagnostics. Grouped diagnostics are
+// printed under a line naming their group.
+func writeText(w io.Writer, diags []diagnostic, group string) error {
+	if group == "" {
+		for _, d := range diags {
+			if err := writeTextLines(w, "", d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	keys, groups := groupDiagnostics(diags, group)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s:\n", k); err != nil {
+			return err
+		}
+		for _, d := range groups[k] {
+			if err := writeTextLines(w, "\t", d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeTextLines prints the lines of a diagnostic for writeText.
+func writeTextLines(w io.Writer, indent string, d diagnostic) error {
+	if _, err := fmt.Fprintf(w, "%s%s: %s\n", indent, d.Posn, d.Message); err != nil {
+		return err
+	}
+	if !d.aggregate {
+		return nil
+	}
+	for _, r := range d.Related {
+		if _, err := fmt.Fprintf(w, "%s\t%s: %s\n", indent, r.Posn, r.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON prints the diagnostics as a JSON array, or as an object mapping
+// each group to its diagnostics.
+func writeJSON(w io.Writer, diags []diagnostic, group string) error {
+	var v any = diags
+	if diags == nil {
+		v = []diagnostic{}
+	}
+	if group != "" {
+		_, v = groupDiagnostics(diags, group)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeCount prints the number of diagnostics and of the packages they were
+// found in.
+func writeCount(w io.Writer, diags []diagnostic) error {
+	packages := make(map[string]bool)
+	for _, d := range diags {
+		packages[d.Package] = true
+	}
+	_, err := fmt.Fprintf(w, "%s across %s\n", plural(len(diags), "diagnostic"), plural(len(packages), "package"))
+	return err
+}
+
+// writeSummary prints a line per group counting its diagnostics and the
+// packages they were found in, largest groups first, e.g.
+//
+//	Config.APIKey: 37 mutation sites across 12 packages
+func writeSummary(w io.Writer, diags []diagnostic, group string) error {
+	keys, groups := groupDiagnostics(diags, group)
+	sort.SliceStable(keys, func(i, j int) bool { return len(groups[keys[i]]) > len(groups[keys[j]]) })
+
+	noun := "diagnostic"
+	if group == "type" || group == "field" {
+		noun = "mutation site"
+	}
+	for _, k := range keys {
+		packages := make(map[string]bool)
+		for _, d := range groups[k] {
+			packages[d.Package] = true
+		}
+		n := noun
+		if k == otherGroup {
+			n = "diagnostic"
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s across %s\n", k, plural(len(groups[k]), n), plural(len(packages), "package")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plural formats a count of things, e.g. "1 package" or "12 packages".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteSummary(t *testing.T) {
+	diag := func(pkg, file, category, field string) diagnostic {
+		return diagnostic{Package: pkg, Category: category, Field: field, position: token.Position{Filename: file}}
+	}
+	diags := []diagnostic{
+		diag("a", "a/a.go", "field-write", "Config.APIKey"),
+		diag("b", "b/b.go", "field-write", "Config.APIKey"),
+		diag("b", "b/b.go", "field-write", "Config.Name"),
+		diag("b", "b/b.go", "field-write", "Config.APIKey"),
+		diag("b", "b/b.go", "marker", ""),
+	}
+
+	for _, test := range []struct {
+		group string
+		want  string
+	}{
+		{"field", "Config.APIKey: 3 mutation sites across 2 packages\n" +
+			"(other): 1 diagnostic across 1 package\n" +
+			"Config.Name: 1 mutation site across 1 package\n"},
+		{"type", "Config: 4 mutation sites across 2 packages\n" +
+			"(other): 1 diagnostic across 1 package\n"},
+		{"rule", "field-write: 4 diagnostics across 2 packages\n" +
+			"marker: 1 diagnostic across 1 package\n"},
+		{"file", "b/b.go: 4 diagnostics across 1 package\n" +
+			"a/a.go: 1 diagnostic across 1 package\n"},
+	} {
+		var out bytes.Buffer
+		if err := writeSummary(&out, diags, test.group); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.want {
+			t.Errorf("-group=%s: got\n%s\nwant\n%s", test.group, out.String(), test.want)
+		}
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	diag := func(file string, line, column int, category, message string) diagnostic {
+		pos := token.Position{Filename: file, Line: line, Column: column}
+		return diagnostic{Category: category, Message: message, position: pos, end: pos}
+	}
+	diags := []diagnostic{
+		diag("b.go", 3, 1, "field-write", "assignment to const field T.F"),
+		diag("a.go", 10, 2, "marker", "unknown marker +cosnt"),
+		diag("a.go", 2, 5, "param-write", "assignment to const parameter p"),
+		diag("a.go", 2, 5, "field-write", "assignment to const field T.F"),
+		diag("b.go", 3, 1, "field-write", "assignment to const field T.F"),
+		diag("a.go", 10, 2, "dead-marker", "const field T.G is never initialized"),
+	}
+
+	var got []string
+	for _, d := range dedupe(diags) {
+		got = append(got, fmt.Sprintf("%s:%d:%d %s", d.position.Filename, d.position.Line, d.position.Column, d.Category))
+	}
+	want := []string{
+		"a.go:2:5 field-write",
+		"a.go:10:2 dead-marker",
+		"a.go:10:2 marker",
+		"b.go:3:1 field-write",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWriteCount(t *testing.T) {
+	diags := []diagnostic{{Package: "a"}, {Package: "b"}, {Package: "b"}}
+	var out bytes.Buffer
+	if err := writeCount(&out, diags); err != nil {
+		t.Fatal(err)
+	}
+	if want := "3 diagnostics across 2 packages\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	diags := []diagnostic{{
+		Package:    "example.com/shop",
+		Posn:       "shop/order.go:42:2",
+		Category:   "field-write",
+		Confidence: "definite",
+		Message:    "assignment to const field Order.ID",
+		Field:      "Order.ID",
+	}, {
+		Package:    "example.com/shop",
+		Posn:       "shop/order.go:50:1",
+		Category:   "marker",
+		Confidence: "definite",
+		Message:    "unknown marker +cosnt, did you mean #const?",
+	}}
+
+	for _, test := range []struct {
+		diags []diagnostic
+		group string
+		want  string
+	}{
+		{nil, "", "TAP version 13\n1..1\nok 1 - no diagnostics\n"},
+		{diags, "", "TAP version 13\n1..2\n" +
+			"not ok 1 - shop/order.go:42:2: assignment to const field Order.ID\n" +
+			"  ---\n  rule: \"field-write\"\n  confidence: \"definite\"\n  package: \"example.com/shop\"\n" +
+			"  field: \"Order.ID\"\n  ...\n" +
+			"not ok 2 - shop/order.go:50:1: unknown marker +cosnt, did you mean \\#const?\n" +
+			"  ---\n  rule: \"marker\"\n  confidence: \"definite\"\n  package: \"example.com/shop\"\n  ...\n"},
+		{diags[1:], "rule", "TAP version 13\n1..1\n# marker\n" +
+			"not ok 1 - shop/order.go:50:1: unknown marker +cosnt, did you mean \\#const?\n" +
+			"  ---\n  rule: \"marker\"\n  confidence: \"definite\"\n  package: \"example.com/shop\"\n  ...\n"},
+	} {
+		var out bytes.Buffer
+		if err := writeTAP(&out, test.diags, test.group); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.want {
+			t.Errorf("got\n%s\nwant\n%s", out.String(), test.want)
+		}
+	}
+}
+
+func TestWritePretty(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "order.go")
+	src := "package shop\n\ntype Order struct {\n\t// +const\n\tID string\n}\n\nfunc (o *Order) Reset() {\n\to.ID = \"é\" + o.ID\n}\n"
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pos := func(line, column int) token.Position {
+		return token.Position{Filename: file, Line: line, Column: column}
+	}
+	diags := []diagnostic{{
+		Posn:     file + ":9:2",
+		Category: "field-write",
+		Message:  "assignment to const field Order.ID",
+		Related:  []related{{Posn: file + ":4:5", Message: "field marked const here", position: pos(4, 5)}},
+		position: pos(9, 2),
+		end:      pos(9, 6),
+	}, {
+		Posn:     file + ":9:14",
+		Category: "marker",
+		Message:  "a warning",
+		position: pos(9, 14),
+		end:      pos(10, 1),
+	}, {
+		Posn:     file + ":9:2",
+		Category: "consolidate",
+		Message:  "a suggestion",
+		position: pos(9, 2),
+	}}
+
+	var out bytes.Buffer
+	if err := writePretty(&out, diags, "", false); err != nil {
+		t.Fatal(err)
+	}
+	want := file + ":9:2: error: assignment to const field Order.ID [field-write]\n" +
+		"    9 | \to.ID = \"é\" + o.ID\n" +
+		"      | \t^~~~\n" +
+		file + ":4:5: note: field marked const here\n" +
+		"    4 | \t// +const\n" +
+		"      | \t   ^\n" +
+		"\n" +
+		file + ":9:14: warning: a warning [marker]\n" +
+		"    9 | \to.ID = \"é\" + o.ID\n" +
+		"      | \t           ^\n" +
+		"\n" +
+		file + ":9:2: suggestion: a suggestion [consolidate]\n" +
+		"    9 | \to.ID = \"é\" + o.ID\n" +
+		"      | \t^\n" +
+		"\n"
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := writePretty(&out, diags[:1], "rule", true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), ansiBold+"== field-write ==") || !strings.Contains(out.String(), ansiRed+"error"+ansiReset) {
+		t.Errorf("colored output lacks the group header or the error color:\n%q", out.String())
+	}
+}
+
+func TestLimitIssues(t *testing.T) {
+	diags := []diagnostic{{Message: "first"}, {Message: "second"}, {Message: "third"}}
+	for format, want := range map[string]int{"text": 2, "pretty": 2, "json": 2, "tap": 2, "summary": 3} {
+		if got := limitIssues(diags, 2, format); len(got) != want {
+			t.Errorf("%s: got %d diagnostics, want %d", format, len(got), want)
+		}
+	}
+	if got := limitIssues(diags, 0, "pretty"); len(got) != 3 {
+		t.Errorf("got %d diagnostics without a limit, want 3", len(got))
+	}
+
+	var out bytes.Buffer
+	if err := writePretty(&out, limitIssues(diags, 2, "pretty"), "", false); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "error: ") != 2 || strings.Contains(out.String(), "third") {
+		t.Errorf("pretty output with -max-issues 2 shows other than the first two diagnostics:\n%s", out.String())
+	}
+}
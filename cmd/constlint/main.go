@@ -1,10 +1,83 @@
+// Command constlint runs the const analyzer over Go packages. It also provides
+// subcommands built on the analyzer, such as code generation:
+//
+//	constlint [-format text|pretty|json|summary] [-group key] [-flag] [package...]
+//	constlint daemon [-socket file] [-flag] [package...]
+//	constlint diff [-json] old new [-flag] [package...]
+//	constlint fix [-refactor] [-n] [package...]
+//	constlint gen <generator> [-flag] [package...]
+//	constlint graph [-o file] [-violations] [package...]
+//	constlint index [-o file] [package...]
+//	constlint init [-f] [-golangci]
+//	constlint migrate [-tag key] [-directives list] [-csv file] [-n] [package...]
+//	constlint query [-json] symbol [package...]
+//	constlint rank [-n count] [-json] [package...]
+//	constlint stats [-by-author] [-teams file] [-results file] [-json] [-flag] [package...]
+//	constlint testgen [-testdata dir] [-n] [-flag] [package...]
 package main
 
 import (
+	"os"
+	"strings"
+
 	"github.com/bunniesandbeatings/constlint/analyzer"
 	"golang.org/x/tools/go/analysis/singlechecker"
 )
 
+// commands maps subcommand names to their implementations. Each receives the
+// arguments following its name and returns the process exit code.
+var commands = map[string]func(args []string) int{
+	"daemon":  daemonMain,
+	"gen":     genMain,
+	"graph":   graphMain,
+	"index":   indexMain,
+	"init":    initMain,
+	"diff":    diffMain,
+	"fix":     fixMain,
+	"migrate": migrateMain,
+	"query":   queryMain,
+	"rank":    rankMain,
+	"stats":   statsMain,
+	"testgen": testgenMain,
+}
+
 func main() {
-	singlechecker.Main(analyzer.Analyzer)
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+	if singlecheckerOnly(os.Args[1:]) {
+		singlechecker.Main(analyzer.Analyzer)
+	}
+	os.Exit(lintMain(os.Args[1:]))
+}
+
+// singlecheckerFlags are the flags only the standard analysis driver
+// supports: those go vet uses to query and run a vet tool, and those applying
+// or printing fixes in other forms.
+var singlecheckerFlags = []string{"V", "flags", "fix", "diff", "json", "c", "debug"}
+
+// singlecheckerOnly reports whether args call for the standard analysis
+// driver: a go vet invocation, with a single .cfg file, or any of
+// singlecheckerFlags.
+func singlecheckerOnly(args []string) bool {
+	if len(args) == 1 && strings.HasSuffix(args[0], ".cfg") {
+		return true
+	}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		for _, flag := range singlecheckerFlags {
+			if name == flag {
+				return true
+			}
+		}
+	}
+	return false
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"go/token"
+	"os"
+	"strings"
+	"time"
+)
+
+// metrics aggregates a run of the linter, for charting the adoption of const
+// markers and the violations left over time.
+type metrics struct {
+	Time         string                     `json:"time"`
+	Commit       string                     `json:"commit,omitempty"` // HEAD of the git repository, if any
+	Diagnostics  int                        `json:"diagnostics"`
+	ConstFields  int                        `json:"constFields"`
+	DeepFields   int                        `json:"deepFields"`
+	SecretFields int                        `json:"secretFields"`
+	ConstParams  int                        `json:"constParams"`
+	Rules        map[string]int             `json:"rules"` // diagnostics per rule
+	Packages     map[string]*packageMetrics `json:"packages"`
+}
+
+// packageMetrics aggregates the results of a single package.
+type packageMetrics struct {
+	Diagnostics int            `json:"diagnostics"`
+	ConstFields int            `json:"constFields"`
+	ConstParams int            `json:"constParams"`
+	Rules       map[string]int `json:"rules,omitempty"`
+}
+
+// collectMetrics aggregates the diagnostics and const declarations of a run.
+func collectMetrics(diags []diagnostic, decls map[token.Position]constDecl, now time.Time) *metrics {
+	m := &metrics{
+		Time:        now.UTC().Format(time.RFC3339),
+		Diagnostics: len(diags),
+		Rules:       make(map[string]int),
+		Packages:    make(map[string]*packageMetrics),
+	}
+	pkg := func(path string) *packageMetrics {
+		p, ok := m.Packages[path]
+		if !ok {
+			p = &packageMetrics{Rules: make(map[string]int)}
+			m.Packages[path] = p
+		}
+		return p
+	}
+
+	for _, d := range decls {
+		p := pkg(d.pkg)
+		if d.param {
+			m.ConstParams++
+			p.ConstParams++
+			continue
+		}
+		m.ConstFields++
+		p.ConstFields++
+		if d.deep {
+			m.DeepFields++
+		}
+		if d.secret {
+			m.SecretFields++
+		}
+	}
+	for _, d := range diags {
+		m.Rules[d.Category]++
+		p := pkg(d.Package)
+		p.Diagnostics++
+		p.Rules[d.Category]++
+	}
+	return m
+}
+
+// headCommit returns the commit checked out in the git repository of the
+// working directory, or "" outside of one.
+func headCommit() string {
+	out, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// writeMetrics writes m as an indented JSON document to file.
+func writeMetrics(file string, m *metrics) error {
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(out, '\n'), 0o644)
+}
+
+// appendMetrics appends m to a history file as a single line of JSON, so the
+// file holds one snapshot per run, oldest first.
+func appendMetrics(file string, m *metrics) error {
+	out, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(out, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
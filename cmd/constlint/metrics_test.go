@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectMetrics(t *testing.T) {
+	diags := []diagnostic{
+		{Package: "shop", Category: "field-write"},
+		{Package: "shop", Category: "field-write"},
+		{Package: "shop", Category: "marker"},
+		{Package: "billing", Category: "param-write"},
+	}
+	decl := func(line int) token.Position { return token.Position{Filename: "x.go", Line: line} }
+	decls := map[token.Position]constDecl{
+		decl(1): {pkg: "shop"},
+		decl(2): {pkg: "shop", deep: true},
+		decl(3): {pkg: "billing", secret: true},
+		decl(4): {pkg: "billing", param: true},
+		decl(5): {pkg: "inventory"},
+	}
+	m := collectMetrics(diags, decls, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	got, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2024-05-01T12:00:00Z","diagnostics":4,"constFields":4,"deepFields":1,"secretFields":1,` +
+		`"constParams":1,"rules":{"field-write":2,"marker":1,"param-write":1},"packages":{` +
+		`"billing":{"diagnostics":1,"constFields":1,"constParams":1,"rules":{"param-write":1}},` +
+		`"inventory":{"diagnostics":0,"constFields":1,"constParams":0},` +
+		`"shop":{"diagnostics":3,"constFields":2,"constParams":0,"rules":{"field-write":2,"marker":1}}}}`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAppendMetrics(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history.jsonl")
+	for _, n := range []int{3, 1} {
+		if err := appendMetrics(file, &metrics{Diagnostics: n}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"diagnostics":3`) || !strings.Contains(lines[1], `"diagnostics":1`) {
+		t.Errorf("history holds\n%s\nwant a line per run, oldest first", data)
+	}
+}
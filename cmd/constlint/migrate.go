@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+)
+
+// migrateMain rewrites the annotations of other conventions into +const
+// markers across the given packages: struct tags like immutable:"true",
+// comment directives of other linters, and fields listed in a CSV file.
+func migrateMain(args []string) int {
+	flags := flag.NewFlagSet("constlint migrate", flag.ExitOnError)
+	tag := flags.String("tag", "immutable", "struct tag `key` marking fields immutable with the value \"true\", empty for none")
+	directives := flags.String("directives", "@immutable,@readonly",
+		"comma separated comment `directives` marking fields immutable, e.g. @immutable for // @immutable")
+	csvFile := flags.String("csv", "", "CSV `file` whose first column lists fields to mark, as Type.Field or path/to/pkg.Type.Field")
+	dryRun := flags.Bool("n", false, "print the conversions without rewriting files")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint migrate [-tag key] [-directives list] [-csv file] [-n] [package...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	m := gen.Migration{Tag: *tag, Fields: make(map[string]bool)}
+	for _, directive := range strings.Split(*directives, ",") {
+		if directive = strings.TrimSpace(directive); directive != "" {
+			m.Directives = append(m.Directives, directive)
+		}
+	}
+	if *csvFile != "" {
+		f, err := os.Open(*csvFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		err = readFieldList(f, m.Fields)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *csvFile, err)
+			return 1
+		}
+	}
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs, err := gen.Load("", patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, pkg := range pkgs {
+		files, conversions, err := m.Migrate(pkg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, c := range conversions {
+			if c.Skipped != "" {
+				fmt.Fprintf(os.Stderr, "%s: %s: not converted (%s): %s\n", relativePosition(c.Pos), c.Field, c.From, c.Skipped)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%s: %s: +const (was %s)\n", relativePosition(c.Pos), c.Field, c.From)
+		}
+		if *dryRun {
+			continue
+		}
+		for _, name := range sortedKeys(files) {
+			if err := os.WriteFile(name, files[name], 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// readFieldList adds the field names in the first column of a CSV file to
+// fields, skipping blank names and a header row, recognized by a first name
+// that isn't qualified.
+func readFieldList(r io.Reader, fields map[string]bool) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSpace(record[0])
+		if name == "" || first && !strings.Contains(name, ".") {
+			continue
+		}
+		fields[name] = true
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+)
+
+// ANSI escape sequences used by the pretty format.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiCyan   = "\x1b[1;36m"
+	ansiBlue   = "\x1b[1;34m"
+)
+
+// warningRules are the rules reporting doubtful markers and declarations
+// rather than broken guarantees, shown as warnings by the pretty format.
+var warningRules = map[string]bool{
+	analyzer.CategoryShallowConst: true,
+	analyzer.CategoryMarker:       true,
+	analyzer.CategoryDeadMarker:   true,
+	analyzer.CategoryConstructor:  true,
+	analyzer.CategorySetter:       true,
+}
+
+// suggestionRules are the rules proposing changes to code that is correct as
+// it is, shown as suggestions by the pretty format.
+var suggestionRules = map[string]bool{
+	analyzer.CategoryRedundantConst: true,
+	analyzer.CategoryConsolidate:    true,
+	analyzer.CategoryConstCandidate: true,
+	analyzer.CategoryValueReceiver:  true,
+}
+
+// severity returns how the pretty format labels a diagnostic of rule, and the
+// color of the label.
+func severity(rule string) (label, color string) {
+	switch {
+	case rule == analyzer.CategoryExemption:
+		return "note", ansiCyan
+	case suggestionRules[rule]:
+		return "suggestion", ansiCyan
+	case warningRules[rule]:
+		return "warning", ansiYellow
+	}
+	return "error", ansiRed
+}
+
+// useColor reports whether the pretty format colors its output to w for the
+// value of -color: always, never, or auto for terminals unless NO_COLOR is
+// set.
+func useColor(mode string, w io.Writer) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && isTerminal(w)
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// prettyWriter prints diagnostics like modern compilers do: a headline with
+// the severity, the offending source line with the reported range underlined,
+// and each related position, such as the marker declaring the field const, as
+// a note with its own line.
+type prettyWriter struct {
+	w     io.Writer
+	color bool
+	lines map[string][][]byte // source lines by file name, nil if unreadable
+}
+
+// writePretty prints diags in the pretty format, under a line naming their
+// group when grouped.
+func writePretty(w io.Writer, diags []diagnostic, group string, color bool) error {
+	p := &prettyWriter{w: w, color: color, lines: make(map[string][][]byte)}
+	if group == "" {
+		for _, d := range diags {
+			if err := p.write(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	keys, groups := groupDiagnostics(diags, group)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s\n\n", p.paint(ansiBold, "== "+k+" ==")); err != nil {
+			return err
+		}
+		for _, d := range groups[k] {
+			if err := p.write(d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write prints a single diagnostic followed by a blank line.
+func (p *prettyWriter) write(d diagnostic) error {
+	var buf bytes.Buffer
+	label, color := severity(d.Category)
+	fmt.Fprintf(&buf, "%s: %s: %s", p.paint(ansiBold, d.Posn), p.paint(color, label), p.paint(ansiBold, d.Message))
+	switch {
+	case d.Confidence != "" && d.Confidence != analyzer.Definite.String():
+		fmt.Fprintf(&buf, " [%s, %s]", d.Category, d.Confidence)
+	case d.Category != "":
+		fmt.Fprintf(&buf, " [%s]", d.Category)
+	}
+	buf.WriteString("\n")
+	p.snippet(&buf, d.position.Filename, d.position.Line, d.position.Column, d.end, color)
+
+	for _, r := range d.Related {
+		fmt.Fprintf(&buf, "%s: %s: %s\n", p.paint(ansiBold, r.Posn), p.paint(ansiCyan, "note"), r.Message)
+		p.snippet(&buf, r.position.Filename, r.position.Line, r.position.Column, r.position, ansiCyan)
+	}
+	buf.WriteString("\n")
+	_, err := p.w.Write(buf.Bytes())
+	return err
+}
+
+// snippet prints a line of a file with a caret under the given column,
+// continued by tildes up to end when it is on the same line. It prints
+// nothing when the line can't be read.
+func (p *prettyWriter) snippet(buf *bytes.Buffer, filename string, line, column int, end token.Position, color string) {
+	src := p.source(filename)
+	if line < 1 || line > len(src) || column < 1 {
+		return
+	}
+	text := src[line-1]
+	if column > len(text)+1 {
+		column = len(text) + 1
+	}
+	width := 1
+	if end.Filename == filename && end.Line == line && end.Column > column {
+		width = utf8.RuneCount(text[column-1 : min(end.Column, len(text)+1)-1])
+	}
+
+	// The underline copies the tabs of the line so it lines up however wide
+	// the terminal shows them.
+	var indent strings.Builder
+	for _, c := range string(text[:column-1]) {
+		if c == '\t' {
+			indent.WriteByte('\t')
+		} else {
+			indent.WriteByte(' ')
+		}
+	}
+	gutter := fmt.Sprintf("%5d | ", line)
+	fmt.Fprintf(buf, "%s%s\n", p.paint(ansiBlue, gutter), text)
+	underline := "^" + strings.Repeat("~", max(width-1, 0))
+	fmt.Fprintf(buf, "%s%s%s\n", p.paint(ansiBlue, "      | "), indent.String(), p.paint(color, underline))
+}
+
+// source returns the lines of a file, reading it once.
+func (p *prettyWriter) source(filename string) [][]byte {
+	if lines, ok := p.lines[filename]; ok {
+		return lines
+	}
+	data, err := readSource(filename)
+	var lines [][]byte
+	if err == nil {
+		lines = bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+		for i, line := range lines {
+			lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+		}
+	}
+	p.lines[filename] = lines
+	return lines
+}
+
+// paint wraps s in an ANSI color when coloring.
+func (p *prettyWriter) paint(color, s string) string {
+	if !p.color {
+		return s
+	}
+	return color + s + ansiReset
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+	"golang.org/x/tools/go/packages"
+)
+
+// queryMain prints whether a field, parameter or package-level variable is
+// const, where its marker is, which functions initialize it and where it is
+// written, answering without a full lint run what would otherwise take
+// grepping through its diagnostics.
+func queryMain(args []string) int {
+	flags := flag.NewFlagSet("constlint query", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the answer as JSON")
+	tests := flags.Bool("test", true, "also check test files")
+	wholeProgram := flags.Bool("whole-program", false, "also list the writes to a const field -whole-program reports")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint query [-json] [-test] [-whole-program] symbol [package...]\n")
+		fmt.Fprintf(os.Stderr, "symbol is a package name or path followed by Type.Field, Func.param, Type.Method.param or Var, e.g. shop.Order.ID\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	symbol, patterns := flags.Arg(0), flags.Args()[1:]
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	configs := newConfigLoader(flags)
+	initial, err := loadPackages(&packages.Config{Tests: *tests}, patterns)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	roots, err := runAnalyzer(initial, func(dir string) (map[string]string, error) {
+		c, err := configs.load(dir)
+		if err != nil {
+			return nil, err
+		}
+		return c.analyzerSettings(nil), nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	results, err := actionResults(roots)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	c, err := lookupConstness(resultsByPath(results), symbol, relativePosition)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "constlint: %v\n", err)
+		return 1
+	}
+	if *wholeProgram && c.Kind == "field" && c.Const {
+		diags, decls := analysisResults(results)
+		for _, d := range checkWholeProgram(initial, decls, diags, "vta") {
+			if relativePosition(d.decl) == c.Declared {
+				c.Writes = append(c.Writes, siteAt(initial, d.position))
+			}
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(c)
+	} else {
+		err = writeConstness(os.Stdout, symbol, c)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// resultsByPath groups the analyzer's results by the path of their package,
+// or for test variants of the package they test.
+func resultsByPath(results []*packageResult) map[string][]*packageResult {
+	byPath := make(map[string][]*packageResult)
+	for _, result := range results {
+		path := testedPath(result.pkg)
+		byPath[path] = append(byPath[path], result)
+	}
+	return byPath
+}
+
+// siteAt returns the write site at a position of the packages.
+func siteAt(pkgs []*packages.Package, position token.Position) Site {
+	site := Site{Posn: relativePosition(position)}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			tokFile := pkg.Fset.File(file.FileStart)
+			if tokFile.Name() != position.Filename || position.Line > tokFile.LineCount() {
+				continue
+			}
+			pos := tokFile.LineStart(position.Line) + token.Pos(position.Column-1)
+			site.Func = enclosingFuncName(&gen.Package{Package: pkg}, pos)
+			return site
+		}
+	}
+	return site
+}
+
+// writeConstness prints the constness of symbol, e.g.
+//
+//	shop.Order.ID: const field (shallow)
+//	  because:  orders are looked up by ID
+//	  declared: shop/order.go:9:2
+//	  marker:   shop/order.go:9:20
+//	  initialized by NewOrder at shop/order.go:18:17
+//	  written by (*Order).Rename at shop/order.go:30:2
+func writeConstness(w io.Writer, symbol string, c Constness) error {
+	state := "mutable"
+	if c.Const {
+		state = "const"
+	}
+	header := fmt.Sprintf("%s: %s %s", symbol, state, c.Kind)
+	if c.Mode != "" {
+		header += " (" + c.Mode + ")"
+	}
+	if c.WriteOnce {
+		header += ", write-once"
+	}
+	if c.Secret {
+		header += ", secret"
+	}
+	lines := []string{header}
+	if c.Reason != "" {
+		lines = append(lines, "  because:  "+c.Reason)
+	}
+	lines = append(lines, "  declared: "+c.Declared)
+	if c.Marker != "" {
+		lines = append(lines, "  marker:   "+c.Marker)
+	}
+	for _, site := range c.Inits {
+		lines = append(lines, fmt.Sprintf("  initialized by %s at %s", site.Func, site.Posn))
+	}
+	for _, site := range c.Writes {
+		lines = append(lines, fmt.Sprintf("  written by %s at %s", site.Func, site.Posn))
+	}
+	if c.Const && c.Kind == "field" && len(c.Writes) == 0 {
+		lines = append(lines, "  no writes reported")
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestQuery(t *testing.T) {
+	initial, err := loadPackages(&packages.Config{}, []string{"./testdata/wholeprogram/..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots, err := runAnalyzer(initial, func(string) (map[string]string, error) { return nil, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := actionResults(roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byPath := resultsByPath(results)
+	base := func(pos token.Position) string {
+		pos.Filename = filepath.Base(pos.Filename)
+		return pos.String()
+	}
+
+	for _, test := range []struct {
+		symbol string
+		want   string
+	}{
+		{"store.Item.Tags", `store.Item.Tags: const field (deep)
+  declared: store.go:8:2
+  marker:   store.go:7:5
+  initialized by NewItem at store.go:14:25
+  written by tag at store.go:33:2
+`},
+		{"store.NewItem.sku", `store.NewItem.sku: mutable parameter
+  declared: store.go:13:14
+`},
+		{"store.Item.Label", "error: github.com/bunniesandbeatings/constlint/cmd/constlint/testdata/wholeprogram/store " +
+			"declares no field, parameter or variable Item.Label"},
+	} {
+		var out bytes.Buffer
+		c, err := lookupConstness(byPath, test.symbol, base)
+		if err == nil {
+			err = writeConstness(&out, test.symbol, c)
+		}
+		got := out.String()
+		if err != nil {
+			got = "error: " + err.Error()
+		}
+		if got != test.want {
+			t.Errorf("query %s: got\n%s\nwant\n%s", test.symbol, got, test.want)
+		}
+	}
+}
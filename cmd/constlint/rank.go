@@ -0,0 +1,327 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// candidate is a struct field without a const marker that behaves as if it
+// had one: nothing writes it but the functions creating values of its type.
+type candidate struct {
+	Package  string `json:"package"`
+	Type     string `json:"type"`
+	Field    string `json:"field"`
+	Declared string `json:"declared"`
+	Exported bool   `json:"exported"` // the field and its type are exported
+	Reads    int    `json:"reads"`
+	Readers  int    `json:"readers"` // packages other than the declaring one reading the field
+	Inits    int    `json:"inits"`   // writes when creating values of the type
+	Score    int    `json:"score"`
+
+	owner   *types.TypeName
+	pos     token.Position
+	writes  int // writes to existing values
+	readers map[*types.Package]bool
+}
+
+// rankMain lists the fields of the given packages that could be marked const
+// without violations, most const-like first, as a prioritized list of markers
+// to adopt by hand, where migrate converts existing annotations wholesale.
+func rankMain(args []string) int {
+	flags := flag.NewFlagSet("constlint rank", flag.ExitOnError)
+	limit := flags.Int("n", 0, "list at most `count` fields, 0 for all")
+	asJSON := flags.Bool("json", false, "print the ranking as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint rank [-n count] [-json] [package...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs, err := gen.Load("", patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ranking := rankCandidates(pkgs)
+	if *limit > 0 && len(ranking) > *limit {
+		ranking = ranking[:*limit]
+	}
+	if *asJSON {
+		out, err := json.MarshalIndent(ranking, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		os.Stdout.Write(append(out, '\n'))
+		return 0
+	}
+	writeRanking(os.Stdout, ranking)
+	return 0
+}
+
+// rankCandidates returns the candidates among the fields of the struct types
+// declared at package level in pkgs, ordered by score. A field scores one per
+// read, ten per other package reading it, and ten more when it is exported,
+// as the contract then matters to more code. Fields written outside of the
+// functions creating values of their type aren't candidates, nor are fields
+// nobody reads, which gain nothing from the marker.
+//
+// Uses are counted across pkgs only, so the ranking is only as good as the
+// packages given: rank a whole module, not the package declaring the fields.
+func rankCandidates(pkgs []*gen.Package) []*candidate {
+	candidates := make(map[*types.Var]*candidate)
+	for _, pkg := range pkgs {
+		marked := make(map[*types.Var]bool)
+		for _, s := range pkg.Inventory.Structs {
+			for _, cf := range s.Fields {
+				marked[cf.Var] = true
+			}
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			structType, ok := typeName.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			for i := 0; i < structType.NumFields(); i++ {
+				field := structType.Field(i)
+				if field.Embedded() || field.Name() == "_" || marked[field] {
+					continue
+				}
+				candidates[field] = &candidate{
+					Package:  pkg.PkgPath,
+					Type:     typeName.Name(),
+					Field:    field.Name(),
+					Exported: typeName.Exported() && field.Exported(),
+					owner:    typeName,
+					pos:      pkg.Fset.Position(field.Pos()),
+					readers:  make(map[*types.Package]bool),
+				}
+			}
+		}
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			countFieldUses(pkg, file, candidates)
+		}
+	}
+
+	var ranking []*candidate
+	for _, c := range candidates {
+		if c.writes > 0 || c.Reads == 0 {
+			continue
+		}
+		c.Declared = relativePosition(c.pos)
+		c.Readers = len(c.readers)
+		c.Score = c.Reads + 10*c.Readers
+		if c.Exported {
+			c.Score += 10
+		}
+		ranking = append(ranking, c)
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		a, b := ranking[i], ranking[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Type+"."+a.Field < b.Type+"."+b.Field
+	})
+	return ranking
+}
+
+// countFieldUses counts the reads and writes of the candidates in a file.
+// Assignments, increments and taking the address of a field write it, as do
+// those of the fields and array elements of a struct or array it holds by
+// value; composite literals initialize the fields they set.
+func countFieldUses(pkg *gen.Package, file *ast.File, candidates map[*types.Var]*candidate) {
+	info := pkg.TypesInfo
+	lookup := func(sel *ast.SelectorExpr) *candidate {
+		selection, ok := info.Selections[sel]
+		if !ok || selection.Kind() != types.FieldVal {
+			return nil
+		}
+		return candidates[selection.Obj().(*types.Var).Origin()]
+	}
+
+	for _, decl := range file.Decls {
+		funcDecl, _ := decl.(*ast.FuncDecl)
+		written := make(map[*ast.SelectorExpr]bool)
+		markWritten := func(expr ast.Expr) {
+			for _, sel := range assignedFields(info, expr) {
+				written[sel] = true
+			}
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.AssignStmt:
+				for _, lhs := range node.Lhs {
+					markWritten(lhs)
+				}
+			case *ast.IncDecStmt:
+				markWritten(node.X)
+			case *ast.RangeStmt:
+				if node.Tok == token.ASSIGN {
+					for _, lhs := range []ast.Expr{node.Key, node.Value} {
+						if lhs != nil {
+							markWritten(lhs)
+						}
+					}
+				}
+			case *ast.UnaryExpr:
+				if node.Op == token.AND {
+					markWritten(node.X)
+				}
+
+			case *ast.CompositeLit:
+				structType, ok := literalStruct(info.TypeOf(node)).(*types.Struct)
+				if !ok {
+					return true
+				}
+				for i, elt := range node.Elts {
+					var field *types.Var
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						key, _ := kv.Key.(*ast.Ident)
+						if key == nil {
+							continue
+						}
+						field, _ = info.Uses[key].(*types.Var)
+					} else if i < structType.NumFields() {
+						field = structType.Field(i)
+					}
+					if field != nil {
+						if c := candidates[field.Origin()]; c != nil {
+							c.Inits++
+						}
+					}
+				}
+
+			case *ast.SelectorExpr:
+				c := lookup(node)
+				if c == nil {
+					return true
+				}
+				if !written[node] {
+					c.Reads++
+					if pkg.Types.Path() != c.Package {
+						c.readers[pkg.Types] = true
+					}
+					return true
+				}
+				if funcDecl != nil && instantiates(info, funcDecl, c.owner) {
+					c.Inits++
+				} else {
+					c.writes++
+				}
+			}
+			return true
+		})
+	}
+}
+
+// assignedFields returns the field selectors an assignment to expr writes: the
+// field of x.F, and those x.F holds by value in x.F.G or x.F[i] with F an
+// array.
+func assignedFields(info *types.Info, expr ast.Expr) []*ast.SelectorExpr {
+	var fields []*ast.SelectorExpr
+	for {
+		switch e := ast.Unparen(expr).(type) {
+		case *ast.SelectorExpr:
+			selection, ok := info.Selections[e]
+			if !ok || selection.Kind() != types.FieldVal {
+				return fields
+			}
+			fields = append(fields, e)
+			if selection.Indirect() {
+				return fields
+			}
+			if _, ok := info.TypeOf(e.X).Underlying().(*types.Pointer); ok {
+				return fields
+			}
+			expr = e.X
+		case *ast.IndexExpr:
+			if _, ok := info.TypeOf(e.X).Underlying().(*types.Array); !ok {
+				return fields
+			}
+			expr = e.X
+		default:
+			return fields
+		}
+	}
+}
+
+// instantiates reports whether a function creates values of the type owner,
+// with a composite literal, new or a var declaration. Such functions
+// initialize the fields they write, as the analyzer allows.
+func instantiates(info *types.Info, funcDecl *ast.FuncDecl, owner *types.TypeName) bool {
+	if funcDecl.Body == nil {
+		return false
+	}
+	isOwner := func(t types.Type) bool {
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		other, ok := t.(*types.Named)
+		return ok && other.Origin().Obj() == owner
+	}
+
+	found := false
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+			found = found || isOwner(info.TypeOf(n))
+		case *ast.CallExpr:
+			builtin, ok := typeutil.Callee(info, n).(*types.Builtin)
+			found = found || ok && builtin.Name() == "new" && len(n.Args) == 1 && isOwner(info.TypeOf(n.Args[0]))
+		case *ast.ValueSpec:
+			found = found || n.Type != nil && len(n.Values) == 0 && isOwner(info.TypeOf(n.Type))
+		}
+		return !found
+	})
+	return found
+}
+
+// literalStruct returns the underlying type of a composite literal, through
+// the pointer of literals elided from &T{}, as in []*T{{...}}.
+func literalStruct(t types.Type) types.Type {
+	if t == nil {
+		return nil
+	}
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	return t.Underlying()
+}
+
+// writeRanking prints a ranking as an aligned table.
+func writeRanking(w io.Writer, ranking []*candidate) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCORE\tFIELD\tREADS\tREADERS\tINITS\tDECLARED")
+	for _, c := range ranking {
+		fmt.Fprintf(tw, "%d\t%s.%s\t%d\t%d\t%d\t%s\n", c.Score, c.Type, c.Field, c.Reads, c.Readers, c.Inits, c.Declared)
+	}
+	tw.Flush()
+}
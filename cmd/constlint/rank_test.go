@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+)
+
+func TestRankCandidates(t *testing.T) {
+	pkgs, err := gen.Load("", "./testdata/rank/...")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	writeRanking(&out, rankCandidates(pkgs))
+	want := []string{
+		"SCORE  FIELD           READS  READERS  INITS  DECLARED",
+		"23     Order.Customer  3      1        1      testdata/rank/shop/shop.go:8:2",
+		"11     Order.Items     1      0        0      testdata/rank/shop/shop.go:10:2",
+		"1      Order.created   1      0        1      testdata/rank/shop/shop.go:12:2",
+	}
+	if got := strings.TrimSpace(out.String()); got != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// errNewPackages is returned when packages the daemon checks again import a
+// package it hasn't loaded, or have test variants it hasn't seen: then it
+// loads all its packages again.
+var errNewPackages = errors.New("the changes bring in packages not loaded yet")
+
+// factStore holds the facts the analyzer exported for every package the
+// daemon loaded, so checking a package again needs the facts of its
+// dependencies, not another analysis of them.
+type factStore struct {
+	objects  map[objectFactKey]analysis.Fact
+	packages map[packageFactKey]analysis.Fact
+}
+
+type objectFactKey struct {
+	obj types.Object
+	typ reflect.Type
+}
+
+type packageFactKey struct {
+	pkg *types.Package
+	typ reflect.Type
+}
+
+func newFactStore() *factStore {
+	return &factStore{
+		objects:  make(map[objectFactKey]analysis.Fact),
+		packages: make(map[packageFactKey]analysis.Fact),
+	}
+}
+
+// addAction adds the facts of an action of the checker, which include those
+// of the packages it imports, directly or not.
+func (s *factStore) addAction(act *checker.Action) {
+	for _, f := range act.AllObjectFacts() {
+		s.objects[objectFactKey{f.Object, reflect.TypeOf(f.Fact)}] = f.Fact
+	}
+	for _, f := range act.AllPackageFacts() {
+		s.packages[packageFactKey{f.Package, reflect.TypeOf(f.Fact)}] = f.Fact
+	}
+}
+
+// forget drops the facts about the objects of pkg, a version of a package
+// that has been checked again.
+func (s *factStore) forget(pkg *types.Package) {
+	for key := range s.objects {
+		if key.obj.Pkg() == pkg {
+			delete(s.objects, key)
+		}
+	}
+	for key := range s.packages {
+		if key.pkg == pkg {
+			delete(s.packages, key)
+		}
+	}
+}
+
+// importFact copies a stored fact into fact, as Pass.ImportObjectFact and
+// Pass.ImportPackageFact do.
+func importFact(stored analysis.Fact, ok bool, fact analysis.Fact) bool {
+	if ok {
+		reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(stored).Elem())
+	}
+	return ok
+}
+
+// objectFacts returns the object facts about the objects of pkgs.
+func (s *factStore) objectFacts(pkgs map[*types.Package]bool) []analysis.ObjectFact {
+	var facts []analysis.ObjectFact
+	for key, fact := range s.objects {
+		if pkgs[key.obj.Pkg()] {
+			facts = append(facts, analysis.ObjectFact{Object: key.obj, Fact: fact})
+		}
+	}
+	return facts
+}
+
+// packageFacts returns the package facts about pkgs.
+func (s *factStore) packageFacts(pkgs map[*types.Package]bool) []analysis.PackageFact {
+	var facts []analysis.PackageFact
+	for key, fact := range s.packages {
+		if pkgs[key.pkg] {
+			facts = append(facts, analysis.PackageFact{Package: key.pkg, Fact: fact})
+		}
+	}
+	return facts
+}
+
+// typeCheck parses the files of the package meta describes into fset and
+// type-checks them against the loaded packages its imports name, by ID.
+func typeCheck(meta *packages.Package, loaded map[string]*packages.Package, fset *token.FileSet) (*packages.Package,
+	error) {
+	pkg := &packages.Package{
+		ID:              meta.ID,
+		Name:            meta.Name,
+		PkgPath:         meta.PkgPath,
+		Errors:          meta.Errors,
+		GoFiles:         meta.GoFiles,
+		CompiledGoFiles: meta.CompiledGoFiles,
+		OtherFiles:      meta.OtherFiles,
+		EmbedFiles:      meta.EmbedFiles,
+		EmbedPatterns:   meta.EmbedPatterns,
+		IgnoredFiles:    meta.IgnoredFiles,
+		ExportFile:      meta.ExportFile,
+		Imports:         make(map[string]*packages.Package),
+		Module:          meta.Module,
+		ForTest:         meta.ForTest,
+		Fset:            fset,
+		TypesSizes:      meta.TypesSizes,
+	}
+	for path, imp := range meta.Imports {
+		dep, ok := loaded[imp.ID]
+		if !ok {
+			return nil, errNewPackages
+		}
+		pkg.Imports[path] = dep
+	}
+
+	for _, file := range meta.CompiledGoFiles {
+		// Parsed as go/packages parses the files it loads.
+		f, err := parser.ParseFile(fset, file, nil, parser.AllErrors|parser.ParseComments|parser.SkipObjectResolution)
+		if f != nil {
+			pkg.Syntax = append(pkg.Syntax, f)
+		}
+		var list scanner.ErrorList
+		if errors.As(err, &list) {
+			for _, e := range list {
+				pkg.Errors = append(pkg.Errors, packages.Error{Pos: e.Pos.String(), Msg: e.Msg, Kind: packages.ParseError})
+			}
+		} else if err != nil {
+			pkg.Errors = append(pkg.Errors, packages.Error{Msg: err.Error(), Kind: packages.ParseError})
+		}
+	}
+
+	pkg.TypesInfo = &types.Info{
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Implicits:    make(map[ast.Node]types.Object),
+		Instances:    make(map[*ast.Ident]types.Instance),
+		Scopes:       make(map[ast.Node]*types.Scope),
+		Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+		FileVersions: make(map[*ast.File]string),
+	}
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			dep, ok := pkg.Imports[path]
+			if !ok {
+				return nil, fmt.Errorf("no package for import %q", path)
+			}
+			return dep.Types, nil
+		}),
+		Sizes: meta.TypesSizes,
+		Error: func(err error) {
+			terr, _ := err.(types.Error)
+			pkg.TypeErrors = append(pkg.TypeErrors, terr)
+			pkg.Errors = append(pkg.Errors, packages.Error{
+				Pos:  fset.Position(terr.Pos).String(),
+				Msg:  terr.Msg,
+				Kind: packages.TypeError,
+			})
+		},
+	}
+	if meta.Module != nil && meta.Module.GoVersion != "" {
+		conf.GoVersion = "go" + meta.Module.GoVersion
+	}
+	pkg.Types, _ = conf.Check(meta.PkgPath, fset, pkg.Syntax, pkg.TypesInfo)
+	pkg.IllTyped = len(pkg.Errors) > 0
+	return pkg, nil
+}
+
+// importerFunc is a types.Importer calling a function.
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// analyzePackage runs the analyzer and the analyzers it requires over a
+// package, importing the facts of its dependencies from facts and exporting
+// its own there, as the checker would with the analysis of the dependencies
+// it repeats. The analyzer's flags must be set for the package.
+func analyzePackage(pkg *packages.Package, facts *factStore) (*packageResult, error) {
+	deps := make(map[*types.Package]bool)
+	packages.Visit([]*packages.Package{pkg}, nil, func(dep *packages.Package) { deps[dep.Types] = true })
+
+	results := make(map[*analysis.Analyzer]any)
+	var diagnostics []analysis.Diagnostic
+	var run func(a *analysis.Analyzer) error
+	run = func(a *analysis.Analyzer) error {
+		if _, ok := results[a]; ok {
+			return nil
+		}
+		for _, req := range a.Requires {
+			if err := run(req); err != nil {
+				return err
+			}
+		}
+		pass := &analysis.Pass{
+			Analyzer:     a,
+			Fset:         pkg.Fset,
+			Files:        pkg.Syntax,
+			OtherFiles:   pkg.OtherFiles,
+			IgnoredFiles: pkg.IgnoredFiles,
+			Pkg:          pkg.Types,
+			TypesInfo:    pkg.TypesInfo,
+			TypesSizes:   pkg.TypesSizes,
+			TypeErrors:   pkg.TypeErrors,
+			ResultOf:     results,
+			Report: func(d analysis.Diagnostic) {
+				if a == analyzer.Analyzer {
+					diagnostics = append(diagnostics, d)
+				}
+			},
+			ReadFile: os.ReadFile,
+			ImportObjectFact: func(obj types.Object, fact analysis.Fact) bool {
+				stored, ok := facts.objects[objectFactKey{obj, reflect.TypeOf(fact)}]
+				return importFact(stored, ok, fact)
+			},
+			ExportObjectFact: func(obj types.Object, fact analysis.Fact) {
+				facts.objects[objectFactKey{obj, reflect.TypeOf(fact)}] = fact
+			},
+			ImportPackageFact: func(p *types.Package, fact analysis.Fact) bool {
+				stored, ok := facts.packages[packageFactKey{p, reflect.TypeOf(fact)}]
+				return importFact(stored, ok, fact)
+			},
+			ExportPackageFact: func(fact analysis.Fact) {
+				facts.packages[packageFactKey{pkg.Types, reflect.TypeOf(fact)}] = fact
+			},
+			AllObjectFacts:  func() []analysis.ObjectFact { return facts.objectFacts(deps) },
+			AllPackageFacts: func() []analysis.PackageFact { return facts.packageFacts(deps) },
+		}
+		if pkg.Module != nil {
+			pass.Module = &analysis.Module{
+				Path:      pkg.Module.Path,
+				Version:   pkg.Module.Version,
+				GoVersion: pkg.Module.GoVersion,
+			}
+		}
+		result, err := a.Run(pass)
+		if err != nil {
+			return fmt.Errorf("%s: %v", pkg.PkgPath, err)
+		}
+		results[a] = result
+		return nil
+	}
+	if err := run(analyzer.Analyzer); err != nil {
+		return nil, err
+	}
+	return &packageResult{
+		pkg:         pkg,
+		inventory:   results[analyzer.Analyzer].(*analyzer.Inventory),
+		diagnostics: diagnostics,
+		objectFacts: facts.objectFacts(deps),
+	}, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// scheduling controls how runAnalyzer goes through packages; lintMain sets it
+// from its flags.
+var scheduling struct {
+	concurrency int       // packages analyzed at once, 0 for no limit
+	progress    io.Writer // where to report packages analyzed so far, if anywhere
+	timings     io.Writer // where to list the time each package took, if anywhere
+}
+
+// packageTiming is the time the analyzer took on a package.
+type packageTiming struct {
+	pkg      string
+	duration time.Duration
+}
+
+// runMonitor bounds the number of packages analyzed at once, following
+// scheduling, and reports progress and timings as they are analyzed.
+type runMonitor struct {
+	slots   chan struct{} // held while analyzing a package, nil for no limit
+	total   int
+	start   time.Time
+	mu      sync.Mutex
+	done    int
+	shown   time.Time // of the last progress line
+	timings []packageTiming
+}
+
+// progressInterval is the least time between progress lines.
+const progressInterval = 200 * time.Millisecond
+
+// monitorRun returns a copy of the analyzer whose run function monitors the
+// analysis of the total packages that groups of initial packages and their
+// dependencies come to, and a function reporting the timings. The analyzer
+// itself is left alone, for other runs.
+func monitorRun(groups [][]*packages.Package) (*analysis.Analyzer, func()) {
+	m := &runMonitor{start: time.Now()}
+	for _, group := range groups {
+		packages.Visit(group, nil, func(*packages.Package) { m.total++ })
+	}
+	if scheduling.concurrency > 0 {
+		m.slots = make(chan struct{}, scheduling.concurrency)
+	}
+	monitored := *analyzer.Analyzer
+	monitored.Run = func(pass *analysis.Pass) (any, error) {
+		if m.slots != nil {
+			m.slots <- struct{}{}
+			defer func() { <-m.slots }()
+		}
+		start := time.Now()
+		defer func() { m.finished(pass.Pkg.Path(), time.Since(start)) }()
+		return analyzer.Analyzer.Run(pass)
+	}
+	return &monitored, m.report
+}
+
+// finished records the analysis of a package and reports progress, at most
+// every progressInterval, with an estimate of the time left, e.g.
+//
+//	constlint: analyzed 120/480 packages, about 35s left
+func (m *runMonitor) finished(pkg string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.done++
+	m.timings = append(m.timings, packageTiming{pkg, d})
+	if scheduling.progress == nil || time.Since(m.shown) < progressInterval && m.done < m.total {
+		return
+	}
+	m.shown = time.Now()
+	elapsed := time.Since(m.start)
+	left := time.Duration(float64(elapsed) / float64(m.done) * float64(max(m.total-m.done, 0)))
+	fmt.Fprintf(scheduling.progress, "\rconstlint: analyzed %d/%d packages, about %s left\x1b[K",
+		m.done, m.total, left.Round(time.Second))
+}
+
+// report ends the progress line and lists the time the analyzer took on each
+// package, slowest first, e.g.
+//
+//	constlint: 1.24s example.com/shop/orders
+func (m *runMonitor) report() {
+	if scheduling.progress != nil && m.done > 0 {
+		fmt.Fprintf(scheduling.progress, "\r\x1b[K")
+	}
+	if scheduling.timings == nil {
+		return
+	}
+	sort.SliceStable(m.timings, func(i, j int) bool { return m.timings[i].duration > m.timings[j].duration })
+	for _, t := range m.timings {
+		fmt.Fprintf(scheduling.timings, "constlint: %s %s\n", t.duration.Round(time.Millisecond), t.pkg)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+)
+
+func TestMonitorRun(t *testing.T) {
+	var progress, timings bytes.Buffer
+	scheduling.concurrency, scheduling.progress, scheduling.timings = 2, &progress, &timings
+	defer func() { scheduling.concurrency, scheduling.progress, scheduling.timings = 0, nil, nil }()
+
+	noSettings := func(string) (map[string]string, error) { return nil, nil }
+	if _, _, err := lint([]string{"./testdata/wholeprogram/..."}, false, nil, "", noSettings); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last progress line counts every package, and is then erased.
+	last := regexp.MustCompile(`\rconstlint: analyzed (\d+)/(\d+) packages, about 0s left\x1b\[K\r\x1b\[K$`)
+	m := last.FindStringSubmatch(progress.String())
+	if m == nil || m[1] != m[2] {
+		t.Fatalf("got progress %q, want it to end with all packages analyzed", progress.String())
+	}
+	lines := strings.Split(strings.TrimSpace(timings.String()), "\n")
+	if total, _ := strconv.Atoi(m[2]); len(lines) != total {
+		t.Errorf("got %d timings, want one per package, %s", len(lines), m[2])
+	}
+	if !strings.Contains(timings.String(), "/testdata/wholeprogram/") {
+		t.Errorf("got timings\n%s\nwant those of the wholeprogram packages", timings.String())
+	}
+}
+
+func TestMonitorRunCopiesAnalyzer(t *testing.T) {
+	run := reflect.ValueOf(analyzer.Analyzer.Run).Pointer()
+	a, report := monitorRun(nil)
+	defer report()
+	if a == analyzer.Analyzer || reflect.ValueOf(analyzer.Analyzer.Run).Pointer() != run {
+		t.Error("monitorRun replaced the run function of the exported analyzer")
+	}
+	if reflect.ValueOf(a.Run).Pointer() == run {
+		t.Error("monitorRun returned an unmonitored analyzer")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// stagedFiles returns the absolute paths of the Go files added, copied,
+// modified or renamed in the git index.
+func stagedFiles() ([]string, error) {
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	out, err := git("diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z", "--", "*.go")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, filepath.Join(strings.TrimSpace(top), filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
+
+// overlay holds the contents packages are loaded with, and diagnostics shown
+// and fingerprinted with, in place of those of the files on disk, by absolute
+// path: with -staged, those of the git index.
+var overlay map[string][]byte
+
+// indexOverlay returns the contents of the Go files in the git index that
+// differ from the working tree, being modified or deleted there since they
+// were staged, by absolute path. Loaded with them, packages are checked as
+// they would be committed, but for files not in the index at all.
+func indexOverlay() (map[string][]byte, error) {
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	out, err := git("diff", "--name-only", "--diff-filter=DMT", "-z", "--", "*.go")
+	if err != nil {
+		return nil, err
+	}
+	contents := make(map[string][]byte)
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" {
+			continue
+		}
+		// :name is the file in the index, relative to the top of the tree.
+		src, err := git("show", ":"+name)
+		if err != nil {
+			return nil, err
+		}
+		contents[filepath.Join(strings.TrimSpace(top), filepath.FromSlash(name))] = []byte(src)
+	}
+	return contents, nil
+}
+
+// readSource reads a file from the overlay, or from disk if it isn't there.
+func readSource(name string) ([]byte, error) {
+	if src, ok := overlay[name]; ok {
+		return src, nil
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		if src, ok := overlay[abs]; ok {
+			return src, nil
+		}
+	}
+	return os.ReadFile(name)
+}
+
+// git runs a git command in the working directory and returns its output.
+func git(args ...string) (string, error) {
+	return gitIn("", args...)
+}
+
+// gitIn runs a git command in dir, or the working directory if empty, and
+// returns its output.
+func gitIn(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// packagePatterns returns the package patterns, relative to the working
+// directory, for the directories holding files.
+func packagePatterns(files []string) []string {
+	if packagesDriver() != "" {
+		return filePatterns(files)
+	}
+	wd, _ := os.Getwd()
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		dirs[filepath.Dir(file)] = true
+	}
+	var patterns []string
+	for dir := range dirs {
+		pattern := dir
+		if rel, err := filepath.Rel(wd, dir); err == nil {
+			pattern = filepath.ToSlash(rel)
+			if pattern != "." && pattern != ".." && !strings.HasPrefix(pattern, "../") {
+				pattern = "./" + pattern
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// inFiles returns the diagnostics positioned in one of files.
+func inFiles(diags []diagnostic, files map[string]bool) []diagnostic {
+	var kept []diagnostic
+	for _, d := range diags {
+		if name, err := filepath.Abs(d.position.Filename); err == nil && files[name] {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackagePatterns(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{
+		filepath.Join(wd, "a.go"),
+		filepath.Join(wd, "pkg", "domain", "order.go"),
+		filepath.Join(wd, "pkg", "domain", "order_test.go"),
+		filepath.Join(filepath.Dir(wd), "other", "x.go"),
+	}
+	got := strings.Join(packagePatterns(files), " ")
+	if want := ". ../other ./pkg/domain"; got != want {
+		t.Errorf("got patterns %s, want %s", got, want)
+	}
+}
+
+func TestInFiles(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	diag := func(file string) diagnostic {
+		return diagnostic{Message: file, position: token.Position{Filename: file}}
+	}
+	diags := []diagnostic{diag("staged.go"), diag(filepath.Join(wd, "staged.go")), diag("unstaged.go")}
+	var got []string
+	for _, d := range inFiles(diags, map[string]bool{filepath.Join(wd, "staged.go"): true}) {
+		got = append(got, d.Message)
+	}
+	if len(got) != 2 || got[0] != "staged.go" {
+		t.Errorf("got diagnostics in %v, want only those in staged.go", got)
+	}
+}
+
+func TestIndexOverlay(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) { writeFile(t, dir, name, content) }
+	write("go.mod", "module example.com/shop\n\ngo 1.22\n")
+	write("order/order.go", `package order
+
+type Order struct {
+	ID string // +const
+}
+`)
+	write("order/rename.go", `package order
+
+func Rename(o *Order) {
+	o.ID = "renamed"
+}
+`)
+	write("order/reset.go", `package order
+
+func Reset(o *Order) {}
+`)
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}} {
+		if _, err := gitIn(dir, args...); err != nil {
+			t.Skip(err)
+		}
+	}
+	// The fix of rename.go and the write in reset.go aren't staged.
+	write("order/rename.go", `package order
+
+func Rename(o *Order) *Order {
+	return &Order{ID: "renamed"}
+}
+`)
+	write("order/reset.go", `package order
+
+func Reset(o *Order) {
+	o.ID = ""
+}
+`)
+	chdir(t, dir)
+
+	files, err := stagedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overlay, err = indexOverlay(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { overlay = nil }()
+	if len(overlay) != 2 {
+		t.Errorf("got %d files in the overlay, want rename.go and reset.go", len(overlay))
+	}
+	noSettings := func(string) (map[string]string, error) { return nil, nil }
+	diags, _, err := lint(packagePatterns(files), false, nil, "", noSettings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diags {
+		got = append(got, fmt.Sprintf("%s:%d", filepath.Base(d.position.Filename), d.position.Line))
+	}
+	if want := "rename.go:4"; strings.Join(got, " ") != want {
+		t.Errorf("got diagnostics at %v, want those of the staged files, at %s", got, want)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// statsMain counts the diagnostics of the given packages, or of a result file
+// written by -format json, by the age of the lines they are reported at and
+// by rule, or by the author or team git blame attributes those lines to, so
+// that cleanup can be routed to the people owning the code.
+func statsMain(args []string) int {
+	flags := flag.NewFlagSet("constlint stats", flag.ExitOnError)
+	byAuthor := flags.Bool("by-author", false, "count the diagnostics by the author of their line")
+	teamsFile := flags.String("teams", "", "count the diagnostics by team, given a CSV `file` of author emails and teams")
+	results := flags.String("results", "", "count the diagnostics of a JSON result `file` instead of analyzing packages")
+	asJSON := flags.Bool("json", false, "print the counts as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint stats [-by-author] [-teams file] [-results file] [-json] [-flag] [package...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *results != "" && flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "constlint stats: flags and packages don't apply to -results\n")
+		return 2
+	}
+
+	var teams map[string]string
+	if *teamsFile != "" {
+		f, err := os.Open(*teamsFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		teams, err = readTeams(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *teamsFile, err)
+			return 1
+		}
+	}
+
+	var (
+		diags []diagnostic
+		err   error
+	)
+	if *results != "" {
+		var data []byte
+		if data, err = os.ReadFile(*results); err == nil {
+			diags, err = parseResults(*results, data)
+		}
+	} else {
+		diags, err = runLint(".", "the working tree", flags.Args())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	blamed, err := blameDiagnostics(diags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	group := groupByRule
+	switch {
+	case teams != nil:
+		group = groupByTeam(teams)
+	case *byAuthor:
+		group = groupByAuthor
+	}
+	stats := countStats(blamed, group, time.Now())
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(stats); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	writeStats(os.Stdout, stats)
+	return 0
+}
+
+// blameLine is what git blame says of a line: the author and time of the
+// commit last changing it, or no commit for lines changed in the working tree.
+type blameLine struct {
+	Author    string
+	Email     string
+	Time      time.Time
+	Committed bool
+}
+
+// blamedDiagnostic is a diagnostic and the line it is reported at.
+type blamedDiagnostic struct {
+	diagnostic
+	line blameLine
+}
+
+// blameDiagnostics blames the lines the diagnostics are reported at, running
+// git blame once per file. Blaming the working tree, not HEAD, keeps the
+// lines the diagnostics point at in step with those blamed, however the files
+// were edited since the last commit. Files git doesn't track are attributed
+// to no commit.
+func blameDiagnostics(diags []diagnostic) ([]blamedDiagnostic, error) {
+	byFile := make(map[string][]int)
+	for i, d := range diags {
+		file, _ := splitPosn(d.Posn)
+		byFile[file] = append(byFile[file], i)
+	}
+	blamed := make([]blamedDiagnostic, len(diags))
+	for _, file := range sortedKeys(byFile) {
+		lines, err := blameFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range byFile[file] {
+			_, lineCol := splitPosn(diags[i].Posn)
+			line, _ := strconv.Atoi(strings.Split(strings.TrimPrefix(lineCol, ":"), ":")[0])
+			blamed[i] = blamedDiagnostic{diagnostic: diags[i], line: lines[line]}
+		}
+	}
+	return blamed, nil
+}
+
+// blameFile returns the blame of the lines of a file, by line number.
+func blameFile(file string) (map[int]blameLine, error) {
+	dir, base := filepath.Split(file)
+	if _, err := gitIn(dir, "ls-files", "--error-unmatch", "--", base); err != nil {
+		return map[int]blameLine{}, nil
+	}
+	out, err := gitIn(dir, "blame", "--line-porcelain", "--", base)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(strings.NewReader(out))
+}
+
+// notCommitted is the commit git blame attributes the lines changed in the
+// working tree to.
+const notCommitted = "0000000000000000000000000000000000000000"
+
+// parseBlame parses the output of git blame --line-porcelain: for every line,
+// a header giving its commit and line numbers, the commit's details, and the
+// line's content prefixed by a tab.
+func parseBlame(r io.Reader) (map[int]blameLine, error) {
+	lines := make(map[int]blameLine)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var (
+		current blameLine
+		number  int
+	)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "\t") {
+			lines[number] = current
+			number = 0
+			continue
+		}
+		key, value, _ := strings.Cut(text, " ")
+		if number == 0 {
+			fields := strings.Fields(value)
+			if len(key) != len(notCommitted) || len(fields) < 2 {
+				return nil, fmt.Errorf("unexpected git blame header %q", text)
+			}
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected git blame header %q", text)
+			}
+			number = n
+			current = blameLine{Committed: key != notCommitted}
+			continue
+		}
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-mail":
+			current.Email = strings.ToLower(strings.Trim(value, "<>"))
+		case "author-time":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected git blame author time %q", value)
+			}
+			current.Time = time.Unix(seconds, 0)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// readTeams reads the teams of authors from a CSV file of emails and team
+// names, skipping a header row, recognized by a first column without an @.
+func readTeams(r io.Reader) (map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.Comment = '#'
+	teams := make(map[string]string)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return teams, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		email := strings.ToLower(strings.TrimSpace(record[0]))
+		if email == "" || first && !strings.Contains(email, "@") {
+			continue
+		}
+		teams[email] = strings.TrimSpace(record[1])
+	}
+}
+
+// statsGroup names the group a blamed diagnostic is counted in.
+type statsGroup struct {
+	name string // what the groups are, e.g. "author"
+	of   func(blamedDiagnostic) string
+}
+
+var (
+	groupByRule   = statsGroup{"rule", func(d blamedDiagnostic) string { return d.Category }}
+	groupByAuthor = statsGroup{"author", func(d blamedDiagnostic) string {
+		if !d.line.Committed {
+			return "(not committed)"
+		}
+		return d.line.Author + " <" + d.line.Email + ">"
+	}}
+)
+
+// groupByTeam groups diagnostics by the team of the author of their line.
+func groupByTeam(teams map[string]string) statsGroup {
+	return statsGroup{"team", func(d blamedDiagnostic) string {
+		if !d.line.Committed {
+			return "(not committed)"
+		}
+		if team, ok := teams[d.line.Email]; ok {
+			return team
+		}
+		return "(no team)"
+	}}
+}
+
+// ageBuckets are the upper bounds of the ages of lines diagnostics are
+// counted by; the last bucket is unbounded. Lines not committed yet are the
+// youngest.
+var ageBuckets = []struct {
+	label string
+	max   time.Duration
+}{
+	{"<30d", 30 * 24 * time.Hour},
+	{"30d-6m", 182 * 24 * time.Hour},
+	{"6m-1y", 365 * 24 * time.Hour},
+	{">1y", 0},
+}
+
+// stats counts diagnostics by group and age.
+type stats struct {
+	Group   string     `json:"group"`
+	Buckets []string   `json:"buckets"`
+	Rows    []statsRow `json:"rows"`
+}
+
+// statsRow counts the diagnostics of a group, in total and by age bucket.
+type statsRow struct {
+	Name   string `json:"name"`
+	Total  int    `json:"total"`
+	Ages   []int  `json:"ages"`
+	Oldest string `json:"oldest,omitempty"` // the date of the oldest committed line
+	oldest time.Time
+}
+
+// countStats counts the diagnostics by group and by the age, at now, of the
+// lines they are reported at, the groups with the most diagnostics first.
+func countStats(diags []blamedDiagnostic, group statsGroup, now time.Time) stats {
+	s := stats{Group: group.name, Rows: []statsRow{}}
+	for _, b := range ageBuckets {
+		s.Buckets = append(s.Buckets, b.label)
+	}
+	rows := make(map[string]*statsRow)
+	for _, d := range diags {
+		name := group.of(d)
+		row, ok := rows[name]
+		if !ok {
+			row = &statsRow{Name: name, Ages: make([]int, len(ageBuckets))}
+			rows[name] = row
+		}
+		row.Total++
+		bucket := 0
+		if d.line.Committed {
+			age := now.Sub(d.line.Time)
+			for bucket < len(ageBuckets)-1 && age >= ageBuckets[bucket].max {
+				bucket++
+			}
+			if row.oldest.IsZero() || d.line.Time.Before(row.oldest) {
+				row.oldest = d.line.Time
+				row.Oldest = d.line.Time.UTC().Format(time.DateOnly)
+			}
+		}
+		row.Ages[bucket]++
+	}
+	for _, name := range sortedKeys(rows) {
+		s.Rows = append(s.Rows, *rows[name])
+	}
+	sort.SliceStable(s.Rows, func(i, j int) bool { return s.Rows[i].Total > s.Rows[j].Total })
+	return s
+}
+
+// writeStats prints the counts as a table, e.g.
+//
+//	AUTHOR                   TOTAL  <30D  30D-6M  6M-1Y  >1Y  OLDEST
+//	Ada <ada@example.com>    5      1     2       0      2    2023-02-01
+func writeStats(w io.Writer, s stats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tTOTAL\t%s\tOLDEST\n", strings.ToUpper(s.Group), strings.ToUpper(strings.Join(s.Buckets, "\t")))
+	for _, row := range s.Rows {
+		fmt.Fprintf(tw, "%s\t%d", row.Name, row.Total)
+		for _, n := range row.Ages {
+			fmt.Fprintf(tw, "\t%d", n)
+		}
+		fmt.Fprintf(tw, "\t%s\n", row.Oldest)
+	}
+	tw.Flush()
+}
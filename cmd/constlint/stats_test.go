@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const blameOutput = `1f2e3d4c5b6a79881f2e3d4c5b6a79881f2e3d4c 3 1 1
+author Ada
+author-mail <Ada@example.com>
+author-time 1600000000
+author-tz +0000
+summary Add orders
+filename shop/order.go
+	package shop
+0000000000000000000000000000000000000000 2 2 1
+author Not Committed Yet
+author-mail <not.committed.yet>
+author-time 1700000000
+author-tz +0000
+summary Version of shop/order.go from shop/order.go
+filename shop/order.go
+	func (o *Order) Reset() { o.ID = 0 }
+`
+
+func TestParseBlame(t *testing.T) {
+	lines, err := parseBlame(strings.NewReader(blameOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]blameLine{
+		1: {Author: "Ada", Email: "ada@example.com", Time: time.Unix(1600000000, 0), Committed: true},
+		2: {Author: "Not Committed Yet", Email: "not.committed.yet", Time: time.Unix(1700000000, 0)},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for n, line := range want {
+		if got := lines[n]; got.Author != line.Author || got.Email != line.Email ||
+			!got.Time.Equal(line.Time) || got.Committed != line.Committed {
+			t.Errorf("line %d: got %+v, want %+v", n, got, line)
+		}
+	}
+}
+
+func TestCountStats(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	blamed := func(author string, age time.Duration) blamedDiagnostic {
+		return blamedDiagnostic{
+			diagnostic: diagnostic{Category: "field-write"},
+			line: blameLine{Author: author, Email: strings.ToLower(author) + "@example.com",
+				Time: now.Add(-age), Committed: true},
+		}
+	}
+	day := 24 * time.Hour
+	diags := []blamedDiagnostic{
+		blamed("Ada", 2*day),
+		blamed("Ada", 400*day),
+		blamed("Ada", 100*day),
+		blamed("Bob", 200*day),
+		{diagnostic: diagnostic{Category: "field-write"}},
+	}
+
+	var out bytes.Buffer
+	writeStats(&out, countStats(diags, groupByAuthor, now))
+	want := "AUTHOR                 TOTAL  <30D  30D-6M  6M-1Y  >1Y  OLDEST\n" +
+		"Ada <ada@example.com>  3      1     1       0      1    2023-04-28\n" +
+		"(not committed)        1      1     0       0      0    \n" +
+		"Bob <bob@example.com>  1      0     0       1      0    2023-11-14\n"
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+
+	teams, err := readTeams(strings.NewReader("email,team\n# platform\nada@example.com, platform\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := countStats(diags, groupByTeam(teams), now)
+	var got []string
+	for _, row := range s.Rows {
+		got = append(got, row.Name)
+	}
+	if strings.Join(got, ",") != "platform,(no team),(not committed)" {
+		t.Errorf("got teams %v, want platform, (no team) and (not committed)", got)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"github.com/bunniesandbeatings/constlint/gen"
+)
+
+// Constness describes whether a field, parameter or package-level variable is
+// const.
+type Constness struct {
+	Kind      string // field, parameter or variable
+	Const     bool
+	Mode      string // shallow or deep, for const fields and variables
+	Secret    bool   // marked +secret, for fields
+	WriteOnce bool   // marked +writeonce, for fields
+	Reason    string // the reason attribute of the marker
+	Declared  string // position of the declaration
+	Marker    string // position of the marker making a field const
+	Inits     []Site // where the package initializes a const field
+	Writes    []Site // writes to a const field reported by the analyzer
+}
+
+// Site is a place where a function writes a field.
+type Site struct {
+	Posn string
+	Func string // the function writing the field, such as NewOrder or (*Order).Reset
+}
+
+// lookupConstness returns the constness of the declaration symbol names,
+// given as a package path or name followed by Type.Field, Func.param,
+// Type.Method.param or Var, e.g. example.com/shop.Order.ID or shop.Order.ID.
+// results are the analyzer's results by package path, and format formats the
+// positions of the result.
+func lookupConstness(results map[string][]*packageResult, symbol string,
+	format func(token.Position) string) (Constness, error) {
+	result, names, err := symbolPackage(results, symbol)
+	if err != nil {
+		return Constness{}, err
+	}
+	pkg, inventory := result.pkg, result.inventory
+	position := func(pos token.Pos) string { return format(pkg.Fset.Position(pos)) }
+	sites := func(positions, except []token.Pos) []Site {
+		sites := []Site{}
+		for _, pos := range positions {
+			if !slices.Contains(except, pos) {
+				sites = append(sites, Site{Posn: position(pos), Func: enclosingFuncName(&gen.Package{Package: pkg}, pos)})
+			}
+		}
+		return sites
+	}
+
+	notFound := fmt.Errorf("%s declares no field, parameter or variable %s", pkg.PkgPath, strings.Join(names, "."))
+	obj := pkg.Types.Scope().Lookup(names[0])
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		if len(names) == 3 {
+			method, _, _ := types.LookupFieldOrMethod(types.NewPointer(obj.Type()), true, pkg.Types, names[1])
+			if fn, ok := method.(*types.Func); ok {
+				return paramConstness(inventory, fn, names[2], position, notFound)
+			}
+			return Constness{}, notFound
+		}
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok || len(names) != 2 {
+			return Constness{}, notFound
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			if field.Name() != names[1] {
+				continue
+			}
+			c := Constness{Kind: "field", Declared: position(field.Pos())}
+			for _, s := range inventory.Structs {
+				if cf := s.Field(field); s.Type == obj && cf != nil {
+					c.Const, c.Secret, c.Reason, c.Marker = true, cf.Secret, cf.Reason, position(cf.Marker)
+					c.WriteOnce = cf.WriteOnce
+					// The writes the analyzer reports are not initializations.
+					c.Inits, c.Writes = sites(cf.Inits, cf.Violations), sites(cf.Violations, nil)
+					c.Mode = "shallow"
+					if cf.Deep {
+						c.Mode = "deep"
+					}
+				}
+			}
+			return c, nil
+		}
+	case *types.Func:
+		if len(names) == 2 {
+			return paramConstness(inventory, obj, names[1], position, notFound)
+		}
+	case *types.Var:
+		if len(names) == 1 {
+			c := Constness{Kind: "variable", Declared: position(obj.Pos())}
+			for _, fact := range result.objectFacts {
+				if fact.Object == obj {
+					// Facts of const variables print as const or const:shallow.
+					c.Const, c.Mode = true, "deep"
+					if strings.HasSuffix(fmt.Sprint(fact.Fact), ":shallow") {
+						c.Mode = "shallow"
+					}
+				}
+			}
+			return c, nil
+		}
+	}
+	return Constness{}, notFound
+}
+
+// paramConstness returns the constness of the parameter name of fn.
+func paramConstness(inventory *analyzer.Inventory, fn *types.Func, name string, position func(token.Pos) string,
+	notFound error) (Constness, error) {
+	params := fn.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len(); i++ {
+		if param := params.At(i); param.Name() == name {
+			c := Constness{Kind: "parameter", Declared: position(param.Pos())}
+			if f := inventory.Func(fn); f != nil {
+				c.Const = f.IsConst(param)
+			}
+			return c, nil
+		}
+	}
+	return Constness{}, notFound
+}
+
+// symbolPackage returns the result of the package a symbol starts with, and
+// the names following it. The package is given by its path, the longest if
+// several match, or by its name if it is the only package checked with that
+// name. The package is preferred to its test variant.
+func symbolPackage(results map[string][]*packageResult, symbol string) (*packageResult, []string, error) {
+	var path string
+	for p := range results {
+		if strings.HasPrefix(symbol, p+".") && len(p) > len(path) {
+			path = p
+		}
+	}
+	if path == "" {
+		name, _, _ := strings.Cut(symbol, ".")
+		var named []string
+		for p, variants := range results {
+			if variants[0].pkg.Name == name {
+				named = append(named, p)
+			}
+		}
+		sort.Strings(named)
+		switch len(named) {
+		case 0:
+			return nil, nil, fmt.Errorf("no package checked declares %s", symbol)
+		case 1:
+			path = named[0]
+			symbol = path + strings.TrimPrefix(symbol, name)
+		default:
+			return nil, nil, fmt.Errorf("%s is ambiguous: %s are all named %s; give the package path",
+				symbol, strings.Join(named, ", "), name)
+		}
+	}
+	names := strings.Split(strings.TrimPrefix(symbol, path+"."), ".")
+	for _, result := range results[path] {
+		if result.pkg.ID == path {
+			return result, names, nil
+		}
+	}
+	return results[path][0], names, nil
+}
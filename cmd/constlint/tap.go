@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// writeTAP prints the diagnostics in the Test Anything Protocol, version 13:
+// a "not ok" test point per diagnostic, described by its position and
+// message, followed by a YAML block with its rule and confidence, e.g.
+//
+//	TAP version 13
+//	1..1
+//	not ok 1 - shop/order.go:42:2: assignment to const field Order.ID
+//	  ---
+//	  rule: "field-write"
+//	  ...
+//
+// A run without diagnostics is a single passing test point, so harnesses see
+// it pass rather than skip. Grouped diagnostics follow a comment line naming
+// their group.
+func writeTAP(w io.Writer, diags []diagnostic, group string) error {
+	if _, err := fmt.Fprintln(w, "TAP version 13"); err != nil {
+		return err
+	}
+	if len(diags) == 0 {
+		_, err := fmt.Fprintln(w, "1..1\nok 1 - no diagnostics")
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "1..%d\n", len(diags)); err != nil {
+		return err
+	}
+
+	if group == "" {
+		for i, d := range diags {
+			if err := writeTAPPoint(w, i+1, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	keys, groups := groupDiagnostics(diags, group)
+	n := 0
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "# %s\n", k); err != nil {
+			return err
+		}
+		for _, d := range groups[k] {
+			n++
+			if err := writeTAPPoint(w, n, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeTAPPoint prints the test point of a diagnostic for writeTAP.
+func writeTAPPoint(w io.Writer, n int, d diagnostic) error {
+	// A # would start a directive, such as # SKIP, in the description.
+	description := strings.ReplaceAll(d.Posn+": "+d.Message, "#", `\#`)
+	if _, err := fmt.Fprintf(w, "not ok %d - %s\n  ---\n", n, description); err != nil {
+		return err
+	}
+	fields := []struct{ key, value string }{
+		{"rule", d.Category},
+		{"confidence", d.Confidence},
+		{"package", d.Package},
+		{"field", d.Field},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", f.key, strconv.Quote(f.value)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "  ...")
+	return err
+}
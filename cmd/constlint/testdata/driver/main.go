@@ -0,0 +1,71 @@
+// Command driver is a go/packages driver for tests, standing in for those of
+// build systems such as Bazel. It loads packages with the go command, but
+// reports the files of the working directory through the execution root
+// DRIVER_EXECROOT links to it from, and adds to each package the files of its
+// directory in the output tree DRIVER_OUTPUT, as if generated.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var req packages.DriverRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	execroot, output := os.Getenv("DRIVER_EXECROOT"), os.Getenv("DRIVER_OUTPUT")
+
+	cfg := &packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedDeps,
+		Env:        append(os.Environ(), "GOPACKAGESDRIVER=off"),
+		BuildFlags: req.BuildFlags,
+		Tests:      req.Tests,
+	}
+	roots, err := packages.Load(cfg, os.Args[1:]...)
+	if err != nil {
+		return err
+	}
+
+	resp := packages.DriverResponse{Compiler: "gc", Arch: runtime.GOARCH}
+	for _, root := range roots {
+		resp.Roots = append(resp.Roots, root.ID)
+	}
+	packages.Visit(roots, nil, func(pkg *packages.Package) {
+		var generated []string
+		for _, files := range []*[]string{&pkg.GoFiles, &pkg.CompiledGoFiles} {
+			for i, file := range *files {
+				rel, err := filepath.Rel(wd, file)
+				if err != nil || strings.HasPrefix(rel, "..") {
+					continue
+				}
+				(*files)[i] = filepath.Join(execroot, rel)
+				if generated == nil {
+					generated, _ = filepath.Glob(filepath.Join(output, "bin", filepath.Dir(rel), "*.go"))
+				}
+			}
+		}
+		pkg.GoFiles = append(pkg.GoFiles, generated...)
+		pkg.CompiledGoFiles = append(pkg.CompiledGoFiles, generated...)
+		resp.Packages = append(resp.Packages, pkg)
+	})
+	return json.NewEncoder(os.Stdout).Encode(resp)
+}
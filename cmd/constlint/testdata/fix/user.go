@@ -0,0 +1,19 @@
+package fix
+
+type User struct {
+	Name  string // +const
+	Email string // +const
+	Phone string // +const
+}
+
+func NewUser(name string) *User {
+	return &User{Name: name}
+}
+
+func (u *User) SetEmail(email string) {
+	u.Email = email
+}
+
+func (u *User) SetPhone(phone string) {
+	u.Phone = phone
+}
@@ -0,0 +1,31 @@
+// Package shop is drawn by TestWriteGraph.
+package shop
+
+// Order has const fields written by its constructor and, wrongly, by methods.
+type Order struct {
+	// +const
+	ID string
+	// +deepconst
+	Items []string
+
+	Note string
+}
+
+// NewOrder sets the const fields.
+func NewOrder(id string) *Order {
+	o := &Order{ID: id}
+	o.Items = nil
+	return o
+}
+
+// Reset writes the const fields.
+func (o *Order) Reset() {
+	o.ID = ""
+	o.Items[0] = ""
+	o.Items = nil
+}
+
+// Annotate only writes a mutable field.
+func (o *Order) Annotate(note string) {
+	o.Note = note
+}
@@ -0,0 +1,11 @@
+package app
+
+import "github.com/bunniesandbeatings/constlint/cmd/constlint/testdata/rank/shop"
+
+func Greeting(o *shop.Order) string {
+	return "Dear " + o.Customer
+}
+
+func Label(o *shop.Order) string {
+	return o.Customer + ": " + o.Summary()
+}
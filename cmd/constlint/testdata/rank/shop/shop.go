@@ -0,0 +1,34 @@
+package shop
+
+// Order is ranked by how const-like its fields are.
+type Order struct {
+	// +const
+	ID string // already const, never ranked
+
+	Customer string   // read here and by app
+	Total    int      // written by AddItem
+	Items    []string // only read here
+	Notes    string   // never read
+	created  int64    // only set by NewOrder
+}
+
+func NewOrder(id, customer string, created int64) *Order {
+	o := &Order{ID: id, Customer: customer}
+	o.created = created
+	return o
+}
+
+func (o *Order) AddPrice(price int) {
+	o.Total += price
+}
+
+func (o *Order) Summary() string {
+	return o.Customer + " " + o.ID
+}
+
+func (o *Order) Age(now int64) int64 {
+	if len(o.Items) == 0 {
+		return 0
+	}
+	return now - o.created
+}
@@ -0,0 +1,19 @@
+package app
+
+import "github.com/bunniesandbeatings/constlint/cmd/constlint/testdata/wholeprogram/store"
+
+// Restock modifies items of another package.
+func Restock() *store.Item {
+	it := store.NewItem("a", "fresh")
+	it.SKU = "b"
+	it.Tags[0] = "stale"
+	it.Count++
+	return store.Clone(it)
+}
+
+// NewLocal builds an item itself.
+func NewLocal() *store.Item {
+	it := &store.Item{}
+	it.SKU = "local"
+	return it
+}
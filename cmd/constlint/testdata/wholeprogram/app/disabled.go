@@ -0,0 +1,21 @@
+package app
+
+import "github.com/bunniesandbeatings/constlint/cmd/constlint/testdata/wholeprogram/store"
+
+// Migrate rewrites the SKUs of legacy items once.
+func Migrate(items []*store.Item) {
+	for _, it := range items {
+		//constlint:disable legacy SKUs, removed after the 2.0 cutover
+		it.SKU = "legacy-" + it.SKU
+		//constlint:enable
+	}
+}
+
+//constlint:disable migrating the SKU references, see #412
+
+// Retire rewrites an SKU through its reference.
+func Retire(it *store.Item) {
+	*it.SKURef() = "used"
+}
+
+//constlint:enable
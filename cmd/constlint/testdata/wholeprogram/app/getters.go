@@ -0,0 +1,18 @@
+package app
+
+import "github.com/bunniesandbeatings/constlint/cmd/constlint/testdata/wholeprogram/store"
+
+// Relabel writes through the getters of an existing item.
+func Relabel(it *store.Item) {
+	*it.SKURef() = "relabeled"
+	it.TagsView()[0] = "relabeled"
+	sku := it.SKURef()
+	*sku = "again"
+}
+
+// NewRelabeled writes through the getters of an item it creates.
+func NewRelabeled() *store.Item {
+	it := &store.Item{}
+	*it.SKURef() = "new"
+	return it
+}
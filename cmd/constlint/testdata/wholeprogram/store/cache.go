@@ -0,0 +1,28 @@
+package store
+
+import "sync"
+
+// Cache computes its entries when first asked for them.
+type Cache struct {
+	// +writeonce
+	data map[string]int
+	once sync.Once
+	// +writeonce
+	names []string
+}
+
+// Data builds the entries of an existing Cache on first use.
+func (c *Cache) Data() map[string]int {
+	if c.data == nil {
+		c.data = map[string]int{}
+	}
+	return c.data
+}
+
+// Names lists the entries once, however many goroutines ask.
+func (c *Cache) Names() []string {
+	c.once.Do(func() {
+		c.names = []string{"a", "b"}
+	})
+	return c.names
+}
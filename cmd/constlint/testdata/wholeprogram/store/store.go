@@ -0,0 +1,44 @@
+package store
+
+// Item is stocked by the store.
+type Item struct {
+	// +const
+	SKU string
+	// +deepconst
+	Tags  []string
+	Count int
+}
+
+// NewItem builds an item.
+func NewItem(sku string, tags ...string) *Item {
+	return &Item{SKU: sku, Tags: tags}
+}
+
+// Clone copies an item, but clears the SKU of the original too, which
+// checking the package alone allows as Clone creates an Item.
+func Clone(src *Item) *Item {
+	it := &Item{SKU: src.SKU}
+	src.SKU = ""
+	return it
+}
+
+// NewTagged builds an item with a helper only ever given new items.
+func NewTagged(sku string) *Item {
+	it := &Item{SKU: sku}
+	tag(it)
+	return it
+}
+
+func tag(it *Item) {
+	it.Tags = []string{"new"}
+}
+
+// SKURef hands out the SKU.
+func (it *Item) SKURef() *string {
+	return &it.SKU
+}
+
+// TagsView hands out the tags.
+func (it *Item) TagsView() []string {
+	return it.Tags[:]
+}
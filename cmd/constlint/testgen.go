@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	textscanner "text/scanner"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/packages"
+)
+
+// testgenMain runs the analyzer over test fixtures and rewrites their // want
+// comments to expect the diagnostics it reports now, the way analysistest
+// reads them. Lines whose comments already match are left alone, so
+// hand-written patterns survive as long as they hold.
+func testgenMain(args []string) int {
+	flags := flag.NewFlagSet("constlint testgen", flag.ExitOnError)
+	analyzer.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		flags.Var(f.Value, f.Name, f.Usage)
+	})
+	testdata := flags.String("testdata", "",
+		"load packages like analysistest.Run from `dir`: a module if it holds a go.mod file, else a GOPATH tree")
+	dryRun := flags.Bool("n", false, "print the updated expectations without rewriting files")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: constlint testgen [-testdata dir] [-n] [-flag] [package...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	commandLine := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) { commandLine[f.Name] = f.Value.String() })
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	cfg := &packages.Config{Tests: true}
+	if *testdata != "" {
+		cfg.Dir, cfg.Env = *testdata, testdataEnv(*testdata)
+	}
+	pkgs, err := loadPackages(cfg, patterns)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	diags, _, err := analyze(pkgs, func(string) (map[string]string, error) {
+		return config{}.analyzerSettings(commandLine), nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	messages := make(map[string]map[int][]string)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.GoFiles {
+			messages[file] = make(map[int][]string)
+		}
+	}
+	for _, d := range diags {
+		if lines, ok := messages[d.position.Filename]; ok {
+			lines[d.position.Line] = append(lines[d.position.Line], d.Message)
+		}
+	}
+
+	for _, name := range sortedKeys(messages) {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		out, updates, err := updateWants(src, messages[name])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", relativePath(name), err)
+			return 1
+		}
+		for _, u := range updates {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s\n", relativePath(name), u.line, u.text)
+		}
+		if *dryRun || bytes.Equal(src, out) {
+			continue
+		}
+		if err := os.WriteFile(name, out, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// testdataEnv returns the environment analysistest loads the packages of dir
+// in: module mode if dir holds a go.mod file, GOPATH mode otherwise.
+func testdataEnv(dir string) []string {
+	env := []string{"GOPATH=" + dir, "GO111MODULE=off", "GOWORK=off"}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		gowork := filepath.Join(dir, "go.work")
+		if _, err := os.Stat(gowork); err != nil {
+			gowork = "off"
+		}
+		env = []string{"GO111MODULE=on", "GOPROXY=off", "GOWORK=" + gowork}
+	}
+	return append(os.Environ(), env...)
+}
+
+// wantUpdate describes the change of the expectations of a line.
+type wantUpdate struct {
+	line int
+	text string // the new // want comment, or why the line changed
+}
+
+// lineComment is the // comment ending a line.
+type lineComment struct {
+	offset int // of the leading //
+	text   string
+}
+
+// updateWants sets the // want comments of src to expect messages, the
+// diagnostic messages reported on each line. A line keeps its comment when
+// each message matches one of its patterns and every pattern is used, and
+// loses it when nothing is reported there. Comments with facts or line
+// offsets are left alone. The result is gofmt-ed.
+func updateWants(src []byte, messages map[int][]string) ([]byte, []wantUpdate, error) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	comments := make(map[int]lineComment)
+	inToken := make(map[int]bool) // lines ending inside a raw string or block comment
+	var errs scanner.ErrorList
+	var s scanner.Scanner
+	s.Init(file, src, func(pos token.Position, msg string) { errs.Add(pos, msg) }, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		start := file.Position(pos)
+		if tok == token.COMMENT && strings.HasPrefix(lit, "//") {
+			comments[start.Line] = lineComment{offset: start.Offset, text: lit}
+			continue
+		}
+		if tok == token.STRING || tok == token.COMMENT {
+			for line := start.Line; line < file.Position(pos+token.Pos(len(lit))).Line; line++ {
+				inToken[line] = true
+			}
+		}
+	}
+	if errs.Len() > 0 {
+		return nil, nil, errs.Err()
+	}
+
+	lines := make(map[int]bool)
+	for line, msgs := range messages {
+		if len(msgs) > 0 {
+			lines[line] = true
+		}
+	}
+	for line, c := range comments {
+		if strings.Contains(c.text, "// want") || strings.HasPrefix(strings.TrimSpace(c.text[2:]), "want") {
+			lines[line] = true
+		}
+	}
+	var sorted []int
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+
+	type edit struct {
+		pos, end int
+		text     string
+	}
+	var edits []edit
+	var updates []wantUpdate
+	for _, line := range sorted {
+		msgs := messages[line]
+		c, hasComment := comments[line]
+		start, patterns := -1, []string(nil)
+		if hasComment {
+			var ok bool
+			start, patterns, ok = parseWant(c.text)
+			if !ok {
+				continue
+			}
+		}
+		if start >= 0 && wantsMatch(patterns, msgs) {
+			continue
+		}
+
+		want := ""
+		if len(msgs) > 0 {
+			quoted := make([]string, len(msgs))
+			for i, msg := range msgs {
+				quoted[i] = quotePattern(msg)
+			}
+			want = "// want " + strings.Join(quoted, " ")
+		}
+		switch {
+		case start >= 0:
+			pos := c.offset + start
+			if want == "" {
+				pos = len(bytes.TrimRight(src[:pos], " \t"))
+			}
+			edits = append(edits, edit{pos, c.offset + len(c.text), want})
+		case inToken[line]:
+			updates = append(updates, wantUpdate{line, "can't add a // want comment to a line ending in a multi-line token"})
+			continue
+		default:
+			lineStart := file.Offset(file.LineStart(line))
+			pos := len(src)
+			if end := bytes.IndexByte(src[lineStart:], '\n'); end >= 0 {
+				pos = lineStart + end
+			}
+			if pos > 0 && src[pos-1] == '\r' {
+				pos--
+			}
+			edits = append(edits, edit{pos, pos, " " + want})
+		}
+		if want == "" {
+			want = "no diagnostics, expectations removed"
+		}
+		updates = append(updates, wantUpdate{line, want})
+	}
+
+	out := src
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		out = append(out[:e.pos:e.pos], append([]byte(e.text), out[e.end:]...)...)
+	}
+	if formatted, err := format.Source(out); err == nil {
+		out = formatted
+	}
+	return out, updates, nil
+}
+
+// parseWant returns the offset of the expectations in a // comment, -1 if it
+// has none, and their patterns. ok is false for expectations updateWants
+// doesn't manage, such as facts, line offsets and invalid ones.
+func parseWant(comment string) (start int, patterns []string, ok bool) {
+	text := strings.TrimPrefix(comment, "//")
+	start = strings.Index(text, "// want")
+	switch {
+	case start >= 0:
+		text = text[start+len("// want"):]
+		start += len("//")
+	case strings.HasPrefix(strings.TrimSpace(text), "want"):
+		text = strings.TrimPrefix(strings.TrimSpace(text), "want")
+		start = 0
+	default:
+		return -1, nil, true
+	}
+
+	var sc textscanner.Scanner
+	sc.Init(strings.NewReader(text))
+	sc.Error = func(*textscanner.Scanner, string) { ok = false }
+	sc.Mode = textscanner.ScanIdents | textscanner.ScanStrings | textscanner.ScanRawStrings | textscanner.ScanInts
+	ok = true
+	for tok := sc.Scan(); tok != textscanner.EOF; tok = sc.Scan() {
+		if tok != textscanner.String && tok != textscanner.RawString {
+			return start, nil, false
+		}
+		pattern, err := strconv.Unquote(sc.TokenText())
+		if err != nil {
+			return start, nil, false
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return start, nil, false
+		}
+		patterns = append(patterns, pattern)
+	}
+	return start, patterns, ok
+}
+
+// wantsMatch reports whether patterns expect exactly msgs, as analysistest
+// checks them: each message consumes the first unused pattern matching it.
+func wantsMatch(patterns, msgs []string) bool {
+	if len(patterns) != len(msgs) {
+		return false
+	}
+	used := make([]bool, len(patterns))
+next:
+	for _, msg := range msgs {
+		for i, pattern := range patterns {
+			if !used[i] && regexp.MustCompile(pattern).MatchString(msg) {
+				used[i] = true
+				continue next
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// quotePattern returns a string literal of a pattern matching msg, raw when
+// the pattern has backslashes or quotes and msg no backquote. Dots are left
+// unescaped for readability, matching themselves along with anything else.
+func quotePattern(msg string) string {
+	pattern := strings.ReplaceAll(regexp.QuoteMeta(msg), `\.`, ".")
+	if strings.Contains(pattern, "`") {
+		return strconv.Quote(pattern)
+	}
+	if !strings.ContainsAny(pattern, `\"`) {
+		return `"` + pattern + `"`
+	}
+	return "`" + pattern + "`"
+}
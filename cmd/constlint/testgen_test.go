@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdateWants(t *testing.T) {
+	src := `package p
+
+func f(o *Order) {
+	o.ID = "a"
+	o.ID = "b" // want "assignment to const field Order.ID"
+	o.ID = "c" // want "stale"
+	o.Total = 1 // want "assignment"
+	o.Note = "" // keep // want "stale"
+	o.Name = "+" // want name:"fact"
+	_ = ` + "`" + `
+	raw` + "`" + `
+	o.ID = "d"
+}
+`
+	messages := map[int][]string{
+		4:  {"assignment to const field Order.ID"},
+		5:  {"assignment to const field Order.ID"},
+		6:  {"assignment to const field Order.ID", "+const marker"},
+		10: {"assignment to const field Order.ID"},
+		12: {`a "quoted" message`},
+	}
+	want := `package p
+
+func f(o *Order) {
+	o.ID = "a" // want "assignment to const field Order.ID"
+	o.ID = "b" // want "assignment to const field Order.ID"
+	o.ID = "c" // want "assignment to const field Order.ID" ` + "`" + `\+const marker` + "`" + `
+	o.Total = 1
+	o.Note = ""  // keep
+	o.Name = "+" // want name:"fact"
+	_ = ` + "`" + `
+	raw` + "`" + `
+	o.ID = "d" // want ` + "`" + `a "quoted" message` + "`" + `
+}
+`
+	out, updates, err := updateWants([]byte(src), messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+
+	var lines []string
+	for _, u := range updates {
+		lines = append(lines, strings.Fields(u.text)[0])
+	}
+	if got, want := strings.Join(lines, " "), "// // no no can't //"; got != want {
+		t.Errorf("got updates %s, want %s", got, want)
+	}
+}
+
+func TestQuotePattern(t *testing.T) {
+	for msg, want := range map[string]string{
+		"assignment to const field Order.ID": `"assignment to const field Order.ID"`,
+		"+const on Order.Items":              "`\\+const on Order.Items`",
+		`say "hi"`:                           "`say \"hi\"`",
+		"use `go vet` (twice)":               "\"use `go vet` \\\\(twice\\\\)\"",
+	} {
+		if got := quotePattern(msg); got != want {
+			t.Errorf("quotePattern(%q) = %s, want %s", msg, got, want)
+		}
+	}
+}
@@ -0,0 +1,692 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// callGraphs maps the values of -callgraph to the algorithm building the call
+// graph of a program: class hierarchy analysis, which resolves dynamic calls
+// to every method or function of the right type; rapid type analysis, which
+// only keeps the types the program instantiates; and variable type analysis,
+// which follows the values actually reaching each call.
+var callGraphs = map[string]func(prog *ssa.Program, fns map[*ssa.Function]bool) *callgraph.Graph{
+	"cha": func(prog *ssa.Program, _ map[*ssa.Function]bool) *callgraph.Graph {
+		return cha.CallGraph(prog)
+	},
+	"rta": func(prog *ssa.Program, fns map[*ssa.Function]bool) *callgraph.Graph {
+		// Libraries have no main function; any declared function may be
+		// called from outside the program.
+		var roots []*ssa.Function
+		for fn := range fns {
+			if fn.Synthetic == "" && fn.Parent() == nil && fn.TypeParams().Len() == 0 && fn.Blocks != nil {
+				roots = append(roots, fn)
+			}
+		}
+		return rta.Analyze(roots, true).CallGraph
+	},
+	"vta": func(prog *ssa.Program, fns map[*ssa.Function]bool) *callgraph.Graph {
+		return vta.CallGraph(fns, cha.CallGraph(prog))
+	},
+}
+
+// checkWholeProgram reports the writes to const fields that the analyzer,
+// checking a package at a time, can't see: writes to the const fields of
+// other packages, and writes in constructors and their helpers to values they
+// didn't create. It builds the SSA form of the packages and their call graph
+// with the given algorithm, and allows a write to a field of a value only
+// where the value was allocated in the function writing it, or received from
+// callers that all allocated it themselves, following parameters and
+// captured variables through the call graph. Functions without callers in
+// the program, such as exported functions of libraries, may be called with
+// any value. Writes through the results of getters aliasing a const field,
+// such as *p.NameRef() = x, are reported too, as they would be through the
+// field itself. Writes the analyzer reported already are left out, and so are
+// those less confident than -min-confidence, as writes blamed on a caller are
+// only as probable as the call graph is precise, and those disable directives
+// turn off.
+func checkWholeProgram(initial []*packages.Package, decls map[token.Position]constDecl, reported []diagnostic,
+	algorithm string) []diagnostic {
+	prog, _ := ssautil.Packages(initial, ssa.InstantiateGenerics)
+	prog.Build()
+	fns := ssautil.AllFunctions(prog)
+	graph := callGraphs[algorithm](prog, fns)
+	graph.DeleteSyntheticNodes()
+
+	seen := make(map[line]bool)
+	for _, d := range reported {
+		if writeRules[d.Category] {
+			seen[line{d.position.Filename, d.position.Line, d.Field}] = true
+		}
+	}
+
+	analyzed := make(map[*types.Package]string)
+	for _, pkg := range initial {
+		analyzed[pkg.Types] = pkg.PkgPath
+	}
+	minConfidence, err := analyzer.ParseConfidence(analyzer.Analyzer.Flags.Lookup("min-confidence").Value.String())
+	if err != nil {
+		minConfidence = analyzer.Possible
+	}
+	w := &wholeProgram{
+		fset:          prog.Fset,
+		decls:         decls,
+		graph:         graph,
+		algorithm:     algorithm,
+		minConfidence: minConfidence,
+		fresh:         make(map[ssa.Value]freshness),
+		getters:       make(map[*ssa.Function][]getterAlias),
+		files:         make(map[*token.File]syntaxFile),
+	}
+	for _, pkg := range initial {
+		disabled := analyzer.FindDisabledCode(pkg.Fset, pkg.Syntax, pkg.TypesInfo)
+		for _, file := range pkg.Syntax {
+			w.files[w.fset.File(file.Pos())] = syntaxFile{file, pkg.TypesInfo, disabled}
+		}
+	}
+
+	var diags []diagnostic
+	for fn := range fns {
+		pkg := fn.Pkg
+		if fn.Origin() != nil {
+			pkg = fn.Origin().Pkg
+		}
+		if pkg == nil || fn.Synthetic != "" {
+			continue
+		}
+		pkgPath, ok := analyzed[pkg.Pkg]
+		if !ok {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				var target ssa.Value
+				indirect := false
+				switch instr := instr.(type) {
+				case *ssa.Store:
+					target = instr.Addr
+				case *ssa.MapUpdate:
+					target, indirect = instr.Map, true
+				default:
+					continue
+				}
+				fa, owner, field, ok := w.constTarget(target, indirect)
+				if !ok {
+					if d, ok := w.getterWrite(pkgPath, fn, instr, target, seen); ok {
+						diags = append(diags, d)
+					}
+					continue
+				}
+				if w.lazyInit(fa, field) || w.isFresh(fa.X) {
+					continue
+				}
+
+				pos := instr.Pos()
+				if !pos.IsValid() {
+					pos = fa.Pos()
+				}
+				if w.disabled(pos, field) {
+					continue
+				}
+				posn := w.fset.Position(pos)
+				name := owner + "." + field.Name()
+				if seen[line{posn.Filename, posn.Line, name}] {
+					continue
+				}
+				d, confidence := w.diagnostic(pkgPath, fn, fa, owner, name, posn)
+				d.decl = w.fset.Position(field.Pos())
+				d.Message += w.because(d.decl)
+				if confidence >= minConfidence {
+					diags = append(diags, d)
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// freshness is the state of a value in wholeProgram.isFresh.
+type freshness int
+
+const (
+	freshUnknown freshness = iota
+	freshPending           // being computed, for values reaching themselves
+	freshYes
+	freshNo
+)
+
+// line identifies the diagnostics about a field on a line, which
+// checkWholeProgram reports once.
+type line struct {
+	file  string
+	line  int
+	field string
+}
+
+// wholeProgram holds the state of checkWholeProgram.
+type wholeProgram struct {
+	fset          *token.FileSet
+	decls         map[token.Position]constDecl
+	graph         *callgraph.Graph
+	algorithm     string
+	minConfidence analyzer.Confidence
+	fresh         map[ssa.Value]freshness
+	getters       map[*ssa.Function][]getterAlias // memoizes getterAliases
+	files         map[*token.File]syntaxFile      // of the initial packages
+}
+
+// syntaxFile is a file of the initial packages, and the type information of
+// its package and what its disable directives turn off.
+type syntaxFile struct {
+	file     *ast.File
+	info     *types.Info
+	disabled *analyzer.DisabledCode
+}
+
+// lazyInit reports whether the store to field through fa is the lazy
+// initialization of a +writeonce field, which the analyzer allows however
+// the value was obtained.
+func (w *wholeProgram) lazyInit(fa *ssa.FieldAddr, field *types.Var) bool {
+	if !w.decls[w.fset.Position(field.Pos())].writeOnce || !fa.Pos().IsValid() {
+		return false
+	}
+	f, ok := w.files[w.fset.File(fa.Pos())]
+	return ok && analyzer.LazyInit(f.info, f.file, fa.Pos())
+}
+
+// disabled reports whether a disable directive turns off the diagnostics at
+// pos about field, as the analyzer leaves out its own.
+func (w *wholeProgram) disabled(pos token.Pos, field *types.Var) bool {
+	f, ok := w.files[w.fset.File(pos)]
+	return ok && f.disabled.Disables(pos, field, nil)
+}
+
+// constTarget returns the selection of the const field a write to addr
+// modifies, along with the name of the field's struct type. A write through a
+// reference held by a field, such as p.Items[i] = x or *p.Ptr = x, only
+// modifies a +deepconst field; indirect tells whether addr already holds such
+// a reference, as the map of a map update does.
+func (w *wholeProgram) constTarget(addr ssa.Value, indirect bool) (*ssa.FieldAddr, string, *types.Var, bool) {
+	for {
+		switch a := addr.(type) {
+		case *ssa.FieldAddr:
+			st, ok := derefType(a.X.Type()).Underlying().(*types.Struct)
+			if !ok {
+				return nil, "", nil, false
+			}
+			field := st.Field(a.Field).Origin()
+			decl, ok := w.decls[w.fset.Position(field.Pos())]
+			if ok && !decl.param && (!indirect || decl.deep) {
+				named, ok := derefType(a.X.Type()).(*types.Named)
+				if !ok {
+					return nil, "", nil, false
+				}
+				return a, named.Obj().Name(), field, true
+			}
+			// The struct is stored in the value a.X points to.
+			addr = a.X
+
+		case *ssa.IndexAddr:
+			if _, ok := derefType(a.X.Type()).Underlying().(*types.Array); ok {
+				// The elements of an array are stored in the array.
+				addr = a.X
+				continue
+			}
+			// The elements of a slice are referenced by the slice.
+			addr, indirect = a.X, true
+
+		case *ssa.UnOp:
+			if a.Op != token.MUL {
+				return nil, "", nil, false
+			}
+			// A value loaded from memory, such as a pointer or slice held by
+			// a field: what it references is reached indirectly.
+			addr, indirect = a.X, true
+
+		default:
+			return nil, "", nil, false
+		}
+	}
+}
+
+// isFresh reports whether v refers to a value allocated by the function using
+// it, or passed to it by callers that all allocated it.
+func (w *wholeProgram) isFresh(v ssa.Value) bool {
+	switch w.fresh[v] {
+	case freshPending:
+		// A value reaching itself, such as a parameter of a recursive
+		// function, is fresh if it is on all its other paths.
+		return true
+	case freshYes:
+		return true
+	case freshNo:
+		return false
+	}
+	w.fresh[v] = freshPending
+	fresh := w.computeFresh(v)
+	w.fresh[v] = freshNo
+	if fresh {
+		w.fresh[v] = freshYes
+	}
+	return fresh
+}
+
+func (w *wholeProgram) computeFresh(v ssa.Value) bool {
+	switch v := v.(type) {
+	case *ssa.Alloc:
+		return true
+
+	case *ssa.FieldAddr:
+		// A struct stored in a fresh value is fresh too.
+		return w.isFresh(v.X)
+
+	case *ssa.IndexAddr:
+		_, isArray := derefType(v.X.Type()).Underlying().(*types.Array)
+		return isArray && w.isFresh(v.X)
+
+	case *ssa.ChangeType:
+		return w.isFresh(v.X)
+
+	case *ssa.MakeInterface:
+		return w.isFresh(v.X)
+
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			if !w.isFresh(edge) {
+				return false
+			}
+		}
+		return true
+
+	case *ssa.UnOp:
+		// A pointer loaded from a variable or field the function only
+		// stored fresh values in, such as o.P after o.P = &Person{}.
+		if v.Op != token.MUL {
+			return false
+		}
+		stored := storedValues(v.Parent(), v.X)
+		for _, s := range stored {
+			if !w.isFresh(s) {
+				return false
+			}
+		}
+		return len(stored) > 0
+
+	case *ssa.Parameter:
+		_, args := w.callerArgs(v)
+		if args == nil {
+			return false
+		}
+		for _, arg := range args {
+			if arg == nil || !w.isFresh(arg) {
+				return false
+			}
+		}
+		return true
+
+	case *ssa.FreeVar:
+		binding := closureBinding(v)
+		return binding != nil && w.isFresh(binding)
+	}
+	return false
+}
+
+// callerArgs returns the call sites of the function declaring param, per the
+// call graph, and the argument each passes for it, nil where it can't be
+// told. It returns no arguments for functions without callers.
+func (w *wholeProgram) callerArgs(param *ssa.Parameter) ([]ssa.CallInstruction, []ssa.Value) {
+	fn := param.Parent()
+	node := w.graph.Nodes[fn]
+	if node == nil {
+		return nil, nil
+	}
+	index := -1
+	for i, p := range fn.Params {
+		if p == param {
+			index = i
+		}
+	}
+
+	var sites []ssa.CallInstruction
+	var args []ssa.Value
+	for _, edge := range node.In {
+		if edge.Site == nil {
+			// Called from outside the program.
+			sites, args = append(sites, nil), append(args, nil)
+			continue
+		}
+		sites, args = append(sites, edge.Site), append(args, callArg(edge.Site.Common(), fn, index))
+	}
+	return sites, args
+}
+
+// diagnostic describes the write to a const field through fa in fn, naming a
+// caller passing an existing value when the value is a parameter, and returns
+// its confidence.
+func (w *wholeProgram) diagnostic(pkgPath string, fn *ssa.Function, fa *ssa.FieldAddr, owner, field string,
+	posn token.Position) (diagnostic, analyzer.Confidence) {
+	d := diagnostic{
+		Package:    pkgPath,
+		Posn:       posn.String(),
+		End:        posn.String(),
+		Category:   analyzer.CategoryFieldWrite,
+		Message:    fmt.Sprintf("assignment to const field %s of a value %s did not create", field, funcName(fn)),
+		Confidence: analyzer.Definite.String(),
+		Field:      field,
+		position:   posn,
+		end:        posn,
+	}
+	confidence := analyzer.Definite
+
+	param, ok := baseValue(fa.X).(*ssa.Parameter)
+	if !ok {
+		return d, confidence
+	}
+	sites, args := w.callerArgs(param)
+	for i, arg := range args {
+		if sites[i] == nil || arg != nil && w.isFresh(arg) {
+			continue
+		}
+		d.Message = fmt.Sprintf("assignment to const field %s after construction: %s is called with an existing %s",
+			field, funcName(fn), owner)
+		callPosn := w.fset.Position(sites[i].Pos())
+		d.Related = []related{{
+			Posn:     callPosn.String(),
+			Message:  "called here by " + funcName(sites[i].Parent()),
+			position: callPosn,
+		}}
+		if sites[i].Common().StaticCallee() == nil {
+			// A dynamic call may only exist in the call graph's
+			// approximation of them.
+			confidence = analyzer.Possible
+			if w.algorithm == "vta" {
+				confidence = analyzer.Probable
+			}
+			d.Confidence = confidence.String()
+		}
+		break
+	}
+	return d, confidence
+}
+
+// getterAlias is a result of a function through which callers can modify a
+// const field, such as the pointer func (p *Person) NameRef() *string
+// returns, &p.Name.
+type getterAlias struct {
+	result int            // index of the result
+	fa     *ssa.FieldAddr // selects the field in the function
+	owner  string         // name of the field's struct type
+	field  *types.Var     // the field
+	param  int            // index of the parameter holding the struct, or -1
+	pos    token.Pos      // of the result returned
+	kind   string         // "pointer to" or "slice of"
+}
+
+// getterAliases returns the results of fn aliasing a const field: pointers
+// into it, and slices of an array field or of the elements of a +deepconst
+// field, as the analyzer's alias rule reports them.
+func (w *wholeProgram) getterAliases(fn *ssa.Function) []getterAlias {
+	if aliases, ok := w.getters[fn]; ok {
+		return aliases
+	}
+	var aliases []getterAlias
+	for _, b := range fn.Blocks {
+		ret, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return)
+		if !ok {
+			continue
+		}
+		for i, result := range ret.Results {
+			addr, kind := result, "pointer to"
+			if slice, ok := result.(*ssa.Slice); ok {
+				if _, ok := slice.X.Type().Underlying().(*types.Basic); ok {
+					continue
+				}
+				addr, kind = slice.X, "slice of"
+			} else if _, ok := result.Type().Underlying().(*types.Pointer); !ok {
+				continue
+			}
+			fa, owner, field, ok := w.constTarget(addr, false)
+			if !ok {
+				continue
+			}
+			param := -1
+			if p, ok := baseValue(fa.X).(*ssa.Parameter); ok {
+				for j, q := range fn.Params {
+					if q == p {
+						param = j
+					}
+				}
+			}
+			pos := result.Pos()
+			if !pos.IsValid() {
+				pos = ret.Pos()
+			}
+			aliases = append(aliases, getterAlias{result: i, fa: fa, owner: owner, field: field, param: param,
+				pos: pos, kind: kind})
+		}
+	}
+	w.getters[fn] = aliases
+	return aliases
+}
+
+// getterWrite reports the write instr of fn makes to addr when addr is
+// reached through a result of a call aliasing a const field, such as
+// *p.NameRef() = x or p.Items()[0] = x, unless the struct holding the field
+// was created by fn, as the analyzer's checks of a single package can't.
+func (w *wholeProgram) getterWrite(pkgPath string, fn *ssa.Function, instr ssa.Instruction, addr ssa.Value,
+	seen map[line]bool) (diagnostic, bool) {
+	for {
+		switch a := addr.(type) {
+		case *ssa.FieldAddr:
+			addr = a.X
+			continue
+		case *ssa.IndexAddr:
+			addr = a.X
+			continue
+		}
+		break
+	}
+	index := 0
+	if extract, ok := addr.(*ssa.Extract); ok {
+		addr, index = extract.Tuple, extract.Index
+	}
+	call, ok := addr.(*ssa.Call)
+	if !ok {
+		return diagnostic{}, false
+	}
+
+	var callees []*ssa.Function
+	if callee := call.Common().StaticCallee(); callee != nil {
+		callees = append(callees, callee)
+	} else if node := w.graph.Nodes[fn]; node != nil {
+		for _, edge := range node.Out {
+			if edge.Site == ssa.CallInstruction(call) {
+				callees = append(callees, edge.Callee.Func)
+			}
+		}
+	}
+	for _, callee := range callees {
+		for _, alias := range w.getterAliases(callee) {
+			if alias.result != index {
+				continue
+			}
+			if arg := callArg(call.Common(), callee, alias.param); arg != nil && w.isFresh(arg) {
+				continue
+			}
+
+			pos := instr.Pos()
+			if !pos.IsValid() {
+				pos = call.Pos()
+			}
+			if w.disabled(pos, alias.field) {
+				continue
+			}
+			posn := w.fset.Position(pos)
+			name := alias.owner + "." + alias.field.Name()
+			if seen[line{posn.Filename, posn.Line, name}] {
+				continue
+			}
+			confidence := analyzer.Definite
+			if call.Common().StaticCallee() == nil {
+				confidence = analyzer.Possible
+				if w.algorithm == "vta" {
+					confidence = analyzer.Probable
+				}
+			}
+			if confidence < w.minConfidence {
+				continue
+			}
+			seen[line{posn.Filename, posn.Line, name}] = true
+			getterPosn := w.fset.Position(alias.pos)
+			decl := w.fset.Position(alias.field.Pos())
+			return diagnostic{
+				Package:  pkgPath,
+				Posn:     posn.String(),
+				End:      posn.String(),
+				Category: analyzer.CategoryFieldWrite,
+				Message: fmt.Sprintf("assignment to const field %s through the result of %s%s", name, funcName(callee),
+					w.because(decl)),
+				Confidence: confidence.String(),
+				Field:      name,
+				Related: []related{{
+					Posn:     getterPosn.String(),
+					Message:  funcName(callee) + " returns a " + alias.kind + " " + name + " here",
+					position: getterPosn,
+				}},
+				position: posn,
+				end:      posn,
+				decl:     decl,
+			}, true
+		}
+	}
+	return diagnostic{}, false
+}
+
+// because returns the end of the message of a write to the const field
+// declared at decl quoting the reason its marker gives, as the analyzer words
+// it, if any.
+func (w *wholeProgram) because(decl token.Position) string {
+	if reason := w.decls[decl].reason; reason != "" {
+		return " (const because: " + reason + ")"
+	}
+	return ""
+}
+
+// callArg returns the argument call passes for the parameter of callee at
+// index, or nil.
+func callArg(common *ssa.CallCommon, callee *ssa.Function, index int) ssa.Value {
+	switch {
+	case index < 0:
+		return nil
+	case common.IsInvoke() && callee.Signature.Recv() != nil:
+		// Interface method calls pass the receiver as the call's value.
+		if index == 0 {
+			return common.Value
+		}
+		if index-1 < len(common.Args) {
+			return common.Args[index-1]
+		}
+	case len(common.Args) == len(callee.Params):
+		return common.Args[index]
+	}
+	return nil
+}
+
+// baseValue returns the value holding the struct v selects a field of,
+// stepping out of nested fields.
+func baseValue(v ssa.Value) ssa.Value {
+	for {
+		switch x := v.(type) {
+		case *ssa.FieldAddr:
+			v = x.X
+		case *ssa.ChangeType:
+			v = x.X
+		default:
+			return v
+		}
+	}
+}
+
+// storedValues returns the values fn stores at the location addr points to:
+// the same variable, or the same field of the same value.
+func storedValues(fn *ssa.Function, addr ssa.Value) []ssa.Value {
+	if fv, ok := addr.(*ssa.FreeVar); ok {
+		// A variable captured by reference is stored to by the function
+		// declaring it.
+		binding := closureBinding(fv)
+		if binding == nil {
+			return nil
+		}
+		fn, addr = fn.Parent(), binding
+	}
+	fa, isField := addr.(*ssa.FieldAddr)
+
+	var stored []ssa.Value
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			store, ok := instr.(*ssa.Store)
+			if !ok {
+				continue
+			}
+			if store.Addr == addr {
+				stored = append(stored, store.Val)
+				continue
+			}
+			if other, ok := store.Addr.(*ssa.FieldAddr); ok && isField && other.X == fa.X && other.Field == fa.Field {
+				stored = append(stored, store.Val)
+			}
+		}
+	}
+	return stored
+}
+
+// closureBinding returns the value the closure creation of the function
+// declaring fv binds to it, or nil.
+func closureBinding(fv *ssa.FreeVar) ssa.Value {
+	fn := fv.Parent()
+	index := -1
+	for i, v := range fn.FreeVars {
+		if v == fv {
+			index = i
+		}
+	}
+	if fn.Parent() == nil || index < 0 {
+		return nil
+	}
+	for _, b := range fn.Parent().Blocks {
+		for _, instr := range b.Instrs {
+			if mc, ok := instr.(*ssa.MakeClosure); ok && mc.Fn == fn {
+				return mc.Bindings[index]
+			}
+		}
+	}
+	return nil
+}
+
+// derefType returns the element type of a pointer type, or t itself.
+func derefType(t types.Type) types.Type {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return t
+}
+
+// funcName names a function relative to its package, such as NewOrder,
+// (*Order).Reset or NewServer$1 for a function literal.
+func funcName(fn *ssa.Function) string {
+	if fn.Pkg != nil {
+		return fn.RelString(fn.Pkg.Pkg)
+	}
+	return fn.String()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWholeProgram(t *testing.T) {
+	noSettings := func(string) (map[string]string, error) { return nil, nil }
+	for algorithm := range callGraphs {
+		t.Run(algorithm, func(t *testing.T) {
+			diags, _, err := lint([]string{"./testdata/wholeprogram/..."}, false, nil, algorithm, noSettings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range diags {
+				line := fmt.Sprintf("%s:%d: %s: %s", filepath.Base(d.position.Filename), d.position.Line, d.Confidence, d.Message)
+				for _, r := range d.Related {
+					line += fmt.Sprintf(" (%s:%d: %s)", filepath.Base(r.position.Filename), r.position.Line, r.Message)
+				}
+				got = append(got, line)
+			}
+			want := []string{
+				"app.go:8: definite: assignment to const field Item.SKU of a value Restock did not create",
+				"app.go:9: definite: assignment to const field Item.Tags of a value Restock did not create",
+				// Nothing in disabled.go: disable directives turn its writes
+				// off, as they do the analyzer's.
+				"getters.go:7: definite: assignment to const field Item.SKU through the result of (*Item).SKURef " +
+					"(store.go:38: (*Item).SKURef returns a pointer to Item.SKU here)",
+				"getters.go:8: definite: assignment to const field Item.Tags through the result of (*Item).TagsView " +
+					"(store.go:43: (*Item).TagsView returns a slice of Item.Tags here)",
+				"getters.go:10: definite: assignment to const field Item.SKU through the result of (*Item).SKURef " +
+					"(store.go:38: (*Item).SKURef returns a pointer to Item.SKU here)",
+				// Nothing in cache.go: Data and Names lazily initialize
+				// write-once fields of existing caches, as is allowed.
+				"store.go:21: definite: assignment to const field Item.SKU after construction: Clone is called with an existing Item " +
+					"(app.go:11: called here by Restock)",
+				// Reported by the analyzer; tag is only given new items, but
+				// checking the package alone can't tell.
+				"store.go:33: definite: assignment to const field Item.Tags (store.go:7: field marked const here)",
+				// The getters the writes go through.
+				"store.go:38: definite: SKURef returns a pointer into const field Item.SKU, through which callers can " +
+					"modify it (store.go:5: field marked const here)",
+				"store.go:43: definite: TagsView returns a slice of const field Item.Tags, through which callers can " +
+					"modify it (store.go:7: field marked const here)",
+			}
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Package constlinttest runs constlint over packages annotated with // want
+// comments, so that a team can assert the immutability contracts of its own
+// code in its tests:
+//
+//	func TestImmutability(t *testing.T) {
+//		constlinttest.Run(t, "testdata", constlinttest.Options{Strict: true})
+//	}
+//
+// Each line a diagnostic is expected on carries a comment such as
+// // want "assignment to const field Order.ID", whose string is a regular
+// expression matching the message; see analysistest for the details.
+package constlinttest
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// strictFlags are the rules a +constlint:strict package directive enables.
+var strictFlags = []string{
+	"dead-markers",
+	"complete-constructors",
+	"setters",
+	"concurrency-audit",
+	"channel-ownership",
+	"strict-globals",
+}
+
+// Options configures the analyzer for a Run.
+type Options struct {
+	// Patterns lists the packages to check, as go list patterns relative to
+	// the directory; "./..." when empty.
+	Patterns []string
+	// Flags sets analyzer flags by name, as on the command line, e.g.
+	// "exported-const": "true".
+	Flags map[string]string
+	// Strict enables every optional rule on const fields, as the strict
+	// package directive, +constlint:strict, does for a single package.
+	Strict bool
+}
+
+// Run checks the packages under dir with the analyzer configured by opts and
+// reports, through t, every diagnostic not expected by a // want comment and
+// every expectation no diagnostic matched. dir is the root of a module when it
+// holds a go.mod file, and of a GOPATH-style tree with packages under dir/src
+// otherwise.
+//
+// The analyzer flags are global, so Run restores them when t ends and tests
+// calling it must not run in parallel.
+func Run(t *testing.T, dir string, opts Options) []*analysistest.Result {
+	t.Helper()
+	flags := make(map[string]string)
+	if opts.Strict {
+		for _, name := range strictFlags {
+			flags[name] = "true"
+		}
+	}
+	for name, value := range opts.Flags {
+		flags[name] = value
+	}
+	var names []string
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		setFlag(t, name, flags[name])
+	}
+
+	patterns := opts.Patterns
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	return analysistest.Run(t, dir, analyzer.Analyzer, patterns...)
+}
+
+// setFlag sets an analyzer flag until t ends.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	f := analyzer.Analyzer.Flags.Lookup(name)
+	if f == nil {
+		t.Fatalf("unknown constlint flag %q", name)
+	}
+	previous := f.Value.String()
+	if err := f.Value.Set(value); err != nil {
+		t.Fatalf("constlint flag %s: %v", name, err)
+	}
+	t.Cleanup(func() { f.Value.Set(previous) })
+}
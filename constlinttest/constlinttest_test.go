@@ -0,0 +1,24 @@
+package constlinttest_test
+
+import (
+	"testing"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"github.com/bunniesandbeatings/constlint/constlinttest"
+)
+
+func TestRunStrict(t *testing.T) {
+	constlinttest.Run(t, "testdata", constlinttest.Options{
+		Strict: true,
+		Flags:  map[string]string{"setters": "false"},
+	})
+}
+
+func TestRunRestoresFlags(t *testing.T) {
+	t.Run("strict", func(t *testing.T) {
+		constlinttest.Run(t, "testdata", constlinttest.Options{Strict: true})
+	})
+	if got := analyzer.Analyzer.Flags.Lookup("dead-markers").Value.String(); got != "false" {
+		t.Errorf("dead-markers = %s after Run, want false", got)
+	}
+}
@@ -0,0 +1,22 @@
+// Package ledger is a downstream package asserting its immutability contract.
+package ledger
+
+// Entry is a ledger entry.
+type Entry struct {
+	// +const
+	ID string
+	// +const
+	Amount int
+	// +const
+	memo string // want "const field Entry.memo is never initialized"
+}
+
+// NewEntry leaves the memo unset.
+func NewEntry(id string, amount int) *Entry { // want "constructor NewEntry does not initialize const field Entry.memo"
+	return &Entry{ID: id, Amount: amount}
+}
+
+// Correct rewrites an entry in place.
+func (e *Entry) Correct(amount int) {
+	e.Amount = amount // want "assignment to const field Entry.Amount"
+}
@@ -0,0 +1,194 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// Clone generates a Clone method for every annotated struct in the package.
+// Reference-typed fields (slices, maps and pointers) are copied so the clone
+// doesn't share data with the original. +deepconst fields are copied
+// recursively, using the Clone method of their elements where one exists;
+// other fields are copied one level deep.
+func Clone(pkg *Package) (map[string][]byte, error) {
+	f := newGeneratedFile(pkg, "clone")
+
+	// Types gaining a Clone method are treated as cloneable up front, so the
+	// output doesn't change once a previous run's methods exist.
+	cloneable := make(map[types.Type]bool)
+	for _, s := range pkg.Inventory.Structs {
+		if pkg.receiverFor(s.Type).pointer {
+			cloneable[types.NewPointer(s.Type.Type())] = true
+		} else {
+			cloneable[s.Type.Type()] = true
+		}
+	}
+
+	for _, s := range pkg.Inventory.Structs {
+		if obj := pkg.methodConflict(s.Type, "Clone", f.Name()); obj != nil {
+			return nil, fmt.Errorf("%s: cannot generate %s.Clone: the name is already used by %s",
+				pkg.Fset.Position(s.Type.Pos()), s.Type.Name(), obj)
+		}
+
+		fields, err := pkg.structFields(s.Type)
+		if err != nil {
+			return nil, err
+		}
+		recv := pkg.receiverFor(s.Type)
+		c := &cloner{f: f, cloneable: cloneable}
+
+		var elts []string
+		for _, field := range fields {
+			src := recv.name + "." + field.Var.Name()
+			if !c.isReference(field.Var.Type()) {
+				elts = append(elts, fmt.Sprintf("%s: %s,", field.Var.Name(), src))
+				continue
+			}
+
+			local := unexportedName(field.Var.Name())
+			if local == recv.name {
+				local += "Copy"
+			}
+			cf := s.Field(field.Var)
+			c.printf("var %s %s\n", local, c.typeString(field.Var.Type()))
+			c.copy(local, src, field.Var.Type(), cf != nil && cf.Deep, 0)
+			elts = append(elts, fmt.Sprintf("%s: %s,", field.Var.Name(), local))
+		}
+
+		result, amp := recv.typ, ""
+		f.Printf("\n// Clone returns a copy of %s that shares no slices, maps or pointers with it.\n", recv.name)
+		if recv.pointer {
+			result, amp = "*"+recv.typ, "&"
+			f.Printf("func (%s) Clone() %s {\n\tif %s == nil {\n\t\treturn nil\n\t}\n", recv, result, recv.name)
+		} else {
+			f.Printf("func (%s) Clone() %s {\n", recv, result)
+		}
+		f.Printf("%s", c.body.String())
+		f.Printf("\treturn %s%s{\n\t\t%s\n\t}\n}\n", amp, recv.typ, strings.Join(elts, "\n\t\t"))
+	}
+
+	return f.Files()
+}
+
+// cloner writes the statements copying a value into a variable.
+type cloner struct {
+	f         *generatedFile
+	cloneable map[types.Type]bool // generated types with a Clone method
+	body      strings.Builder
+}
+
+func (c *cloner) printf(format string, args ...any) {
+	fmt.Fprintf(&c.body, "\t"+format, args...)
+}
+
+// typeString formats t for the generated file, importing the packages it
+// refers to.
+func (c *cloner) typeString(t types.Type) string {
+	return types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == c.f.pkg.Types {
+			return ""
+		}
+		c.f.ImportPath(pkg.Path())
+		return pkg.Name()
+	})
+}
+
+// copy emits statements setting dst to a copy of src, of type t. Deep copies
+// recurse into elements; shallow copies duplicate only the outermost level.
+func (c *cloner) copy(dst, src string, t types.Type, deep bool, depth int) {
+	if deep && c.hasClone(t) {
+		if _, ok := t.Underlying().(*types.Pointer); ok {
+			c.printf("if %s != nil {\n", src)
+			c.printf("\t%s = %s.Clone()\n", dst, src)
+			c.printf("}\n")
+		} else {
+			c.printf("%s = %s.Clone()\n", dst, src)
+		}
+		return
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		c.printf("if %s != nil {\n", src)
+		c.printf("\t%s = make(%s, len(%s))\n", dst, c.typeString(t), src)
+		if deep && c.isReference(u.Elem()) {
+			i := fmt.Sprintf("i%d", depth)
+			c.printf("\tfor %s := range %s {\n", i, src)
+			c.nested(dst+"["+i+"]", src+"["+i+"]", u.Elem(), depth)
+			c.printf("\t}\n")
+		} else {
+			c.printf("\tcopy(%s, %s)\n", dst, src)
+		}
+		c.printf("}\n")
+
+	case *types.Map:
+		k, v := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		c.printf("if %s != nil {\n", src)
+		c.printf("\t%s = make(%s, len(%s))\n", dst, c.typeString(t), src)
+		c.printf("\tfor %s, %s := range %s {\n", k, v, src)
+		if deep && c.isReference(u.Elem()) {
+			// Copy into a variable so nil elements keep their key.
+			e := fmt.Sprintf("e%d", depth)
+			c.printf("\t\tvar %s %s\n", e, c.typeString(u.Elem()))
+			c.nested(e, v, u.Elem(), depth)
+			c.printf("\t\t%s[%s] = %s\n", dst, k, e)
+		} else {
+			c.printf("\t\t%s[%s] = %s\n", dst, k, v)
+		}
+		c.printf("\t}\n")
+		c.printf("}\n")
+
+	case *types.Pointer:
+		p := fmt.Sprintf("p%d", depth)
+		c.printf("if %s != nil {\n", src)
+		if deep && c.isReference(u.Elem()) {
+			c.printf("\tvar %s %s\n", p, c.typeString(u.Elem()))
+			c.nested(p, "(*"+src+")", u.Elem(), depth)
+		} else {
+			c.printf("\t%s := *%s\n", p, src)
+		}
+		c.printf("\t%s = &%s\n", dst, p)
+		c.printf("}\n")
+
+	default:
+		c.printf("%s = %s\n", dst, src)
+	}
+}
+
+// nested emits a deep copy of an element, indented one level further.
+func (c *cloner) nested(dst, src string, t types.Type, depth int) {
+	inner := &cloner{f: c.f, cloneable: c.cloneable}
+	inner.copy(dst, src, t, true, depth+1)
+	for _, line := range strings.SplitAfter(inner.body.String(), "\n") {
+		if line != "" {
+			c.body.WriteString("\t\t" + line)
+		}
+	}
+}
+
+// isReference reports whether values of t share data when copied.
+func (c *cloner) isReference(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Slice, *types.Map, *types.Pointer:
+		return true
+	}
+	return c.hasClone(t)
+}
+
+// hasClone reports whether t has a method Clone() t.
+func (c *cloner) hasClone(t types.Type) bool {
+	for generated := range c.cloneable {
+		if types.Identical(generated, t) {
+			return true
+		}
+	}
+
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "Clone")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), t)
+}
@@ -0,0 +1,149 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// Constructors generates a New<Type> function for every annotated struct in
+// the package, taking one parameter per const field so the constructor can't
+// fall out of step with the markers. Mutable fields are set with functional
+// options: a <Type>Option type and a With<Type><Field> function per field.
+func Constructors(pkg *Package) (map[string][]byte, error) {
+	f := newGeneratedFile(pkg, "constructors")
+
+	for _, s := range pkg.Inventory.Structs {
+		fields, err := pkg.structFields(s.Type)
+		if err != nil {
+			return nil, err
+		}
+		spec := pkg.TypeSpec(s.Type)
+		tparams, err := typeParamDecl(pkg, f, spec)
+		if err != nil {
+			return nil, err
+		}
+		recv := pkg.receiverFor(s.Type)
+		typ := s.Type.Name() + typeParams(spec)
+
+		declName := func(name string) (string, error) {
+			if !s.Type.Exported() {
+				name = unexportedName(name)
+			}
+			if obj := pkg.declConflict(name, f.Name()); obj != nil {
+				return "", fmt.Errorf("%s: cannot generate %s for %s: the name is already used by %s",
+					pkg.Fset.Position(s.Type.Pos()), name, s.Type.Name(), obj)
+			}
+			return name, nil
+		}
+		paramName := func(field *types.Var) string {
+			name := unexportedName(field.Name())
+			if name == recv.name {
+				name += "Value"
+			}
+			return name
+		}
+
+		ctor, err := declName("New" + exportedName(s.Type.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var params, elts []string
+		var mutable []structField
+		for _, field := range fields {
+			if s.Field(field.Var) == nil {
+				mutable = append(mutable, field)
+				continue
+			}
+			source, err := pkg.Source(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			f.Import(field.Type)
+			params = append(params, paramName(field.Var)+" "+source)
+			elts = append(elts, fmt.Sprintf("%s: %s,", field.Var.Name(), paramName(field.Var)))
+		}
+		var option string
+		if len(mutable) > 0 {
+			if option, err = declName(exportedName(s.Type.Name()) + "Option"); err != nil {
+				return nil, err
+			}
+			params = append(params, "opts ..."+option+typeParams(spec))
+		}
+
+		result, amp, ref := typ, "", "&"
+		if recv.pointer {
+			result, amp, ref = "*"+typ, "&", ""
+		}
+
+		f.Printf("\n// %s returns a new %s with every const field set", ctor, s.Type.Name())
+		if len(mutable) > 0 {
+			f.Printf(". Mutable fields are set\n// with options")
+		}
+		f.Printf(".\n")
+		guarded := recv.pointer && pkg.hasGuards(s.Type)
+		if guarded {
+			f.Printf("// The result is frozen, for its VerifyConst method to catch later writes.\n")
+		}
+		f.Printf("func %s%s(%s) %s {\n", ctor, tparams, strings.Join(params, ", "), result)
+		f.Printf("\t%s := %s%s{\n\t\t%s\n\t}\n", recv.name, amp, typ, strings.Join(elts, "\n\t\t"))
+		if len(mutable) > 0 {
+			f.Printf("\tfor _, opt := range opts {\n\t\topt(%s%s)\n\t}\n", ref, recv.name)
+		}
+		if guarded {
+			f.Printf("\t%s.Freeze()\n", recv.name)
+		}
+		f.Printf("\treturn %s\n}\n", recv.name)
+
+		if len(mutable) == 0 {
+			continue
+		}
+
+		f.Printf("\n// %s sets a mutable field of a %s created by %s.\n", option, s.Type.Name(), ctor)
+		f.Printf("type %s%s func(*%s)\n", option, tparams, typ)
+
+		for _, field := range mutable {
+			fn, err := declName("With" + exportedName(s.Type.Name()) + exportedName(field.Var.Name()))
+			if err != nil {
+				return nil, err
+			}
+			source, err := pkg.Source(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			f.Import(field.Type)
+			param := paramName(field.Var)
+
+			f.Printf("\n// %s sets %s.%s.\n", fn, s.Type.Name(), field.Var.Name())
+			f.Printf("func %s%s(%s %s) %s%s {\n", fn, tparams, param, source, option, typeParams(spec))
+			f.Printf("\treturn func(%s *%s) {\n\t\t%s.%s = %s\n\t}\n}\n", recv.name, typ, recv.name, field.Var.Name(), param)
+		}
+	}
+
+	return f.Files()
+}
+
+// typeParamDecl returns the type parameter list of a type declaration with its
+// constraints, e.g. "[K comparable, V any]", or an empty string.
+func typeParamDecl(pkg *Package, f *generatedFile, spec *ast.TypeSpec) (string, error) {
+	if spec == nil || spec.TypeParams == nil {
+		return "", nil
+	}
+	var groups []string
+	for _, field := range spec.TypeParams.List {
+		constraint, err := pkg.Source(field.Type)
+		if err != nil {
+			return "", err
+		}
+		f.Import(field.Type)
+
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		groups = append(groups, strings.Join(names, ", ")+" "+constraint)
+	}
+	return "[" + strings.Join(groups, ", ") + "]", nil
+}
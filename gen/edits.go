@@ -0,0 +1,81 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"sort"
+)
+
+// edit replaces the source between pos and end with text.
+type edit struct {
+	pos, end token.Pos
+	text     string
+}
+
+// applyEdits applies edits to the named source file of the package and returns
+// the formatted result. Edits of the copy cgo compiles a file from are applied
+// to the original.
+func applyEdits(pkg *Package, name string, edits []edit) ([]byte, error) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].pos < edits[j].pos })
+	offset := func(pos token.Pos) int { return pkg.Fset.Position(pos).Offset }
+	if pkg.cgoCopy(edits[0].pos) {
+		if offset, err = originalOffsets(pkg, name, src, edits); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []byte
+	last := 0
+	for _, e := range edits {
+		start, end := offset(e.pos), offset(e.end)
+		if start < last {
+			return nil, fmt.Errorf("%s: overlapping edits at %s", name, pkg.Fset.Position(e.pos))
+		}
+		out = append(out, src[last:start]...)
+		out = append(out, e.text...)
+		last = end
+	}
+	out = append(out, src[last:]...)
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return formatted, nil
+}
+
+// originalOffsets returns the offsets in src, a file cgo rewrote, of
+// positions in its copy, which cgo's //line directives give as lines and
+// columns of the original. Edits may only replace text cgo left alone.
+func originalOffsets(pkg *Package, name string, src []byte, edits []edit) (func(token.Pos) int, error) {
+	copied, err := os.ReadFile(pkg.Fset.File(edits[0].pos).Name())
+	if err != nil {
+		return nil, err
+	}
+	lines := token.NewFileSet().AddFile(name, -1, len(src))
+	lines.SetLinesForContent(src)
+	offset := func(pos token.Pos) int {
+		position := pkg.Fset.Position(pos)
+		if position.Line < 1 || position.Line > lines.LineCount() {
+			return -1
+		}
+		return lines.Offset(lines.LineStart(position.Line)) + position.Column - 1
+	}
+	for _, e := range edits {
+		start, end := offset(e.pos), offset(e.end)
+		copyStart, copyEnd := pkg.Fset.Position(e.pos).Offset, pkg.Fset.Position(e.end).Offset
+		if start < 0 || end < start || end > len(src) ||
+			!bytes.Equal(src[start:end], copied[copyStart:copyEnd]) {
+			return nil, fmt.Errorf("%s: cannot edit code cgo rewrote", pkg.Fset.Position(e.pos))
+		}
+	}
+	return offset, nil
+}
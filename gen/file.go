@@ -0,0 +1,122 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// generatedFile accumulates the declarations of a new file written by a
+// generator, along with the imports they need.
+type generatedFile struct {
+	pkg        *Package
+	generator  string
+	variant    string            // appended to the file name, for generators writing several files
+	constraint string            // build constraint, e.g. "constlint_guards"
+	imports    map[string]string // path -> local name, "" for the default name
+	body       bytes.Buffer
+}
+
+func newGeneratedFile(pkg *Package, generator string) *generatedFile {
+	return &generatedFile{pkg: pkg, generator: generator, imports: make(map[string]string)}
+}
+
+// Printf appends formatted source to the file body.
+func (f *generatedFile) Printf(format string, args ...any) {
+	fmt.Fprintf(&f.body, format, args...)
+}
+
+// Import records the imports used by a type expression taken from the
+// package's source, keeping the local names used there. C types, which cgo
+// rewrites, need "C".
+func (f *generatedFile) Import(expr ast.Expr) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if strings.HasPrefix(ident.Name, "_Ctype_") && f.pkg.cgoCopy(ident.Pos()) {
+			f.imports["C"] = ""
+		}
+		if pkgName, ok := f.pkg.TypesInfo.Uses[ident].(*types.PkgName); ok {
+			name := ""
+			if pkgName.Name() != pkgName.Imported().Name() {
+				name = pkgName.Name()
+			}
+			f.imports[pkgName.Imported().Path()] = name
+		}
+		return true
+	})
+}
+
+// ImportPath records an import by path.
+func (f *generatedFile) ImportPath(path string) {
+	if _, ok := f.imports[path]; !ok {
+		f.imports[path] = ""
+	}
+}
+
+// Name returns the path of the file: constlint_<generator>.go, or
+// constlint_<generator>_<variant>.go, in the package directory.
+func (f *generatedFile) Name() string {
+	name := "constlint_" + f.generator
+	if f.variant != "" {
+		name += "_" + f.variant
+	}
+	return filepath.Join(filepath.Dir(f.pkg.GoFiles[0]), name+".go")
+}
+
+// Bytes returns the formatted file, including the generated code header.
+func (f *generatedFile) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by constlint gen %s. DO NOT EDIT.\n\n", f.generator)
+	if f.constraint != "" {
+		fmt.Fprintf(&buf, "//go:build %s\n\n", f.constraint)
+	}
+	fmt.Fprintf(&buf, "package %s\n", f.pkg.Name)
+
+	if len(f.imports) > 0 {
+		paths := make([]string, 0, len(f.imports))
+		for path := range f.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		buf.WriteString("\nimport (\n")
+		for _, path := range paths {
+			if name := f.imports[path]; name != "" {
+				fmt.Fprintf(&buf, "\t%s %s\n", name, strconv.Quote(path))
+			} else {
+				fmt.Fprintf(&buf, "\t%s\n", strconv.Quote(path))
+			}
+		}
+		buf.WriteString(")\n")
+	}
+
+	buf.Write(f.body.Bytes())
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w\n%s", f.Name(), err, buf.Bytes())
+	}
+	return out, nil
+}
+
+// Files returns the generated file keyed by its name, or nothing if no
+// declarations were generated.
+func (f *generatedFile) Files() (map[string][]byte, error) {
+	if f.body.Len() == 0 {
+		return nil, nil
+	}
+	out, err := f.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{f.Name(): out}, nil
+}
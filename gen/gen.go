@@ -0,0 +1,313 @@
+// Package gen generates code from the const markers found by the analyzer.
+// Every generator works from the analyzer's Inventory, so generated code always
+// agrees with what the linter enforces.
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bunniesandbeatings/constlint/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Package is a loaded package together with the const declarations the
+// analyzer found in it.
+type Package struct {
+	*packages.Package
+	Inventory *analyzer.Inventory
+	// Tests is the package compiled with its _test.go files, if it has any,
+	// for generators rewriting references in the package's own tests.
+	Tests *packages.Package
+}
+
+// Load loads the packages matching patterns, relative to dir, and runs the
+// analyzer over them to build their inventories. Each package comes once,
+// without its test files, which its Tests variant holds; external test
+// packages and test mains are left out.
+func Load(dir string, patterns ...string) ([]*Package, error) {
+	cfg := &packages.Config{Mode: packages.LoadAllSyntax | packages.NeedForTest, Dir: dir, Tests: true}
+	loaded, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(loaded) > 0 {
+		return nil, errors.New("packages contain errors")
+	}
+	var initial []*packages.Package
+	tests := make(map[string]*packages.Package)
+	for _, pkg := range loaded {
+		switch {
+		case pkg.ForTest != "" && pkg.PkgPath == pkg.ForTest:
+			tests[pkg.PkgPath] = pkg
+		case pkg.ForTest == "" && !strings.HasSuffix(pkg.ID, ".test"):
+			initial = append(initial, pkg)
+		}
+	}
+	if len(initial) == 0 {
+		return nil, fmt.Errorf("no packages matching %s", strings.Join(patterns, " "))
+	}
+
+	graph, err := checker.Analyze([]*analysis.Analyzer{analyzer.Analyzer}, initial, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []*Package
+	for _, root := range graph.Roots {
+		if root.Err != nil {
+			return nil, fmt.Errorf("%s: %w", root.Package.PkgPath, root.Err)
+		}
+		pkgs = append(pkgs, &Package{
+			Package:   root.Package,
+			Inventory: root.Result.(*analyzer.Inventory),
+			Tests:     tests[root.Package.PkgPath],
+		})
+	}
+	return pkgs, nil
+}
+
+// File returns the syntax tree of the file containing pos.
+func (p *Package) File(pos token.Pos) *ast.File {
+	for _, file := range p.Syntax {
+		if file.FileStart <= pos && pos <= file.FileEnd {
+			return file
+		}
+	}
+	return nil
+}
+
+// SourceFile returns the name of the source file a syntax tree of the package
+// was parsed from. For a file importing "C", that is the file cgo rewrote
+// into the copy the tree was parsed from, which //line directives position in
+// the original. It is empty for declarations cgo synthesizes.
+func (p *Package) SourceFile(file *ast.File) string {
+	if name := p.Fset.File(file.FileStart).Name(); slices.Contains(p.GoFiles, name) {
+		return name
+	}
+	if name := p.Fset.Position(file.Package).Filename; slices.Contains(p.GoFiles, name) {
+		return name
+	}
+	return ""
+}
+
+// cgoCopy reports whether pos lies in a copy of a source file rewritten by
+// cgo.
+func (p *Package) cgoCopy(pos token.Pos) bool {
+	file := p.File(pos)
+	return file != nil && p.SourceFile(file) != p.Fset.File(pos).Name()
+}
+
+// TypeSpec returns the declaration of a type defined in the package.
+func (p *Package) TypeSpec(typeName *types.TypeName) *ast.TypeSpec {
+	var found *ast.TypeSpec
+	ast.Inspect(p.File(typeName.Pos()), func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok && p.TypesInfo.Defs[spec.Name] == typeName {
+			found = spec
+		}
+		return found == nil
+	})
+	return found
+}
+
+// Source returns the source text of a node, with the names of C types cgo
+// rewrote in copies of source files restored.
+func (p *Package) Source(node ast.Node) (string, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, p.Fset, node); err != nil {
+		return "", err
+	}
+	if p.cgoCopy(node.Pos()) {
+		return cgoTypeName.ReplaceAllString(buf.String(), "C.$1"), nil
+	}
+	return buf.String(), nil
+}
+
+// cgoTypeName matches the names cgo gives C types, such as _Ctype_int for
+// C.int or _Ctype_struct_stat for C.struct_stat.
+var cgoTypeName = regexp.MustCompile(`\b_Ctype_(\w+)`)
+
+// receiver describes how a generated method receives its type.
+type receiver struct {
+	name    string // receiver variable, e.g. "p"
+	pointer bool   // whether the receiver is a pointer
+	typ     string // receiver type without pointer, including type parameters, e.g. "Box[T]"
+}
+
+// String returns the receiver as it appears in a method declaration.
+func (r receiver) String() string {
+	if r.pointer {
+		return r.name + " *" + r.typ
+	}
+	return r.name + " " + r.typ
+}
+
+// receiverFor picks the receiver for methods generated on typeName, following
+// the existing methods of the type: the first one found decides the receiver
+// name and whether it's a pointer. Generated files are ignored, as their
+// receivers were chosen the same way or forced by their generator. Without
+// methods a pointer receiver named after the type is used.
+func (p *Package) receiverFor(typeName *types.TypeName) receiver {
+	r := receiver{
+		name:    string(unicode.ToLower([]rune(typeName.Name())[0])),
+		pointer: true,
+		typ:     typeName.Name() + typeParams(p.TypeSpec(typeName)),
+	}
+
+	for _, file := range p.Syntax {
+		if ast.IsGenerated(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+				continue
+			}
+			field := funcDecl.Recv.List[0]
+			t := p.TypesInfo.TypeOf(field.Type)
+			ptr, isPointer := t.(*types.Pointer)
+			if isPointer {
+				t = ptr.Elem()
+			}
+			named, ok := t.(*types.Named)
+			if !ok || named.Obj() != typeName {
+				continue
+			}
+			if len(field.Names) > 0 && field.Names[0].Name != "_" {
+				r.name = field.Names[0].Name
+			}
+			r.pointer = isPointer
+			return r
+		}
+	}
+	return r
+}
+
+// typeParams returns the type parameter list of a type declaration as used in
+// a receiver, e.g. "[K, V]", or an empty string.
+func typeParams(spec *ast.TypeSpec) string {
+	if spec == nil || spec.TypeParams == nil {
+		return ""
+	}
+	var names []string
+	for _, field := range spec.TypeParams.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// fieldType returns the source text of a struct field's type.
+func (p *Package) fieldType(spec *ast.TypeSpec, field *types.Var) (string, error) {
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return "", fmt.Errorf("%s is not a struct", spec.Name.Name)
+	}
+	for _, f := range structType.Fields.List {
+		for _, name := range f.Names {
+			if p.TypesInfo.Defs[name] == field {
+				return p.Source(f.Type)
+			}
+		}
+	}
+	return "", fmt.Errorf("field %s not found in %s", field.Name(), spec.Name.Name)
+}
+
+// unexportedName returns name with its leading upper case run lowered, keeping
+// the last upper case letter of an initialism that starts a new word:
+// Name -> name, ID -> id, URLPath -> urlPath.
+func unexportedName(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) {
+		n-- // the last upper case letter starts the next word
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	result := string(runes)
+	if token.IsKeyword(result) {
+		result += "_"
+	}
+	return result
+}
+
+// structField is a field of an annotated struct, with its type as written.
+type structField struct {
+	Var  *types.Var
+	Type ast.Expr
+}
+
+// structFields returns the fields of a struct type declared in the package, in
+// declaration order. Blank fields are skipped.
+func (p *Package) structFields(typeName *types.TypeName) ([]structField, error) {
+	spec := p.TypeSpec(typeName)
+	if spec == nil {
+		return nil, fmt.Errorf("declaration of %s not found", typeName.Name())
+	}
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", typeName.Name())
+	}
+
+	var fields []structField
+	for _, f := range structType.Fields.List {
+		idents := f.Names
+		if len(idents) == 0 {
+			idents = []*ast.Ident{analyzer.EmbeddedFieldName(f.Type)}
+		}
+		for _, ident := range idents {
+			if ident == nil || ident.Name == "_" {
+				continue
+			}
+			v, ok := p.TypesInfo.Defs[ident].(*types.Var)
+			if !ok {
+				continue
+			}
+			fields = append(fields, structField{Var: v, Type: f.Type})
+		}
+	}
+	return fields, nil
+}
+
+// methodConflict returns the existing field or method named name on typeName,
+// ignoring declarations in the file being regenerated.
+func (p *Package) methodConflict(typeName *types.TypeName, name, generatedFile string) types.Object {
+	obj, _, _ := types.LookupFieldOrMethod(typeName.Type(), true, p.Types, name)
+	if obj == nil || p.Fset.Position(obj.Pos()).Filename == generatedFile {
+		return nil
+	}
+	return obj
+}
+
+// declConflict returns the existing package-level declaration named name,
+// ignoring declarations in the file being regenerated.
+func (p *Package) declConflict(name, generatedFile string) types.Object {
+	obj := p.Types.Scope().Lookup(name)
+	if obj == nil || p.Fset.Position(obj.Pos()).Filename == generatedFile {
+		return nil
+	}
+	return obj
+}
+
+// exportedName returns name with its first letter in upper case.
+func exportedName(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
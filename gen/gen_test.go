@@ -0,0 +1,169 @@
+package gen_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bunniesandbeatings/constlint/gen"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGetters(t *testing.T) {
+	runGolden(t, "getters", gen.Getters)
+}
+
+func TestGettersEncodingTags(t *testing.T) {
+	pkg := loadTestdata(t, "getterstags")
+	_, err := gen.Getters(pkg)
+	if err == nil || !strings.Contains(err.Error(), "cannot rename Order.ID to id: encoders skip unexported fields, so its json tag would no longer apply") {
+		t.Errorf("got error %v, want Order.ID left alone for its json tag", err)
+	}
+}
+
+func TestGettersCgo(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("no C compiler")
+	}
+	runGolden(t, "cgo", gen.Getters)
+}
+
+func TestWith(t *testing.T) {
+	runGolden(t, "with", gen.With)
+}
+
+func TestConstructors(t *testing.T) {
+	runGolden(t, "constructors", gen.Constructors)
+}
+
+func TestViews(t *testing.T) {
+	runGolden(t, "views", gen.Views)
+}
+
+func TestGuards(t *testing.T) {
+	runGolden(t, "guards", gen.Guards)
+}
+
+func TestGuarded(t *testing.T) {
+	runGolden(t, "guarded", gen.Constructors)
+	runGolden(t, "guarded", gen.With)
+}
+
+func TestClone(t *testing.T) {
+	runGolden(t, "clone", gen.Clone)
+}
+
+func TestMigrate(t *testing.T) {
+	m := gen.Migration{
+		Tag:        "immutable",
+		Directives: []string{"@immutable", "@readonly"},
+		Fields: map[string]bool{
+			"Config.APIKey":                 true,
+			"Config.Host":                   true,
+			"example.com/example.Pair.Left": true,
+			"Point.X":                       true,
+		},
+	}
+	var conversions []gen.Conversion
+	runGolden(t, "migrate", func(pkg *gen.Package) (map[string][]byte, error) {
+		files, converted, err := m.Migrate(pkg)
+		conversions = converted
+		return files, err
+	})
+
+	var got []string
+	for _, c := range conversions {
+		line := fmt.Sprintf("%d %s from %s", c.Pos.Line, c.Field, c.From)
+		if c.Skipped != "" {
+			line += " skipped: " + c.Skipped
+		}
+		got = append(got, line)
+	}
+	want := []string{
+		`5 Config.ID from immutable:"true"`,
+		`6 Config.Region from immutable:"true"`,
+		`9 Config.Owner from // @immutable`,
+		`10 Config.Name from // @readonly`,
+		`12 Config.APIKey from listed`,
+		`17 Config.Host from listed skipped: declared together with other fields`,
+		`17 Config.Path from listed skipped: declared together with other fields`,
+		`21 Point.X from listed skipped: declared together with other fields`,
+		`21 Point.Y from listed skipped: declared together with other fields`,
+		`25 Pair.Left from listed`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got conversions\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// runGolden runs a generator over the package in testdata/<name> and compares
+// every file it produces with the matching .golden file.
+func runGolden(t *testing.T, name string, generate func(*gen.Package) (map[string][]byte, error)) {
+	t.Helper()
+
+	src := filepath.Join("testdata", name)
+	files, err := generate(loadTestdata(t, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("generator produced no files")
+	}
+
+	for path, got := range files {
+		golden := filepath.Join(src, filepath.Base(path)+".golden")
+		if *update {
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from %s:\n%s", filepath.Base(path), golden, got)
+		}
+	}
+}
+
+// loadTestdata loads the package in testdata/<name>, copied into a module of
+// its own.
+func loadTestdata(t *testing.T, name string) *gen.Package {
+	t.Helper()
+
+	src := filepath.Join("testdata", name)
+	dir := t.TempDir()
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".go" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, entry.Name()), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/example\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := gen.Load(dir, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkgs[0]
+}
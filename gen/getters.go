@@ -0,0 +1,168 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+)
+
+// rename is a const field being turned into an unexported field with a getter.
+type rename struct {
+	owner   *types.TypeName
+	field   *types.Var
+	newName string
+}
+
+// encodingTags are the struct tag keys of encoders, which skip unexported
+// fields.
+var encodingTags = []string{"json", "yaml", "xml", "toml"}
+
+// Getters rewrites the exported const fields of the package to unexported
+// names and adds a getter named after each original field. References within
+// the package and its own tests are updated; code in other packages can then
+// only read the fields, which the compiler enforces. It refuses to rename a
+// field with an encoding tag, which encoders would then skip. It returns the
+// new content of every file that changed, keyed by file name.
+func Getters(pkg *Package) (map[string][]byte, error) {
+	// Fields are matched by position in the test variant of the package,
+	// which parses the same files but type-checks them again.
+	renames := make(map[token.Pos]rename)
+	var order []rename
+	for _, s := range pkg.Inventory.Structs {
+		for _, cf := range s.Fields {
+			if !cf.Var.Exported() {
+				continue
+			}
+
+			r := rename{owner: s.Type, field: cf.Var, newName: unexportedName(cf.Var.Name())}
+			if obj, _, _ := types.LookupFieldOrMethod(s.Type.Type(), true, pkg.Types, r.newName); obj != nil {
+				return nil, fmt.Errorf("%s: cannot rename %s.%s to %s: the name is already used by %s",
+					pkg.Fset.Position(cf.Pos), s.Type.Name(), cf.Var.Name(), r.newName, obj)
+			}
+			if key, ok := encodingTag(s.Type, cf.Var); ok {
+				return nil, fmt.Errorf("%s: cannot rename %s.%s to %s: encoders skip unexported fields, so its %s "+
+					"tag would no longer apply", pkg.Fset.Position(cf.Pos), s.Type.Name(), cf.Var.Name(), r.newName, key)
+			}
+			renames[cf.Var.Pos()] = r
+			order = append(order, r)
+		}
+	}
+	if len(renames) == 0 {
+		return nil, nil
+	}
+
+	// Getters are inserted after the declaration of their type.
+	getters := make(map[*ast.GenDecl]string)
+	for _, r := range order {
+		decl := typeDecl(pkg, r.owner)
+		if decl == nil {
+			return nil, fmt.Errorf("declaration of %s not found", r.owner.Name())
+		}
+		getter, err := getterSource(pkg, r)
+		if err != nil {
+			return nil, err
+		}
+		getters[decl] += getter
+	}
+
+	files := make(map[string][]byte)
+	rewrite := func(pkg *Package, file *ast.File) error {
+		name := pkg.SourceFile(file)
+		if name == "" {
+			return nil
+		}
+		var edits []edit
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch node := n.(type) {
+			case *ast.Ident:
+				obj := pkg.TypesInfo.Defs[node]
+				if obj == nil {
+					obj = pkg.TypesInfo.Uses[node]
+				}
+				if v, ok := obj.(*types.Var); ok && v.IsField() {
+					if r, ok := renames[v.Origin().Pos()]; ok {
+						edits = append(edits, edit{pos: node.Pos(), end: node.End(), text: r.newName})
+					}
+				}
+			case *ast.GenDecl:
+				if getter, ok := getters[node]; ok {
+					edits = append(edits, edit{pos: node.End(), end: node.End(), text: getter})
+				}
+			}
+			return true
+		})
+		if len(edits) == 0 {
+			return nil
+		}
+
+		out, err := applyEdits(pkg, name, edits)
+		if err != nil {
+			return err
+		}
+		files[name] = out
+		return nil
+	}
+	for _, file := range pkg.Syntax {
+		if err := rewrite(pkg, file); err != nil {
+			return nil, err
+		}
+	}
+	if pkg.Tests != nil {
+		tests := &Package{Package: pkg.Tests}
+		for _, file := range pkg.Tests.Syntax {
+			if !slices.Contains(pkg.Syntax, file) {
+				if err := rewrite(tests, file); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// encodingTag returns the key of the first encoding tag of a field of owner.
+func encodingTag(owner *types.TypeName, field *types.Var) (string, bool) {
+	structType, ok := owner.Type().Underlying().(*types.Struct)
+	if !ok {
+		return "", false
+	}
+	for i := 0; i < structType.NumFields(); i++ {
+		if structType.Field(i) != field {
+			continue
+		}
+		tag := reflect.StructTag(structType.Tag(i))
+		for _, key := range encodingTags {
+			if _, ok := tag.Lookup(key); ok {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// getterSource returns the getter method for a renamed field.
+func getterSource(pkg *Package, r rename) (string, error) {
+	spec := pkg.TypeSpec(r.owner)
+	typ, err := pkg.fieldType(spec, r.field)
+	if err != nil {
+		return "", err
+	}
+	recv := pkg.receiverFor(r.owner)
+
+	return fmt.Sprintf("\n\n// %s returns the value of the const field %s.\nfunc (%s) %s() %s {\n\treturn %s.%s\n}",
+		r.field.Name(), r.newName, recv, r.field.Name(), typ, recv.name, r.newName), nil
+}
+
+// typeDecl returns the declaration containing the spec of a type.
+func typeDecl(pkg *Package, typeName *types.TypeName) *ast.GenDecl {
+	for _, decl := range pkg.File(typeName.Pos()).Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Pos() <= typeName.Pos() && typeName.Pos() < decl.End() {
+			return decl
+		}
+	}
+	return nil
+}
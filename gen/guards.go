@@ -0,0 +1,162 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// guardsTag is the build tag enabling the runtime guards.
+const guardsTag = "constlint_guards"
+
+// guardsConstraint is the build constraint of the runtime guards, which need
+// the weak pointers of Go 1.24 to forget the values that are collected.
+const guardsConstraint = guardsTag + " && go1.24"
+
+// Guards generates runtime checks for the const fields of the annotated
+// structs, catching writes the linter can't see, such as those made through
+// reflection or unsafe. Each struct gets a Freeze method, to be called at the
+// end of its constructors, recording its const fields, and a VerifyConst
+// method panicking if any of them changed since. +deepconst fields are
+// compared by content, other const fields by identity. The snapshots are
+// keyed by weak pointers and dropped once their value is collected.
+//
+// The checks are only compiled with the constlint_guards build tag, e.g.
+// go test -tags constlint_guards, by Go 1.24 or later; otherwise both methods
+// do nothing. The constructors and With methods generated for a type with
+// guards call them.
+func Guards(pkg *Package) (map[string][]byte, error) {
+	on := newGeneratedFile(pkg, "guards")
+	on.constraint = guardsConstraint
+	off := newGeneratedFile(pkg, "guards")
+	off.variant = "off"
+	off.constraint = "!(" + guardsConstraint + ")"
+
+	for _, s := range pkg.Inventory.Structs {
+		for _, method := range []string{"Freeze", "VerifyConst"} {
+			if obj := pkg.methodConflict(s.Type, method, off.Name()); obj != nil {
+				return nil, fmt.Errorf("%s: cannot generate %s.%s: the name is already used by %s",
+					pkg.Fset.Position(s.Type.Pos()), s.Type.Name(), method, obj)
+			}
+		}
+
+		// Freeze needs an address to record, so the receiver is always a pointer.
+		recv := pkg.receiverFor(s.Type)
+		recv.pointer = true
+
+		off.Printf("\n// Freeze does nothing without the %s build tag and Go 1.24.\n", guardsTag)
+		off.Printf("func (%s) Freeze() {}\n", recv)
+		off.Printf("\n// VerifyConst does nothing without the %s build tag and Go 1.24.\n", guardsTag)
+		off.Printf("func (%s) VerifyConst() {}\n", recv)
+
+		c := &cloner{f: on, cloneable: map[types.Type]bool{}}
+		var names, deep, values []string
+		for _, cf := range s.Fields {
+			src := recv.name + "." + cf.Var.Name()
+			names = append(names, strconv.Quote(cf.Var.Name()))
+			deep = append(deep, strconv.FormatBool(cf.Deep))
+			if !cf.Deep || !c.isReference(cf.Var.Type()) {
+				values = append(values, src)
+				continue
+			}
+
+			// Deep fields are copied, so later writes to their contents show.
+			local := unexportedName(cf.Var.Name())
+			if local == recv.name {
+				local += "Copy"
+			}
+			c.printf("var %s %s\n", local, c.typeString(cf.Var.Type()))
+			c.copy(local, src, cf.Var.Type(), true, 0)
+			values = append(values, local)
+		}
+
+		on.Printf("\n// Freeze records the const fields of %s; VerifyConst panics if they change afterwards.\n", recv.name)
+		on.Printf("func (%s) Freeze() {\n\tconstlintFreeze(%s, %s.constlintSnapshot())\n}\n", recv, recv.name, recv.name)
+		on.Printf("\n// VerifyConst panics if a const field of %s changed since it was frozen.\n", recv.name)
+		on.Printf("func (%s) VerifyConst() {\n", recv)
+		on.Printf("\tif frozen, ok := constlintFrozen.Load(weak.Make(%s)); ok {\n", recv.name)
+		on.Printf("\t\tconstlintVerify(%q, []string{%s}, []bool{%s}, frozen.([]any), %s.constlintSnapshot())\n",
+			s.Type.Name(), strings.Join(names, ", "), strings.Join(deep, ", "), recv.name)
+		on.Printf("\t}\n}\n")
+		on.Printf("\nfunc (%s) constlintSnapshot() []any {\n%s", recv, c.body.String())
+		on.Printf("\treturn []any{%s}\n}\n", strings.Join(values, ", "))
+	}
+	if on.body.Len() == 0 {
+		return nil, nil
+	}
+
+	on.ImportPath("fmt")
+	on.ImportPath("reflect")
+	on.ImportPath("runtime")
+	on.ImportPath("sync")
+	on.ImportPath("weak")
+	on.Printf("%s", guardsRuntime)
+
+	files, err := on.Files()
+	if err != nil {
+		return nil, err
+	}
+	offFiles, err := off.Files()
+	if err != nil {
+		return nil, err
+	}
+	for name, content := range offFiles {
+		files[name] = content
+	}
+	return files, nil
+}
+
+// hasGuards reports whether typeName has the Freeze and VerifyConst methods
+// Guards generates, which the other generators then call.
+func (p *Package) hasGuards(typeName *types.TypeName) bool {
+	for _, name := range []string{"Freeze", "VerifyConst"} {
+		obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(typeName.Type()), true, p.Types, name)
+		fn, ok := obj.(*types.Func)
+		if !ok || !strings.HasPrefix(filepath.Base(p.Fset.Position(fn.Pos()).Filename), "constlint_guards") {
+			return false
+		}
+	}
+	return true
+}
+
+// guardsRuntime is the support code shared by the generated guards of a package.
+const guardsRuntime = `
+// constlintFrozen maps weak pointers to frozen values to snapshots of their
+// const fields.
+var constlintFrozen sync.Map
+
+// constlintFreeze records the snapshot of the value p points to, until the
+// value is collected. Values whose const fields refer back to them are never
+// collected.
+func constlintFreeze[T any](p *T, snapshot []any) {
+	key := weak.Make(p)
+	if _, loaded := constlintFrozen.Swap(key, snapshot); !loaded {
+		runtime.AddCleanup(p, func(key weak.Pointer[T]) { constlintFrozen.Delete(key) }, key)
+	}
+}
+
+// constlintVerify panics naming the first const field that differs between
+// the frozen and current snapshots of a value.
+func constlintVerify(typ string, fields []string, deep []bool, frozen, current []any) {
+	for i, field := range fields {
+		if deep[i] && !reflect.DeepEqual(frozen[i], current[i]) || !deep[i] && !constlintSame(frozen[i], current[i]) {
+			panic(fmt.Sprintf("constlint: const field %s.%s changed after Freeze", typ, field))
+		}
+	}
+}
+
+// constlintSame reports whether a and b are the same value: slices, maps and
+// pointers must refer to the same data, other values must be equal.
+func constlintSame(a, b any) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch va.Kind() {
+	case reflect.Slice:
+		return va.Len() == vb.Len() && va.Cap() == vb.Cap() && va.UnsafePointer() == vb.UnsafePointer()
+	case reflect.Map, reflect.Pointer, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return va.UnsafePointer() == vb.UnsafePointer()
+	}
+	return reflect.DeepEqual(a, b)
+}
+`
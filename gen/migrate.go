@@ -0,0 +1,244 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// Migration describes annotations of other conventions marking struct fields
+// immutable, which Migrate turns into +const markers.
+type Migration struct {
+	Tag        string          // struct tag key marking a field immutable with "true", e.g. "immutable"
+	Directives []string        // comment directives marking a field immutable, e.g. "@immutable"
+	Fields     map[string]bool // fields to mark, as Type.Field or path/to/pkg.Type.Field
+}
+
+// Conversion is a field Migrate marked const, or left alone when Skipped
+// says why.
+type Conversion struct {
+	Pos     token.Position
+	Field   string // Type.Field
+	From    string // the annotation converted, e.g. immutable:"true"
+	Skipped string
+}
+
+// Migrate rewrites the annotations m describes into +const markers in the
+// files of the package: a directive comment is replaced by the marker, while
+// fields with the tag, which is removed, or listed in m.Fields get a marker
+// line right above them. Fields the analyzer already treats as const are left
+// alone. It returns the rewritten files, keyed by file name, and the
+// conversions made.
+func (m Migration) Migrate(pkg *Package) (map[string][]byte, []Conversion, error) {
+	files := make(map[string][]byte)
+	var conversions []Conversion
+	for _, file := range pkg.Syntax {
+		name := pkg.SourceFile(file)
+		if name == "" {
+			continue
+		}
+		var edits []edit
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			owner, _ := pkg.TypesInfo.Defs[spec.Name].(*types.TypeName)
+
+			for _, field := range st.Fields.List {
+				if len(field.Names) == 0 || pkg.isConstField(owner, field.Names[0]) {
+					continue
+				}
+				convert := func(from, skipped string) {
+					for _, name := range field.Names {
+						conversions = append(conversions, Conversion{
+							Pos:     pkg.Fset.Position(name.Pos()),
+							Field:   spec.Name.Name + "." + name.Name,
+							From:    from,
+							Skipped: skipped,
+						})
+					}
+				}
+
+				if comment := m.directive(field); comment != nil {
+					edits = append(edits, edit{pos: comment.Pos(), end: comment.End(), text: "// +const"})
+					convert(comment.Text, "")
+					continue
+				}
+
+				from := ""
+				if field.Tag != nil && m.Tag != "" {
+					if value, ok := lookupTag(field.Tag.Value, m.Tag); ok && value == "true" {
+						rest := removeTag(field.Tag.Value, m.Tag)
+						pos := field.Tag.Pos()
+						if rest == "" {
+							pos = field.Type.End()
+						}
+						edits = append(edits, edit{pos: pos, end: field.Tag.End(), text: rest})
+						from = fmt.Sprintf("%s:%q", m.Tag, value)
+					}
+				}
+				if from == "" && m.listed(pkg, spec, field) {
+					if len(field.Names) > 1 {
+						convert("listed", "declared together with other fields")
+						continue
+					}
+					from = "listed"
+				}
+				if from == "" {
+					continue
+				}
+
+				// The marker goes on a line of its own, between the field and its doc.
+				pos := field.Pos()
+				position := pkg.Fset.Position(pos)
+				if pkg.Fset.Position(st.Fields.Opening).Line == position.Line {
+					convert(from, "declared on the line of its struct")
+					continue
+				}
+				lineStart := pos - token.Pos(position.Column-1)
+				edits = append(edits, edit{pos: lineStart, end: lineStart, text: "// +const\n"})
+				convert(from, "")
+			}
+			return true
+		})
+		if len(edits) == 0 {
+			continue
+		}
+
+		out, err := applyEdits(pkg, name, edits)
+		if err != nil {
+			return nil, nil, err
+		}
+		files[name] = out
+	}
+	return files, conversions, nil
+}
+
+// isConstField reports whether the analyzer treats the field declared by name
+// as const.
+func (p *Package) isConstField(owner *types.TypeName, name *ast.Ident) bool {
+	v, ok := p.TypesInfo.Defs[name].(*types.Var)
+	if !ok || owner == nil {
+		return false
+	}
+	for _, s := range p.Inventory.Structs {
+		if s.Type == owner {
+			return s.IsConst(v)
+		}
+	}
+	return false
+}
+
+// directive returns the doc or trailing comment of field consisting of one of
+// m's directives.
+func (m Migration) directive(field *ast.Field) *ast.Comment {
+	for _, group := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+			for _, directive := range m.Directives {
+				if text == directive {
+					return comment
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// listed reports whether m.Fields lists a name declared by field.
+func (m Migration) listed(pkg *Package, spec *ast.TypeSpec, field *ast.Field) bool {
+	for _, name := range field.Names {
+		qualified := spec.Name.Name + "." + name.Name
+		if m.Fields[qualified] || m.Fields[pkg.PkgPath+"."+qualified] {
+			return true
+		}
+	}
+	return false
+}
+
+// tagPair is a key:"value" pair of a struct tag.
+type tagPair struct {
+	key, value string // value as written, quotes included
+}
+
+// parseTag splits a struct tag literal into its pairs, following the
+// conventional format reflect.StructTag understands.
+func parseTag(literal string) ([]tagPair, bool) {
+	tag, err := strconv.Unquote(literal)
+	if err != nil {
+		return nil, false
+	}
+	var pairs []tagPair
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return pairs, true
+		}
+		i := strings.Index(tag, `:"`)
+		if i <= 0 || strings.ContainsAny(tag[:i], ` "`) {
+			return nil, false
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		j := 1
+		for j < len(tag) && tag[j] != '"' {
+			if tag[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(tag) {
+			return nil, false
+		}
+		pairs = append(pairs, tagPair{key: key, value: tag[:j+1]})
+		tag = tag[j+1:]
+	}
+}
+
+// lookupTag returns the value of key in a struct tag literal.
+func lookupTag(literal, key string) (string, bool) {
+	pairs, _ := parseTag(literal)
+	for _, pair := range pairs {
+		if pair.key == key {
+			value, err := strconv.Unquote(pair.value)
+			return value, err == nil
+		}
+	}
+	return "", false
+}
+
+// removeTag returns a struct tag literal without key, quoted as before, or ""
+// if no pair is left.
+func removeTag(literal, key string) string {
+	pairs, ok := parseTag(literal)
+	if !ok {
+		return literal
+	}
+	var kept []string
+	for _, pair := range pairs {
+		if pair.key != key {
+			kept = append(kept, pair.key+":"+pair.value)
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	tag := strings.Join(kept, " ")
+	if strings.HasPrefix(literal, "`") {
+		return "`" + tag + "`"
+	}
+	return strconv.Quote(tag)
+}
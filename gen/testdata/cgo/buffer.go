@@ -0,0 +1,26 @@
+package example
+
+/*
+#include <stdlib.h>
+
+typedef struct { int len; } cbuf;
+
+static int twice(int x) { return 2 * x; }
+*/
+import "C"
+
+// Buffer wraps memory allocated by C.
+type Buffer struct {
+	Size int     // +const
+	Data *C.cbuf // +const:shallow
+}
+
+// NewBuffer allocates a buffer of n bytes.
+func NewBuffer(n int) *Buffer {
+	return &Buffer{Size: n, Data: (*C.cbuf)(C.malloc(C.size_t(n)))}
+}
+
+// Doubled returns twice the size of the buffer.
+func (b *Buffer) Doubled() int {
+	return int(C.twice(C.int(b.Size)))
+}
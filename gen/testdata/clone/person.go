@@ -0,0 +1,43 @@
+package example
+
+// Address is a nested const struct; Person clones it through its Clone method.
+// +const
+type Address struct {
+	Street string
+	Lines  []string
+}
+
+// Person has fields of every reference kind.
+type Person struct {
+	// +const
+	Name string
+	// +deepconst
+	Home *Address
+	// +deepconst
+	Previous []*Address
+	// +const:shallow
+	Friends []*Person
+	// +deepconst
+	Labels map[string][]string
+	// +deepconst
+	Scores map[string]*[]int
+
+	Age int
+}
+
+// Greeting greets the person.
+func (p *Person) Greeting() string {
+	return "hi " + p.Name
+}
+
+// Pair is const as a whole and has value receivers.
+// +const
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value *V
+}
+
+// String formats the pair.
+func (p Pair[K, V]) String() string {
+	return "pair"
+}
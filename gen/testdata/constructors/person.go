@@ -0,0 +1,50 @@
+package example
+
+import (
+	stdtime "time"
+)
+
+// Person has const and mutable fields.
+type Person struct {
+	// +const
+	Name string
+	// +deepconst
+	Tags []string
+	// +const
+	Born stdtime.Time
+	// +const
+	P int
+
+	Age      int
+	Nickname string
+}
+
+// Greeting greets the person.
+func (p *Person) Greeting() string {
+	return "hi " + p.Name
+}
+
+// pair is const as a whole and has value receivers.
+// +const
+type pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// String formats the pair.
+func (p pair[K, V]) String() string {
+	return "pair"
+}
+
+// Box has a mutable field and value receivers.
+type Box[T any] struct {
+	// +const
+	Label string
+
+	Item T
+}
+
+// Describe describes the box.
+func (b Box[T]) Describe() string {
+	return b.Label
+}
@@ -0,0 +1,35 @@
+package example
+
+import "time"
+
+// Person has const fields.
+type Person struct {
+	// +const
+	Name string
+	ID   int // +const
+	// +const
+	Created time.Time
+
+	Age int
+}
+
+// NewPerson creates a person.
+func NewPerson(name string) *Person {
+	return &Person{Name: name, ID: 1, Created: time.Now()}
+}
+
+// Greeting greets the person.
+func (p *Person) Greeting() string {
+	return "hi " + p.Name
+}
+
+// Box is const as a whole.
+// +const
+type Box[T any] struct {
+	Value T
+}
+
+// Get returns the value.
+func (b Box[T]) Get() T {
+	return b.Value
+}
@@ -0,0 +1,10 @@
+package example
+
+import "testing"
+
+func TestGreeting(t *testing.T) {
+	p := &Person{Name: "ann", ID: 2}
+	if got := p.Greeting(); got != "hi "+p.Name {
+		t.Errorf("got %q for %d", got, p.ID)
+	}
+}
@@ -0,0 +1,7 @@
+package example
+
+func use() int {
+	p := NewPerson("x")
+	b := Box[int]{Value: p.ID}
+	return b.Value
+}
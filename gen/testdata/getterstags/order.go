@@ -0,0 +1,7 @@
+package example
+
+// Order is sent to clients as JSON.
+type Order struct {
+	ID    string `json:"id"` // +const
+	Total int    `json:"total"`
+}
@@ -0,0 +1,11 @@
+// Code generated by constlint gen guards. DO NOT EDIT.
+
+//go:build !(constlint_guards && go1.24)
+
+package example
+
+// Freeze does nothing without the constlint_guards build tag and Go 1.24.
+func (p *Person) Freeze() {}
+
+// VerifyConst does nothing without the constlint_guards build tag and Go 1.24.
+func (p *Person) VerifyConst() {}
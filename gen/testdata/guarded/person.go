@@ -0,0 +1,11 @@
+package example
+
+// Person has guards, which its generated constructor and With methods call.
+type Person struct {
+	// +const
+	Name string
+	// +deepconst
+	Tags []string
+
+	Age int
+}
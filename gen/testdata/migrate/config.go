@@ -0,0 +1,27 @@
+package example
+
+// Config holds settings in a mix of conventions.
+type Config struct {
+	ID     string `json:"id" immutable:"true"`
+	Region string `immutable:"true"`
+	// Owner is the team running the service.
+	// @immutable
+	Owner string
+	Name  string // @readonly
+	// APIKey authenticates requests.
+	APIKey string
+	Port   int `immutable:"false"`
+	// +const
+	Created string `immutable:"true"`
+
+	Host, Path string
+}
+
+// Point declares its fields together.
+type Point struct{ X, Y int }
+
+// Pair is listed by its qualified name.
+type Pair struct {
+	Left  string
+	Right string
+}
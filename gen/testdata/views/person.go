@@ -0,0 +1,37 @@
+package example
+
+import (
+	stdtime "time"
+)
+
+// Person has const and mutable fields.
+type Person struct {
+	// +const
+	Name string
+	// +deepconst
+	Tags []string
+	// +const
+	Born stdtime.Time
+	// +const
+	Scores map[string]int
+
+	Age    int
+	secret string
+}
+
+// Greeting greets the person.
+func (v *Person) Greeting() string {
+	return "hi " + v.Name
+}
+
+// Pair is const as a whole and has value receivers.
+// +const
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// String formats the pair.
+func (p Pair[K, V]) String() string {
+	return "pair"
+}
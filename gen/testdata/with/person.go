@@ -0,0 +1,34 @@
+package example
+
+import (
+	stdtime "time"
+)
+
+// Person has const and mutable fields.
+type Person struct {
+	// +const
+	Name string
+	// +deepconst
+	Tags []string
+	// +const
+	Born stdtime.Time
+
+	Age int
+}
+
+// Greeting greets the person.
+func (p *Person) Greeting() string {
+	return "hi " + p.Name
+}
+
+// Pair is const as a whole and has value receivers.
+// +const
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// String formats the pair.
+func (p Pair[K, V]) String() string {
+	return "pair"
+}
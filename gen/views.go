@@ -0,0 +1,80 @@
+package gen
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// Views generates a read-only view type for every annotated struct in the
+// package: <Type>View wraps the struct and has a method named after each
+// exported field returning its value, and a View method on the struct returns
+// one. Handing out the view instead of the struct makes writes a compile
+// error. Slices and maps are returned as copies, so they can't be written
+// through either.
+func Views(pkg *Package) (map[string][]byte, error) {
+	f := newGeneratedFile(pkg, "views")
+
+	for _, s := range pkg.Inventory.Structs {
+		if obj := pkg.methodConflict(s.Type, "View", f.Name()); obj != nil {
+			return nil, fmt.Errorf("%s: cannot generate %s.View: the name is already used by %s",
+				pkg.Fset.Position(s.Type.Pos()), s.Type.Name(), obj)
+		}
+		name := s.Type.Name() + "View"
+		if obj := pkg.declConflict(name, f.Name()); obj != nil {
+			return nil, fmt.Errorf("%s: cannot generate %s: the name is already used by %s",
+				pkg.Fset.Position(s.Type.Pos()), name, obj)
+		}
+
+		fields, err := pkg.structFields(s.Type)
+		if err != nil {
+			return nil, err
+		}
+		spec := pkg.TypeSpec(s.Type)
+		tparams, err := typeParamDecl(pkg, f, spec)
+		if err != nil {
+			return nil, err
+		}
+		recv := pkg.receiverFor(s.Type)
+		view := name + typeParams(spec)
+		held := recv.typ
+		if recv.pointer {
+			held = "*" + recv.typ
+		}
+		self := "v"
+		if recv.name == self {
+			self = "view"
+		}
+
+		f.Printf("\n// %s is a read-only view of a %s.\n", name, s.Type.Name())
+		f.Printf("type %s%s struct {\n\t%s %s\n}\n", name, tparams, recv.name, held)
+		f.Printf("\n// View returns a read-only view of %s.\n", recv.name)
+		f.Printf("func (%s) View() %s {\n\treturn %s{%s: %s}\n}\n", recv, view, view, recv.name, recv.name)
+
+		for _, field := range fields {
+			if !field.Var.Exported() {
+				continue
+			}
+			typ, err := pkg.Source(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			f.Import(field.Type)
+
+			value := self + "." + recv.name + "." + field.Var.Name()
+			doc := "returns the value of"
+			switch field.Var.Type().Underlying().(type) {
+			case *types.Slice:
+				f.ImportPath("slices")
+				value, doc = "slices.Clone("+value+")", "returns a copy of"
+			case *types.Map:
+				f.ImportPath("maps")
+				value, doc = "maps.Clone("+value+")", "returns a copy of"
+			}
+
+			f.Printf("\n// %s %s the field %s of the viewed %s.\n", field.Var.Name(), doc, field.Var.Name(), s.Type.Name())
+			f.Printf("func (%s %s) %s() %s {\n\treturn %s\n}\n", self, view, field.Var.Name(), typ, value)
+		}
+	}
+
+	return f.Files()
+}
@@ -0,0 +1,72 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// With generates a With<Field> method for every const field of the annotated
+// structs in the package. Each method returns a modified copy built with a
+// composite literal, never touching the original, which gives callers an
+// alternative to setters the linter rejects. For types with guards, the
+// methods verify the original and freeze the copy.
+func With(pkg *Package) (map[string][]byte, error) {
+	f := newGeneratedFile(pkg, "with")
+
+	for _, s := range pkg.Inventory.Structs {
+		fields, err := pkg.structFields(s.Type)
+		if err != nil {
+			return nil, err
+		}
+		recv := pkg.receiverFor(s.Type)
+		result, amp := recv.typ, ""
+		if recv.pointer {
+			result, amp = "*"+recv.typ, "&"
+		}
+		guarded := recv.pointer && pkg.hasGuards(s.Type)
+
+		for _, cf := range s.Fields {
+			method := "With" + exportedName(cf.Var.Name())
+			if obj := pkg.methodConflict(s.Type, method, f.Name()); obj != nil {
+				return nil, fmt.Errorf("%s: cannot generate %s.%s: the name is already used by %s",
+					pkg.Fset.Position(cf.Pos), s.Type.Name(), method, obj)
+			}
+
+			param := unexportedName(cf.Var.Name())
+			if param == recv.name {
+				param = "value"
+			}
+
+			var typ string
+			var elts []string
+			for _, field := range fields {
+				value := recv.name + "." + field.Var.Name()
+				if field.Var == cf.Var {
+					value = param
+					f.Import(field.Type)
+					if typ, err = pkg.Source(field.Type); err != nil {
+						return nil, err
+					}
+				}
+				elts = append(elts, fmt.Sprintf("%s: %s,", field.Var.Name(), value))
+			}
+
+			f.Printf("\n// %s returns a copy of %s with %s set to %s.\n", method, recv.name, cf.Var.Name(), param)
+			if !guarded {
+				f.Printf("func (%s) %s(%s %s) %s {\n", recv, method, param, typ, result)
+				f.Printf("\treturn %s%s{\n\t\t%s\n\t}\n}\n", amp, recv.typ, strings.Join(elts, "\n\t\t"))
+				continue
+			}
+			updated := "updated"
+			if updated == recv.name || updated == param {
+				updated = "updatedCopy"
+			}
+			f.Printf("// It panics if a const field of %s changed since it was frozen, and freezes the copy.\n", recv.name)
+			f.Printf("func (%s) %s(%s %s) %s {\n\t%s.VerifyConst()\n", recv, method, param, typ, result, recv.name)
+			f.Printf("\t%s := %s%s{\n\t\t%s\n\t}\n", updated, amp, recv.typ, strings.Join(elts, "\n\t\t"))
+			f.Printf("\t%s.Freeze()\n\treturn %s\n}\n", updated, updated)
+		}
+	}
+
+	return f.Files()
+}